@@ -0,0 +1,402 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tron-format/trongo/pkg/tron"
+	"github.com/tron-format/trongo/pkg/tronlint"
+)
+
+// newFlagSet returns a flag set for the named command that reports errors
+// instead of exiting.
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: tron %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("fmt", "[file ...]", stderr)
+	write := fs.Bool("w", false, "write results to the files instead of standard output")
+	compact := fs.Bool("compact", false, "write compact output (see tron.Compact)")
+	indent := fs.String("indent", "  ", "indentation string")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	format := func(src []byte) ([]byte, error) {
+		var buf bytes.Buffer
+		var err error
+		if *compact {
+			err = tron.Compact(&buf, src)
+		} else {
+			err = tron.Indent(&buf, src, "", *indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteByte('\n')
+		return buf.Bytes(), nil
+	}
+
+	if fs.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(stderr, "tron fmt: cannot use -w with standard input")
+			return 2
+		}
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "tron fmt: %v\n", err)
+			return 1
+		}
+		out, err := format(src)
+		if err != nil {
+			reportError(stderr, "<stdin>", err, src)
+			return 1
+		}
+		stdout.Write(out)
+		return 0
+	}
+
+	status := 0
+	for _, name := range fs.Args() {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "tron fmt: %v\n", err)
+			status = 1
+			continue
+		}
+		out, err := format(src)
+		if err != nil {
+			reportError(stderr, name, err, src)
+			status = 1
+			continue
+		}
+		if *write && !bytes.Equal(src, out) {
+			if err := os.WriteFile(name, out, 0o644); err != nil {
+				fmt.Fprintf(stderr, "tron fmt: %v\n", err)
+				status = 1
+			}
+			continue
+		}
+		if !*write {
+			stdout.Write(out)
+		}
+	}
+	return status
+}
+
+// reportError writes err for the named input, followed by the offending line
+// of src if err is a located syntax error.
+func reportError(stderr io.Writer, name string, err error, src []byte) {
+	fmt.Fprintf(stderr, "%s: %v\n", name, err)
+	var syntaxErr *tron.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		io.WriteString(stderr, syntaxErr.Snippet(src))
+	}
+}
+
+func runValidate(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("validate", "[file ...]", stderr)
+	var limits tron.Limits
+	defaults := tron.DefaultLimits()
+	fs.IntVar(&limits.MaxInputBytes, "max-bytes", defaults.MaxInputBytes, "maximum input size in bytes")
+	fs.IntVar(&limits.MaxTokens, "max-tokens", defaults.MaxTokens, "maximum number of tokens")
+	fs.IntVar(&limits.MaxDepth, "max-depth", defaults.MaxDepth, "maximum nesting depth")
+	fs.IntVar(&limits.MaxClasses, "max-classes", defaults.MaxClasses, "maximum number of class definitions")
+	fs.IntVar(&limits.MaxProperties, "max-properties", defaults.MaxProperties, "maximum properties per class")
+	strictJSON := fs.Bool("json", false, "accept only strict JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	check := func(name string, src []byte) bool {
+		dec := tron.NewDecoder(bytes.NewReader(src))
+		dec.SetLimits(limits)
+		if *strictJSON {
+			dec.UseStrictJSON()
+		}
+		var v any
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			err = fmt.Errorf("empty document")
+		}
+		if err != nil {
+			reportError(stderr, name, err, src)
+			return false
+		}
+		return true
+	}
+
+	if fs.NArg() == 0 {
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "tron validate: %v\n", err)
+			return 1
+		}
+		if !check("<stdin>", src) {
+			return 1
+		}
+		return 0
+	}
+	status := 0
+	for _, name := range fs.Args() {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "tron validate: %v\n", err)
+			status = 1
+			continue
+		}
+		if !check(name, src) {
+			status = 1
+		}
+	}
+	return status
+}
+
+func runJSON2TRON(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("json2tron", "[file]", stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	in, err := openInput(fs.Args(), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "tron json2tron: %v\n", err)
+		return 2
+	}
+	defer in.Close()
+
+	r := tron.NewJSONToTRONReader(in)
+	defer r.Close()
+	if _, err := io.Copy(stdout, r); err != nil {
+		fmt.Fprintf(stderr, "tron json2tron: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(stdout)
+	return 0
+}
+
+func runTRON2JSON(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("tron2json", "[file]", stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	in, err := openInput(fs.Args(), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "tron tron2json: %v\n", err)
+		return 2
+	}
+	defer in.Close()
+
+	w := tron.NewTRONToJSONWriter(stdout)
+	_, err = io.Copy(w, in)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "tron tron2json: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(stdout)
+	return 0
+}
+
+func runStats(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("stats", "[file]", stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	in, err := openInput(fs.Args(), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "tron stats: %v\n", err)
+		return 2
+	}
+	src, err := io.ReadAll(in)
+	in.Close()
+	if err != nil {
+		fmt.Fprintf(stderr, "tron stats: %v\n", err)
+		return 1
+	}
+
+	// TRON accepts JSON too, so a document that is not JSON is converted
+	// to JSON first and measured as FromJSON would encode it.
+	jsonData := src
+	if !json.Valid(src) {
+		jsonData, err = tron.ToJSON(src)
+	}
+	var st tron.Statistics
+	if err == nil {
+		st, err = tron.StatsWithTokenizer(json.RawMessage(jsonData), tron.TokenCounterFunc(estimateTokens))
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "tron stats: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "JSON:     %8d bytes  %8d tokens (approx.)\n", st.JSONBytes, st.JSONTokens)
+	fmt.Fprintf(stdout, "TRON:     %8d bytes  %8d tokens (approx.)\n", st.TRONBytes, st.TRONTokens)
+	fmt.Fprintf(stdout, "Savings:  %7.1f%% bytes %7.1f%% tokens\n", st.SavingsPercent, st.TokenSavingsPercent)
+	fmt.Fprintf(stdout, "Classes:  %8d\n", st.ClassCount)
+	return 0
+}
+
+func runClasses(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("classes", "[file]", stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	in, err := openInput(fs.Args(), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "tron classes: %v\n", err)
+		return 2
+	}
+	src, err := io.ReadAll(in)
+	in.Close()
+	var classes []tron.ClassUsage
+	if err == nil {
+		classes, err = tron.ExtractClasses(src)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "tron classes: %v\n", err)
+		return 1
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CLASS\tUSES\tPROPERTIES")
+	for _, c := range classes {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", c.Name, c.Uses, strings.Join(c.Keys, ","))
+	}
+	tw.Flush()
+	return 0
+}
+
+func runLint(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("lint", "[file ...]", stderr)
+	rules := fs.String("rules", "", "comma-separated rules to check; default all")
+	maxDepth := fs.Int("max-depth", tronlint.DefaultMaxDepth, "deepest nesting allowed by deep-nesting")
+	jsonOut := fs.Bool("json", false, "write findings as JSON lines")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	opts := &tronlint.Options{MaxDepth: *maxDepth}
+	if *rules != "" {
+		for _, r := range strings.Split(*rules, ",") {
+			if !slices.Contains(tronlint.AllRules, tronlint.Rule(r)) {
+				fmt.Fprintf(stderr, "tron lint: unknown rule %q\n", r)
+				return 2
+			}
+			opts.Rules = append(opts.Rules, tronlint.Rule(r))
+		}
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetEscapeHTML(false)
+	status := 0
+	lint := func(name string, src []byte) {
+		findings, err := tronlint.Lint(src, opts)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", name, err)
+			status = 1
+			return
+		}
+		for _, f := range findings {
+			if *jsonOut {
+				enc.Encode(struct {
+					File    string        `json:"file"`
+					Rule    tronlint.Rule `json:"rule"`
+					Path    string        `json:"path"`
+					Message string        `json:"message"`
+				}{name, f.Rule, f.Path, f.Message})
+			} else {
+				fmt.Fprintf(stdout, "%s: %s\n", name, f)
+			}
+			status = 1
+		}
+	}
+
+	if fs.NArg() == 0 {
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "tron lint: %v\n", err)
+			return 1
+		}
+		lint("<stdin>", src)
+		return status
+	}
+	for _, name := range fs.Args() {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "tron lint: %v\n", err)
+			status = 1
+			continue
+		}
+		lint(name, src)
+	}
+	return status
+}
+
+// estimateTokens approximates the number of tokens a BPE tokenizer of the
+// kind used by language models produces for data: each run of letters and
+// digits counts one token per four bytes, and every other character that is
+// not a space counts one.
+func estimateTokens(data []byte) int {
+	n, run := 0, 0
+	flush := func() {
+		n += (run + 3) / 4
+		run = 0
+	}
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			run += size
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			n++
+		}
+	}
+	flush()
+	return n
+}
+
+func runGenGo(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("gen-go", "[file]", stderr)
+	pkg := fs.String("pkg", "main", "package name of the generated file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	in, err := openInput(fs.Args(), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "tron gen-go: %v\n", err)
+		return 2
+	}
+	src, err := io.ReadAll(in)
+	in.Close()
+	var out []byte
+	if err == nil {
+		out, err = tron.GenerateGo(src, *pkg)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "tron gen-go: %v\n", err)
+		return 1
+	}
+	stdout.Write(out)
+	return 0
+}
@@ -0,0 +1,86 @@
+// Command tron works with TRON files from the command line.
+//
+// Usage:
+//
+//	tron <command> [flags] [file ...]
+//
+// The commands are:
+//
+//	fmt        rewrite files in canonical layout (see tron.Indent)
+//	validate   check that files are valid TRON within the decoding limits
+//	json2tron  convert JSON to TRON, inferring classes for repeated shapes
+//	tron2json  convert TRON to JSON
+//	stats      compare the size of a document in JSON and in TRON
+//	classes    list the classes of a document and how often each is used
+//	lint       report valid but suspicious constructs (see package tronlint)
+//	gen-go     generate Go struct declarations from class definitions
+//
+// Commands that take files read standard input when none are given, and
+// write to standard output. Run "tron <command> -h" for a command's flags.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// A command runs with its arguments and standard streams and returns the
+// process exit code.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"fmt", "rewrite files in canonical layout", runFmt},
+		{"validate", "check that files are valid TRON", runValidate},
+		{"json2tron", "convert JSON to TRON", runJSON2TRON},
+		{"tron2json", "convert TRON to JSON", runTRON2JSON},
+		{"stats", "compare JSON and TRON sizes", runStats},
+		{"classes", "list class definitions and their uses", runClasses},
+		{"lint", "report suspicious constructs", runLint},
+		{"gen-go", "generate Go structs from class definitions", runGenGo},
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stderr)
+		return 2
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdin, stdout, stderr)
+		}
+	}
+	fmt.Fprintf(stderr, "tron: unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: tron <command> [flags] [file ...]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// openInput opens the single optional file argument, or returns stdin.
+func openInput(args []string, stdin io.Reader) (io.ReadCloser, error) {
+	switch len(args) {
+	case 0:
+		return io.NopCloser(stdin), nil
+	case 1:
+		return os.Open(args[0])
+	}
+	return nil, fmt.Errorf("too many arguments")
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runCmd(t *testing.T, stdin string, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	code = run(args, strings.NewReader(stdin), &out, &errOut)
+	return out.String(), errOut.String(), code
+}
+
+func TestConvert(t *testing.T) {
+	out, errOut, code := runCmd(t, `[{"a":1,"b":"x"},{"a":2,"b":"y"}]`, "json2tron")
+	if code != 0 {
+		t.Fatalf("json2tron failed: %s", errOut)
+	}
+	if want := "class A: a,b\n\n[A(1,\"x\"),A(2,\"y\")]\n"; out != want {
+		t.Fatalf("json2tron = %q, want %q", out, want)
+	}
+
+	out, errOut, code = runCmd(t, out, "tron2json")
+	if code != 0 {
+		t.Fatalf("tron2json failed: %s", errOut)
+	}
+	if want := `[{"a":1,"b":"x"},{"a":2,"b":"y"}]` + "\n"; out != want {
+		t.Fatalf("tron2json = %q, want %q", out, want)
+	}
+}
+
+func TestFmt(t *testing.T) {
+	src := "# people\nclass P: name , age\n[ P(\"a\", 1) , # first\n P(\"b\",2)]"
+	out, _, code := runCmd(t, src, "fmt")
+	if want := "class P: name,age\n\n[\n  P(\"a\",1),\n  P(\"b\",2)\n]\n"; code != 0 || out != want {
+		t.Fatalf("fmt = %q (%d), want %q", out, code, want)
+	}
+	out, _, _ = runCmd(t, src, "fmt", "-compact")
+	if want := "class P: name,age\n\n[P(\"a\",1),P(\"b\",2)]\n"; out != want {
+		t.Fatalf("fmt -compact = %q, want %q", out, want)
+	}
+
+	path := filepath.Join(t.TempDir(), "x.tron")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, errOut, code := runCmd(t, "", "fmt", "-w", path); code != 0 {
+		t.Fatalf("fmt -w failed: %s", errOut)
+	}
+	got, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(got), "class P: name,age\n\n[\n") {
+		t.Fatalf("file after fmt -w = %q", got)
+	}
+
+	if _, errOut, code := runCmd(t, "[1,", "fmt"); code != 1 || errOut == "" {
+		t.Fatalf("fmt of invalid input = %d, %q", code, errOut)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if _, errOut, code := runCmd(t, "[[1]]", "validate"); code != 0 {
+		t.Fatalf("validate failed: %s", errOut)
+	}
+	if _, errOut, code := runCmd(t, "[[1]]", "validate", "-max-depth", "1"); code != 1 || !strings.Contains(errOut, "depth") {
+		t.Fatalf("validate -max-depth = %d, %q", code, errOut)
+	}
+	if _, errOut, code := runCmd(t, "{a: 1}", "validate", "-json"); code != 1 || !strings.Contains(errOut, "unquoted") {
+		t.Fatalf("validate -json = %d, %q", code, errOut)
+	}
+	if _, errOut, code := runCmd(t, "", "validate"); code != 1 || !strings.Contains(errOut, "empty") {
+		t.Fatalf("validate of empty input = %d, %q", code, errOut)
+	}
+	if _, errOut, code := runCmd(t, "class A: x\n\n[A(1), B(2)]", "validate"); code != 1 || !strings.Contains(errOut, "3 | [A(1), B(2)]\n  |        ^\n") {
+		t.Fatalf("validate of undefined class = %d, %q", code, errOut)
+	}
+}
+
+func TestStats(t *testing.T) {
+	out, errOut, code := runCmd(t, `[{"name":"a","age":1},{"name":"b","age":2},{"name":"c","age":3}]`, "stats")
+	if code != 0 {
+		t.Fatalf("stats failed: %s", errOut)
+	}
+	for _, want := range []string{"JSON:", "TRON:", "Savings:", "Classes:         1"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("stats output %q lacks %q", out, want)
+		}
+	}
+}
+
+func TestClasses(t *testing.T) {
+	out, errOut, code := runCmd(t, "class A: x,y\nclass B: a,b\n\n[A(1,2),A(3,B(4,5))]", "classes")
+	if code != 0 {
+		t.Fatalf("classes failed: %s", errOut)
+	}
+	want := "CLASS  USES  PROPERTIES\nA      2     x,y\nB      1     a,b\n"
+	if out != want {
+		t.Fatalf("classes = %q, want %q", out, want)
+	}
+}
+
+func TestLint(t *testing.T) {
+	src := "class A: x,y\n\n{\"a\": A(1, \"2\")}"
+	out, _, code := runCmd(t, src, "lint", "-rules", "numeric-string")
+	if want := "<stdin>: $.a.y: string \"2\" holds a number (numeric-string)\n"; code != 1 || out != want {
+		t.Fatalf("lint = %q (%d), want %q", out, code, want)
+	}
+	out, _, _ = runCmd(t, src, "lint", "-json", "-rules", "single-use-class")
+	if want := `{"file":"<stdin>","rule":"single-use-class","path":"class A","message":"class is used only once; an object literal would be shorter"}` + "\n"; out != want {
+		t.Fatalf("lint -json = %q, want %q", out, want)
+	}
+	if out, _, code := runCmd(t, "[1, 2]", "lint"); code != 0 || out != "" {
+		t.Fatalf("lint of clean input = %q (%d)", out, code)
+	}
+	if _, _, code := runCmd(t, "[]", "lint", "-rules", "bogus"); code != 2 {
+		t.Fatalf("lint with unknown rule = %d, want 2", code)
+	}
+}
+
+func TestGenGo(t *testing.T) {
+	src := "class User: id,name,created_at,tags=null\n\n[User(1,\"ann\",\"2024-01-01\",[\"a\"]),User(2,\"bob\",null)]"
+	out, errOut, code := runCmd(t, src, "gen-go", "-pkg", "models")
+	if code != 0 {
+		t.Fatalf("gen-go failed: %s", errOut)
+	}
+	want := "// Code generated from TRON class definitions. DO NOT EDIT.\n\npackage models\n\n" +
+		"type User struct {\n" +
+		"\tID        int      `json:\"id\"`\n" +
+		"\tName      string   `json:\"name\"`\n" +
+		"\tCreatedAt string   `json:\"created_at\"`\n" +
+		"\tTags      []string `json:\"tags\" tron:\"tags,omitempty\"`\n" +
+		"}\n"
+	if out != want {
+		t.Fatalf("gen-go = %q, want %q", out, want)
+	}
+	if _, _, code := runCmd(t, "[1,", "gen-go"); code != 1 {
+		t.Fatalf("gen-go of invalid input = %d, want 1", code)
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	if _, errOut, code := runCmd(t, "", "frobnicate"); code != 2 || !strings.Contains(errOut, "unknown command") {
+		t.Fatalf("got %d, %q", code, errOut)
+	}
+}
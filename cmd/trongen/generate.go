@@ -0,0 +1,894 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tron-format/trongo/pkg/tron"
+)
+
+// directive marks a struct type for generation when it appears as a line of
+// the type's doc comment.
+const directive = "//trongen:generate"
+
+// kind classifies the field types trongen knows how to encode.
+type kind int
+
+const (
+	kindBool kind = iota
+	kindString
+	kindInt
+	kindUint
+	kindFloat
+	kindTime
+	kindBytes
+	kindStruct
+	kindPtr
+	kindSlice
+	kindMap
+)
+
+// goType describes a supported field type.
+type goType struct {
+	kind kind
+	name string  // Go spelling of the type, e.g. "[]Item"
+	bits int     // bit size for numbers; 0 means int or uint
+	elem *goType // element type for pointers, slices and maps
+}
+
+// field is an exported struct field as Marshal sees it.
+type field struct {
+	goName    string
+	key       string
+	typ       *goType
+	omitempty bool
+	omitzero  bool
+}
+
+// structType is a struct type to generate code for.
+type structType struct {
+	name   string
+	fields []field
+	decl   *ast.StructType
+}
+
+// generator accumulates the generated file for one input file.
+type generator struct {
+	structs map[string]*structType
+	order   []*structType
+	timePkg string // local name of the "time" import
+	suffix  string // makes file-level helper names unique within the package
+	buf     bytes.Buffer
+}
+
+// generate returns the formatted source of the generated file for the struct
+// types in src that carry the directive or are listed in extra. outName is the
+// base name of the output file.
+func generate(filename string, src []byte, extra []string, outName string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		structs: make(map[string]*structType),
+		timePkg: importName(file, "time"),
+		suffix:  helperSuffix(outName),
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range extra {
+		wanted[strings.TrimSpace(name)] = true
+	}
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			marked := hasDirective(ts.Doc) || (len(gd.Specs) == 1 && hasDirective(gd.Doc))
+			if !marked && !wanted[ts.Name.Name] {
+				continue
+			}
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok || ts.TypeParams != nil {
+				return nil, fmt.Errorf("%s: %s is not a non-generic struct type", fset.Position(ts.Pos()), ts.Name.Name)
+			}
+			delete(wanted, ts.Name.Name)
+			s := &structType{name: ts.Name.Name, decl: st}
+			g.structs[s.name] = s
+			g.order = append(g.order, s)
+		}
+	}
+	for name := range wanted {
+		return nil, fmt.Errorf("type %s not found in %s", name, filename)
+	}
+	if len(g.order) == 0 {
+		return nil, fmt.Errorf("%s: no struct types marked with %s", filename, directive)
+	}
+
+	// Resolve fields once every generated type is known, so fields can refer
+	// to types declared later in the file.
+	for _, s := range g.order {
+		if err := g.resolveFields(fset, s); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range g.order {
+		g.genEncode(s)
+		g.genDecode(s)
+	}
+	g.genHelpers()
+
+	return g.format(file.Name.Name)
+}
+
+// hasDirective reports whether a doc comment contains the directive line.
+func hasDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// importName returns the local name under which file imports path, or the
+// last path element if it is not imported.
+func importName(file *ast.File, path string) string {
+	for _, imp := range file.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p == path && imp.Name != nil {
+			return imp.Name.Name
+		}
+	}
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// helperSuffix derives an identifier suffix from the output file name, so two
+// generated files in one package do not declare the same helpers.
+func helperSuffix(outName string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range strings.TrimSuffix(outName, ".go") {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// resolveFields fills s.fields, applying the same tag rules as Marshal.
+func (g *generator) resolveFields(fset *token.FileSet, s *structType) error {
+	for _, f := range s.decl.Fields.List {
+		if len(f.Names) == 0 {
+			return fmt.Errorf("%s: %s: embedded fields are not supported", fset.Position(f.Pos()), s.name)
+		}
+		tag := ""
+		if f.Tag != nil {
+			raw, _ := strconv.Unquote(f.Tag.Value)
+			st := reflect.StructTag(raw)
+			if v, ok := st.Lookup("tron"); ok {
+				tag = v
+			} else {
+				tag = st.Get("json")
+			}
+		}
+		for _, name := range f.Names {
+			if !name.IsExported() {
+				continue
+			}
+			fd := field{goName: name.Name, key: name.Name}
+			if tag != "" {
+				parts := strings.Split(tag, ",")
+				if parts[0] == "-" {
+					continue
+				}
+				if parts[0] != "" {
+					fd.key = parts[0]
+				}
+				for _, opt := range parts[1:] {
+					switch opt {
+					case "omitempty":
+						fd.omitempty = true
+					case "omitzero":
+						fd.omitzero = true
+					case "inline", "remain":
+						return fmt.Errorf("%s: %s.%s: the %q option is not supported", fset.Position(f.Pos()), s.name, name.Name, opt)
+					}
+				}
+			}
+			typ, err := g.resolve(f.Type)
+			if err != nil {
+				return fmt.Errorf("%s: %s.%s: %v", fset.Position(f.Pos()), s.name, name.Name, err)
+			}
+			if fd.omitzero && typ.kind == kindStruct {
+				return fmt.Errorf("%s: %s.%s: omitzero is not supported on struct fields", fset.Position(f.Pos()), s.name, name.Name)
+			}
+			fd.typ = typ
+			s.fields = append(s.fields, fd)
+		}
+	}
+	return nil
+}
+
+// resolve maps a field type expression to a supported goType.
+func (g *generator) resolve(expr ast.Expr) (*goType, error) {
+	t := &goType{name: types.ExprString(expr)}
+	switch x := expr.(type) {
+	case *ast.Ident:
+		switch x.Name {
+		case "bool":
+			t.kind = kindBool
+		case "string":
+			t.kind = kindString
+		case "int":
+			t.kind = kindInt
+		case "int8", "int16", "int32", "int64":
+			t.kind, t.bits = kindInt, mustAtoi(x.Name[3:])
+		case "rune":
+			t.kind, t.bits = kindInt, 32
+		case "uint":
+			t.kind = kindUint
+		case "uint8", "uint16", "uint32", "uint64":
+			t.kind, t.bits = kindUint, mustAtoi(x.Name[4:])
+		case "byte":
+			t.kind, t.bits = kindUint, 8
+		case "float32", "float64":
+			t.kind, t.bits = kindFloat, mustAtoi(x.Name[5:])
+		default:
+			if _, ok := g.structs[x.Name]; !ok {
+				return nil, fmt.Errorf("unsupported type %s (mark it with %s)", t.name, directive)
+			}
+			t.kind = kindStruct
+		}
+		return t, nil
+	case *ast.SelectorExpr:
+		if pkg, ok := x.X.(*ast.Ident); ok && pkg.Name == g.timePkg && x.Sel.Name == "Time" {
+			t.kind = kindTime
+			return t, nil
+		}
+	case *ast.StarExpr:
+		elem, err := g.resolve(x.X)
+		if err != nil {
+			return nil, err
+		}
+		t.kind, t.elem = kindPtr, elem
+		return t, nil
+	case *ast.ArrayType:
+		if x.Len != nil {
+			break
+		}
+		elem, err := g.resolve(x.Elt)
+		if err != nil {
+			return nil, err
+		}
+		if elem.kind == kindUint && elem.bits == 8 {
+			t.kind = kindBytes
+		} else {
+			t.kind, t.elem = kindSlice, elem
+		}
+		return t, nil
+	case *ast.MapType:
+		if key, ok := x.Key.(*ast.Ident); !ok || key.Name != "string" {
+			break
+		}
+		elem, err := g.resolve(x.Value)
+		if err != nil {
+			return nil, err
+		}
+		t.kind, t.elem = kindMap, elem
+		return t, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t.name)
+}
+
+func mustAtoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// usesStruct reports whether encoding t calls a generated AppendTRON method.
+func usesStruct(t *goType) bool {
+	for ; t != nil; t = t.elem {
+		if t.kind == kindStruct {
+			return true
+		}
+	}
+	return false
+}
+
+// p writes one line of generated code.
+func (g *generator) p(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+// appendLiteral emits code appending the constant string s.
+func (g *generator) appendLiteral(s string) {
+	if len(s) == 1 {
+		g.p("dst = append(dst, %s)", strconv.QuoteRune(rune(s[0])))
+		return
+	}
+	g.p("dst = append(dst, %s...)", strconv.Quote(s))
+}
+
+// genEncode emits AppendTRON, MarshalTRON and the slice encoder for s.
+func (g *generator) genEncode(s *structType) {
+	needErr := false
+	firstOptional := -1
+	for i, f := range s.fields {
+		needErr = needErr || usesStruct(f.typ)
+		if firstOptional < 0 && (f.omitempty || f.omitzero) {
+			firstOptional = i
+		}
+	}
+	// Commas are known statically until a field may have been omitted.
+	dynamic := firstOptional >= 0 && firstOptional < len(s.fields)-1
+
+	g.p("// AppendTRON appends the TRON encoding of v, an object, to dst.")
+	g.p("func (v %s) AppendTRON(dst []byte) ([]byte, error) {", s.name)
+	if needErr {
+		g.p("var err error")
+	}
+	g.appendLiteral("{")
+	if dynamic {
+		g.p("start := len(dst)")
+	}
+	for i, f := range s.fields {
+		key := string(tron.AppendString(nil, f.key)) + ":"
+		x := "v." + f.goName
+		cond := presentCondition(f, x)
+		if cond != "" {
+			g.p("if %s {", cond)
+		}
+		switch {
+		case firstOptional >= 0 && i > firstOptional:
+			g.p("if len(dst) > start {")
+			g.appendLiteral(",")
+			g.p("}")
+			g.appendLiteral(key)
+		case i > 0:
+			g.appendLiteral("," + key)
+		default:
+			g.appendLiteral(key)
+		}
+		g.encodeValue(f.typ, x, 0)
+		if cond != "" {
+			g.p("}")
+		}
+	}
+	g.appendLiteral("}")
+	g.p("return dst, nil")
+	g.p("}")
+	g.p("")
+
+	g.p("// MarshalTRON implements tron.Marshaler.")
+	g.p("func (v %s) MarshalTRON() ([]byte, error) {", s.name)
+	g.p("return v.AppendTRON(nil)")
+	g.p("}")
+	g.p("")
+
+	if len(s.fields) == 0 {
+		return
+	}
+
+	var header strings.Builder
+	header.WriteString("class A: ")
+	for i, f := range s.fields {
+		if i > 0 {
+			header.WriteByte(',')
+		}
+		if isValidIdentifier(f.key) {
+			header.WriteString(f.key)
+		} else {
+			header.Write(tron.AppendString(nil, f.key))
+		}
+	}
+	header.WriteString("\n\n")
+
+	g.p("// tronClass%s is the class definition Marshal%sSlice declares for %s.", s.name, s.name, s.name)
+	g.p("const tronClass%s = %s", s.name, strconv.Quote(header.String()))
+	g.p("")
+	g.p("// Marshal%sSlice encodes vs as a TRON document that defines a class for", s.name)
+	g.p("// %s once and instantiates it for every element. Instantiations always", s.name)
+	g.p("// carry every field, so omitempty and omitzero do not apply.")
+	g.p("func Marshal%sSlice(vs []%s) ([]byte, error) {", s.name, s.name)
+	g.p("if vs == nil {")
+	g.p(`return []byte("null"), nil`)
+	g.p("}")
+	g.p("if len(vs) == 0 {")
+	g.p(`return []byte("[]"), nil`)
+	g.p("}")
+	if needErr {
+		g.p("var err error")
+	}
+	g.p("dst := append([]byte(nil), tronClass%s...)", s.name)
+	g.appendLiteral("[")
+	g.p("for i := range vs {")
+	g.p("v := &vs[i]")
+	g.p("if i > 0 {")
+	g.appendLiteral(",")
+	g.p("}")
+	g.appendLiteral("A(")
+	for i, f := range s.fields {
+		if i > 0 {
+			g.appendLiteral(",")
+		}
+		g.encodeValue(f.typ, "v."+f.goName, 0)
+	}
+	g.appendLiteral(")")
+	g.p("}")
+	g.appendLiteral("]")
+	g.p("return dst, nil")
+	g.p("}")
+	g.p("")
+}
+
+// presentCondition returns the Go condition under which field f, read as x,
+// is encoded, or "" if it always is. It mirrors Marshal's omitempty and
+// omitzero rules.
+func presentCondition(f field, x string) string {
+	var conds []string
+	if f.omitempty {
+		switch f.typ.kind {
+		case kindBool:
+			conds = append(conds, x)
+		case kindString:
+			conds = append(conds, x+` != ""`)
+		case kindInt, kindUint, kindFloat:
+			conds = append(conds, x+" != 0")
+		case kindPtr:
+			conds = append(conds, x+" != nil")
+		case kindBytes, kindSlice, kindMap:
+			conds = append(conds, "len("+x+") != 0")
+		}
+	}
+	if f.omitzero {
+		switch f.typ.kind {
+		case kindBool:
+			conds = append(conds, x)
+		case kindString:
+			conds = append(conds, x+` != ""`)
+		case kindInt, kindUint, kindFloat:
+			conds = append(conds, x+" != 0")
+		case kindPtr, kindBytes, kindSlice, kindMap:
+			conds = append(conds, x+" != nil")
+		case kindTime:
+			conds = append(conds, "!"+x+".IsZero()")
+		}
+	}
+	return strings.Join(conds, " && ")
+}
+
+// encodeValue emits code appending the encoding of x, of type t, to dst.
+// depth keeps loop variables of nested containers distinct.
+func (g *generator) encodeValue(t *goType, x string, depth int) {
+	switch t.kind {
+	case kindBool:
+		g.p("dst = strconv.AppendBool(dst, %s)", x)
+	case kindString:
+		g.p("dst = tron.AppendString(dst, %s)", x)
+	case kindInt:
+		g.p("dst = strconv.AppendInt(dst, int64(%s), 10)", x)
+	case kindUint:
+		g.p("dst = strconv.AppendUint(dst, uint64(%s), 10)", x)
+	case kindFloat:
+		g.p("dst = strconv.AppendFloat(dst, float64(%s), 'g', -1, %d)", x, t.bits)
+	case kindTime:
+		g.p("dst = tron.AppendString(dst, %s.Format(%s.RFC3339Nano))", x, g.timePkg)
+	case kindBytes:
+		g.p("if %s == nil {", x)
+		g.appendLiteral("null")
+		g.p("} else {")
+		g.appendLiteral(`"`)
+		g.p("dst = base64.StdEncoding.AppendEncode(dst, %s)", x)
+		g.appendLiteral(`"`)
+		g.p("}")
+	case kindStruct:
+		g.p("if dst, err = %s.AppendTRON(dst); err != nil {", x)
+		g.p("return dst, err")
+		g.p("}")
+	case kindPtr:
+		g.p("if %s == nil {", x)
+		g.appendLiteral("null")
+		g.p("} else {")
+		g.encodeValue(t.elem, "(*"+x+")", depth)
+		g.p("}")
+	case kindSlice:
+		i, e := fmt.Sprintf("i%d", depth), fmt.Sprintf("e%d", depth)
+		g.p("if %s == nil {", x)
+		g.appendLiteral("null")
+		g.p("} else {")
+		g.appendLiteral("[")
+		g.p("for %s, %s := range %s {", i, e, x)
+		g.p("if %s > 0 {", i)
+		g.appendLiteral(",")
+		g.p("}")
+		g.encodeValue(t.elem, e, depth+1)
+		g.p("}")
+		g.appendLiteral("]")
+		g.p("}")
+	case kindMap:
+		i, k, keys := fmt.Sprintf("i%d", depth), fmt.Sprintf("k%d", depth), fmt.Sprintf("keys%d", depth)
+		g.p("if %s == nil {", x)
+		g.appendLiteral("null")
+		g.p("} else {")
+		g.p("%s := make([]string, 0, len(%s))", keys, x)
+		g.p("for %s := range %s {", k, x)
+		g.p("%s = append(%s, %s)", keys, keys, k)
+		g.p("}")
+		g.p("sort.Strings(%s)", keys)
+		g.appendLiteral("{")
+		g.p("for %s, %s := range %s {", i, k, keys)
+		g.p("if %s > 0 {", i)
+		g.appendLiteral(",")
+		g.p("}")
+		g.p("dst = tron.AppendString(dst, %s)", k)
+		g.appendLiteral(":")
+		g.encodeValue(t.elem, x+"["+k+"]", depth+1)
+		g.p("}")
+		g.appendLiteral("}")
+		g.p("}")
+	}
+}
+
+// genDecode emits UnmarshalTRON, decodeTRON and the key lookup for s.
+func (g *generator) genDecode(s *structType) {
+	// Build the key tables the way Unmarshal does: exact names, then
+	// lowercase forms for case-insensitive matching, where the first field
+	// wins. An exact match is always tried first.
+	exact := make(map[string]int)
+	folded := make(map[string]int)
+	for i, f := range s.fields {
+		exact[f.key] = i
+		if _, ok := folded[strings.ToLower(f.key)]; !ok {
+			folded[strings.ToLower(f.key)] = i
+		}
+	}
+
+	g.p("// tronField%s maps an object key to the index of a field of %s, or -1.", s.name, s.name)
+	g.p("func tronField%s(key string) int {", s.name)
+	for _, table := range []struct {
+		match string
+		byKey map[string]int
+	}{{"key", exact}, {"strings.ToLower(key)", folded}} {
+		if len(table.byKey) == 0 {
+			break
+		}
+		keys := make([]string, 0, len(table.byKey))
+		for k := range table.byKey {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		g.p("switch %s {", table.match)
+		for _, k := range keys {
+			g.p("case %s:", strconv.Quote(k))
+			g.p("return %d", table.byKey[k])
+		}
+		g.p("}")
+	}
+	g.p("return -1")
+	g.p("}")
+	g.p("")
+
+	g.p("// UnmarshalTRON implements tron.Unmarshaler. It accepts the object form")
+	g.p("// produced by MarshalTRON, which is also the form in which tron.Unmarshal")
+	g.p("// passes values to unmarshalers.")
+	g.p("func (v *%s) UnmarshalTRON(data []byte) error {", s.name)
+	g.p("dec := json.NewDecoder(bytes.NewReader(data))")
+	g.p("dec.UseNumber()")
+	g.p("tok, err := dec.Token()")
+	g.p("if err != nil {")
+	g.p("return err")
+	g.p("}")
+	g.p("return v.decodeTRON(dec, tok)")
+	g.p("}")
+	g.p("")
+
+	g.p("// decodeTRON decodes the value that starts with tok into v.")
+	g.p("func (v *%s) decodeTRON(dec *json.Decoder, tok json.Token) error {", s.name)
+	g.p("if tok == nil {")
+	g.p("return nil")
+	g.p("}")
+	g.p("if tok != json.Delim('{') {")
+	g.p("return &tron.UnmarshalTypeError{Value: tronKind%s(tok), Type: reflect.TypeFor[%s]()}", g.suffix, s.name)
+	g.p("}")
+	g.p("for dec.More() {")
+	g.p("tok, err := dec.Token()")
+	g.p("if err != nil {")
+	g.p("return err")
+	g.p("}")
+	g.p("key, _ := tok.(string)")
+	g.p("if tok, err = dec.Token(); err != nil {")
+	g.p("return err")
+	g.p("}")
+	g.p("switch tronField%s(key) {", s.name)
+	for i, f := range s.fields {
+		g.p("case %d:", i)
+		g.decodeValue(f.typ, "v."+f.goName, 0, s, f)
+	}
+	g.p("default:")
+	g.p("if err := tronSkip%s(dec, tok); err != nil {", g.suffix)
+	g.p("return err")
+	g.p("}")
+	g.p("}")
+	g.p("}")
+	g.p("_, err := dec.Token()")
+	g.p("return err")
+	g.p("}")
+	g.p("")
+}
+
+// typeError returns the expression for an UnmarshalTypeError against field f
+// of s, with value as its Value.
+func (g *generator) typeError(value string, s *structType, f field) string {
+	return fmt.Sprintf("&tron.UnmarshalTypeError{Value: %s, Type: reflect.TypeFor[%s](), Struct: %q, Field: %q}",
+		value, f.typ.name, s.name, f.goName)
+}
+
+// decodeValue emits code decoding the value that starts with tok into lv, of
+// type t. Like Unmarshal, null leaves scalars untouched and sets pointers,
+// slices and maps to nil.
+func (g *generator) decodeValue(t *goType, lv string, depth int, s *structType, f field) {
+	kindErr := g.typeError("tronKind"+g.suffix+"(tok)", s, f)
+	switch t.kind {
+	case kindBool:
+		g.p("switch tv := tok.(type) {")
+		g.p("case nil:")
+		g.p("case bool:")
+		g.p("%s = tv", lv)
+		g.p("default:")
+		g.p("return %s", kindErr)
+		g.p("}")
+	case kindString:
+		g.p("switch tv := tok.(type) {")
+		g.p("case nil:")
+		g.p("case string:")
+		g.p("%s = tv", lv)
+		g.p("default:")
+		g.p("return %s", kindErr)
+		g.p("}")
+	case kindInt, kindUint, kindFloat:
+		parse := map[kind]string{
+			kindInt:   "strconv.ParseInt(string(tv), 10, %d)",
+			kindUint:  "strconv.ParseUint(string(tv), 10, %d)",
+			kindFloat: "strconv.ParseFloat(string(tv), %d)",
+		}[t.kind]
+		bits := t.bits
+		if bits == 0 {
+			bits = strconv.IntSize
+		}
+		g.p("switch tv := tok.(type) {")
+		g.p("case nil:")
+		g.p("case json.Number:")
+		g.p("n, err := "+parse, bits)
+		g.p("if err != nil {")
+		g.p("return %s", g.typeError(`"number "+string(tv)`, s, f))
+		g.p("}")
+		g.p("%s = %s(n)", lv, t.name)
+		g.p("default:")
+		g.p("return %s", kindErr)
+		g.p("}")
+	case kindTime:
+		g.p("switch tv := tok.(type) {")
+		g.p("case nil:")
+		g.p("case string:")
+		g.p("tm, err := %s.Parse(%s.RFC3339Nano, tv)", g.timePkg, g.timePkg)
+		g.p("if err != nil {")
+		g.p("return %s", g.typeError(`"string "+strconv.Quote(tv)`, s, f))
+		g.p("}")
+		g.p("%s = tm", lv)
+		g.p("default:")
+		g.p("return %s", kindErr)
+		g.p("}")
+	case kindBytes:
+		g.p("switch tv := tok.(type) {")
+		g.p("case nil:")
+		g.p("%s = nil", lv)
+		g.p("case string:")
+		g.p("b, err := base64.StdEncoding.DecodeString(tv)")
+		g.p("if err != nil {")
+		g.p("return err")
+		g.p("}")
+		g.p("%s = b", lv)
+		g.p("default:")
+		g.p("return %s", kindErr)
+		g.p("}")
+	case kindStruct:
+		g.p("if err := %s.decodeTRON(dec, tok); err != nil {", lv)
+		g.p("return err")
+		g.p("}")
+	case kindPtr:
+		g.p("if tok == nil {")
+		g.p("%s = nil", lv)
+		g.p("} else {")
+		g.p("if %s == nil {", lv)
+		g.p("%s = new(%s)", lv, t.elem.name)
+		g.p("}")
+		g.decodeValue(t.elem, "(*"+lv+")", depth, s, f)
+		g.p("}")
+	case kindSlice:
+		sv, e := fmt.Sprintf("s%d", depth), fmt.Sprintf("e%d", depth)
+		g.p("switch tok {")
+		g.p("case nil:")
+		g.p("%s = nil", lv)
+		g.p("case json.Delim('['):")
+		g.p("%s := make(%s, 0)", sv, t.name)
+		g.p("for dec.More() {")
+		g.p("tok, err := dec.Token()")
+		g.p("if err != nil {")
+		g.p("return err")
+		g.p("}")
+		g.p("var %s %s", e, t.elem.name)
+		g.decodeValue(t.elem, e, depth+1, s, f)
+		g.p("%s = append(%s, %s)", sv, sv, e)
+		g.p("}")
+		g.p("if _, err := dec.Token(); err != nil {")
+		g.p("return err")
+		g.p("}")
+		g.p("%s = %s", lv, sv)
+		g.p("default:")
+		g.p("return %s", kindErr)
+		g.p("}")
+	case kindMap:
+		k, e := fmt.Sprintf("k%d", depth), fmt.Sprintf("e%d", depth)
+		g.p("switch tok {")
+		g.p("case nil:")
+		g.p("%s = nil", lv)
+		g.p("case json.Delim('{'):")
+		g.p("if %s == nil {", lv)
+		g.p("%s = make(%s)", lv, t.name)
+		g.p("}")
+		g.p("for dec.More() {")
+		g.p("tok, err := dec.Token()")
+		g.p("if err != nil {")
+		g.p("return err")
+		g.p("}")
+		g.p("%s, _ := tok.(string)", k)
+		g.p("if tok, err = dec.Token(); err != nil {")
+		g.p("return err")
+		g.p("}")
+		g.p("var %s %s", e, t.elem.name)
+		g.decodeValue(t.elem, e, depth+1, s, f)
+		g.p("%s[%s] = %s", lv, k, e)
+		g.p("}")
+		g.p("if _, err := dec.Token(); err != nil {")
+		g.p("return err")
+		g.p("}")
+		g.p("default:")
+		g.p("return %s", kindErr)
+		g.p("}")
+	}
+}
+
+// genHelpers emits the file-level helpers shared by the decoders.
+func (g *generator) genHelpers() {
+	g.p("// tronSkip%s consumes the rest of the value that starts with tok.", g.suffix)
+	g.p("func tronSkip%s(dec *json.Decoder, tok json.Token) error {", g.suffix)
+	g.p("for depth := 0; ; {")
+	g.p("switch tok {")
+	g.p("case json.Delim('{'), json.Delim('['):")
+	g.p("depth++")
+	g.p("case json.Delim('}'), json.Delim(']'):")
+	g.p("depth--")
+	g.p("}")
+	g.p("if depth == 0 {")
+	g.p("return nil")
+	g.p("}")
+	g.p("var err error")
+	g.p("if tok, err = dec.Token(); err != nil {")
+	g.p("return err")
+	g.p("}")
+	g.p("}")
+	g.p("}")
+	g.p("")
+	g.p("// tronKind%s names the kind of the value that starts with tok, for errors.", g.suffix)
+	g.p("func tronKind%s(tok json.Token) string {", g.suffix)
+	g.p("switch tok.(type) {")
+	g.p("case nil:")
+	g.p(`return "null"`)
+	g.p("case bool:")
+	g.p(`return "bool"`)
+	g.p("case json.Number:")
+	g.p(`return "number"`)
+	g.p("case string:")
+	g.p(`return "string"`)
+	g.p("}")
+	g.p("if tok == json.Delim('[') {")
+	g.p(`return "array"`)
+	g.p("}")
+	g.p(`return "object"`)
+	g.p("}")
+}
+
+// format prepends the header and imports to the generated body and gofmts it.
+func (g *generator) format(pkg string) ([]byte, error) {
+	body := g.buf.String()
+	var imports []string
+	for _, imp := range []struct{ name, path string }{
+		{"base64", "encoding/base64"},
+		{"bytes", "bytes"},
+		{"json", "encoding/json"},
+		{"reflect", "reflect"},
+		{"sort", "sort"},
+		{"strconv", "strconv"},
+		{"strings", "strings"},
+		{g.timePkg, "time"},
+		{"tron", "github.com/tron-format/trongo/pkg/tron"},
+	} {
+		if !regexp.MustCompile(`\b` + imp.name + `\.`).MatchString(body) {
+			continue
+		}
+		spec := strconv.Quote(imp.path)
+		if imp.path == "time" && imp.name != "time" {
+			spec = imp.name + " " + spec
+		}
+		imports = append(imports, spec)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by trongen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	out.WriteString("import (\n")
+	for _, spec := range imports {
+		if strings.Contains(spec, ".") {
+			// Separate non-standard imports from the standard library.
+			out.WriteString("\n")
+		}
+		fmt.Fprintf(&out, "\t%s\n", spec)
+	}
+	out.WriteString(")\n\n")
+	out.WriteString(body)
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+// isValidIdentifier reports whether s can appear unquoted in a class header,
+// matching the rule Marshal uses.
+func isValidIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if i == 0 {
+			if !(unicode.IsLetter(r) || r == '_') {
+				return false
+			}
+			continue
+		}
+		if !(unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_') {
+			return false
+		}
+	}
+	return true
+}
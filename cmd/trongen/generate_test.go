@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateMatchesCommittedExample(t *testing.T) {
+	dir := filepath.Join("internal", "example")
+	src, err := os.ReadFile(filepath.Join(dir, "example.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join(dir, "example_tron.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := generate("example.go", src, nil, "example_tron.go")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("example_tron.go is stale; run go generate ./cmd/trongen/internal/example")
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		extra []string
+		want  string
+	}{
+		{
+			name: "no types",
+			src:  "package p\ntype T struct{ A int }\n",
+			want: "no struct types marked with //trongen:generate",
+		},
+		{
+			name:  "missing type",
+			src:   "package p\n",
+			extra: []string{"T"},
+			want:  "type T not found in in.go",
+		},
+		{
+			name:  "not a struct",
+			src:   "package p\ntype T int\n",
+			extra: []string{"T"},
+			want:  "T is not a non-generic struct type",
+		},
+		{
+			name:  "unsupported field",
+			src:   "package p\ntype T struct{ C chan int }\n",
+			extra: []string{"T"},
+			want:  "T.C: unsupported type",
+		},
+		{
+			name:  "unmarked struct field",
+			src:   "package p\ntype U struct{}\ntype T struct{ U U }\n",
+			extra: []string{"T"},
+			want:  "unsupported type U (mark it with //trongen:generate)",
+		},
+		{
+			name:  "embedded field",
+			src:   "package p\ntype U struct{}\ntype T struct{ U }\n",
+			extra: []string{"T", "U"},
+			want:  "embedded fields are not supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := generate("in.go", []byte(tt.src), tt.extra, "in_tron.go")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("generate error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
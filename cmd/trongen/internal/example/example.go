@@ -0,0 +1,42 @@
+// Package example holds types used to test the code trongen generates.
+package example
+
+//go:generate go run github.com/tron-format/trongo/cmd/trongen example.go
+
+import "time"
+
+// Item is a line item.
+//
+//trongen:generate
+type Item struct {
+	SKU      string            `json:"sku"`
+	Quantity int               `json:"qty"`
+	Price    float64           `json:"price"`
+	Tags     []string          `json:"tags,omitempty"`
+	Attrs    map[string]string `json:"attrs,omitempty"`
+}
+
+// Order is a customer order.
+//
+//trongen:generate
+type Order struct {
+	ID       uint64    `tron:"id" json:"order_id"`
+	Customer *Customer `json:"customer"`
+	Items    []Item    `json:"items"`
+	Placed   time.Time `json:"placed"`
+	Paid     bool      `json:"paid"`
+	Note     string    `json:"note,omitempty"`
+	Payload  []byte    `json:"payload"`
+	Ratings  []float32 `json:"ratings"`
+	Internal string    `json:"-"`
+	secret   string
+}
+
+// Customer is the buyer of an order.
+//
+//trongen:generate
+type Customer struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Level int8   `json:"level"`
+}
@@ -0,0 +1,122 @@
+package example
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tron-format/trongo/pkg/tron"
+)
+
+// The plain types have the same fields but none of the generated methods, so
+// tron.Marshal encodes them by reflection.
+type (
+	plainItem  Item
+	plainOrder Order
+)
+
+func sampleOrder() Order {
+	return Order{
+		ID:       42,
+		Customer: &Customer{Name: "Ada <ada@example.com>", Email: "ada@example.com", Level: -3},
+		Items: []Item{
+			{SKU: "A-1", Quantity: 2, Price: 9.99, Tags: []string{"new"}, Attrs: map[string]string{"b": "2", "a": "1"}},
+			{SKU: "B\n2", Quantity: 1, Price: 1e21},
+		},
+		Placed:  time.Date(2024, 5, 1, 12, 30, 0, 123, time.UTC),
+		Paid:    true,
+		Payload: []byte{0, 1, 2, 250},
+		Ratings: []float32{4.5, 0.1},
+	}
+}
+
+func TestGeneratedMarshalMatchesReflection(t *testing.T) {
+	o := sampleOrder()
+	got, err := o.MarshalTRON()
+	if err != nil {
+		t.Fatalf("MarshalTRON: %v", err)
+	}
+	want, err := tron.Marshal(plainOrder(o))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("MarshalTRON =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGeneratedSliceMatchesReflection(t *testing.T) {
+	items := []Item{
+		{SKU: "a", Quantity: 1, Price: 1.5, Tags: []string{"x"}, Attrs: map[string]string{"k": "v"}},
+		{SKU: "b", Quantity: 2, Price: 2, Tags: []string{"y", "z"}, Attrs: map[string]string{"k": "w"}},
+	}
+	got, err := MarshalItemSlice(items)
+	if err != nil {
+		t.Fatalf("MarshalItemSlice: %v", err)
+	}
+	want, err := tron.Marshal([]plainItem{plainItem(items[0]), plainItem(items[1])})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("MarshalItemSlice =\n%s\nwant\n%s", got, want)
+	}
+
+	var back []Item
+	if err := tron.Unmarshal(got, &back); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(back, items) {
+		t.Fatalf("round trip = %+v, want %+v", back, items)
+	}
+}
+
+func TestGeneratedUnmarshalRoundTrip(t *testing.T) {
+	o := sampleOrder()
+	data, err := o.MarshalTRON()
+	if err != nil {
+		t.Fatalf("MarshalTRON: %v", err)
+	}
+
+	var direct Order
+	if err := direct.UnmarshalTRON(data); err != nil {
+		t.Fatalf("UnmarshalTRON: %v", err)
+	}
+	if !reflect.DeepEqual(direct, o) {
+		t.Fatalf("UnmarshalTRON = %+v, want %+v", direct, o)
+	}
+
+	var viaUnmarshal Order
+	if err := tron.Unmarshal(data, &viaUnmarshal); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(viaUnmarshal, o) {
+		t.Fatalf("Unmarshal = %+v, want %+v", viaUnmarshal, o)
+	}
+}
+
+func TestGeneratedUnmarshalMatchesKeysLikeUnmarshal(t *testing.T) {
+	var c Customer
+	err := c.UnmarshalTRON([]byte(`{"NAME":"x","unknown":{"deep":[1,{"a":null}]},"Level":7,"email":null}`))
+	if err != nil {
+		t.Fatalf("UnmarshalTRON: %v", err)
+	}
+	if c != (Customer{Name: "x", Level: 7}) {
+		t.Fatalf("UnmarshalTRON = %+v", c)
+	}
+}
+
+func TestGeneratedUnmarshalTypeErrors(t *testing.T) {
+	var c Customer
+	err := c.UnmarshalTRON([]byte(`{"level":1000}`))
+	var typeErr *tron.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Field != "Level" || typeErr.Struct != "Customer" {
+		t.Fatalf("expected overflow UnmarshalTypeError on Customer.Level, got %v", err)
+	}
+
+	err = c.UnmarshalTRON([]byte(`{"name":["x"]}`))
+	if !errors.As(err, &typeErr) || typeErr.Value != "array" {
+		t.Fatalf("expected array UnmarshalTypeError, got %v", err)
+	}
+}
@@ -0,0 +1,835 @@
+// Code generated by trongen. DO NOT EDIT.
+
+package example
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tron-format/trongo/pkg/tron"
+)
+
+// AppendTRON appends the TRON encoding of v, an object, to dst.
+func (v Item) AppendTRON(dst []byte) ([]byte, error) {
+	dst = append(dst, '{')
+	start := len(dst)
+	dst = append(dst, "\"sku\":"...)
+	dst = tron.AppendString(dst, v.SKU)
+	dst = append(dst, ",\"qty\":"...)
+	dst = strconv.AppendInt(dst, int64(v.Quantity), 10)
+	dst = append(dst, ",\"price\":"...)
+	dst = strconv.AppendFloat(dst, float64(v.Price), 'g', -1, 64)
+	if len(v.Tags) != 0 {
+		dst = append(dst, ",\"tags\":"...)
+		if v.Tags == nil {
+			dst = append(dst, "null"...)
+		} else {
+			dst = append(dst, '[')
+			for i0, e0 := range v.Tags {
+				if i0 > 0 {
+					dst = append(dst, ',')
+				}
+				dst = tron.AppendString(dst, e0)
+			}
+			dst = append(dst, ']')
+		}
+	}
+	if len(v.Attrs) != 0 {
+		if len(dst) > start {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, "\"attrs\":"...)
+		if v.Attrs == nil {
+			dst = append(dst, "null"...)
+		} else {
+			keys0 := make([]string, 0, len(v.Attrs))
+			for k0 := range v.Attrs {
+				keys0 = append(keys0, k0)
+			}
+			sort.Strings(keys0)
+			dst = append(dst, '{')
+			for i0, k0 := range keys0 {
+				if i0 > 0 {
+					dst = append(dst, ',')
+				}
+				dst = tron.AppendString(dst, k0)
+				dst = append(dst, ':')
+				dst = tron.AppendString(dst, v.Attrs[k0])
+			}
+			dst = append(dst, '}')
+		}
+	}
+	dst = append(dst, '}')
+	return dst, nil
+}
+
+// MarshalTRON implements tron.Marshaler.
+func (v Item) MarshalTRON() ([]byte, error) {
+	return v.AppendTRON(nil)
+}
+
+// tronClassItem is the class definition MarshalItemSlice declares for Item.
+const tronClassItem = "class A: sku,qty,price,tags,attrs\n\n"
+
+// MarshalItemSlice encodes vs as a TRON document that defines a class for
+// Item once and instantiates it for every element. Instantiations always
+// carry every field, so omitempty and omitzero do not apply.
+func MarshalItemSlice(vs []Item) ([]byte, error) {
+	if vs == nil {
+		return []byte("null"), nil
+	}
+	if len(vs) == 0 {
+		return []byte("[]"), nil
+	}
+	dst := append([]byte(nil), tronClassItem...)
+	dst = append(dst, '[')
+	for i := range vs {
+		v := &vs[i]
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, "A("...)
+		dst = tron.AppendString(dst, v.SKU)
+		dst = append(dst, ',')
+		dst = strconv.AppendInt(dst, int64(v.Quantity), 10)
+		dst = append(dst, ',')
+		dst = strconv.AppendFloat(dst, float64(v.Price), 'g', -1, 64)
+		dst = append(dst, ',')
+		if v.Tags == nil {
+			dst = append(dst, "null"...)
+		} else {
+			dst = append(dst, '[')
+			for i0, e0 := range v.Tags {
+				if i0 > 0 {
+					dst = append(dst, ',')
+				}
+				dst = tron.AppendString(dst, e0)
+			}
+			dst = append(dst, ']')
+		}
+		dst = append(dst, ',')
+		if v.Attrs == nil {
+			dst = append(dst, "null"...)
+		} else {
+			keys0 := make([]string, 0, len(v.Attrs))
+			for k0 := range v.Attrs {
+				keys0 = append(keys0, k0)
+			}
+			sort.Strings(keys0)
+			dst = append(dst, '{')
+			for i0, k0 := range keys0 {
+				if i0 > 0 {
+					dst = append(dst, ',')
+				}
+				dst = tron.AppendString(dst, k0)
+				dst = append(dst, ':')
+				dst = tron.AppendString(dst, v.Attrs[k0])
+			}
+			dst = append(dst, '}')
+		}
+		dst = append(dst, ')')
+	}
+	dst = append(dst, ']')
+	return dst, nil
+}
+
+// tronFieldItem maps an object key to the index of a field of Item, or -1.
+func tronFieldItem(key string) int {
+	switch key {
+	case "attrs":
+		return 4
+	case "price":
+		return 2
+	case "qty":
+		return 1
+	case "sku":
+		return 0
+	case "tags":
+		return 3
+	}
+	switch strings.ToLower(key) {
+	case "attrs":
+		return 4
+	case "price":
+		return 2
+	case "qty":
+		return 1
+	case "sku":
+		return 0
+	case "tags":
+		return 3
+	}
+	return -1
+}
+
+// UnmarshalTRON implements tron.Unmarshaler. It accepts the object form
+// produced by MarshalTRON, which is also the form in which tron.Unmarshal
+// passes values to unmarshalers.
+func (v *Item) UnmarshalTRON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	return v.decodeTRON(dec, tok)
+}
+
+// decodeTRON decodes the value that starts with tok into v.
+func (v *Item) decodeTRON(dec *json.Decoder, tok json.Token) error {
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[Item]()}
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+		switch tronFieldItem(key) {
+		case 0:
+			switch tv := tok.(type) {
+			case nil:
+			case string:
+				v.SKU = tv
+			default:
+				return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[string](), Struct: "Item", Field: "SKU"}
+			}
+		case 1:
+			switch tv := tok.(type) {
+			case nil:
+			case json.Number:
+				n, err := strconv.ParseInt(string(tv), 10, 64)
+				if err != nil {
+					return &tron.UnmarshalTypeError{Value: "number " + string(tv), Type: reflect.TypeFor[int](), Struct: "Item", Field: "Quantity"}
+				}
+				v.Quantity = int(n)
+			default:
+				return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[int](), Struct: "Item", Field: "Quantity"}
+			}
+		case 2:
+			switch tv := tok.(type) {
+			case nil:
+			case json.Number:
+				n, err := strconv.ParseFloat(string(tv), 64)
+				if err != nil {
+					return &tron.UnmarshalTypeError{Value: "number " + string(tv), Type: reflect.TypeFor[float64](), Struct: "Item", Field: "Price"}
+				}
+				v.Price = float64(n)
+			default:
+				return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[float64](), Struct: "Item", Field: "Price"}
+			}
+		case 3:
+			switch tok {
+			case nil:
+				v.Tags = nil
+			case json.Delim('['):
+				s0 := make([]string, 0)
+				for dec.More() {
+					tok, err := dec.Token()
+					if err != nil {
+						return err
+					}
+					var e0 string
+					switch tv := tok.(type) {
+					case nil:
+					case string:
+						e0 = tv
+					default:
+						return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[[]string](), Struct: "Item", Field: "Tags"}
+					}
+					s0 = append(s0, e0)
+				}
+				if _, err := dec.Token(); err != nil {
+					return err
+				}
+				v.Tags = s0
+			default:
+				return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[[]string](), Struct: "Item", Field: "Tags"}
+			}
+		case 4:
+			switch tok {
+			case nil:
+				v.Attrs = nil
+			case json.Delim('{'):
+				if v.Attrs == nil {
+					v.Attrs = make(map[string]string)
+				}
+				for dec.More() {
+					tok, err := dec.Token()
+					if err != nil {
+						return err
+					}
+					k0, _ := tok.(string)
+					if tok, err = dec.Token(); err != nil {
+						return err
+					}
+					var e0 string
+					switch tv := tok.(type) {
+					case nil:
+					case string:
+						e0 = tv
+					default:
+						return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[map[string]string](), Struct: "Item", Field: "Attrs"}
+					}
+					v.Attrs[k0] = e0
+				}
+				if _, err := dec.Token(); err != nil {
+					return err
+				}
+			default:
+				return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[map[string]string](), Struct: "Item", Field: "Attrs"}
+			}
+		default:
+			if err := tronSkipExampleTron(dec, tok); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// AppendTRON appends the TRON encoding of v, an object, to dst.
+func (v Order) AppendTRON(dst []byte) ([]byte, error) {
+	var err error
+	dst = append(dst, '{')
+	start := len(dst)
+	dst = append(dst, "\"id\":"...)
+	dst = strconv.AppendUint(dst, uint64(v.ID), 10)
+	dst = append(dst, ",\"customer\":"...)
+	if v.Customer == nil {
+		dst = append(dst, "null"...)
+	} else {
+		if dst, err = (*v.Customer).AppendTRON(dst); err != nil {
+			return dst, err
+		}
+	}
+	dst = append(dst, ",\"items\":"...)
+	if v.Items == nil {
+		dst = append(dst, "null"...)
+	} else {
+		dst = append(dst, '[')
+		for i0, e0 := range v.Items {
+			if i0 > 0 {
+				dst = append(dst, ',')
+			}
+			if dst, err = e0.AppendTRON(dst); err != nil {
+				return dst, err
+			}
+		}
+		dst = append(dst, ']')
+	}
+	dst = append(dst, ",\"placed\":"...)
+	dst = tron.AppendString(dst, v.Placed.Format(time.RFC3339Nano))
+	dst = append(dst, ",\"paid\":"...)
+	dst = strconv.AppendBool(dst, v.Paid)
+	if v.Note != "" {
+		dst = append(dst, ",\"note\":"...)
+		dst = tron.AppendString(dst, v.Note)
+	}
+	if len(dst) > start {
+		dst = append(dst, ',')
+	}
+	dst = append(dst, "\"payload\":"...)
+	if v.Payload == nil {
+		dst = append(dst, "null"...)
+	} else {
+		dst = append(dst, '"')
+		dst = base64.StdEncoding.AppendEncode(dst, v.Payload)
+		dst = append(dst, '"')
+	}
+	if len(dst) > start {
+		dst = append(dst, ',')
+	}
+	dst = append(dst, "\"ratings\":"...)
+	if v.Ratings == nil {
+		dst = append(dst, "null"...)
+	} else {
+		dst = append(dst, '[')
+		for i0, e0 := range v.Ratings {
+			if i0 > 0 {
+				dst = append(dst, ',')
+			}
+			dst = strconv.AppendFloat(dst, float64(e0), 'g', -1, 32)
+		}
+		dst = append(dst, ']')
+	}
+	dst = append(dst, '}')
+	return dst, nil
+}
+
+// MarshalTRON implements tron.Marshaler.
+func (v Order) MarshalTRON() ([]byte, error) {
+	return v.AppendTRON(nil)
+}
+
+// tronClassOrder is the class definition MarshalOrderSlice declares for Order.
+const tronClassOrder = "class A: id,customer,items,placed,paid,note,payload,ratings\n\n"
+
+// MarshalOrderSlice encodes vs as a TRON document that defines a class for
+// Order once and instantiates it for every element. Instantiations always
+// carry every field, so omitempty and omitzero do not apply.
+func MarshalOrderSlice(vs []Order) ([]byte, error) {
+	if vs == nil {
+		return []byte("null"), nil
+	}
+	if len(vs) == 0 {
+		return []byte("[]"), nil
+	}
+	var err error
+	dst := append([]byte(nil), tronClassOrder...)
+	dst = append(dst, '[')
+	for i := range vs {
+		v := &vs[i]
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, "A("...)
+		dst = strconv.AppendUint(dst, uint64(v.ID), 10)
+		dst = append(dst, ',')
+		if v.Customer == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if dst, err = (*v.Customer).AppendTRON(dst); err != nil {
+				return dst, err
+			}
+		}
+		dst = append(dst, ',')
+		if v.Items == nil {
+			dst = append(dst, "null"...)
+		} else {
+			dst = append(dst, '[')
+			for i0, e0 := range v.Items {
+				if i0 > 0 {
+					dst = append(dst, ',')
+				}
+				if dst, err = e0.AppendTRON(dst); err != nil {
+					return dst, err
+				}
+			}
+			dst = append(dst, ']')
+		}
+		dst = append(dst, ',')
+		dst = tron.AppendString(dst, v.Placed.Format(time.RFC3339Nano))
+		dst = append(dst, ',')
+		dst = strconv.AppendBool(dst, v.Paid)
+		dst = append(dst, ',')
+		dst = tron.AppendString(dst, v.Note)
+		dst = append(dst, ',')
+		if v.Payload == nil {
+			dst = append(dst, "null"...)
+		} else {
+			dst = append(dst, '"')
+			dst = base64.StdEncoding.AppendEncode(dst, v.Payload)
+			dst = append(dst, '"')
+		}
+		dst = append(dst, ',')
+		if v.Ratings == nil {
+			dst = append(dst, "null"...)
+		} else {
+			dst = append(dst, '[')
+			for i0, e0 := range v.Ratings {
+				if i0 > 0 {
+					dst = append(dst, ',')
+				}
+				dst = strconv.AppendFloat(dst, float64(e0), 'g', -1, 32)
+			}
+			dst = append(dst, ']')
+		}
+		dst = append(dst, ')')
+	}
+	dst = append(dst, ']')
+	return dst, nil
+}
+
+// tronFieldOrder maps an object key to the index of a field of Order, or -1.
+func tronFieldOrder(key string) int {
+	switch key {
+	case "customer":
+		return 1
+	case "id":
+		return 0
+	case "items":
+		return 2
+	case "note":
+		return 5
+	case "paid":
+		return 4
+	case "payload":
+		return 6
+	case "placed":
+		return 3
+	case "ratings":
+		return 7
+	}
+	switch strings.ToLower(key) {
+	case "customer":
+		return 1
+	case "id":
+		return 0
+	case "items":
+		return 2
+	case "note":
+		return 5
+	case "paid":
+		return 4
+	case "payload":
+		return 6
+	case "placed":
+		return 3
+	case "ratings":
+		return 7
+	}
+	return -1
+}
+
+// UnmarshalTRON implements tron.Unmarshaler. It accepts the object form
+// produced by MarshalTRON, which is also the form in which tron.Unmarshal
+// passes values to unmarshalers.
+func (v *Order) UnmarshalTRON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	return v.decodeTRON(dec, tok)
+}
+
+// decodeTRON decodes the value that starts with tok into v.
+func (v *Order) decodeTRON(dec *json.Decoder, tok json.Token) error {
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[Order]()}
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+		switch tronFieldOrder(key) {
+		case 0:
+			switch tv := tok.(type) {
+			case nil:
+			case json.Number:
+				n, err := strconv.ParseUint(string(tv), 10, 64)
+				if err != nil {
+					return &tron.UnmarshalTypeError{Value: "number " + string(tv), Type: reflect.TypeFor[uint64](), Struct: "Order", Field: "ID"}
+				}
+				v.ID = uint64(n)
+			default:
+				return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[uint64](), Struct: "Order", Field: "ID"}
+			}
+		case 1:
+			if tok == nil {
+				v.Customer = nil
+			} else {
+				if v.Customer == nil {
+					v.Customer = new(Customer)
+				}
+				if err := (*v.Customer).decodeTRON(dec, tok); err != nil {
+					return err
+				}
+			}
+		case 2:
+			switch tok {
+			case nil:
+				v.Items = nil
+			case json.Delim('['):
+				s0 := make([]Item, 0)
+				for dec.More() {
+					tok, err := dec.Token()
+					if err != nil {
+						return err
+					}
+					var e0 Item
+					if err := e0.decodeTRON(dec, tok); err != nil {
+						return err
+					}
+					s0 = append(s0, e0)
+				}
+				if _, err := dec.Token(); err != nil {
+					return err
+				}
+				v.Items = s0
+			default:
+				return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[[]Item](), Struct: "Order", Field: "Items"}
+			}
+		case 3:
+			switch tv := tok.(type) {
+			case nil:
+			case string:
+				tm, err := time.Parse(time.RFC3339Nano, tv)
+				if err != nil {
+					return &tron.UnmarshalTypeError{Value: "string " + strconv.Quote(tv), Type: reflect.TypeFor[time.Time](), Struct: "Order", Field: "Placed"}
+				}
+				v.Placed = tm
+			default:
+				return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[time.Time](), Struct: "Order", Field: "Placed"}
+			}
+		case 4:
+			switch tv := tok.(type) {
+			case nil:
+			case bool:
+				v.Paid = tv
+			default:
+				return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[bool](), Struct: "Order", Field: "Paid"}
+			}
+		case 5:
+			switch tv := tok.(type) {
+			case nil:
+			case string:
+				v.Note = tv
+			default:
+				return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[string](), Struct: "Order", Field: "Note"}
+			}
+		case 6:
+			switch tv := tok.(type) {
+			case nil:
+				v.Payload = nil
+			case string:
+				b, err := base64.StdEncoding.DecodeString(tv)
+				if err != nil {
+					return err
+				}
+				v.Payload = b
+			default:
+				return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[[]byte](), Struct: "Order", Field: "Payload"}
+			}
+		case 7:
+			switch tok {
+			case nil:
+				v.Ratings = nil
+			case json.Delim('['):
+				s0 := make([]float32, 0)
+				for dec.More() {
+					tok, err := dec.Token()
+					if err != nil {
+						return err
+					}
+					var e0 float32
+					switch tv := tok.(type) {
+					case nil:
+					case json.Number:
+						n, err := strconv.ParseFloat(string(tv), 32)
+						if err != nil {
+							return &tron.UnmarshalTypeError{Value: "number " + string(tv), Type: reflect.TypeFor[[]float32](), Struct: "Order", Field: "Ratings"}
+						}
+						e0 = float32(n)
+					default:
+						return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[[]float32](), Struct: "Order", Field: "Ratings"}
+					}
+					s0 = append(s0, e0)
+				}
+				if _, err := dec.Token(); err != nil {
+					return err
+				}
+				v.Ratings = s0
+			default:
+				return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[[]float32](), Struct: "Order", Field: "Ratings"}
+			}
+		default:
+			if err := tronSkipExampleTron(dec, tok); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// AppendTRON appends the TRON encoding of v, an object, to dst.
+func (v Customer) AppendTRON(dst []byte) ([]byte, error) {
+	dst = append(dst, '{')
+	dst = append(dst, "\"name\":"...)
+	dst = tron.AppendString(dst, v.Name)
+	dst = append(dst, ",\"email\":"...)
+	dst = tron.AppendString(dst, v.Email)
+	dst = append(dst, ",\"level\":"...)
+	dst = strconv.AppendInt(dst, int64(v.Level), 10)
+	dst = append(dst, '}')
+	return dst, nil
+}
+
+// MarshalTRON implements tron.Marshaler.
+func (v Customer) MarshalTRON() ([]byte, error) {
+	return v.AppendTRON(nil)
+}
+
+// tronClassCustomer is the class definition MarshalCustomerSlice declares for Customer.
+const tronClassCustomer = "class A: name,email,level\n\n"
+
+// MarshalCustomerSlice encodes vs as a TRON document that defines a class for
+// Customer once and instantiates it for every element. Instantiations always
+// carry every field, so omitempty and omitzero do not apply.
+func MarshalCustomerSlice(vs []Customer) ([]byte, error) {
+	if vs == nil {
+		return []byte("null"), nil
+	}
+	if len(vs) == 0 {
+		return []byte("[]"), nil
+	}
+	dst := append([]byte(nil), tronClassCustomer...)
+	dst = append(dst, '[')
+	for i := range vs {
+		v := &vs[i]
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, "A("...)
+		dst = tron.AppendString(dst, v.Name)
+		dst = append(dst, ',')
+		dst = tron.AppendString(dst, v.Email)
+		dst = append(dst, ',')
+		dst = strconv.AppendInt(dst, int64(v.Level), 10)
+		dst = append(dst, ')')
+	}
+	dst = append(dst, ']')
+	return dst, nil
+}
+
+// tronFieldCustomer maps an object key to the index of a field of Customer, or -1.
+func tronFieldCustomer(key string) int {
+	switch key {
+	case "email":
+		return 1
+	case "level":
+		return 2
+	case "name":
+		return 0
+	}
+	switch strings.ToLower(key) {
+	case "email":
+		return 1
+	case "level":
+		return 2
+	case "name":
+		return 0
+	}
+	return -1
+}
+
+// UnmarshalTRON implements tron.Unmarshaler. It accepts the object form
+// produced by MarshalTRON, which is also the form in which tron.Unmarshal
+// passes values to unmarshalers.
+func (v *Customer) UnmarshalTRON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	return v.decodeTRON(dec, tok)
+}
+
+// decodeTRON decodes the value that starts with tok into v.
+func (v *Customer) decodeTRON(dec *json.Decoder, tok json.Token) error {
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[Customer]()}
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+		switch tronFieldCustomer(key) {
+		case 0:
+			switch tv := tok.(type) {
+			case nil:
+			case string:
+				v.Name = tv
+			default:
+				return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[string](), Struct: "Customer", Field: "Name"}
+			}
+		case 1:
+			switch tv := tok.(type) {
+			case nil:
+			case string:
+				v.Email = tv
+			default:
+				return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[string](), Struct: "Customer", Field: "Email"}
+			}
+		case 2:
+			switch tv := tok.(type) {
+			case nil:
+			case json.Number:
+				n, err := strconv.ParseInt(string(tv), 10, 8)
+				if err != nil {
+					return &tron.UnmarshalTypeError{Value: "number " + string(tv), Type: reflect.TypeFor[int8](), Struct: "Customer", Field: "Level"}
+				}
+				v.Level = int8(n)
+			default:
+				return &tron.UnmarshalTypeError{Value: tronKindExampleTron(tok), Type: reflect.TypeFor[int8](), Struct: "Customer", Field: "Level"}
+			}
+		default:
+			if err := tronSkipExampleTron(dec, tok); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// tronSkipExampleTron consumes the rest of the value that starts with tok.
+func tronSkipExampleTron(dec *json.Decoder, tok json.Token) error {
+	for depth := 0; ; {
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+		var err error
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+	}
+}
+
+// tronKindExampleTron names the kind of the value that starts with tok, for errors.
+func tronKindExampleTron(tok json.Token) string {
+	switch tok.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	}
+	if tok == json.Delim('[') {
+		return "array"
+	}
+	return "object"
+}
@@ -0,0 +1,84 @@
+// Command trongen generates reflection-free TRON encoders and decoders for Go
+// struct types, in the spirit of easyjson.
+//
+// Usage:
+//
+//	trongen [-type T,U] [-o output.go] file.go
+//
+// Every struct type in file.go whose doc comment contains the line
+//
+//	//trongen:generate
+//
+// and every type named with -type gets three methods:
+//
+//	func (v T) AppendTRON(dst []byte) ([]byte, error)
+//	func (v T) MarshalTRON() ([]byte, error)
+//	func (v *T) UnmarshalTRON(data []byte) error
+//
+// and a function that encodes a slice as a TRON document whose class
+// definition for T is computed at generation time:
+//
+//	func MarshalTSlice(vs []T) ([]byte, error)
+//
+// The methods produce and accept the same encoding as tron.Marshal and
+// tron.Unmarshal, honoring tron and json tags, omitempty and omitzero. Fields
+// must be booleans, numbers, strings, []byte, time.Time, other generated
+// struct types, or pointers, slices and string-keyed maps of those; trongen
+// reports any other field type as an error.
+//
+// Output is written to file_tron.go next to the input unless -o is given.
+// The usual way to run trongen is a go:generate directive:
+//
+//	//go:generate trongen $GOFILE
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of additional struct type names")
+	output := flag.String("o", "", "output file name; default srcdir/<file>_tron.go")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: trongen [-type T,U] [-o output.go] file.go\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	input := flag.Arg(0)
+	src, err := os.ReadFile(input)
+	if err != nil {
+		fatal(err)
+	}
+
+	var extra []string
+	if *typeNames != "" {
+		extra = strings.Split(*typeNames, ",")
+	}
+
+	out := *output
+	if out == "" {
+		out = strings.TrimSuffix(input, ".go") + "_tron.go"
+	}
+
+	code, err := generate(filepath.Base(input), src, extra, filepath.Base(out))
+	if err != nil {
+		fatal(err)
+	}
+	if err := os.WriteFile(out, code, 0o644); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "trongen: %v\n", err)
+	os.Exit(1)
+}
@@ -28,12 +28,12 @@ func useCase1_APIResponses() {
 
 	fmt.Println("=== Use Case 1: API Response (Product Catalog) ===")
 
-	jsonData, _ := json.Marshal(products)
 	tronData, _ := tron.Marshal(products)
+	stats, _ := tron.Stats(products)
 
-	fmt.Printf("JSON size: %d bytes\n", len(jsonData))
-	fmt.Printf("TRON size: %d bytes\n", len(tronData))
-	fmt.Printf("Savings: %.1f%%\n\n", 100.0*(1.0-float64(len(tronData))/float64(len(jsonData))))
+	fmt.Printf("JSON size: %d bytes\n", stats.JSONBytes)
+	fmt.Printf("TRON size: %d bytes\n", stats.TRONBytes)
+	fmt.Printf("Savings: %.1f%%\n\n", stats.SavingsPercent)
 
 	fmt.Println("TRON output:")
 	fmt.Println(string(tronData))
@@ -62,12 +62,11 @@ func useCase2_TimeSeriesData() {
 
 	fmt.Println("=== Use Case 2: Time-Series/IoT Data (10 data points) ===")
 
-	jsonData, _ := json.Marshal(data)
-	tronData, _ := tron.Marshal(data)
+	stats, _ := tron.Stats(data)
 
-	fmt.Printf("JSON size: %d bytes\n", len(jsonData))
-	fmt.Printf("TRON size: %d bytes\n", len(tronData))
-	fmt.Printf("Savings: %.1f%%\n\n", 100.0*(1.0-float64(len(tronData))/float64(len(jsonData))))
+	fmt.Printf("JSON size: %d bytes\n", stats.JSONBytes)
+	fmt.Printf("TRON size: %d bytes\n", stats.TRONBytes)
+	fmt.Printf("Savings: %.1f%%\n\n", stats.SavingsPercent)
 
 	fmt.Println("First 3 points in JSON:")
 	jsonFirst3, _ := json.Marshal(data[:3])
@@ -101,12 +100,11 @@ func useCase3_DatabaseResults() {
 
 	fmt.Println("=== Use Case 3: Database Query Results ===")
 
-	jsonData, _ := json.Marshal(users)
-	tronData, _ := tron.Marshal(users)
+	stats, _ := tron.Stats(users)
 
-	fmt.Printf("JSON size: %d bytes\n", len(jsonData))
-	fmt.Printf("TRON size: %d bytes\n", len(tronData))
-	fmt.Printf("Savings: %.1f%%\n\n", 100.0*(1.0-float64(len(tronData))/float64(len(jsonData))))
+	fmt.Printf("JSON size: %d bytes\n", stats.JSONBytes)
+	fmt.Printf("TRON size: %d bytes\n", stats.TRONBytes)
+	fmt.Printf("Savings: %.1f%%\n\n", stats.SavingsPercent)
 
 	// Pretty print for comparison
 	jsonPretty, _ := json.MarshalIndent(users[:2], "", "  ")
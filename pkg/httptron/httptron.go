@@ -0,0 +1,74 @@
+// Package httptron provides helpers for serving and accepting TRON over HTTP.
+//
+// A handler can adopt TRON with two lines:
+//
+//	if err := httptron.DecodeRequest(r, &req); err != nil { ... }
+//	httptron.WriteTRON(w, http.StatusOK, resp)
+package httptron
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/tron-format/trongo/pkg/tron"
+)
+
+// MediaType is the media type of TRON documents.
+const MediaType = "application/tron"
+
+// ContentType is the Content-Type header value WriteTRON sets.
+const ContentType = MediaType + "; charset=utf-8"
+
+// ErrUnsupportedMediaType is returned by DecodeRequest when the request
+// declares a Content-Type other than MediaType.
+var ErrUnsupportedMediaType = errors.New("httptron: request body is not " + MediaType)
+
+// WriteTRON writes v as a TRON response with the given status code. v is
+// encoded before anything is written, so if encoding fails WriteTRON returns
+// the error and the caller can still send an error response.
+func WriteTRON(w http.ResponseWriter, status int, v any) error {
+	data, err := tron.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	_, err = w.Write(data)
+	return err
+}
+
+// DecodeRequest decodes the TRON body of r into v. A request without a
+// Content-Type is accepted; one with a different media type yields
+// ErrUnsupportedMediaType. Bodies larger than tron.DefaultLimits().MaxInputBytes
+// are not read past the limit and yield an *http.MaxBytesError, and the
+// document itself is checked against the default decoding limits.
+func DecodeRequest(r *http.Request, v any) error {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || mediaType != MediaType {
+			return ErrUnsupportedMediaType
+		}
+	}
+
+	data, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	return tron.Unmarshal(data, v)
+}
+
+// readBody reads the body of r, failing with an *http.MaxBytesError if it is
+// larger than the default input limit.
+func readBody(r *http.Request) ([]byte, error) {
+	limit := int64(tron.DefaultLimits().MaxInputBytes)
+	data, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, &http.MaxBytesError{Limit: limit}
+	}
+	return data, nil
+}
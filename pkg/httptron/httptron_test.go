@@ -0,0 +1,79 @@
+package httptron
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tron-format/trongo/pkg/tron"
+)
+
+type point struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+func TestWriteTRON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := WriteTRON(rec, http.StatusCreated, []point{{1, 2}, {3, 4}}); err != nil {
+		t.Fatalf("WriteTRON: %v", err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/tron; charset=utf-8" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	if want := "class A: x,y\n\n[A(1,2),A(3,4)]"; rec.Body.String() != want {
+		t.Fatalf("body = %q, want %q", rec.Body.String(), want)
+	}
+
+	rec = httptest.NewRecorder()
+	if err := WriteTRON(rec, http.StatusOK, make(chan int)); err == nil {
+		t.Fatal("expected encoding error")
+	}
+	if rec.Body.Len() != 0 || rec.Header().Get("Content-Type") != "" {
+		t.Fatal("nothing should be written when encoding fails")
+	}
+}
+
+func TestDecodeRequest(t *testing.T) {
+	for _, ct := range []string{"", MediaType, "application/tron; charset=utf-8"} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("class P: x,y\n\n[P(1,2)]"))
+		if ct != "" {
+			req.Header.Set("Content-Type", ct)
+		}
+		var got []point
+		if err := DecodeRequest(req, &got); err != nil {
+			t.Fatalf("DecodeRequest(%q): %v", ct, err)
+		}
+		if len(got) != 1 || got[0] != (point{1, 2}) {
+			t.Fatalf("DecodeRequest = %+v", got)
+		}
+	}
+}
+
+func TestDecodeRequestErrors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"x":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	var p point
+	if err := DecodeRequest(req, &p); !errors.Is(err, ErrUnsupportedMediaType) {
+		t.Fatalf("expected ErrUnsupportedMediaType, got %v", err)
+	}
+
+	big := bytes.Repeat([]byte(" "), tron.DefaultLimits().MaxInputBytes+1)
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(big))
+	var maxErr *http.MaxBytesError
+	if err := DecodeRequest(req, &p); !errors.As(err, &maxErr) {
+		t.Fatalf("expected *http.MaxBytesError, got %v", err)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"x":`))
+	var syntaxErr *tron.SyntaxError
+	if err := DecodeRequest(req, &p); !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected *tron.SyntaxError, got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package httptron
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tron-format/trongo/pkg/tron"
+)
+
+// AcceptsTRON reports whether the client asked for TRON responses: its
+// Accept header must name MediaType explicitly, with a quality at least as
+// high as that of JSON. Wildcards alone never select TRON, so clients that
+// have not opted in keep receiving JSON.
+func AcceptsTRON(r *http.Request) bool {
+	tronQ, jsonQ := 0.0, 0.0
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			q := 1.0
+			if s, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(s, 64); err != nil {
+					continue
+				}
+			}
+			switch mediaType {
+			case MediaType:
+				tronQ = max(tronQ, q)
+			case "application/json", "application/*", "*/*":
+				jsonQ = max(jsonQ, q)
+			}
+		}
+	}
+	return tronQ > 0 && tronQ >= jsonQ
+}
+
+// Respond writes v as TRON if the client accepts it (see AcceptsTRON) and as
+// JSON otherwise, so one handler can serve both kinds of client.
+func Respond(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	w.Header().Add("Vary", "Accept")
+	if AcceptsTRON(r) {
+		return WriteTRON(w, status, v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(data)
+	return err
+}
+
+// Negotiate wraps a handler that speaks JSON so that it also serves clients
+// that speak TRON, allowing TRON to be rolled out without changing handlers.
+//
+// Request bodies sent as MediaType are converted to JSON before next sees
+// them, with the Content-Type rewritten to application/json; a body that is
+// not valid TRON is answered with 400 Bad Request, and one larger than
+// tron.DefaultLimits().MaxInputBytes with 413 Request Entity Too Large.
+//
+// If the client accepts TRON, responses that next declares as
+// application/json, by the time it first calls WriteHeader or Write, are
+// buffered and converted with tron.FromJSON, which turns repeated object
+// shapes into classes. Responses of other types are written straight
+// through, and JSON that fails to convert is passed on unchanged. The
+// response writer given to next implements http.Flusher; flushing a JSON
+// response sends it as JSON, as it cannot be converted before it is
+// complete.
+func Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isTRON(r.Header.Get("Content-Type")) {
+			data, err := readBody(r)
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			var body []byte
+			if err == nil {
+				body, err = tron.ToJSON(data)
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			r = r.Clone(r.Context())
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			r.Header.Set("Content-Type", "application/json")
+		}
+
+		w.Header().Add("Vary", "Accept")
+		if !AcceptsTRON(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		tw := &tronResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(tw, r)
+		tw.finish()
+	})
+}
+
+// isTRON reports whether contentType names MediaType.
+func isTRON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == MediaType
+}
+
+// tronResponseWriter buffers a JSON response so that its body can be
+// converted to TRON once the handler has finished, and passes any other
+// response straight through.
+type tronResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buffering   bool // the response is JSON, held in buf
+	buf         bytes.Buffer
+}
+
+func (tw *tronResponseWriter) WriteHeader(status int) {
+	if tw.wroteHeader {
+		return
+	}
+	if status >= 100 && status < 200 && status != http.StatusSwitchingProtocols {
+		// Informational responses precede the real one.
+		tw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	tw.status = status
+	tw.wroteHeader = true
+	mediaType, _, err := mime.ParseMediaType(tw.Header().Get("Content-Type"))
+	tw.buffering = err == nil && mediaType == "application/json"
+	if !tw.buffering {
+		tw.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (tw *tronResponseWriter) Write(p []byte) (int, error) {
+	tw.WriteHeader(http.StatusOK)
+	if tw.buffering {
+		return tw.buf.Write(p)
+	}
+	return tw.ResponseWriter.Write(p)
+}
+
+// Flush sends the response written so far, giving up on converting a JSON
+// one.
+func (tw *tronResponseWriter) Flush() {
+	if tw.buffering {
+		tw.buffering = false
+		tw.ResponseWriter.WriteHeader(tw.status)
+		tw.ResponseWriter.Write(tw.buf.Bytes())
+		tw.buf = bytes.Buffer{}
+	}
+	http.NewResponseController(tw.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (tw *tronResponseWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+// finish writes the buffered JSON response, converted to TRON.
+func (tw *tronResponseWriter) finish() {
+	if !tw.buffering {
+		return
+	}
+	body := tw.buf.Bytes()
+	h := tw.ResponseWriter.Header()
+	if converted, err := tron.FromJSON(body); err == nil {
+		body = converted
+		h.Set("Content-Type", ContentType)
+		h.Del("Content-Length")
+	}
+	tw.ResponseWriter.WriteHeader(tw.status)
+	tw.ResponseWriter.Write(body)
+}
@@ -0,0 +1,169 @@
+package httptron
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAcceptsTRON(t *testing.T) {
+	tests := map[string]bool{
+		"":                                   false,
+		"*/*":                                false,
+		"application/json":                   false,
+		"application/tron":                   true,
+		"application/json, application/tron": true,
+		"application/tron;q=0.5, application/json":     false,
+		"application/tron, application/json;q=0.9":     true,
+		"application/tron;q=0":                         false,
+		"text/html, application/tron;q=0.8, */*;q=0.1": true,
+	}
+	for accept, want := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		if got := AcceptsTRON(r); got != want {
+			t.Errorf("AcceptsTRON(%q) = %v, want %v", accept, got, want)
+		}
+	}
+}
+
+func TestRespond(t *testing.T) {
+	v := []point{{1, 2}, {3, 4}}
+	for accept, want := range map[string]string{
+		"application/tron": "class A: x,y\n\n[A(1,2),A(3,4)]",
+		"application/json": `[{"x":1,"y":2},{"x":3,"y":4}]`,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		if err := Respond(rec, r, http.StatusOK, v); err != nil {
+			t.Fatalf("Respond: %v", err)
+		}
+		if rec.Body.String() != want {
+			t.Errorf("Accept %s: body = %q, want %q", accept, rec.Body.String(), want)
+		}
+		if rec.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %s: missing Vary header", accept)
+		}
+	}
+}
+
+// echoJSON is a plain JSON handler: it decodes a JSON body and echoes it back
+// wrapped in an object.
+var echoJSON = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+		http.Error(w, "want JSON, got "+ct, http.StatusUnsupportedMediaType)
+		return
+	}
+	var in []point
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string][]point{"points": in})
+})
+
+func TestNegotiate(t *testing.T) {
+	srv := httptest.NewServer(Negotiate(echoJSON))
+	defer srv.Close()
+
+	tests := []struct {
+		contentType, body, accept string
+		wantType, wantBody        string
+	}{
+		{"application/json", `[{"x":1,"y":2}]`, "", "application/json", `{"points":[{"x":1,"y":2}]}` + "\n"},
+		{"application/tron", "class P: x,y\n\n[P(1,2),P(3,4)]", "", "application/json", `{"points":[{"x":1,"y":2},{"x":3,"y":4}]}` + "\n"},
+		{"application/json", `[{"x":1,"y":2},{"x":3,"y":4}]`, "application/tron", ContentType, "class A: x,y\n\n" + `{"points":[A(1,2),A(3,4)]}`},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("status %d: %s", resp.StatusCode, body)
+		}
+		if got := resp.Header.Get("Content-Type"); got != tt.wantType {
+			t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+		}
+		if string(body) != tt.wantBody {
+			t.Errorf("body = %q, want %q", body, tt.wantBody)
+		}
+	}
+}
+
+func TestNegotiatePassesThroughOtherResponses(t *testing.T) {
+	h := Negotiate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusNotFound)
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", MediaType)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusNotFound || rec.Body.String() != "nope\n" || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("unexpected response %d %q %q", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+}
+
+func TestNegotiateStreamsOtherResponses(t *testing.T) {
+	rec := httptest.NewRecorder()
+	h := Negotiate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: 1\n\n")
+		if rec.Body.String() != "data: 1\n\n" {
+			t.Errorf("body before the handler returned = %q, want the first event", rec.Body.String())
+		}
+		w.(http.Flusher).Flush()
+		if !rec.Flushed {
+			t.Error("Flush was not passed on")
+		}
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", MediaType)
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestNegotiateFlushSendsJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	h := Negotiate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `[{"x":1,"y":2},`)
+		http.NewResponseController(w).Flush()
+		io.WriteString(w, `{"x":3,"y":4}]`)
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", MediaType)
+	h.ServeHTTP(rec, r)
+	want := `[{"x":1,"y":2},{"x":3,"y":4}]`
+	if rec.Code != http.StatusCreated || rec.Header().Get("Content-Type") != "application/json" || rec.Body.String() != want {
+		t.Fatalf("response %d %q %q, want %d application/json %q", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String(), http.StatusCreated, want)
+	}
+}
+
+func TestNegotiateRejectsInvalidTRON(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("[1,"))
+	r.Header.Set("Content-Type", MediaType)
+	rec := httptest.NewRecorder()
+	Negotiate(echoJSON).ServeHTTP(rec, r)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,58 @@
+package tron
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAppendMatchesMarshal(t *testing.T) {
+	type person struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	v := []person{{Name: "a", Age: 1}, {Name: "b", Age: 2}}
+	want, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	dst := []byte("prefix:")
+	got, err := Append(dst, v)
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if !bytes.Equal(got, append([]byte("prefix:"), want...)) {
+		t.Fatalf("Append = %q, want prefix followed by %q", got, want)
+	}
+
+	got, err = AppendIndent(nil, v, "", "  ")
+	if err != nil {
+		t.Fatalf("AppendIndent: %v", err)
+	}
+	want, _ = MarshalIndent(v, "", "  ")
+	if !bytes.Equal(got, want) {
+		t.Fatalf("AppendIndent = %q, want %q", got, want)
+	}
+}
+
+func TestAppendReturnsDstOnError(t *testing.T) {
+	dst := []byte("keep")
+	got, err := Append(dst, map[string]interface{}{"f": func() {}})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if string(got) != "keep" {
+		t.Fatalf("Append = %q on error, want dst unchanged", got)
+	}
+}
+
+func TestAppendReusesCapacity(t *testing.T) {
+	buf := make([]byte, 0, 64)
+	got, err := Append(buf, 42)
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if string(got) != "42" || &got[0] != &buf[:1][0] {
+		t.Fatalf("Append should write into dst's spare capacity, got %q", got)
+	}
+}
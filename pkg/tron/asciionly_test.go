@@ -0,0 +1,61 @@
+package tron
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"unicode/utf8"
+)
+
+type asciiCity struct {
+	Name    string `json:"name"`
+	Country string `json:"pays" tron:"país,default=España"`
+}
+
+type asciiRaw struct{}
+
+func (asciiRaw) MarshalJSON() ([]byte, error) { return []byte(`{"ü":"ß"}`), nil }
+
+func TestEncoderSetASCIIOnly(t *testing.T) {
+	v := map[string]any{
+		"cities": []asciiCity{{"Zürich", "Schweiz"}, {"Málaga", "España"}},
+		"emoji":  "ok 👍",
+		"bad":    "a\xffb",
+		"raw":    asciiRaw{},
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetASCIIOnly(true)
+	if err := enc.Encode(v); err != nil {
+		t.Fatal(err)
+	}
+	want := "class A: name,\"pa\\u00eds\"=\"Espa\\u00f1a\"\n\n" +
+		`{"bad":"a\ufffdb","cities":[A("Z\u00fcrich","Schweiz"),A("M\u00e1laga")],"emoji":"ok \ud83d\udc4d","raw":{"\u00fc":"\u00df"}}` + "\n"
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+	for i := 0; i < buf.Len(); i++ {
+		if buf.Bytes()[i] >= utf8.RuneSelf {
+			t.Fatalf("non-ASCII byte at offset %d", i)
+		}
+	}
+
+	// The escapes decode to the original text.
+	var back struct {
+		Cities []asciiCity `json:"cities"`
+		Emoji  string      `json:"emoji"`
+	}
+	if err := Unmarshal(buf.Bytes(), &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Cities[1] != (asciiCity{"Málaga", "España"}) || back.Emoji != "ok 👍" {
+		t.Errorf("round trip: got %+v", back)
+	}
+
+	// The escaping agrees with encoding/json's \u form.
+	var s string
+	if err := json.Unmarshal(appendQuotedWith(nil, "€𝄞", escapeNonASCII), &s); err != nil || s != "€𝄞" {
+		t.Errorf("json.Unmarshal = %q, %v", s, err)
+	}
+}
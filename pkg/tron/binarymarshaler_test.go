@@ -0,0 +1,72 @@
+package tron
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// binaryID implements only the binary encoding interfaces.
+type binaryID struct {
+	Hi, Lo uint32
+}
+
+func (b binaryID) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 8)
+	binary.BigEndian.PutUint32(out, b.Hi)
+	binary.BigEndian.PutUint32(out[4:], b.Lo)
+	return out, nil
+}
+
+func (b *binaryID) UnmarshalBinary(data []byte) error {
+	if len(data) != 8 {
+		return errors.New("binaryID: expected 8 bytes")
+	}
+	b.Hi = binary.BigEndian.Uint32(data)
+	b.Lo = binary.BigEndian.Uint32(data[4:])
+	return nil
+}
+
+type binaryErr struct{}
+
+func (binaryErr) MarshalBinary() ([]byte, error) { return nil, errors.New("boom") }
+
+func TestBinaryMarshalerRoundTrip(t *testing.T) {
+	in := []struct {
+		ID   binaryID `json:"id"`
+		Name string   `json:"name"`
+	}{{binaryID{1, 2}, "a"}, {binaryID{3, 0xffffffff}, "b"}}
+
+	out, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := "class A: id,name\n\n[A(\"AAAAAQAAAAI=\",\"a\"),A(\"AAAAA/////8=\",\"b\")]"
+	if string(out) != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+
+	var got []struct {
+		ID   binaryID `json:"id"`
+		Name string   `json:"name"`
+	}
+	if err := Unmarshal(out, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != in[0].ID || got[1].ID != in[1].ID {
+		t.Fatalf("round trip mismatch: %+v", got)
+	}
+}
+
+func TestBinaryMarshalerErrors(t *testing.T) {
+	if _, err := Marshal(binaryErr{}); err == nil {
+		t.Fatalf("expected error")
+	}
+	var id binaryID
+	if err := Unmarshal([]byte(`"!!"`), &id); err == nil {
+		t.Fatalf("expected base64 error")
+	}
+	if err := Unmarshal([]byte(`"AAAA"`), &id); err == nil {
+		t.Fatalf("expected UnmarshalBinary error")
+	}
+}
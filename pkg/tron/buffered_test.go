@@ -0,0 +1,48 @@
+package tron
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoderBuffered(t *testing.T) {
+	// A TRONL section followed by data in another format.
+	input := "class P: x,y\nP(1,2)\nP(3,4)\nEND\nraw trailer"
+	dec := NewDecoder(strings.NewReader(input))
+	dec.UseLineMode()
+	for range 2 {
+		var p struct{ X, Y int }
+		if err := dec.Decode(&p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rest, err := io.ReadAll(dec.Buffered())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "END\nraw trailer"; string(rest) != want {
+		t.Errorf("Buffered() = %q, want %q", rest, want)
+	}
+
+	// After More, the input past the element read.
+	dec = NewDecoder(bufio.NewReaderSize(strings.NewReader("[1,2,3]"), 16))
+	if !dec.More() {
+		t.Fatal("More() = false")
+	}
+	rest, _ = io.ReadAll(dec.Buffered())
+	if want := "2,3]"; string(rest) != want {
+		t.Errorf("Buffered() after More = %q, want %q", rest, want)
+	}
+
+	// A whole document leaves nothing.
+	dec = NewDecoder(strings.NewReader("[1]"))
+	var v []int
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if rest, _ := io.ReadAll(dec.Buffered()); len(rest) != 0 {
+		t.Errorf("Buffered() after Decode = %q", rest)
+	}
+}
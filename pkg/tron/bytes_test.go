@@ -0,0 +1,79 @@
+package tron
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMarshalBytesBase64(t *testing.T) {
+	data := []byte{0xff, 0x00, 0x80, 'a'}
+	out, err := Marshal(data)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want, _ := json.Marshal(data)
+	if string(out) != string(want) {
+		t.Fatalf("expected %s, got %s", want, out)
+	}
+
+	var got []byte
+	if err := Unmarshal(out, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("round trip mismatch: %v", got)
+	}
+}
+
+func TestMarshalByteArrayIsNumeric(t *testing.T) {
+	out, err := Marshal([2]byte{1, 2})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(out) != "[1,2]" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
+
+func TestUnmarshalBytesInvalidBase64(t *testing.T) {
+	var got []byte
+	if err := Unmarshal([]byte(`"not base64!"`), &got); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestRawBytesOptions(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetRawBytes(true)
+	if err := enc.Encode(struct {
+		B []byte `json:"b"`
+	}{B: []byte("hello")}); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != `{"b":"hello"}` {
+		t.Fatalf("unexpected output: %s", buf.String())
+	}
+
+	var v struct {
+		B []byte `json:"b"`
+	}
+	dec := NewDecoder(&buf)
+	dec.UseRawBytes()
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if string(v.B) != "hello" {
+		t.Fatalf("unexpected value: %q", v.B)
+	}
+
+	// By default the same input is decoded as base64.
+	if err := Unmarshal([]byte(`{"b":"aGVsbG8="}`), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if string(v.B) != "hello" {
+		t.Fatalf("unexpected value: %q", v.B)
+	}
+}
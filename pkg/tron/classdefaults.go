@@ -0,0 +1,74 @@
+package tron
+
+import (
+	"fmt"
+	"slices"
+)
+
+// parsePropDefault consumes the "=value" that follows property prop, the
+// last one added to def. The value must be a scalar literal.
+func (p *parser) parsePropDefault(def *classDef, prop string) error {
+	p.advance() // consume '='
+	tok := p.current()
+	switch tok.Type {
+	case TokenNumber, TokenString, TokenTrue, TokenFalse, TokenNull:
+	default:
+		if !p.atNonFinite() {
+			return p.syntaxError(fmt.Sprintf("default value of property %s must be a number, string, boolean or null", prop))
+		}
+	}
+	p.advance()
+	def.setDefault(tok)
+	return nil
+}
+
+// setDefault makes tok the default of the last property added to c. An
+// optional property, marked with '?', has a default of null.
+func (c *classDef) setDefault(tok Token) {
+	c.defaults = append(c.defaults, make([]*Token, len(c.props)-len(c.defaults))...)
+	c.defaults[len(c.props)-1] = &tok
+}
+
+// required returns the number of arguments an instantiation of c must give:
+// all of them up to the last property without a default.
+func (c *classDef) required() int {
+	n := len(c.props)
+	for n > 0 && n <= len(c.defaults) && c.defaults[n-1] != nil {
+		n--
+	}
+	return n
+}
+
+// defaultTexts returns the TRON text of each default of c, or "" for a
+// property without one. It returns nil if c has no defaults.
+func (c *classDef) defaultTexts() []string {
+	if !slices.ContainsFunc(c.defaults, func(tok *Token) bool { return tok != nil }) {
+		return nil
+	}
+	texts := make([]string, len(c.props))
+	for i, tok := range c.defaults {
+		if tok != nil {
+			texts[i] = tokenText(*tok)
+		}
+	}
+	return texts
+}
+
+// tokenText returns the TRON source of the scalar token tok.
+func tokenText(tok Token) string {
+	if tok.Type == TokenString {
+		return string(appendQuoted(nil, tok.Value))
+	}
+	return tok.Value
+}
+
+// withToken runs f with the parser positioned at tok, as if tok came next in
+// the input, and then restores the position. It is used to feed default
+// values to the callbacks that consume class arguments.
+func (p *parser) withToken(tok Token, f func() error) error {
+	tokens, pos := p.tokens, p.pos
+	p.tokens, p.pos = []Token{tok, {Type: TokenEOF, Offset: tok.Offset, Line: tok.Line, Column: tok.Column}}, 0
+	err := f()
+	p.tokens, p.pos = tokens, pos
+	return err
+}
@@ -0,0 +1,117 @@
+package tron
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type defaultsPoint struct {
+	X      int     `json:"x"`
+	Y      int     `json:"y"`
+	Z      float64 `tron:"z,default=0"`
+	Status string  `tron:"status,default=active"`
+}
+
+func TestMarshalElidesTrailingDefaults(t *testing.T) {
+	points := []defaultsPoint{
+		{X: 1, Y: 2, Status: "active"},
+		{X: 3, Y: 4, Z: 5, Status: "active"},
+		{X: 6, Y: 7, Status: "gone"},
+	}
+	out, err := Marshal(points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "class A: x,y,z=0,status=\"active\"\n\n[A(1,2),A(3,4,5),A(6,7,0,\"gone\")]"
+	if string(out) != want {
+		t.Fatalf("got\n%s\nwant\n%s", out, want)
+	}
+
+	var back []defaultsPoint
+	if err := Unmarshal(out, &back); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(back, points) {
+		t.Fatalf("round trip: got %+v, want %+v", back, points)
+	}
+
+	var tree interface{}
+	if err := Unmarshal(out, &tree); err != nil {
+		t.Fatalf("Unmarshal into interface{}: %v", err)
+	}
+	if got := tree.([]interface{})[0].(map[string]interface{}); got["z"] != float64(0) || got["status"] != "active" {
+		t.Fatalf("defaults not applied: %v", got)
+	}
+
+	classes, err := ExtractClasses(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := classes[0].Defaults; !reflect.DeepEqual(d, []string{"", "", "0", `"active"`}) {
+		t.Fatalf("ExtractClasses defaults = %q", d)
+	}
+}
+
+func TestInvalidDefaultTag(t *testing.T) {
+	type bad struct {
+		N int `tron:"n,default=many"`
+	}
+	if _, err := Marshal(bad{}); err == nil || !strings.Contains(err.Error(), "bad.N") {
+		t.Fatalf("got %v, want error naming the field", err)
+	}
+}
+
+func TestDecodeClassDefaults(t *testing.T) {
+	var v []map[string]interface{}
+	if err := Unmarshal([]byte("class P: x, y=true, z=null\n\n[P(1), P(1,false,\"z\")]"), &v); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	want := []map[string]interface{}{{"x": float64(1), "y": true, "z": nil}, {"x": float64(1), "y": false, "z": "z"}}
+	if !reflect.DeepEqual(v, want) {
+		t.Fatalf("got %v, want %v", v, want)
+	}
+
+	var tree interface{}
+	if err := Unmarshal([]byte("class P: a=1,b=2\n\nP()"), &tree); err != nil || !reflect.DeepEqual(tree, map[string]interface{}{"a": float64(1), "b": float64(2)}) {
+		t.Fatalf("empty argument list: got %v, %v", tree, err)
+	}
+
+	err := Unmarshal([]byte("class P: x, y, z=0\n\nP(1)"), &tree)
+	if !errors.Is(err, ErrArgCountMismatch) || !strings.Contains(err.Error(), "expects 2 to 3 arguments, got 1") {
+		t.Fatalf("got %v, want argument count error", err)
+	}
+	if err := Unmarshal([]byte("class P: x=[1]\n\nP()"), &tree); err == nil {
+		t.Fatal("accepted a non-scalar default")
+	}
+
+	dec := NewDecoder(strings.NewReader("class P: x int, y int = \"a\"\n\nP(1)"))
+	dec.AllowTypedClasses()
+	if err := dec.Decode(&tree); !errors.Is(err, ErrArgTypeMismatch) {
+		t.Fatalf("got %v, want type mismatch for the default", err)
+	}
+}
+
+func TestClassDefaultsBoundedByDefaultLimits(t *testing.T) {
+	// With room for 2,000 tokens, and so 4,000 values, 100 instantiations
+	// leaving out all 100 defaulted properties would expand to 10,000.
+	withLimits(t, maxInputBytes, 2_000, maxParseDepth, maxWalkDepth)
+	props := make([]string, 100)
+	for i := range props {
+		props[i] = fmt.Sprintf("p%d=0", i)
+	}
+	header := "class A: " + strings.Join(props, ",") + "\n\n"
+	for _, inst := range []string{"A()", "A(p0: 1)"} {
+		input := header + "[" + strings.Repeat(inst+",", 99) + inst + "]"
+		var maps []map[string]int
+		if err := Unmarshal([]byte(input), &maps); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("%s into maps: got %v, want ErrTooLarge", inst, err)
+		}
+		var v interface{}
+		if err := Unmarshal([]byte(input), &v); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("%s into interface{}: got %v, want ErrTooLarge", inst, err)
+		}
+	}
+}
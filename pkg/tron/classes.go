@@ -0,0 +1,151 @@
+package tron
+
+import "reflect"
+
+// ClassUsage describes a class defined in a document's header and how many
+// times the document instantiates it.
+type ClassUsage struct {
+	ClassDef
+	Uses int
+}
+
+// ExtractClasses returns the classes defined in the TRON document data, in
+// order of definition, with the number of instantiations of each. It helps
+// with understanding payloads produced elsewhere. If a class is defined more
+// than once, it is listed where first defined, with the properties of its
+// last definition, as the parser resolves it.
+//
+// ExtractClasses returns an error if data is not a valid TRON document.
+func ExtractClasses(data []byte) ([]ClassUsage, error) {
+	if err := validate(data); err != nil {
+		return nil, err
+	}
+	tokens, err := tokenizeWith(data, decodeOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var classes []ClassUsage
+	index := make(map[string]int)
+	defs := make(map[string]*classDef)
+	for i := 0; i+1 < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case tok.Type == TokenClass:
+			// validate guarantees the name and colon follow.
+			p := newParser(tokens[i:])
+			p.classes = defs
+			if err := p.parseClassDefinition(); err != nil {
+				return nil, err
+			}
+			name := tokens[i+1].Value
+			c := defs[name]
+			def := ClassDef{Name: name, Keys: c.props, Defaults: c.defaultTexts(), Extends: c.extends}
+			if j, ok := index[name]; ok {
+				classes[j].ClassDef = def
+			} else {
+				index[name] = len(classes)
+				classes = append(classes, ClassUsage{ClassDef: def})
+			}
+			i += p.pos - 1
+		case tok.Type == TokenIdentifier && tokens[i+1].Type == TokenLParen:
+			classes[index[tok.Value]].Uses++
+		}
+	}
+	return classes, nil
+}
+
+// ClassesFor returns a standalone class header, ending in a blank line, that
+// defines a class for each struct type among types and for each struct type
+// they contain through fields, pointers, slices, arrays and maps. An element
+// of types may be a value of the type, a nil pointer to it or its
+// reflect.Type. The properties of each class are the keys Marshal writes for
+// the struct, in the same order and with their defaults, so the header can be
+// given to a language model as the schema of the data it should produce, or
+// sent ahead of the data in a protocol that transmits the header separately.
+//
+// Each class is named after its struct type. Anonymous and generic types,
+// and types whose name another class already has, get names of the form
+// Marshal generates. Types with their own marshaling, such as time.Time, are
+// treated as scalars and get no class.
+func ClassesFor(types ...any) (string, error) {
+	g := &classGenerator{seen: make(map[reflect.Type]bool), names: make(map[string]bool)}
+	for _, v := range types {
+		t, ok := v.(reflect.Type)
+		if !ok {
+			t = reflect.TypeOf(v)
+		}
+		if t == nil {
+			continue
+		}
+		if err := g.visit(t); err != nil {
+			return "", err
+		}
+	}
+	return string(appendHeader(nil, g.classes, escapeHTML)), nil
+}
+
+// classGenerator collects the classes of ClassesFor.
+type classGenerator struct {
+	seen    map[reflect.Type]bool
+	names   map[string]bool
+	classes []ClassDef
+}
+
+// visit adds the classes for the struct types reachable from t.
+func (g *classGenerator) visit(t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if g.seen[t] || t == timeType || marshalsItself(t) {
+		return nil
+	}
+	g.seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return g.visit(t.Elem())
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	ti := (*encoder)(nil).getStructTypeInfo(t)
+	if ti.err != nil {
+		return ti.err
+	}
+	if len(ti.fields) > 0 {
+		keys := make([]string, len(ti.fields))
+		for i, f := range ti.fields {
+			keys[i] = f.name
+		}
+		g.classes = append(g.classes, ClassDef{Name: g.name(t), Keys: keys, Defaults: ti.defaultsFor(keys)})
+	}
+	for _, f := range ti.fields {
+		if err := g.visit(t.Field(f.index).Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// name returns an unused class name for struct type t.
+func (g *classGenerator) name(t reflect.Type) string {
+	name := t.Name()
+	for i := len(g.classes); name == "" || !isValidIdentifier(name) || g.names[name]; i++ {
+		name = generateClassName(i)
+	}
+	g.names[name] = true
+	return name
+}
+
+// marshalsItself reports whether values of type t, or pointers to them,
+// implement one of the marshaling interfaces Marshal defers to.
+func marshalsItself(t reflect.Type) bool {
+	for _, mt := range []reflect.Type{marshalerType, jsonMarshalerType, textMarshalerType, binaryMarshalerType} {
+		if t.Implements(mt) || reflect.PointerTo(t).Implements(mt) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package tron
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractClasses(t *testing.T) {
+	data := []byte(`class A: name,age
+class B: x,"y z"
+class C: unused,prop
+
+{"people": [A("a", 1), A("b", B(1, [A("c", 3)]))], "count": 3}
+`)
+	got, err := ExtractClasses(data)
+	if err != nil {
+		t.Fatalf("ExtractClasses: %v", err)
+	}
+	want := []ClassUsage{
+		{ClassDef{Name: "A", Keys: []string{"name", "age"}}, 3},
+		{ClassDef{Name: "B", Keys: []string{"x", "y z"}}, 1},
+		{ClassDef{Name: "C", Keys: []string{"unused", "prop"}}, 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestExtractClassesWithoutHeader(t *testing.T) {
+	got, err := ExtractClasses([]byte(`{"a": 1}`))
+	if err != nil || len(got) != 0 {
+		t.Fatalf("got %+v, %v", got, err)
+	}
+	if _, err := ExtractClasses([]byte("[A(1)]")); err == nil {
+		t.Fatal("expected error for undefined class")
+	}
+}
@@ -0,0 +1,58 @@
+package tron
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type classesForAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+}
+
+type classesForUser struct {
+	ID       int                        `json:"id"`
+	Name     string                     `json:"name"`
+	Role     string                     `tron:"role,default=user"`
+	Created  time.Time                  `json:"created"`
+	Home     *classesForAddress         `json:"home"`
+	Previous []classesForAddress        `json:"previous,omitempty"`
+	Manager  *classesForUser            `json:"manager"`
+	Tags     map[string]struct{ N int } `json:"tags"`
+	Secret   string                     `json:"-"`
+	Extras   map[string]any             `tron:",inline"`
+}
+
+func TestClassesFor(t *testing.T) {
+	header, err := ClassesFor(classesForUser{}, reflect.TypeOf(classesForAddress{}), 42, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "class classesForUser: id,name,role=\"user\",created,home,previous,manager,tags\n" +
+		"class classesForAddress: street,city\n" +
+		"class C: N\n\n"
+	if header != want {
+		t.Fatalf("got\n%s\nwant\n%s", header, want)
+	}
+
+	// The header can stand in for the one Marshal would write.
+	var u classesForUser
+	data := header + "classesForUser(1,\"ann\",\"admin\",\"2024-01-02T03:04:05Z\",null,[classesForAddress(\"Main St\",\"Springfield\")],null,{})"
+	if err := Unmarshal([]byte(data), &u); err != nil {
+		t.Fatal(err)
+	}
+	if len(u.Previous) != 1 || u.Previous[0].City != "Springfield" || u.Role != "admin" {
+		t.Fatalf("decoded %+v", u)
+	}
+
+	if header, err := ClassesFor((*classesForAddress)(nil)); err != nil || header != "class classesForAddress: street,city\n\n" {
+		t.Fatalf("pointer type: got %q, %v", header, err)
+	}
+	type bad struct {
+		N int `tron:"n,default=x"`
+	}
+	if _, err := ClassesFor(bad{}); err == nil {
+		t.Error("invalid default: got no error")
+	}
+}
@@ -0,0 +1,132 @@
+package tron
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type extendsUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type extendsAdmin struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+type extendsTeam struct {
+	Users  []extendsUser  `json:"users"`
+	Admins []extendsAdmin `json:"admins"`
+}
+
+func TestDecodeClassExtends(t *testing.T) {
+	input := "class User: id,name\nclass Admin extends User: permissions\n\n" +
+		"users: [User(1,\"ann\"),User(2,\"bob\")]\nadmins: [Admin(3,\"cy\",[\"all\"])]"
+
+	var team extendsTeam
+	if err := Unmarshal([]byte(input), &team); err != nil {
+		t.Fatal(err)
+	}
+	want := extendsTeam{
+		Users:  []extendsUser{{1, "ann"}, {2, "bob"}},
+		Admins: []extendsAdmin{{3, "cy", []string{"all"}}},
+	}
+	if !reflect.DeepEqual(team, want) {
+		t.Fatalf("got %+v, want %+v", team, want)
+	}
+
+	classes, err := ExtractClasses([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if admin := classes[1]; admin.Extends != "User" || !reflect.DeepEqual(admin.Keys, []string{"id", "name", "permissions"}) {
+		t.Fatalf("ExtractClasses = %+v", admin.ClassDef)
+	}
+
+	var buf bytes.Buffer
+	if err := Compact(&buf, []byte("class  User : id , name\nclass Admin  extends User:permissions\n\n{}")); err != nil {
+		t.Fatal(err)
+	}
+	if want := "class User: id,name\nclass Admin extends User: permissions\n\n{}"; buf.String() != want {
+		t.Fatalf("Compact = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestDecodeClassExtendsErrors(t *testing.T) {
+	var v interface{}
+	err := Unmarshal([]byte("class Admin extends User: permissions\n\nAdmin(1)"), &v)
+	if !errors.Is(err, ErrUndefinedClass) {
+		t.Errorf("undefined parent: got %v, want ErrUndefinedClass", err)
+	}
+
+	dec := NewDecoder(bytes.NewReader([]byte("class A: x,y\nclass B extends A: z,x\n\nB(1,2,3,4)")))
+	dec.SetDuplicateKeys(DuplicateKeysError)
+	if err := dec.Decode(&v); err == nil {
+		t.Error("inherited property repeated: got no error")
+	}
+
+	err = Unmarshal([]byte("class A: x,y=0\nclass B extends A: z\n\nB(1)"), &v)
+	if !errors.Is(err, ErrArgCountMismatch) {
+		t.Errorf("inherited default before required property: got %v, want ErrArgCountMismatch", err)
+	}
+}
+
+func TestDecodeClassExtendsLimit(t *testing.T) {
+	// 150 classes of 10 inherited properties each, written in 775 tokens.
+	var header strings.Builder
+	header.WriteString("class A: a,b,c,d,e,f,g,h,i,j\n")
+	for i := range 150 {
+		fmt.Fprintf(&header, "class B%d extends A:\n", i)
+	}
+	data := header.String() + "\n1"
+
+	for limit, wantErr := range map[int]bool{1000: true, 2000: false} {
+		dec := NewDecoder(strings.NewReader(data))
+		dec.SetLimits(Limits{MaxTokens: limit})
+		var v interface{}
+		if err := dec.Decode(&v); errors.Is(err, ErrTooLarge) != wantErr {
+			t.Errorf("MaxTokens %d: got %v", limit, err)
+		}
+	}
+}
+
+func TestEncoderSetClassInheritance(t *testing.T) {
+	team := extendsTeam{
+		Users:  []extendsUser{{1, "ann"}, {2, "bob"}},
+		Admins: []extendsAdmin{{3, "cy", []string{"all"}}, {4, "di", nil}},
+	}
+
+	out, err := Marshal(team)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(out, []byte("extends")) {
+		t.Fatalf("class inheritance used by default:\n%s", out)
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetClassInheritance(true)
+	if err := enc.Encode(team); err != nil {
+		t.Fatal(err)
+	}
+	want := "class A: id,name\nclass B extends A: permissions\n\n" +
+		"{\"users\":[A(1,\"ann\"),A(2,\"bob\")],\"admins\":[B(3,\"cy\",[\"all\"]),B(4,\"di\",null)]}\n"
+	if buf.String() != want {
+		t.Fatalf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	var back extendsTeam
+	if err := Unmarshal(buf.Bytes(), &back); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(back, team) {
+		t.Fatalf("round trip: got %+v, want %+v", back, team)
+	}
+}
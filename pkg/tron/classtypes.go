@@ -0,0 +1,118 @@
+package tron
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// propType is the declared type of a property in a typed class definition,
+// such as the int in "class User: id int, name string".
+type propType uint8
+
+const (
+	typeAny propType = iota // no declared type
+	typeInt
+	typeFloat
+	typeString
+	typeBool
+	typeArray
+	typeObject
+)
+
+// propTypeNames maps the type names allowed in class definitions to types.
+var propTypeNames = map[string]propType{
+	"any":    typeAny,
+	"int":    typeInt,
+	"float":  typeFloat,
+	"number": typeFloat,
+	"string": typeString,
+	"bool":   typeBool,
+	"array":  typeArray,
+	"object": typeObject,
+}
+
+func (t propType) String() string {
+	switch t {
+	case typeInt:
+		return "int"
+	case typeFloat:
+		return "float"
+	case typeString:
+		return "string"
+	case typeBool:
+		return "bool"
+	case typeArray:
+		return "array"
+	case typeObject:
+		return "object"
+	default:
+		return "any"
+	}
+}
+
+// equal reports whether c and other define the same properties with the
+// same types and defaults.
+func (c *classDef) equal(other *classDef) bool {
+	return slices.Equal(c.props, other.props) && slices.Equal(c.typesOrNil(), other.typesOrNil()) &&
+		slices.Equal(c.defaultTexts(), other.defaultTexts())
+}
+
+// typesOrNil returns c.types, or nil if every property is untyped.
+func (c *classDef) typesOrNil() []propType {
+	if !slices.ContainsFunc(c.types, func(t propType) bool { return t != typeAny }) {
+		return nil
+	}
+	return c.types
+}
+
+// parsePropType consumes the type name that follows property prop, the last
+// one added to def.
+func (p *parser) parsePropType(def *classDef, prop string) error {
+	tok := p.current()
+	typ, ok := propTypeNames[tok.Value]
+	if !ok {
+		return syntaxErrorAt(tok, nil, fmt.Sprintf("unknown type %s for property %s", tok.Value, prop))
+	}
+	p.advance()
+	// Properties before this one without a type of their own are typeAny.
+	def.types = append(def.types, make([]propType, len(def.props)-1-len(def.types))...)
+	def.types = append(def.types, typ)
+	return nil
+}
+
+// checkArgType reports an error if the value at the current position does
+// not match the declared type of argument n of class name. Null matches every
+// type.
+func (p *parser) checkArgType(name string, def *classDef, n int) error {
+	if n >= len(def.types) {
+		return nil
+	}
+	typ := def.types[n]
+	tok := p.current()
+	var ok bool
+	switch tok.Type {
+	case TokenNull:
+		ok = true
+	case TokenNumber:
+		ok = typ == typeFloat || typ == typeInt && !strings.ContainsAny(tok.Value, ".eE") && !isNonFinite(tok.Value)
+	case TokenString:
+		ok = typ == typeString
+	case TokenTrue, TokenFalse:
+		ok = typ == typeBool
+	case TokenLBracket:
+		ok = typ == typeArray
+	case TokenLBrace:
+		ok = typ == typeObject
+	case TokenIdentifier:
+		if p.atNonFinite() {
+			ok = typ == typeFloat
+		} else {
+			ok = typ == typeObject
+		}
+	}
+	if ok || typ == typeAny {
+		return nil
+	}
+	return syntaxErrorAt(tok, ErrArgTypeMismatch, fmt.Sprintf("class %s property %s expects %s, got %s", name, def.props[n], typ, describeToken(tok)))
+}
@@ -0,0 +1,79 @@
+package tron
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func decodeTyped(input string, v interface{}) error {
+	dec := NewDecoder(strings.NewReader(input))
+	dec.AllowTypedClasses()
+	return dec.Decode(v)
+}
+
+func TestTypedClasses(t *testing.T) {
+	type user struct {
+		ID     int               `json:"id"`
+		Name   string            `json:"name"`
+		Active bool              `json:"active"`
+		Score  float64           `json:"score"`
+		Tags   []string          `json:"tags"`
+		Meta   map[string]string `json:"meta"`
+		Note   interface{}       `json:"note"`
+	}
+	header := "class M: k,v\nclass U: id int, name string, active bool, score float, tags array, meta object, note\n\n"
+	input := header + `[U(1,"ann",true,2.5,["a"],{"k":"v"},3), U(2,null,false,3,[],M("x","y"),"n")]`
+
+	var got []user
+	if err := decodeTyped(input, &got); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := []user{
+		{1, "ann", true, 2.5, []string{"a"}, map[string]string{"k": "v"}, float64(3)},
+		{2, "", false, 3, []string{}, map[string]string{"k": "x", "v": "y"}, "n"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	// Without the option, typed definitions do not parse.
+	if err := Unmarshal([]byte(input), &got); err == nil {
+		t.Fatal("Unmarshal accepted a typed class definition")
+	}
+}
+
+func TestTypedClassMismatch(t *testing.T) {
+	header := "class U: id int, name string, active bool\n\n"
+	tests := []struct {
+		args   string
+		column int
+		want   string
+	}{
+		{`"ann",1,true`, 3, "property id expects int, got string"},
+		{`1.5,"ann",true`, 3, "property id expects int, got number"},
+		{`1,"ann","yes"`, 11, "property active expects bool, got string"},
+		{`1,["ann"],true`, 5, "property name expects string, got array"},
+	}
+	for _, tt := range tests {
+		var v interface{}
+		err := decodeTyped(header+"U("+tt.args+")", &v)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) || !errors.Is(err, ErrArgTypeMismatch) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("U(%s): got %v, want %q", tt.args, err, tt.want)
+			continue
+		}
+		if syntaxErr.Line != 3 || syntaxErr.Column != tt.column {
+			t.Errorf("U(%s): error at %d:%d, want 3:%d", tt.args, syntaxErr.Line, syntaxErr.Column, tt.column)
+		}
+	}
+
+	var v interface{}
+	if err := decodeTyped("class U: id integer\n\nU(1)", &v); err == nil || !strings.Contains(err.Error(), "unknown type integer") {
+		t.Errorf("got %v, want unknown type error", err)
+	}
+	if err := decodeTyped("class U: id int\nclass U: id string\n\nU(1)", &v); err == nil || !strings.Contains(err.Error(), "redefined") {
+		t.Errorf("got %v, want redefinition error", err)
+	}
+}
@@ -0,0 +1,68 @@
+package tron
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecoderClearMaps(t *testing.T) {
+	for _, input := range []string{
+		`{"a":1,"b":null}`,
+		"a: 1\nb: null",
+	} {
+		// By default entries are kept, and null stores the zero value.
+		m := map[string]int{"a": 5, "b": 6, "c": 7}
+		if err := Unmarshal([]byte(input), &m); err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]int{"a": 1, "b": 0, "c": 7}; !reflect.DeepEqual(m, want) {
+			t.Errorf("%q: got %v, want %v", input, m, want)
+		}
+
+		m = map[string]int{"a": 5, "b": 6, "c": 7}
+		dec := NewDecoder(strings.NewReader(input))
+		dec.ClearMaps()
+		if err := dec.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]int{"a": 1, "b": 0}; !reflect.DeepEqual(m, want) {
+			t.Errorf("%q with ClearMaps: got %v, want %v", input, m, want)
+		}
+	}
+
+	// Pointer elements are replaced by nil.
+	one := 1
+	ptrs := map[string]*int{"p": &one}
+	if err := Unmarshal([]byte(`{"p":null}`), &ptrs); err != nil || ptrs["p"] != nil {
+		t.Errorf("got %v, %v", ptrs, err)
+	}
+
+	// Nested maps and OrderedMaps are cleared too.
+	type config struct {
+		Env  map[string]string `json:"env"`
+		Meta OrderedMap        `json:"meta"`
+	}
+	var cfg config
+	cfg.Env = map[string]string{"OLD": "1"}
+	cfg.Meta.Set("old", true)
+	dec := NewDecoder(strings.NewReader(`{"env":{"NEW":"2"},"meta":{"new":true}}`))
+	dec.ClearMaps()
+	if err := dec.Decode(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg.Env, map[string]string{"NEW": "2"}) || !reflect.DeepEqual(cfg.Meta.Keys(), []string{"new"}) {
+		t.Errorf("got %v and %v", cfg.Env, cfg.Meta.Keys())
+	}
+
+	// The lenient decoder, which works from a parsed tree, does the same.
+	m := map[string]int{"old": 1}
+	var om OrderedMap
+	om.Set("old", 1)
+	if _, err := UnmarshalLenient([]byte(`{"new":2}`), &m); err != nil || len(m) != 2 {
+		t.Errorf("lenient: got %v, %v", m, err)
+	}
+	if _, err := UnmarshalLenient([]byte(`{"new":2}`), &om); err != nil || om.Len() != 2 {
+		t.Errorf("lenient OrderedMap: got %v, %v", om.Keys(), err)
+	}
+}
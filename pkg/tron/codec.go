@@ -0,0 +1,36 @@
+package tron
+
+// Codec encodes and decodes TRON through the Marshal/Unmarshal/Name method
+// set that pluggable wire formats commonly expect, such as grpc's
+// encoding.Codec. For example, with gRPC:
+//
+//	encoding.RegisterCodec(tron.Codec{})
+//
+// and then grpc.CallContentSubtype(tron.CodecName) on calls that should use
+// it. The zero value is ready to use.
+type Codec struct{}
+
+// CodecName is the name Codec reports, used as the content subtype in
+// frameworks such as gRPC.
+const CodecName = "tron"
+
+// Marshal returns the TRON encoding of v, as Marshal does.
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	return Marshal(v)
+}
+
+// Unmarshal parses the TRON-encoded data into v, as Unmarshal does.
+func (Codec) Unmarshal(data []byte, v interface{}) error {
+	return Unmarshal(data, v)
+}
+
+// Name returns CodecName.
+func (Codec) Name() string {
+	return CodecName
+}
+
+// String returns CodecName, for codec interfaces that use String instead of
+// Name.
+func (Codec) String() string {
+	return CodecName
+}
@@ -0,0 +1,39 @@
+package tron
+
+import "testing"
+
+// wireCodec has the shape of grpc's encoding.Codec.
+type wireCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	Name() string
+}
+
+func TestCodec(t *testing.T) {
+	var c wireCodec = Codec{}
+	if c.Name() != "tron" {
+		t.Fatalf("Name = %q", c.Name())
+	}
+
+	type msg struct {
+		ID   int      `json:"id"`
+		Tags []string `json:"tags"`
+	}
+	data, err := c.Marshal([]msg{{1, []string{"a"}}, {2, nil}})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if want := "class A: id,tags\n\n[A(1,[\"a\"]),A(2,null)]"; string(data) != want {
+		t.Fatalf("Marshal = %q, want %q", data, want)
+	}
+	var got []msg
+	if err := c.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(got) != 2 || got[0].Tags[0] != "a" || got[1].ID != 2 {
+		t.Fatalf("Unmarshal = %+v", got)
+	}
+	if err := c.Unmarshal(data, got); err == nil {
+		t.Fatal("expected InvalidUnmarshalError for non-pointer")
+	}
+}
@@ -0,0 +1,212 @@
+package tron
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Difference is a value that TRON encoding and decoding changed, as
+// reported by CompareJSON and CheckRoundTrip.
+type Difference struct {
+	Path string      // path to the value from the root, as in "items[2].status"; "" for the root
+	Want interface{} // the expected value, or nil if it is missing
+	Got  interface{} // the value TRON gave, or nil if it is missing
+}
+
+func (d Difference) String() string {
+	path := d.Path
+	if path == "" {
+		path = "root"
+	}
+	return fmt.Sprintf("%s: got %#v, want %#v", path, d.Got, d.Want)
+}
+
+// CompareJSON encodes v with encoding/json and with Marshal, decodes each
+// encoding into an interface{} with the matching Unmarshal, and returns the
+// places where the two results differ. TRON follows the semantics of
+// encoding/json for the values both can represent, so CompareJSON finds no
+// differences for them; it lets tests and fuzz targets check that a type
+// means the same in TRON as in JSON.
+//
+// CompareJSON returns an error, and no differences, if only one of the two
+// packages can encode v, or if either cannot decode its own encoding. Values
+// that neither can encode agree.
+func CompareJSON(v interface{}) ([]Difference, error) {
+	jsonData, jsonErr := json.Marshal(v)
+	tronData, tronErr := Marshal(v)
+	switch {
+	case jsonErr != nil && tronErr != nil:
+		return nil, nil
+	case jsonErr != nil:
+		return nil, fmt.Errorf("tron: encoding/json cannot encode %T but TRON can: %v", v, jsonErr)
+	case tronErr != nil:
+		return nil, fmt.Errorf("tron: TRON cannot encode %T but encoding/json can: %w", v, tronErr)
+	}
+
+	var want, got interface{}
+	if err := json.Unmarshal(jsonData, &want); err != nil {
+		return nil, fmt.Errorf("tron: encoding/json cannot decode its encoding of %T: %v", v, err)
+	}
+	if err := Unmarshal(tronData, &got); err != nil {
+		return nil, fmt.Errorf("tron: cannot decode the TRON encoding of %T: %w", v, err)
+	}
+	return appendDifferences(nil, "", reflect.ValueOf(want), reflect.ValueOf(got)), nil
+}
+
+// appendDifferences appends the differences between want and got, found at
+// path, to diffs. Struct fields that are not encoded, being unexported or
+// tagged "-", are not compared; time.Time values are compared with Equal, and
+// numbers of different types by value.
+func appendDifferences(diffs []Difference, path string, want, got reflect.Value) []Difference {
+	differ := func() []Difference {
+		return append(diffs, Difference{Path: path, Want: interfaceOf(want), Got: interfaceOf(got)})
+	}
+	for want.IsValid() && want.Kind() == reflect.Interface && !want.IsNil() {
+		want = want.Elem()
+	}
+	for got.IsValid() && got.Kind() == reflect.Interface && !got.IsNil() {
+		got = got.Elem()
+	}
+	if want.IsValid() && got.IsValid() && want.Type() != got.Type() && isNumber(want) && isNumber(got) {
+		// An interface{} holding an int holds a float64 once decoded.
+		if numberText(want) != numberText(got) {
+			return differ()
+		}
+		return diffs
+	}
+	if !want.IsValid() || !got.IsValid() || want.Type() != got.Type() {
+		if want.IsValid() != got.IsValid() || want.IsValid() && want.Type() != got.Type() {
+			return differ()
+		}
+		return diffs
+	}
+
+	if want.Type() == timeType {
+		if !want.Interface().(time.Time).Equal(got.Interface().(time.Time)) {
+			return differ()
+		}
+		return diffs
+	}
+
+	switch want.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if want.IsNil() || got.IsNil() {
+			if want.IsNil() != got.IsNil() {
+				return differ()
+			}
+			return diffs
+		}
+		return appendDifferences(diffs, path, want.Elem(), got.Elem())
+
+	case reflect.Slice, reflect.Array:
+		if want.Kind() == reflect.Slice && want.IsNil() != got.IsNil() || want.Len() != got.Len() {
+			return differ()
+		}
+		for i := range want.Len() {
+			diffs = appendDifferences(diffs, memberPath(path, indexPath(i)), want.Index(i), got.Index(i))
+		}
+		return diffs
+
+	case reflect.Map:
+		if want.IsNil() != got.IsNil() {
+			return differ()
+		}
+		keys := append(want.MapKeys(), got.MapKeys()...)
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+		})
+		seen := make(map[interface{}]bool)
+		for _, k := range keys {
+			if seen[k.Interface()] {
+				continue
+			}
+			seen[k.Interface()] = true
+			diffs = appendDifferences(diffs, memberPath(path, fmt.Sprint(k.Interface())), mapIndex(want, k), mapIndex(got, k))
+		}
+		return diffs
+
+	case reflect.Struct:
+		for i := range want.NumField() {
+			if f := want.Type().Field(i); encodesField(f) {
+				diffs = appendDifferences(diffs, memberPath(path, f.Name), want.Field(i), got.Field(i))
+			}
+		}
+		return diffs
+
+	case reflect.Float32, reflect.Float64:
+		if a, b := want.Float(), got.Float(); a != b && !(math.IsNaN(a) && math.IsNaN(b)) {
+			return differ()
+		}
+		return diffs
+
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return diffs
+	}
+
+	if want.Interface() != got.Interface() {
+		return differ()
+	}
+	return diffs
+}
+
+// mapIndex returns the element of m for key, or the zero Value if m has
+// none.
+func mapIndex(m, key reflect.Value) reflect.Value {
+	if m.IsNil() {
+		return reflect.Value{}
+	}
+	return m.MapIndex(key)
+}
+
+// interfaceOf returns the value held by v for a Difference, or nil.
+func interfaceOf(v reflect.Value) interface{} {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// encodesField reports whether Marshal encodes the struct field f.
+func encodesField(f reflect.StructField) bool {
+	name, _, _ := strings.Cut(fieldTag(f), ",")
+	return f.IsExported() && name != "-"
+}
+
+// isNumber reports whether v holds an integer or a floating-point number.
+func isNumber(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// numberText returns the exact decimal text of the number held by v.
+func numberText(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	}
+	return strconv.FormatInt(v.Int(), 10)
+}
+
+// memberPath returns the path of the member or element child of the value
+// at path, which is "" for the root.
+func memberPath(path, child string) string {
+	if path == "" {
+		return child
+	}
+	return joinPath(path, child)
+}
@@ -0,0 +1,70 @@
+package tron
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompareJSON(t *testing.T) {
+	when := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	values := []interface{}{
+		nil,
+		"text",
+		42,
+		[]int{1, 2, 3},
+		map[string]int{"b": 2, "a": 1},
+		map[int]bool{1: true},
+		compareRecord{Name: "x", Tags: []string{"a"}, Next: &compareRecord{}},
+		struct {
+			When time.Time `json:"when"`
+			Any  interface{}
+		}{when, []interface{}{1.5, "s", nil}},
+	}
+	for _, v := range values {
+		diffs, err := CompareJSON(v)
+		if err != nil || len(diffs) != 0 {
+			t.Errorf("CompareJSON(%#v) = %v, %v; want no differences", v, diffs, err)
+		}
+	}
+
+	// Values neither package can encode agree.
+	if diffs, err := CompareJSON(make(chan int)); err != nil || diffs != nil {
+		t.Errorf("CompareJSON(chan) = %v, %v; want nil, nil", diffs, err)
+	}
+}
+
+func TestAppendDifferences(t *testing.T) {
+	want := map[string]interface{}{
+		"items": []interface{}{1.0, map[string]interface{}{"status": "ok"}},
+		"gone":  true,
+		"nan":   math.NaN(),
+	}
+	got := map[string]interface{}{
+		"items": []interface{}{1.0, map[string]interface{}{"status": "failed"}},
+		"added": "x",
+		"nan":   math.NaN(),
+	}
+	diffs := appendDifferences(nil, "", reflect.ValueOf(want), reflect.ValueOf(got))
+	expected := []Difference{
+		{Path: "added", Got: "x"},
+		{Path: "gone", Want: true},
+		{Path: "items[1].status", Want: "ok", Got: "failed"},
+	}
+	if !reflect.DeepEqual(diffs, expected) {
+		t.Fatalf("differences = %v, want %v", diffs, expected)
+	}
+	if s := diffs[2].String(); s != `items[1].status: got "failed", want "ok"` {
+		t.Errorf("String() = %q", s)
+	}
+	if s := (Difference{Want: 1, Got: "1"}).String(); s != `root: got "1", want 1` {
+		t.Errorf("String() = %q", s)
+	}
+
+	// Slices of different lengths are reported whole.
+	diffs = appendDifferences(nil, "", reflect.ValueOf([]int{1, 2}), reflect.ValueOf([]int{1}))
+	if len(diffs) != 1 || diffs[0].Path != "" {
+		t.Errorf("differences = %v, want one at the root", diffs)
+	}
+}
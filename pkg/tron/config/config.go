@@ -0,0 +1,375 @@
+// Package config loads application configuration from TRON files.
+//
+// On top of plain decoding, Load expands environment variables, follows
+// include directives and fills in defaults declared on struct fields:
+//
+//	# app.tron
+//	"@include": "base.tron"
+//	listen: "${HOST:-localhost}:${PORT}"
+//	debug: true
+//
+//	type Config struct {
+//		Listen  string `json:"listen"`
+//		Debug   bool   `json:"debug"`
+//		Workers int    `json:"workers" default:"4"`
+//	}
+//
+//	var cfg Config
+//	err := config.Load("app.tron", &cfg)
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/tron-format/trongo/pkg/tron"
+)
+
+// IncludeKey is the object key that includes other files. Its value is a
+// path, or an array of paths, relative to the directory of the file that
+// contains it. The included documents must be objects; they are merged in
+// order, and the members of the including object are merged over them, so
+// that a file can include shared settings and override some of them.
+// Objects are merged key by key at every depth, while any other value
+// replaces the one it is merged over.
+//
+// IncludeKey may appear in any object of a document, including the root.
+const IncludeKey = "@include"
+
+// maxIncludeDepth bounds how deeply includes may nest.
+const maxIncludeDepth = 32
+
+// Load reads the TRON configuration file at path and stores it in the value
+// pointed to by v, which is typically a struct.
+//
+// Before the file is parsed, each ${VAR} in it is replaced by the value of
+// the environment variable VAR, and each ${VAR:-default} by that value or,
+// if VAR is unset or empty, by default. A reference to an unset variable
+// without a default is an error. References inside string literals are
+// replaced by the escaped value, so the variable's text always stays within
+// the string; elsewhere it is inserted as TRON source, which lets numbers and
+// booleans come from the environment. Write $${ for a literal ${. Comments
+// are left alone.
+//
+// Numbers may be written in hexadecimal, binary or octal (0xFF, 0b1010,
+// 0o17) and with underscores between digits (1_000_000); see
+// tron.Decoder.AllowExtendedNumbers.
+//
+// Files named by IncludeKey members are then loaded in the same way and
+// merged in.
+//
+// Finally, fields of v's structs that are zero and have a default tag are
+// set from the tag, and the merged document is decoded over v, as with
+// tron.Unmarshal. Fields the document does not mention therefore keep their
+// default, or the value v already held. A default tag holds the field's
+// value as TRON, except for string fields, where it is the string itself:
+//
+//	Port int    `json:"port" default:"8080"`
+//	Host string `json:"host" default:"localhost"`
+//
+// Defaults are applied to the fields of v and of the structs it contains
+// directly or through non-nil pointers, not to elements of slices and maps.
+func Load(path string, v any) error {
+	l := &loader{
+		read: os.ReadFile,
+		join: func(dir, name string) string {
+			if filepath.IsAbs(name) {
+				return name
+			}
+			return filepath.Join(dir, name)
+		},
+		dir:   filepath.Dir,
+		clean: filepath.Clean,
+	}
+	return l.load(path, v)
+}
+
+// LoadFS is like Load but reads path, and the files it includes, from fsys.
+// Include paths are resolved with slash-separated path semantics and must
+// stay within fsys.
+func LoadFS(fsys fs.FS, path string, v any) error {
+	l := &loader{
+		read:  func(name string) ([]byte, error) { return fs.ReadFile(fsys, name) },
+		join:  joinSlash,
+		dir:   dirSlash,
+		clean: cleanSlash,
+	}
+	return l.load(path, v)
+}
+
+func joinSlash(dir, name string) string { return path.Join(dir, name) }
+func dirSlash(name string) string       { return path.Dir(name) }
+func cleanSlash(name string) string     { return path.Clean(name) }
+
+// loader reads configuration files through read, resolving include paths
+// with join and dir, and comparing them once put in canonical form by clean.
+type loader struct {
+	read  func(name string) ([]byte, error)
+	join  func(dir, name string) string
+	dir   func(name string) string
+	clean func(name string) string
+}
+
+func (l *loader) load(path string, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return &tron.InvalidUnmarshalError{Type: reflect.TypeOf(v)}
+	}
+
+	doc, err := l.loadFile(path, nil)
+	if err != nil {
+		return err
+	}
+	if err := applyDefaults(rv, make(map[pointer]bool)); err != nil {
+		return err
+	}
+	data, err := tron.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	if err := tron.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadFile reads, expands and parses the file at name, with its includes
+// merged in. stack holds the files that include it, to detect cycles.
+func (l *loader) loadFile(name string, stack []string) (any, error) {
+	name = l.clean(name)
+	if slices.Contains(stack, name) {
+		return nil, fmt.Errorf("config: %s includes itself", name)
+	}
+	if len(stack) >= maxIncludeDepth {
+		return nil, fmt.Errorf("config: %s: includes nested too deeply", name)
+	}
+	src, err := l.read(name)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	src, err = expandEnv(src, os.LookupEnv)
+	if err != nil {
+		return nil, fmt.Errorf("config: %s: %w", name, err)
+	}
+
+	dec := tron.NewDecoder(bytes.NewReader(src))
+	dec.PreferInt64()
+	dec.AllowExtendedNumbers()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("config: %s: %w", name, err)
+	}
+	return l.resolveIncludes(doc, name, append(stack, name))
+}
+
+// resolveIncludes replaces every object in v that has an IncludeKey member
+// by the merge of the files it names and its other members. name is the
+// file v was read from.
+func (l *loader) resolveIncludes(v any, name string, stack []string) (any, error) {
+	switch v := v.(type) {
+	case []any:
+		for i, elem := range v {
+			resolved, err := l.resolveIncludes(elem, name, stack)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+		return v, nil
+
+	case map[string]any:
+		for key, member := range v {
+			if key == IncludeKey {
+				continue
+			}
+			resolved, err := l.resolveIncludes(member, name, stack)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = resolved
+		}
+
+		include, ok := v[IncludeKey]
+		if !ok {
+			return v, nil
+		}
+		delete(v, IncludeKey)
+		paths, err := includePaths(include)
+		if err != nil {
+			return nil, fmt.Errorf("config: %s: %w", name, err)
+		}
+		base := make(map[string]any)
+		for _, p := range paths {
+			doc, err := l.loadFile(l.join(l.dir(name), p), stack)
+			if err != nil {
+				return nil, err
+			}
+			obj, ok := doc.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("config: %s: included file %s is not an object", name, p)
+			}
+			merge(base, obj)
+		}
+		merge(base, v)
+		return base, nil
+	}
+	return v, nil
+}
+
+// includePaths returns the paths named by the value of an IncludeKey member.
+func includePaths(v any) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case []any:
+		paths := make([]string, len(v))
+		for i, elem := range v {
+			p, ok := elem.(string)
+			if !ok {
+				return nil, errors.New(IncludeKey + " must be a string or an array of strings")
+			}
+			paths[i] = p
+		}
+		return paths, nil
+	}
+	return nil, errors.New(IncludeKey + " must be a string or an array of strings")
+}
+
+// merge merges the members of src into dst, recursing into members that are
+// objects in both.
+func merge(dst, src map[string]any) {
+	for key, value := range src {
+		d, dOK := dst[key].(map[string]any)
+		s, sOK := value.(map[string]any)
+		if dOK && sOK {
+			merge(d, s)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// expandEnv replaces the ${VAR} and ${VAR:-default} references in src with
+// values from lookup, as described for Load.
+func expandEnv(src []byte, lookup func(string) (string, bool)) ([]byte, error) {
+	if !bytes.Contains(src, []byte("${")) {
+		return src, nil
+	}
+
+	out := make([]byte, 0, len(src))
+	inString, escaped, inComment := false, false, false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case inComment:
+			inComment = c != '\n'
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case !inString && c == '#':
+			inComment = true
+		case c == '$' && bytes.HasPrefix(src[i+1:], []byte("${")):
+			// $${ is a literal ${.
+			out = append(out, "${"...)
+			i += 2
+			continue
+		case c == '$' && bytes.HasPrefix(src[i+1:], []byte("{")):
+			end := bytes.IndexByte(src[i+2:], '}')
+			if end < 0 {
+				return nil, errors.New("unterminated ${ reference")
+			}
+			value, err := resolveVar(string(src[i+2:i+2+end]), lookup)
+			if err != nil {
+				return nil, err
+			}
+			if inString {
+				quoted := tron.AppendString(nil, value)
+				out = append(out, quoted[1:len(quoted)-1]...)
+			} else {
+				out = append(out, value...)
+			}
+			i += 2 + end
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// resolveVar returns the value of the reference VAR or VAR:-default.
+func resolveVar(ref string, lookup func(string) (string, bool)) (string, error) {
+	name, def, hasDefault := strings.Cut(ref, ":-")
+	if name == "" {
+		return "", fmt.Errorf("empty variable name in ${%s}", ref)
+	}
+	value, ok := lookup(name)
+	if hasDefault && value == "" {
+		return def, nil
+	}
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// pointer identifies a pointer followed by applyDefaults. The type is needed
+// as a struct and its first field share an address.
+type pointer struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+// applyDefaults sets the zero fields of the structs reachable from v that
+// have a default tag. seen holds the pointers already followed, so that each
+// struct is visited once even in cyclic data.
+func applyDefaults(v reflect.Value, seen map[pointer]bool) error {
+	for v.Kind() == reflect.Pointer {
+		key := pointer{v.Pointer(), v.Type()}
+		if v.IsNil() || seen[key] {
+			return nil
+		}
+		seen[key] = true
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if tag, ok := f.Tag.Lookup("default"); ok && fv.IsZero() {
+			if err := setDefault(fv, tag); err != nil {
+				return fmt.Errorf("config: default for %s.%s: %w", t.Name(), f.Name, err)
+			}
+		}
+		if err := applyDefaults(fv, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setDefault sets fv from the text of its default tag.
+func setDefault(fv reflect.Value, tag string) error {
+	if fv.Kind() == reflect.String {
+		fv.SetString(tag)
+		return nil
+	}
+	return tron.Unmarshal([]byte(tag), fv.Addr().Interface())
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type serverConfig struct {
+	Host string `json:"host" default:"localhost"`
+	Port int    `json:"port" default:"8080"`
+}
+
+type appConfig struct {
+	Name    string         `json:"name"`
+	Debug   bool           `json:"debug"`
+	Workers int            `json:"workers" default:"4"`
+	Tags    []string       `json:"tags" default:"[\"a\",\"b\"]"`
+	Server  serverConfig   `json:"server"`
+	Extra   map[string]int `json:"extra"`
+}
+
+func TestLoadFSMergesIncludes(t *testing.T) {
+	t.Setenv("APP_NAME", `my "app"`)
+	t.Setenv("APP_PORT", "9000")
+	fsys := fstest.MapFS{
+		"conf/app.tron": {Data: []byte(`# main config, ${NOT_EXPANDED}
+"@include": ["base.tron", "shared/server.tron"]
+name: "${APP_NAME}"
+server: {"port": ${APP_PORT}}
+`)},
+		"conf/base.tron": {Data: []byte(`name: "base"
+debug: true
+extra: {"x": 0b1, "y": 2_000}
+`)},
+		"conf/shared/server.tron": {Data: []byte(`server: {"host": "example.com", "port": 1}
+extra: {"y": 3}
+`)},
+	}
+
+	var cfg appConfig
+	if err := LoadFS(fsys, "conf/app.tron", &cfg); err != nil {
+		t.Fatalf("LoadFS: %v", err)
+	}
+	if cfg.Name != `my "app"` || !cfg.Debug || cfg.Workers != 4 {
+		t.Fatalf("got %+v", cfg)
+	}
+	if cfg.Server != (serverConfig{"example.com", 9000}) {
+		t.Fatalf("server = %+v", cfg.Server)
+	}
+	if cfg.Extra["x"] != 1 || cfg.Extra["y"] != 3 {
+		t.Fatalf("extra = %v", cfg.Extra)
+	}
+	if strings.Join(cfg.Tags, ",") != "a,b" {
+		t.Fatalf("tags = %v", cfg.Tags)
+	}
+}
+
+func TestLoadNestedIncludeFromDisk(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("app.tron", `name: "app"
+server: {"@include": "server.tron", "port": 81}
+`)
+	write("server.tron", `host: "${SERVER_HOST:-fallback}"`+"\n")
+
+	cfg := appConfig{Debug: true}
+	if err := Load(filepath.Join(dir, "app.tron"), &cfg); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !cfg.Debug || cfg.Server != (serverConfig{"fallback", 81}) {
+		t.Fatalf("got %+v", cfg)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"unset.tron": {Data: []byte(`name: "${CONFIG_TEST_UNSET}"`)},
+		"a.tron":     {Data: []byte(`"@include": "b.tron"`)},
+		"b.tron":     {Data: []byte(`"@include": "a.tron"`)},
+		"list.tron":  {Data: []byte(`"@include": "array.tron"`)},
+		"array.tron": {Data: []byte(`[1, 2]`)},
+		"bad.tron":   {Data: []byte(`"@include": 1`)},
+		"type.tron":  {Data: []byte(`workers: "many"`)},
+	}
+	tests := map[string]string{
+		"unset.tron":   "CONFIG_TEST_UNSET is not set",
+		"a.tron":       "includes itself",
+		"list.tron":    "is not an object",
+		"bad.tron":     "must be a string or an array of strings",
+		"type.tron":    "type.tron",
+		"missing.tron": "missing.tron",
+	}
+	for name, want := range tests {
+		var cfg appConfig
+		err := LoadFS(fsys, name, &cfg)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got %v, want error containing %q", name, err, want)
+		}
+	}
+
+	if err := LoadFS(fsys, "a.tron", appConfig{}); err == nil {
+		t.Error("expected error for non-pointer")
+	}
+}
+
+func TestLoadFindsCyclesThroughEquivalentPaths(t *testing.T) {
+	fsys := fstest.MapFS{
+		"self.tron":     {Data: []byte(`"@include": "self.tron"`)},
+		"sub/loop.tron": {Data: []byte(`"@include": "../sub/./loop.tron"`)},
+	}
+	for _, name := range []string{"./self.tron", "sub/loop.tron"} {
+		var cfg appConfig
+		if err := LoadFS(fsys, name, &cfg); err == nil || !strings.Contains(err.Error(), "includes itself") {
+			t.Errorf("%s: got %v, want error containing %q", name, err, "includes itself")
+		}
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "self.tron"), []byte(`"@include": "self.tron"`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var cfg appConfig
+	if err := Load(dir+"/./self.tron", &cfg); err == nil || !strings.Contains(err.Error(), "includes itself") {
+		t.Errorf("Load: got %v, want error containing %q", err, "includes itself")
+	}
+}
+
+func TestLoadAppliesDefaultsToCyclicValues(t *testing.T) {
+	type node struct {
+		Name string `json:"name" default:"n"`
+		Next *node  `json:"-"`
+	}
+	fsys := fstest.MapFS{"node.tron": {Data: []byte("other: 1")}}
+	n := &node{}
+	n.Next = n
+	if err := LoadFS(fsys, "node.tron", n); err != nil {
+		t.Fatalf("LoadFS: %v", err)
+	}
+	if n.Name != "n" || n.Next != n {
+		t.Fatalf("got %+v", n)
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		v, ok := map[string]string{"A": "1", "Q": `x"y`, "E": ""}[name]
+		return v, ok
+	}
+	tests := []struct{ in, want string }{
+		{`n: ${A}`, `n: 1`},
+		{`s: "${Q}"`, `s: "x\"y"`},
+		{`s: "${E:-d}" # ${MISSING}`, `s: "d" # ${MISSING}`},
+		{`s: "$${A}"`, `s: "${A}"`},
+		{`s: "\"${A}"`, `s: "\"1"`},
+	}
+	for _, tt := range tests {
+		got, err := expandEnv([]byte(tt.in), lookup)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("expandEnv(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := expandEnv([]byte(`n: ${A`), lookup); err == nil {
+		t.Error("expected error for unterminated reference")
+	}
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/tron-format/trongo/pkg/tron"
+)
+
+// Parser adapts TRON to configuration libraries that accept custom formats.
+// Its Unmarshal and Marshal methods implement koanf's Parser interface, and
+// its Decode and Encode methods viper's Decoder and Encoder interfaces:
+//
+//	k.Load(file.Provider("app.tron"), config.Parser{})
+//
+//	codecs := viper.NewCodecRegistry()
+//	codecs.RegisterCodec("tron", config.Parser{})
+//	v := viper.NewWithOptions(viper.WithCodecRegistry(codecs))
+//
+// Documents must be objects. Integers are decoded as int64 and other numbers
+// as float64. Environment variables and includes are left to the library;
+// use Load for those.
+type Parser struct{}
+
+// Unmarshal parses the TRON document data into a map.
+func (Parser) Unmarshal(data []byte) (map[string]any, error) {
+	dec := tron.NewDecoder(bytes.NewReader(data))
+	dec.PreferInt64()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	m, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("config: document is %T, not an object", doc)
+	}
+	return m, nil
+}
+
+// Marshal encodes m as a TRON document.
+func (Parser) Marshal(m map[string]any) ([]byte, error) {
+	return tron.Marshal(m)
+}
+
+// Decode parses the TRON document data and stores its members in v.
+func (p Parser) Decode(data []byte, v map[string]any) error {
+	m, err := p.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	for key, value := range m {
+		v[key] = value
+	}
+	return nil
+}
+
+// Encode encodes v as a TRON document.
+func (p Parser) Encode(v map[string]any) ([]byte, error) {
+	return p.Marshal(v)
+}
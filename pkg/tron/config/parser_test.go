@@ -0,0 +1,58 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+// The interfaces of koanf and viper that Parser is meant to implement.
+var (
+	_ interface {
+		Unmarshal([]byte) (map[string]any, error)
+		Marshal(map[string]any) ([]byte, error)
+	} = Parser{}
+	_ interface {
+		Decode(b []byte, v map[string]any) error
+		Encode(v map[string]any) ([]byte, error)
+	} = Parser{}
+)
+
+func TestParserRoundTrip(t *testing.T) {
+	src := []byte("class A: host,port\n\nname: \"app\"\nservers: [A(\"a\",1),A(\"b\",2)]\nratio: 0.5\n")
+	m, err := Parser{}.Unmarshal(src)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	want := map[string]any{
+		"name":  "app",
+		"ratio": 0.5,
+		"servers": []any{
+			map[string]any{"host": "a", "port": int64(1)},
+			map[string]any{"host": "b", "port": int64(2)},
+		},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("got %#v, want %#v", m, want)
+	}
+
+	data, err := Parser{}.Encode(m)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	got := make(map[string]any)
+	if err := (Parser{}).Decode(data, got); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip got %#v, want %#v", got, want)
+	}
+}
+
+func TestParserRejectsNonObject(t *testing.T) {
+	if _, err := (Parser{}).Unmarshal([]byte("[1,2]")); err == nil {
+		t.Fatal("expected error for array document")
+	}
+	if err := (Parser{}).Decode([]byte("{"), map[string]any{}); err == nil {
+		t.Fatal("expected syntax error")
+	}
+}
@@ -0,0 +1,42 @@
+package tron
+
+import "context"
+
+// cancelCheckInterval is how many units of work (tokens, parsed values or
+// decoded values) pass between polls of the context.
+const cancelCheckInterval = 1024
+
+// canceler polls a context for cancellation during decoding. A nil
+// canceler never reports cancellation.
+type canceler struct {
+	ctx context.Context
+	n   int
+}
+
+// check returns the context's error every cancelCheckInterval calls.
+func (c *canceler) check() error {
+	if c == nil {
+		return nil
+	}
+	c.n++
+	if c.n%cancelCheckInterval != 0 {
+		return nil
+	}
+	return c.ctx.Err()
+}
+
+// UnmarshalContext is like Unmarshal but stops early and returns ctx.Err()
+// if ctx is canceled or its deadline passes while the data is being
+// tokenized, parsed or decoded.
+func UnmarshalContext(ctx context.Context, data []byte, v interface{}) error {
+	return unmarshalContext(ctx, data, v, decodeOptions{})
+}
+
+// unmarshalContext runs unmarshal with cancellation checks attached to opts.
+func unmarshalContext(ctx context.Context, data []byte, v interface{}, opts decodeOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	opts.cancel = &canceler{ctx: ctx}
+	return unmarshal(data, v, opts)
+}
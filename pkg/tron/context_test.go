@@ -0,0 +1,81 @@
+package tron
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func largeArrayInput(n int) []byte {
+	var b strings.Builder
+	b.WriteString("class A: a,b\n\n[")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(`A(1,"x")`)
+	}
+	b.WriteString("]")
+	return []byte(b.String())
+}
+
+func TestUnmarshalContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var v interface{}
+	if err := UnmarshalContext(ctx, []byte("[1,2,3]"), &v); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestUnmarshalContextDeadlineDuringDecode(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	// Skip the up-front check so cancellation is detected by the periodic polls.
+	var v []struct{ A, B interface{} }
+	err := unmarshal(largeArrayInput(5000), &v, decodeOptions{cancel: &canceler{ctx: ctx}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestCancelerPollsEachStage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tokens := strings.Repeat("1,", cancelCheckInterval) + "1"
+	if _, err := tokenizeWith([]byte(tokens), decodeOptions{cancel: &canceler{ctx: ctx}}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("tokenize: expected context.Canceled, got %v", err)
+	}
+
+	toks, err := tokenize("[" + tokens + "]")
+	if err != nil {
+		t.Fatalf("tokenize: %v", err)
+	}
+	p := newParser(toks)
+	p.opts.cancel = &canceler{ctx: ctx}
+	if _, err := p.parse(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("parse: expected context.Canceled, got %v", err)
+	}
+}
+
+func TestUnmarshalContextSucceeds(t *testing.T) {
+	var v []struct{ A, B interface{} }
+	if err := UnmarshalContext(context.Background(), largeArrayInput(3000), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(v) != 3000 {
+		t.Fatalf("expected 3000 elements, got %d", len(v))
+	}
+
+	var w []struct{ A, B interface{} }
+	dec := NewDecoder(strings.NewReader(string(largeArrayInput(10))))
+	if err := dec.DecodeContext(context.Background(), &w); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(w) != 10 {
+		t.Fatalf("expected 10 elements, got %d", len(w))
+	}
+}
@@ -105,8 +105,8 @@ func TestTokenize_StringErrorBranches(t *testing.T) {
 		t.Fatalf("expected error")
 	}
 
-	// Unpaired surrogate should error
-	if _, err := tokenize("\"\\uD800\""); err == nil {
+	// Unpaired surrogate should error in strict mode
+	if _, err := tokenizeWith([]byte("\"\\uD800\""), decodeOptions{strictUTF8: true}); err == nil {
 		t.Fatalf("expected error")
 	}
 
@@ -45,12 +45,13 @@ func TestParseString_MoreErrorBranches(t *testing.T) {
 	if _, err := tokenize("\"\\u\""); err == nil {
 		t.Fatalf("expected error")
 	}
-	// invalid UTF-8 inside string
-	if _, err := tokenize(string([]byte{'"', 0xff, '"'})); err == nil {
+	// invalid UTF-8 inside string, in strict mode
+	strict := decodeOptions{strictUTF8: true}
+	if _, err := tokenizeWith([]byte{'"', 0xff, '"'}, strict); err == nil {
 		t.Fatalf("expected error")
 	}
 	// invalid UTF-8 right after backslash
-	if _, err := tokenize(string([]byte{'"', '\\', 0xff, '"'})); err == nil {
+	if _, err := tokenizeWith([]byte{'"', '\\', 0xff, '"'}, strict); err == nil {
 		t.Fatalf("expected error")
 	}
 }
@@ -0,0 +1,226 @@
+package tron
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// CSVToTRON reads a table from src and writes it to dst as a TRON array with
+// one class instantiation per row. The header row supplies the class's
+// properties. Cells holding a JSON number or true or false are written as
+// TRON numbers and booleans, keeping their text; every other cell, including
+// an empty one, is written as a string. Rows are converted as they are read,
+// so tables of any size can be converted. The output ends with a newline.
+//
+// Configure src (for example its Comma or LazyQuotes) to match the input.
+// The header must be present and its names distinct, and every row must have
+// a field for each of them, even with src.FieldsPerRecord set to -1.
+func CSVToTRON(dst io.Writer, src *csv.Reader) error {
+	header, err := src.Read()
+	if err == io.EOF {
+		return errors.New("tron: CSV input has no header row")
+	}
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(header))
+	for _, name := range header {
+		if seen[name] {
+			return fmt.Errorf("tron: duplicate CSV column %q", name)
+		}
+		seen[name] = true
+	}
+
+	cls := ClassDef{Name: generateClassName(0), Keys: header}
+	out := appendHeader(nil, []ClassDef{cls}, escapeHTML)
+	out = append(out, '[')
+	for n := 0; ; n++ {
+		record, err := src.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(record) != len(header) {
+			line, _ := src.FieldPos(0)
+			return fmt.Errorf("tron: CSV row on line %d has %d fields, want %d", line, len(record), len(header))
+		}
+
+		if n > 0 {
+			out = append(out, ',')
+		}
+		out = append(out, cls.Name...)
+		out = append(out, '(')
+		for i, cell := range record {
+			if i > 0 {
+				out = append(out, ',')
+			}
+			out = appendCSVCell(out, cell)
+		}
+		out = append(out, ')')
+
+		if len(out) >= seqFlushSize {
+			if _, err := dst.Write(out); err != nil {
+				return err
+			}
+			out = out[:0]
+		}
+	}
+	out = append(out, "]\n"...)
+	_, err = dst.Write(out)
+	return err
+}
+
+// appendCSVCell appends the TRON value for a CSV cell to out.
+func appendCSVCell(out []byte, cell string) []byte {
+	switch {
+	case cell == "true" || cell == "false":
+		return append(out, cell...)
+	case isNumberLiteral(cell):
+		return append(out, cell...)
+	}
+	return appendQuoted(out, cell)
+}
+
+// isNumberLiteral reports whether s is exactly one TRON number token whose
+// value fits in a float64.
+func isNumberLiteral(s string) bool {
+	if s == "" || !(s[0] == '-' || '0' <= s[0] && s[0] <= '9') {
+		return false
+	}
+	value, end, _, ok := parseNumberJSON([]byte(s), 0, 0)
+	if !ok || end != len(s) {
+		return false
+	}
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+// TRONToCSV reads a TRON document whose data is an array of objects or class
+// instantiations from src and writes it to dst as a table. The keys of the
+// first element, in order, become the header row; later elements may omit
+// keys, leaving their cells empty, but may not add new ones. Strings are
+// written as they are, numbers keep their text, booleans become true or
+// false, and null becomes an empty cell. Nested arrays and objects cannot be
+// written to a cell and are reported as errors.
+//
+// Elements are converted one at a time as they are read, and dst is flushed
+// before TRONToCSV returns. An empty array writes nothing.
+func TRONToCSV(dst *csv.Writer, src io.Reader) error {
+	s := &streamScanner{r: bufio.NewReader(src)}
+	classes, err := s.readHeader()
+	if err == errNotArray {
+		err = &SyntaxError{msg: "expected top-level array"}
+	}
+	if err != nil {
+		return err
+	}
+
+	var columns map[string]int
+	var record []string
+	for {
+		elem, more, err := s.nextElement()
+		if err != nil {
+			return err
+		}
+		if elem == nil {
+			break
+		}
+
+		keys, cells, err := csvRow(elem, classes)
+		if err != nil {
+			return err
+		}
+		if columns == nil {
+			columns = make(map[string]int, len(keys))
+			for i, key := range keys {
+				columns[key] = i
+			}
+			if err := dst.Write(keys); err != nil {
+				return err
+			}
+			record = make([]string, len(keys))
+		}
+		clear(record)
+		for i, key := range keys {
+			col, ok := columns[key]
+			if !ok {
+				return fmt.Errorf("tron: key %q is not a CSV column", key)
+			}
+			record[col] = cells[i]
+		}
+		if err := dst.Write(record); err != nil {
+			return err
+		}
+
+		if !more {
+			if err := s.expectEnd(); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	dst.Flush()
+	return dst.Error()
+}
+
+// csvRow parses the source of one array element, which must be an object or
+// class instantiation of scalars, into its keys and cell texts.
+func csvRow(elem []byte, classes map[string]*classDef) (keys, cells []string, err error) {
+	p, err := newDocumentParser(elem, decodeOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	p.classes = classes
+	p.skipNewlines()
+
+	member := func(key string) error {
+		if t := p.current().Type; t == TokenLBracket || t == TokenLBrace || t == TokenIdentifier {
+			return fmt.Errorf("tron: CSV cell for key %q must be a scalar, got %s", key, describeToken(p.current()))
+		}
+		// Element members sit at depth 4: the root array's children are at
+		// depth 2, as in parseValue.
+		v, err := p.parseValue(4)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		cells = append(cells, csvCell(v))
+		return nil
+	}
+	switch p.current().Type {
+	case TokenLBrace:
+		err = p.parseObjectWith(3, member)
+	case TokenIdentifier:
+		err = p.parseClassInstantiationWith(3, member)
+	default:
+		return nil, nil, fmt.Errorf("tron: CSV rows must be objects, got %s", describeToken(p.current()))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.skipNewlines()
+	if p.current().Type != TokenEOF {
+		return nil, nil, p.syntaxError("expected ',' or ']' after array element")
+	}
+	return keys, cells, nil
+}
+
+// csvCell returns the cell text for a parsed scalar.
+func csvCell(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case numberLiteral:
+		return string(v)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
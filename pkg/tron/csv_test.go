@@ -0,0 +1,118 @@
+package tron
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const csvTable = `id,name,score,active,zip,note
+1,Ann,9.5,true,01234,
+2,"Bo, Jr.",-3,false,90210,"said ""hi"""
+`
+
+func TestCSVToTRON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := CSVToTRON(&buf, csv.NewReader(strings.NewReader(csvTable))); err != nil {
+		t.Fatalf("CSVToTRON: %v", err)
+	}
+	want := "class A: id,name,score,active,zip,note\n\n" +
+		`[A(1,"Ann",9.5,true,"01234",""),A(2,"Bo, Jr.",-3,false,90210,"said \"hi\"")]` + "\n"
+	if buf.String() != want {
+		t.Fatalf("CSVToTRON =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	type row struct {
+		ID    int     `json:"id"`
+		Name  string  `json:"name"`
+		Score float64 `json:"score"`
+	}
+	var rows []row
+	if err := Unmarshal(buf.Bytes(), &rows); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(rows) != 2 || rows[1] != (row{2, "Bo, Jr.", -3}) {
+		t.Fatalf("Unmarshal = %+v", rows)
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	var tronData bytes.Buffer
+	if err := CSVToTRON(&tronData, csv.NewReader(strings.NewReader(csvTable))); err != nil {
+		t.Fatalf("CSVToTRON: %v", err)
+	}
+	var out bytes.Buffer
+	if err := TRONToCSV(csv.NewWriter(&out), &tronData); err != nil {
+		t.Fatalf("TRONToCSV: %v", err)
+	}
+	if out.String() != csvTable {
+		t.Fatalf("round trip =\n%s\nwant\n%s", out.String(), csvTable)
+	}
+}
+
+func TestTRONToCSV(t *testing.T) {
+	in := "class P: x,y\n\n[P(1,\"a\"), {\"y\":null}, {\"x\":2.50}]"
+	var out bytes.Buffer
+	if err := TRONToCSV(csv.NewWriter(&out), strings.NewReader(in)); err != nil {
+		t.Fatalf("TRONToCSV: %v", err)
+	}
+	if want := "x,y\n1,a\n,\n2.50,\n"; out.String() != want {
+		t.Fatalf("TRONToCSV = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	if err := TRONToCSV(csv.NewWriter(&out), strings.NewReader("[]")); err != nil || out.Len() != 0 {
+		t.Fatalf("empty array: %q, %v", out.String(), err)
+	}
+}
+
+func TestCSVErrors(t *testing.T) {
+	if err := CSVToTRON(&bytes.Buffer{}, csv.NewReader(strings.NewReader(""))); err == nil {
+		t.Error("expected error for missing header")
+	}
+	if err := CSVToTRON(&bytes.Buffer{}, csv.NewReader(strings.NewReader("a,a\n1,2\n"))); err == nil {
+		t.Error("expected error for duplicate column")
+	}
+	if err := CSVToTRON(&bytes.Buffer{}, csv.NewReader(strings.NewReader("a,b\n1\n"))); err == nil {
+		t.Error("expected error for short row")
+	}
+	for in, want := range map[string]string{
+		"a,b\n1,2\n3\n": "tron: CSV row on line 3 has 1 fields, want 2",
+		"a,b\n1,2,3\n":  "tron: CSV row on line 2 has 3 fields, want 2",
+	} {
+		r := csv.NewReader(strings.NewReader(in))
+		r.FieldsPerRecord = -1
+		if err := CSVToTRON(&bytes.Buffer{}, r); err == nil || err.Error() != want {
+			t.Errorf("CSVToTRON(%q) with ragged rows: got %v, want %s", in, err, want)
+		}
+	}
+
+	for _, in := range []string{
+		`[{"a":1},{"b":2}]`,
+		`[{"a":[1]}]`,
+		`[1]`,
+		`{"a":1}`,
+		`[{"a":1} {"a":2}]`,
+	} {
+		if err := TRONToCSV(csv.NewWriter(&bytes.Buffer{}), strings.NewReader(in)); err == nil {
+			t.Errorf("TRONToCSV(%s): expected error", in)
+		}
+	}
+	var syntaxErr *SyntaxError
+	if err := TRONToCSV(csv.NewWriter(&bytes.Buffer{}), strings.NewReader(`a: 1`)); !errors.As(err, &syntaxErr) {
+		t.Errorf("expected SyntaxError for non-array document, got %v", err)
+	}
+}
+
+func TestIsNumberLiteral(t *testing.T) {
+	for s, want := range map[string]bool{
+		"0": true, "-1.5e3": true, "10": true,
+		"": false, "01": false, "1.": false, "+1": false, "1e400": false, "1 ": false, "NaN": false, "0x1": false,
+	} {
+		if got := isNumberLiteral(s); got != want {
+			t.Errorf("isNumberLiteral(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
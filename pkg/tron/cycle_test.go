@@ -0,0 +1,80 @@
+package tron
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarshalCycles(t *testing.T) {
+	type node struct {
+		Next *node         `json:"next"`
+		Kids []interface{} `json:"kids"`
+	}
+
+	n := &node{}
+	n.Next = &node{Next: n}
+
+	m := map[string]interface{}{}
+	m["self"] = m
+
+	s := make([]interface{}, 1)
+	s[0] = s
+
+	kid := &node{}
+	kid.Kids = []interface{}{kid}
+
+	for name, v := range map[string]interface{}{
+		"pointer":            n,
+		"pointer by value":   *n,
+		"map":                m,
+		"slice":              s,
+		"slice in interface": []interface{}{s},
+		"struct field":       kid,
+		"ordered map":        selfOrderedMap(),
+	} {
+		_, err := Marshal(v)
+		var uerr *UnsupportedValueError
+		if !errors.As(err, &uerr) || !strings.Contains(err.Error(), "circular") {
+			t.Errorf("%s: Marshal error = %v, want a circular structure error", name, err)
+		}
+	}
+}
+
+func selfOrderedMap() *OrderedMap {
+	m := &OrderedMap{}
+	m.Set("self", m)
+	return m
+}
+
+func TestMarshalSharedValuesAreNotCycles(t *testing.T) {
+	type inner struct {
+		A int `json:"a"`
+	}
+	type outer struct {
+		Inner inner `json:"inner"`
+	}
+
+	// A pointer to a struct and one to its first field share an address.
+	o := &outer{Inner: inner{A: 1}}
+	shared := []int{1, 2}
+	var deep interface{} = "leaf"
+	for range cycleCheckDepth + 50 {
+		deep = []interface{}{o, &o.Inner, shared, shared[:1], deep}
+	}
+	if _, err := Marshal(deep); err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+}
+
+func TestMarshalShallowValuesDoNotTrackCycles(t *testing.T) {
+	e := newEncoder(encodeOptions{noPool: true})
+	v := map[string]interface{}{"a": []int{1}, "b": &struct{ X *int }{new(int)}}
+	if err := e.serialize(reflect.ValueOf(v), 0); err != nil {
+		t.Fatalf("serialize: %v", err)
+	}
+	if e.seen != nil {
+		t.Fatalf("cycle tracking allocated for a shallow value")
+	}
+}
@@ -0,0 +1,74 @@
+package tron
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoderDecodeArray(t *testing.T) {
+	type order struct {
+		ID     int     `json:"id"`
+		Amount float64 `json:"amount"`
+	}
+	input := "class O: id,amount\n\n[O(1,2.5),O(2,4),{\"id\":3,\"amount\":10},O(4,0.5)]"
+
+	dec := NewDecoder(strings.NewReader(input))
+	var total float64
+	var seen int
+	err := dec.DecodeArray(func(dec *Decoder) error {
+		seen++
+		if seen == 2 {
+			return nil // skipped
+		}
+		var o order
+		if err := dec.Decode(&o); err != nil {
+			return err
+		}
+		total += o.Amount
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen != 4 || total != 13 {
+		t.Errorf("seen %d elements, total %v; want 4, 13", seen, total)
+	}
+	var v any
+	if err := dec.Decode(&v); err != io.EOF {
+		t.Errorf("Decode after the array: got %v, want io.EOF", err)
+	}
+
+	// fn's error stops the walk.
+	stop := errors.New("stop")
+	dec = NewDecoder(strings.NewReader(input))
+	seen = 0
+	if err := dec.DecodeArray(func(*Decoder) error { seen++; return stop }); err != stop || seen != 1 {
+		t.Errorf("got %v after %d elements, want stop after 1", err, seen)
+	}
+
+	// Errors reading the array.
+	for src, want := range map[string]string{
+		"":         "EOF",
+		`{"a":1}`:  "expected top-level array",
+		"[1,2":     "",
+		"[1,2] 3":  "",
+		"[1,{]":    "",
+		"[[1],[2]": "",
+	} {
+		err := NewDecoder(strings.NewReader(src)).DecodeArray(func(dec *Decoder) error {
+			var v any
+			return dec.Decode(&v)
+		})
+		if err == nil || want != "" && !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: got %v, want error containing %q", src, err, want)
+		}
+	}
+
+	dec = NewDecoder(strings.NewReader("[1]"))
+	dec.UseLineMode()
+	if err := dec.DecodeArray(func(*Decoder) error { return nil }); err == nil {
+		t.Error("line mode: expected error")
+	}
+}
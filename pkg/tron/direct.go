@@ -0,0 +1,406 @@
+package tron
+
+import (
+	"errors"
+	"reflect"
+	"unsafe"
+)
+
+// Unmarshal decodes arrays, objects and class instantiations straight from the
+// parser into slices, arrays, maps and structs, without first building the
+// []interface{} and map[string]interface{} trees that parse returns. Scalars,
+// interface{} destinations and types with custom unmarshalers fall back to
+// parseValue followed by decode, which is also what produces the type errors
+// for mismatched values.
+//
+// Once a member or element fails with a non-fatal error, decoding carries on
+// with the rest of the input, like encoding/json, so that as much of the
+// value as possible is stored. Syntax errors are found by checking the whole
+// document before anything is stored, so that they leave dst unchanged.
+
+// decodeDocument parses a complete TRON document from p directly into dst.
+func (d *decoder) decodeDocument(p *parser, dst reflect.Value) error {
+	if err := p.parseHeader(); err != nil {
+		return err
+	}
+	d.classes = p.classes
+	if err := p.checkData(); err != nil {
+		return err
+	}
+
+	// Skip blank lines between header and data
+	p.skipNewlines()
+	if p.current().Type == TokenEOF {
+		return d.decode(nil, dst)
+	}
+
+	// Implicit root object: key: value lines.
+	if (p.current().Type == TokenIdentifier || p.current().Type == TokenString) && p.peek(1).Type == TokenColon {
+		if dst.Kind() == reflect.Interface && dst.NumMethod() == 0 && (d.types != nil || d.orderedMaps) {
+			// Decode the members one by one so that registered classes
+			// among them are found, and their order can be kept.
+			m, target := d.newObject()
+			err := d.decodeDirectMembers(p, target, 2, nil, func(member func(key string) error) error {
+				return p.parseImplicitObjectWith(1, member)
+			})
+			if err == nil || !isFatal(err) {
+				dst.Set(m)
+			}
+			return joinErrors(err)
+		}
+		if !decodesMembersDirectly(dst) {
+			obj, err := p.parseImplicitObject()
+			if err != nil {
+				return err
+			}
+			return joinErrors(d.decode(obj, dst))
+		}
+		return joinErrors(d.decodeDirectMembers(p, dst, 2, nil, func(member func(key string) error) error {
+			return p.parseImplicitObjectWith(1, member)
+		}))
+	}
+
+	err := d.decodeDirect(p, dst, 0)
+	if err != nil && isFatal(err) {
+		return err
+	}
+	p.skipNewlines()
+	if p.current().Type != TokenEOF {
+		return p.syntaxError("unexpected trailing tokens")
+	}
+	return joinErrors(err)
+}
+
+// checkData checks the data of the document after the header, leaving p
+// where it was so that the data can then be decoded.
+func (p *parser) checkData() error {
+	pos, values, issues := p.pos, p.values, len(p.issues)
+	defer func() {
+		p.pos, p.values, p.issues = pos, values, p.issues[:issues]
+	}()
+
+	p.skipNewlines()
+	if p.current().Type == TokenEOF {
+		return nil
+	}
+	if (p.current().Type == TokenIdentifier || p.current().Type == TokenString) && p.peek(1).Type == TokenColon {
+		return p.parseImplicitObjectWith(1, func(string) error { return p.skipValue(2) })
+	}
+	if err := p.skipValue(0); err != nil {
+		return err
+	}
+	p.skipNewlines()
+	if p.current().Type != TokenEOF {
+		return p.syntaxError("unexpected trailing tokens")
+	}
+	return nil
+}
+
+// decodeDirect parses the value at the current position into dst. depth
+// follows the same accounting as parseValue so limits apply identically.
+func (d *decoder) decodeDirect(p *parser, dst reflect.Value, depth int) error {
+	if depth > p.limits().MaxDepth {
+		return p.categoryError(ErrTooDeep, "maximum parse depth exceeded")
+	}
+	if err := d.cancel.check(); err != nil {
+		return err
+	}
+
+	if dst.Kind() == reflect.Ptr && p.current().Type != TokenNull {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return d.decodeDirect(p, dst.Elem(), depth)
+	}
+
+	if dst.Kind() == reflect.Interface && d.types != nil {
+		if ok, err := d.decodeDynamic(p, dst, depth, d.types.discriminator); ok {
+			return err
+		}
+	} else if dst.Kind() == reflect.Interface && dst.NumMethod() == 0 && d.orderedMaps {
+		if ok, err := d.decodeContainer(p, dst, depth); ok {
+			return err
+		}
+	}
+
+	switch p.current().Type {
+	case TokenLBracket:
+		if (dst.Kind() == reflect.Slice || dst.Kind() == reflect.Array) && !hasCustomUnmarshaler(dst) {
+			if dst.Kind() == reflect.Slice && d.decodesInParallel(p, depth) {
+				return d.decodeParallelSlice(p, dst, depth+2)
+			}
+			if dst.Kind() == reflect.Slice {
+				if ok, err := d.decodePrimitiveSlice(p, dst, depth+2); ok {
+					return err
+				}
+				return d.decodeDirectSlice(p, dst, depth+2)
+			}
+			return d.decodeDirectArray(p, dst, depth+2)
+		}
+	case TokenLBrace:
+		if decodesMembersDirectly(dst) {
+			return d.decodeDirectMembers(p, dst, depth+2, nil, func(member func(key string) error) error {
+				return p.parseObjectWith(depth+1, member)
+			})
+		}
+	case TokenIdentifier:
+		if decodesMembersDirectly(dst) && !p.atNonFinite() {
+			def := p.classes[p.current().Value]
+			return d.decodeDirectMembers(p, dst, depth+2, def, func(member func(key string) error) error {
+				return p.parseClassInstantiationWith(depth+1, member)
+			})
+		}
+	}
+
+	v, err := p.parseValue(depth)
+	if err != nil {
+		return err
+	}
+	return d.decode(v, dst)
+}
+
+// decodesMembersDirectly reports whether object members can be stored into
+// dst as they are parsed.
+func decodesMembersDirectly(dst reflect.Value) bool {
+	return (dst.Kind() == reflect.Struct || dst.Kind() == reflect.Map) && !hasCustomUnmarshaler(dst)
+}
+
+// hasCustomUnmarshaler reports whether dst decodes itself from the parsed
+// value, via UnmarshalTRON, UnmarshalJSON or the built-in time handling.
+func hasCustomUnmarshaler(dst reflect.Value) bool {
+	if dst.Type() == timeType {
+		return true
+	}
+	if !dst.CanAddr() {
+		return false
+	}
+	pt := dst.Addr().Type()
+	return pt.Implements(unmarshalerType) || pt.Implements(jsonUnmarshalerType)
+}
+
+// decodeDirectSlice decodes an array into dst, reusing its backing array
+// while it has room and growing it as needed. An empty array replaces dst
+// with a new empty slice.
+func (d *decoder) decodeDirectSlice(p *parser, dst reflect.Value, depth int) error {
+	slice := dst.Slice(0, 0)
+	zero := reflect.Zero(dst.Type().Elem())
+	var errs error
+	err := p.parseArrayWith(func() error {
+		i := slice.Len()
+		if i < slice.Cap() {
+			slice = slice.Slice(0, i+1)
+			slice.Index(i).Set(zero)
+		} else {
+			slice = reflect.Append(slice, zero)
+		}
+		return d.recordError(&errs, elementError(d.decodeDirect(p, slice.Index(i), depth), indexPath(i)))
+	})
+	if err != nil {
+		return err
+	}
+	if slice.Len() == 0 {
+		slice = reflect.MakeSlice(dst.Type(), 0, 0)
+	}
+	dst.Set(slice)
+	return errs
+}
+
+// decodeDirectArray decodes an array into a fixed-size Go array, discarding
+// surplus elements and zeroing missing ones.
+func (d *decoder) decodeDirectArray(p *parser, dst reflect.Value, depth int) error {
+	n := 0
+	var errs error
+	err := p.parseArrayWith(func() error {
+		i := n
+		n++
+		if i >= dst.Len() {
+			return p.skipValue(depth)
+		}
+		return d.recordError(&errs, elementError(d.decodeDirect(p, dst.Index(i), depth), indexPath(i)))
+	})
+	if err != nil {
+		return err
+	}
+	for i := n; i < dst.Len(); i++ {
+		dst.Index(i).Set(reflect.Zero(dst.Type().Elem()))
+	}
+	return errs
+}
+
+// decodeDirectMembers decodes the members produced by parse into the struct
+// or map dst. parse is one of the parser's object, class instantiation or
+// implicit object walkers; depth is the depth of the member values. def is
+// the class being instantiated, or nil for objects.
+func (d *decoder) decodeDirectMembers(p *parser, dst reflect.Value, depth int, def *classDef, parse func(member func(key string) error) error) error {
+	var errs error
+	var member func(key string) error
+
+	if dst.Kind() == reflect.Map {
+		// Create map if nil
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(dst.Type()))
+		} else if d.clearMaps {
+			dst.Clear()
+		}
+		keyType := dst.Type().Key()
+		elemType := dst.Type().Elem()
+		member = func(key string) error {
+			keyVal := reflect.New(keyType).Elem()
+			if err := d.decodeMapKey(key, keyVal); err != nil {
+				if err := d.recordError(&errs, err); err != nil {
+					return err
+				}
+				return p.skipValue(depth)
+			}
+			elemVal := reflect.New(elemType).Elem()
+			if err := d.decodeDirect(p, elemVal, depth); err != nil {
+				return d.recordError(&errs, elementError(err, key))
+			}
+			dst.SetMapIndex(keyVal, elemVal)
+			return nil
+		}
+	} else if dst.Type() == orderedMapType {
+		defer d.orderObjects()()
+		m := dst.Addr().Interface().(*OrderedMap)
+		if d.clearMaps {
+			*m = OrderedMap{}
+		}
+		member = func(key string) error {
+			var v interface{}
+			if err := d.decodeDirect(p, reflect.ValueOf(&v).Elem(), depth); err != nil {
+				return d.recordError(&errs, elementError(err, key))
+			}
+			m.Set(key, v)
+			return nil
+		}
+	} else {
+		t := dst.Type()
+		fields := d.structFields(t)
+		byArg := d.classFields(def, t) // the fields of the class's arguments, from a TypeDecoder
+		var base unsafe.Pointer        // the struct's address, for UseUnsafeFieldSetters
+		if d.unsafeFields && dst.CanAddr() && p.preserveNumbers && depth <= p.limits().MaxDepth {
+			base = dst.Addr().UnsafePointer()
+		}
+		member = func(key string) error {
+			value := describeToken(p.current())
+			var field structField
+			var ok bool
+			if byArg != nil {
+				field = byArg[p.arg]
+				ok = field.index >= 0
+			} else {
+				field, ok = fields.lookup(key, d.exactNames)
+			}
+			if ok && base != nil && field.set != nil && field.set(unsafe.Add(base, field.offset), p.current()) {
+				p.advance()
+				return d.cancel.check()
+			}
+			if !ok {
+				if fields.remain < 0 {
+					// Unknown field - ignore (JSON behavior)
+					return p.skipValue(depth)
+				}
+				if err := d.decodeDirectRemain(p, key, dst.Field(fields.remain), depth); err != nil {
+					return d.recordError(&errs, fieldError(err, value, t.Field(fields.remain).Type.Elem(), t, key))
+				}
+				return nil
+			}
+			fv := dst.Field(field.index)
+			if field.discriminator != "" && fv.Kind() == reflect.Interface && d.types != nil {
+				if ok, err := d.decodeDynamic(p, fv, depth, field.discriminator); ok && err != nil {
+					return d.recordError(&errs, fieldError(err, value, field.typ, t, field.name))
+				} else if ok {
+					return nil
+				}
+			}
+			if err := d.decodeDirect(p, fv, depth); err != nil {
+				return d.recordError(&errs, fieldError(err, value, field.typ, t, field.name))
+			}
+			return nil
+		}
+	}
+
+	if err := parse(member); err != nil {
+		return err
+	}
+	return errs
+}
+
+// decodeDirectRemain stores an unknown object member in the catch-all map
+// dst, allocating the map if needed.
+func (d *decoder) decodeDirectRemain(p *parser, key string, dst reflect.Value, depth int) error {
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMap(dst.Type()))
+	}
+	elemVal := reflect.New(dst.Type().Elem()).Elem()
+	if err := d.decodeDirect(p, elemVal, depth); err != nil {
+		return err
+	}
+	keyVal := reflect.New(dst.Type().Key()).Elem()
+	keyVal.SetString(key)
+	dst.SetMapIndex(keyVal, elemVal)
+	return nil
+}
+
+// recordError adds the non-fatal decode error err to *errs so decoding can
+// continue, keeping only the first one unless the decoder reports all errors.
+// Fatal errors are returned so they abort the parse.
+func (d *decoder) recordError(errs *error, err error) error {
+	if err == nil {
+		return nil
+	}
+	if isFatal(err) {
+		return err
+	}
+	if *errs == nil {
+		*errs = err
+	} else if d.allErrors {
+		*errs = append(errorList(*errs), errorList(err)...)
+	}
+	return nil
+}
+
+// decodeErrors is the non-fatal errors from decoding a value, in input order,
+// when the decoder reports all errors. It is flattened by joinErrors before
+// being returned to the caller.
+type decodeErrors []error
+
+func (e decodeErrors) Error() string {
+	return errors.Join(e...).Error()
+}
+
+// errorList returns the errors held by err.
+func errorList(err error) decodeErrors {
+	if list, ok := err.(decodeErrors); ok {
+		return list
+	}
+	return decodeErrors{err}
+}
+
+// joinErrors returns the errors collected in err joined with errors.Join, or
+// err itself if it holds just one.
+func joinErrors(err error) error {
+	if list, ok := err.(decodeErrors); ok {
+		return errors.Join(list...)
+	}
+	return err
+}
+
+// describeToken names the TRON kind of the value starting at tok for error
+// messages, matching describeParsed.
+func describeToken(tok Token) string {
+	switch tok.Type {
+	case TokenTrue, TokenFalse:
+		return "bool"
+	case TokenNull:
+		return "null"
+	case TokenNumber:
+		return "number"
+	case TokenString:
+		return "string"
+	case TokenLBracket:
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,192 @@
+package tron
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type directItem struct {
+	ID   int               `json:"id"`
+	Tags []string          `json:"tags"`
+	Meta map[string]string `json:"meta"`
+}
+
+type directDoc struct {
+	Name  string       `json:"name"`
+	Items []directItem `json:"items"`
+	Pair  [2]int       `json:"pair"`
+	Any   interface{}  `json:"any"`
+}
+
+const directInput = `class I: id,tags,meta
+
+name: "doc"
+items: [I(1,["a","b"],{"k":"v"}), I(2,[],{})]
+pair: [7,8,9]
+any: {"x":[1,true,null]}
+`
+
+func TestDirectDecodeMatchesTreeDecode(t *testing.T) {
+	var direct directDoc
+	if err := Unmarshal([]byte(directInput), &direct); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	tokens, err := tokenize(directInput)
+	if err != nil {
+		t.Fatalf("tokenize: %v", err)
+	}
+	p := newParser(tokens)
+	p.preserveNumbers = true
+	parsed, err := p.parse()
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	var tree directDoc
+	if err := (&decoder{}).decode(parsed, reflect.ValueOf(&tree).Elem()); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if !reflect.DeepEqual(direct, tree) {
+		t.Fatalf("direct and tree decoding differ:\n%#v\n%#v", direct, tree)
+	}
+	if direct.Items[1].Tags == nil || direct.Items[1].Meta == nil {
+		t.Fatalf("empty containers should be allocated: %#v", direct.Items[1])
+	}
+}
+
+func TestTypeErrorReportsFieldPath(t *testing.T) {
+	tests := []struct {
+		input  string
+		field  string
+		strct  string
+		substr string
+	}{
+		{`items: [I(1,["a"],{}), I(2,["b",3],{})]`, "Items[1].Tags[1]", "directItem", "Go struct field directItem.Items[1].Tags[1] of type string"},
+		{`items: [{"meta":{"k":1}}]`, "Items[0].Meta.k", "directItem", ""},
+		{`pair: [1,"x"]`, "Pair[1]", "directDoc", ""},
+	}
+	for _, tt := range tests {
+		// Both the direct path and the tree path (via a parsed value) must
+		// report the same location.
+		var direct directDoc
+		err := Unmarshal([]byte("class I: id,tags,meta\n\n"+tt.input), &direct)
+		var typeErr *UnmarshalTypeError
+		if !errors.As(err, &typeErr) || typeErr.Field != tt.field || typeErr.Struct != tt.strct {
+			t.Errorf("%s: got %v, want field %q of %s", tt.input, err, tt.field, tt.strct)
+			continue
+		}
+		if tt.substr != "" && !strings.Contains(err.Error(), tt.substr) {
+			t.Errorf("%s: error %q does not mention %q", tt.input, err, tt.substr)
+		}
+
+		var parsed interface{}
+		if err := Unmarshal([]byte("class I: id,tags,meta\n\n"+tt.input), &parsed); err != nil {
+			t.Fatal(err)
+		}
+		var tree directDoc
+		err = (&decoder{}).decode(parsed, reflect.ValueOf(&tree).Elem())
+		if !errors.As(err, &typeErr) || typeErr.Field != tt.field {
+			t.Errorf("%s: tree decode got %v, want field %q", tt.input, err, tt.field)
+		}
+	}
+
+	var list [][]int
+	err := Unmarshal([]byte(`[[1],[2,"x"]]`), &list)
+	if err == nil || err.Error() != "tron: cannot unmarshal string into Go value [1][1] of type int" {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestDecodeContinuesAfterTypeError(t *testing.T) {
+	input := "class I: id,tags,meta\n\nname: 1\nitems: [I(\"x\",[\"a\"],{}), I(2,[true],{})]\npair: [3,4]\n"
+
+	var v directDoc
+	err := Unmarshal([]byte(input), &v)
+	var typeErr *UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Field != "Name" {
+		t.Fatalf("got %v, want the first error, at Name", err)
+	}
+	if len(v.Items) != 2 || v.Items[0].Tags[0] != "a" || v.Items[1].ID != 2 || v.Pair != [2]int{3, 4} {
+		t.Fatalf("remaining fields not stored: %+v", v)
+	}
+
+	dec := NewDecoder(strings.NewReader(input))
+	dec.ReportAllErrors()
+	err = dec.Decode(&directDoc{})
+	var fields []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		if !errors.As(e, &typeErr) {
+			t.Fatalf("unexpected error %v", e)
+		}
+		fields = append(fields, typeErr.Field)
+	}
+	if want := []string{"Name", "Items[0].ID", "Items[1].Tags[0]"}; !reflect.DeepEqual(fields, want) {
+		t.Fatalf("got errors at %q, want %q", fields, want)
+	}
+
+	dec = NewDecoder(strings.NewReader(`[1,"x",2]`))
+	dec.ReportAllErrors()
+	var ints []int
+	err = dec.Decode(&ints)
+	if !errors.As(err, &typeErr) || typeErr.Field != "[1]" || !reflect.DeepEqual(ints, []int{1, 0, 2}) {
+		t.Fatalf("got %v, %v", ints, err)
+	}
+}
+
+func TestDirectDecodeReportsFieldOfTypeError(t *testing.T) {
+	var v directDoc
+	err := Unmarshal([]byte(`{"pair":[1,2], "name":5}`), &v)
+	var typeErr *UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("expected *UnmarshalTypeError, got %T: %v", err, err)
+	}
+	if typeErr.Struct != "directDoc" || typeErr.Field != "Name" || typeErr.Value != "number" {
+		t.Fatalf("unexpected error details: %+v", typeErr)
+	}
+}
+
+func TestDirectDecodePrefersLaterSyntaxError(t *testing.T) {
+	var v directDoc
+	err := Unmarshal([]byte(`{"items":[{"id":"x"}], "name": }`), &v)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected *SyntaxError, got %T: %v", err, err)
+	}
+}
+
+func TestDirectDecodeSyntaxErrorLeavesValueUnchanged(t *testing.T) {
+	inputs := []string{
+		`{"name":"new","items":[{"id":2}], "pair": [1,}`,
+		"class I: id,tags,meta\n\nname: \"new\"\nitems: [I(2,[],{}), J(3)]\n",
+		`{"name":"new"} trailing`,
+	}
+	for _, in := range inputs {
+		v := directDoc{Name: "old", Items: []directItem{{ID: 1, Meta: map[string]string{"k": "v"}}}}
+		want := directDoc{Name: "old", Items: []directItem{{ID: 1, Meta: map[string]string{"k": "v"}}}}
+		if err := Unmarshal([]byte(in), &v); err == nil {
+			t.Fatalf("Unmarshal(%q): expected error", in)
+		}
+		if !reflect.DeepEqual(v, want) {
+			t.Errorf("Unmarshal(%q) changed the value to %+v", in, v)
+		}
+	}
+
+	m := map[string]interface{}{"keep": true}
+	if err := Unmarshal([]byte(`{"a":1,"b":[1,2}`), &m); err == nil || len(m) != 1 {
+		t.Errorf("Unmarshal into map = %v, %v; want a syntax error and the map unchanged", m, err)
+	}
+}
+
+func TestDirectDecodeEnforcesDepthLimit(t *testing.T) {
+	withLimits(t, maxInputBytes, maxTokens, 4, maxWalkDepth)
+
+	var v [][][]int
+	err := Unmarshal([]byte(`[[[1]]]`), &v)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected depth error, got %v", err)
+	}
+}
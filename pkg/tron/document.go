@@ -0,0 +1,192 @@
+package tron
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// ErrNoSection reports a Document section that does not exist.
+var ErrNoSection = errors.New("tron: no such section")
+
+// A Document is a TRON document whose root is an object, such as a
+// configuration file or a vAgenda file, held as its named sections: the
+// members of the root object, in order. A section is only decoded when Get
+// reads it, so an application can read or replace one section without
+// decoding the rest of the document into Go values, and Bytes writes the
+// sections it did not replace as they were, comments included.
+//
+// The zero value is an empty document, ready to use.
+type Document struct {
+	header   []byte     // class definitions, as in the source and then as added by Set
+	classes  []ClassDef // classes the header defines
+	sections []section
+}
+
+// section is a member of the root object of a Document.
+type section struct {
+	name  string
+	value []byte // TRON text of the value, against the classes of the header
+}
+
+// ParseDocument parses data, a TRON document whose root is an object written
+// with or without braces, or which is empty. If a section occurs more than
+// once, the last occurrence wins, as in Unmarshal.
+func ParseDocument(data []byte) (*Document, error) {
+	if err := validate(data); err != nil {
+		return nil, err
+	}
+	p, err := newDocumentParser(data, decodeOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if err := p.parseHeader(); err != nil {
+		return nil, err
+	}
+	p.skipNewlines()
+
+	d := &Document{header: bytes.TrimSpace(data[:p.current().Offset])}
+	if len(d.header) > 0 {
+		classes, err := ExtractClasses(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range classes {
+			d.classes = append(d.classes, c.ClassDef)
+		}
+	}
+
+	member := func(key string) error {
+		start := p.current().Offset
+		if err := p.skipValue(2); err != nil {
+			return err
+		}
+		d.put(key, bytes.TrimSpace(data[start:p.current().Offset]))
+		return nil
+	}
+	switch tok := p.current(); {
+	case tok.Type == TokenEOF:
+	case tok.Type == TokenLBrace:
+		err = p.parseObjectWith(1, member)
+	case (tok.Type == TokenIdentifier || tok.Type == TokenString) && p.peek(1).Type == TokenColon:
+		err = p.parseImplicitObjectWith(1, member)
+	default:
+		err = p.syntaxError("document root is not an object")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// put sets the text of section name, appending the section if it is new.
+func (d *Document) put(name string, value []byte) {
+	if i := d.index(name); i >= 0 {
+		d.sections[i].value = value
+		return
+	}
+	d.sections = append(d.sections, section{name: name, value: value})
+}
+
+// index returns the position of section name, or -1.
+func (d *Document) index(name string) int {
+	return slices.IndexFunc(d.sections, func(s section) bool { return s.name == name })
+}
+
+// Names returns the names of the sections, in order.
+func (d *Document) Names() []string {
+	names := make([]string, len(d.sections))
+	for i, s := range d.sections {
+		names[i] = s.name
+	}
+	return names
+}
+
+// Has reports whether the document has section name.
+func (d *Document) Has(name string) bool {
+	return d.index(name) >= 0
+}
+
+// Get decodes section name into the value pointed to by v, as Unmarshal
+// would decode the section's value with the document's classes defined. It
+// returns an error wrapping ErrNoSection if there is no such section.
+func (d *Document) Get(name string, v interface{}) error {
+	i := d.index(name)
+	if i < 0 {
+		return fmt.Errorf("%w: %q", ErrNoSection, name)
+	}
+	src := make([]byte, 0, len(d.header)+2+len(d.sections[i].value))
+	if len(d.header) > 0 {
+		src = append(append(src, d.header...), "\n\n"...)
+	}
+	return Unmarshal(append(src, d.sections[i].value...), v)
+}
+
+// Set replaces section name with the TRON encoding of v, or appends it as
+// the last section if the document has none of that name. Structs whose
+// keys match a class of the document are written as its instances; classes
+// for other recurring shapes are added to the header under new names.
+func (d *Document) Set(name string, v interface{}) error {
+	if v == nil {
+		d.put(name, []byte("null"))
+		return nil
+	}
+	e := newEncoder(encodeOptions{})
+	defer e.release()
+	e.classes = append(e.classes, d.classes...)
+	e.given = len(d.classes)
+	if err := e.serialize(reflect.ValueOf(v), 0); err != nil {
+		return err
+	}
+	e.assignClasses()
+	value := e.render(nil, 0, len(e.buf), 0, len(e.objects))
+
+	for i := e.given; i < len(e.classes); i++ {
+		if len(d.header) > 0 {
+			d.header = append(d.header, '\n')
+		}
+		d.header = appendClassDef(d.header, e.classes[i], e.classes[:i], e.escapes())
+		d.header = d.header[:len(d.header)-1] // the newline
+		d.classes = append(d.classes, e.classes[i])
+	}
+	d.put(name, value)
+	return nil
+}
+
+// Delete removes section name, if present.
+func (d *Document) Delete(name string) {
+	if i := d.index(name); i >= 0 {
+		d.sections = slices.Delete(d.sections, i, i+1)
+	}
+}
+
+// Bytes returns the document in TRON: the header of class definitions, a
+// blank line, and one section per line in the implicit root object form,
+// with keys that are identifiers left unquoted.
+func (d *Document) Bytes() []byte {
+	var out []byte
+	if len(d.header) > 0 {
+		out = append(append(out, d.header...), "\n\n"...)
+	}
+	for _, s := range d.sections {
+		if isBareKey(s.name) {
+			out = append(out, s.name...)
+		} else {
+			out = appendQuoted(out, s.name)
+		}
+		out = append(out, ": "...)
+		out = append(out, s.value...)
+		out = append(out, '\n')
+	}
+	return out
+}
+
+// SectionAs is like d.Get but decodes into a new value of type T and returns
+// it.
+func SectionAs[T any](d *Document, name string) (T, error) {
+	var v T
+	err := d.Get(name, &v)
+	return v, err
+}
@@ -0,0 +1,135 @@
+package tron
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const agendaDoc = `# Sprint plan
+class vAgendaInfo: version
+class TodoList: items
+class TodoItem: title, status
+
+vAgendaInfo: vAgendaInfo("0.2")
+todoList: TodoList([
+  TodoItem("Implement authentication", "pending"), # first
+  TodoItem("Write API documentation", "pending")
+])
+owner: "ana" # lead
+`
+
+type agendaItem struct {
+	Title  string `json:"title"`
+	Status string `json:"status"`
+}
+
+type agendaList struct {
+	Items []agendaItem `json:"items"`
+}
+
+func TestDocumentGet(t *testing.T) {
+	doc, err := ParseDocument([]byte(agendaDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := doc.Names(); !reflect.DeepEqual(got, []string{"vAgendaInfo", "todoList", "owner"}) {
+		t.Fatalf("Names() = %v", got)
+	}
+	list, err := SectionAs[agendaList](doc, "todoList")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 2 || list.Items[1].Title != "Write API documentation" {
+		t.Errorf("todoList = %+v", list)
+	}
+	var owner string
+	if err := doc.Get("owner", &owner); err != nil || owner != "ana" {
+		t.Errorf("owner = %q, %v", owner, err)
+	}
+	if err := doc.Get("missing", &owner); !errors.Is(err, ErrNoSection) {
+		t.Errorf("missing section: got %v, want ErrNoSection", err)
+	}
+	if doc.Has("missing") || !doc.Has("owner") {
+		t.Error("Has reports the wrong sections")
+	}
+}
+
+func TestDocumentSet(t *testing.T) {
+	doc, err := ParseDocument([]byte(agendaDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type milestone struct {
+		Name string `json:"name"`
+		Week int    `json:"week"`
+	}
+	if err := doc.Set("vAgendaInfo", struct {
+		Version string `json:"version"`
+	}{"0.3"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Set("milestones", []milestone{{"alpha", 2}, {"beta", 5}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Set("done", []agendaItem{{"Kickoff", "done"}}); err != nil {
+		t.Fatal(err)
+	}
+	doc.Delete("owner")
+
+	want := `# Sprint plan
+class vAgendaInfo: version
+class TodoList: items
+class TodoItem: title, status
+class D: name,week
+
+vAgendaInfo: vAgendaInfo("0.3")
+todoList: TodoList([
+  TodoItem("Implement authentication", "pending"), # first
+  TodoItem("Write API documentation", "pending")
+])
+milestones: [D("alpha",2),D("beta",5)]
+done: [TodoItem("Kickoff","done")]
+`
+	if got := string(doc.Bytes()); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The result is a document that decodes as a whole.
+	var all struct {
+		Info       struct{ Version string } `json:"vAgendaInfo"`
+		TodoList   agendaList               `json:"todoList"`
+		Milestones []milestone              `json:"milestones"`
+	}
+	if err := Unmarshal(doc.Bytes(), &all); err != nil {
+		t.Fatal(err)
+	}
+	if all.Info.Version != "0.3" || len(all.TodoList.Items) != 2 || all.Milestones[1].Week != 5 {
+		t.Errorf("round trip: got %+v", all)
+	}
+}
+
+func TestDocumentForms(t *testing.T) {
+	// A braced root, and an empty document.
+	doc, err := ParseDocument([]byte(`{"a b": [1, 2], "c": {"d": null}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(doc.Bytes()), "\"a b\": [1, 2]\nc: {\"d\": null}\n"; got != want {
+		t.Errorf("braced root: got %q, want %q", got, want)
+	}
+
+	var empty Document
+	if err := empty.Set("x", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(empty.Bytes()); got != "x: null\n" {
+		t.Errorf("zero Document: got %q", got)
+	}
+
+	for _, src := range []string{"[1,2]", "a: ["} {
+		if _, err := ParseDocument([]byte(src)); err == nil {
+			t.Errorf("ParseDocument(%q): expected error", src)
+		}
+	}
+}
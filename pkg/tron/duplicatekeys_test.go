@@ -0,0 +1,102 @@
+package tron
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type dupKeyDoc struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func decodeWithPolicy(input string, policy DuplicateKeyPolicy, v interface{}) error {
+	dec := NewDecoder(strings.NewReader(input))
+	dec.SetDuplicateKeys(policy)
+	return dec.Decode(v)
+}
+
+func TestDuplicateKeysKeepFirstAndLast(t *testing.T) {
+	inputs := []string{
+		`{"a":1,"b":2,"a":3}`,
+		"a: 1\nb: 2\na: 3\n",
+		"class X: a,b,a\n\nX(1,2,3)",
+	}
+	for _, input := range inputs {
+		for policy, want := range map[DuplicateKeyPolicy]int{DuplicateKeysKeepLast: 3, DuplicateKeysKeepFirst: 1} {
+			var doc dupKeyDoc
+			if err := decodeWithPolicy(input, policy, &doc); err != nil {
+				t.Fatalf("%q (policy %d): %v", input, policy, err)
+			}
+			if doc != (dupKeyDoc{A: want, B: 2}) {
+				t.Errorf("%q (policy %d): got %+v, want a=%d", input, policy, doc, want)
+			}
+
+			var tree interface{}
+			if err := decodeWithPolicy(input, policy, &tree); err != nil {
+				t.Fatalf("%q (policy %d): %v", input, policy, err)
+			}
+			if got := tree.(map[string]interface{})["a"]; got != float64(want) {
+				t.Errorf("%q (policy %d): tree decode got a=%v, want %d", input, policy, got, want)
+			}
+		}
+	}
+
+	// Repeats in different objects are not duplicates.
+	var list []map[string]int
+	if err := decodeWithPolicy(`[{"a":1},{"a":2}]`, DuplicateKeysError, &list); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(list, []map[string]int{{"a": 1}, {"a": 2}}) {
+		t.Fatalf("got %v", list)
+	}
+}
+
+func TestDuplicateKeysError(t *testing.T) {
+	tests := []struct {
+		input  string
+		line   int
+		column int
+	}{
+		{`{"a":1,"b":{"c":2,"c":3}}`, 1, 19},
+		{"a: 1\nb: 2\na: 3\n", 3, 1},
+		{"class X: a,b,a\n\nX(1,2,3)", 1, 14},
+	}
+	for _, tt := range tests {
+		var v interface{}
+		err := decodeWithPolicy(tt.input, DuplicateKeysError, &v)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) || !strings.Contains(err.Error(), "duplicate key") {
+			t.Errorf("%q: got %v, want duplicate key error", tt.input, err)
+			continue
+		}
+		if syntaxErr.Line != tt.line || syntaxErr.Column != tt.column {
+			t.Errorf("%q: error at %d:%d, want %d:%d", tt.input, syntaxErr.Line, syntaxErr.Column, tt.line, tt.column)
+		}
+	}
+}
+
+func TestClassRedefinition(t *testing.T) {
+	input := "class A: a\nclass A: b\n\nA(1)"
+	var v interface{}
+	err := Unmarshal([]byte(input), &v)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Line != 2 || syntaxErr.Column != 7 {
+		t.Fatalf("got %v, want redefinition error at 2:7", err)
+	}
+
+	if err := Unmarshal([]byte("class A: a\nclass A: a\n\nA(1)"), &v); err != nil {
+		t.Fatalf("identical redefinition: %v", err)
+	}
+
+	dec := NewDecoder(strings.NewReader(input))
+	dec.AllowClassShadowing()
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("shadowing: %v", err)
+	}
+	if !reflect.DeepEqual(v, map[string]interface{}{"b": float64(1)}) {
+		t.Fatalf("got %v, want the later definition", v)
+	}
+}
@@ -0,0 +1,28 @@
+package tron
+
+import "testing"
+
+// TestEncodeAllocationsPerStruct checks that serializing a struct allocates
+// little beyond its key list: schema keys and map keys are written into
+// scratch space and the output rather than built as strings.
+func TestEncodeAllocationsPerStruct(t *testing.T) {
+	type point struct {
+		X, Y int
+		Tags map[string]int
+	}
+	small := make([]point, 100)
+	large := make([]point, 200)
+	for _, s := range [][]point{small, large} {
+		for i := range s {
+			s[i] = point{X: i, Tags: map[string]int{"a": i}}
+		}
+	}
+	if _, err := Marshal(large); err != nil { // warm the pools and caches
+		t.Fatalf("Marshal: %v", err)
+	}
+	before := testing.AllocsPerRun(10, func() { Marshal(small) })
+	after := testing.AllocsPerRun(10, func() { Marshal(large) })
+	if perElement := (after - before) / 100; perElement > 5 {
+		t.Fatalf("%.1f allocations per element, want at most 5", perElement)
+	}
+}
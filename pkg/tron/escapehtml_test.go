@@ -0,0 +1,61 @@
+package tron
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncoderSetEscapeHTML(t *testing.T) {
+	type tag struct {
+		Text string `json:"<b>&"`
+		Link string `json:"link"`
+	}
+	v := []any{
+		[]tag{{"<i>x</i>", "a&b"}, {"y", "c>d"}},
+		map[string]string{"</script>": "&amp;"},
+	}
+
+	for _, tc := range []struct {
+		on   bool
+		want string
+	}{
+		{true, "class A: \"\\u003cb\\u003e\\u0026\",link\n\n" +
+			`[[A("\u003ci\u003ex\u003c/i\u003e","a\u0026b"),A("y","c\u003ed")],{"\u003c/script\u003e":"\u0026amp;"}]` + "\n"},
+		{false, "class A: \"<b>&\",link\n\n" +
+			`[[A("<i>x</i>","a&b"),A("y","c>d")],{"</script>":"&amp;"}]` + "\n"},
+	} {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetEscapeHTML(tc.on)
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tc.want {
+			t.Errorf("SetEscapeHTML(%v):\ngot  %s\nwant %s", tc.on, buf.String(), tc.want)
+		}
+
+		var back []any
+		if err := Unmarshal(buf.Bytes(), &back); err != nil {
+			t.Fatal(err)
+		}
+		if got := back[1].(map[string]any)["</script>"]; got != "&amp;" {
+			t.Errorf("SetEscapeHTML(%v): round trip got %v", tc.on, got)
+		}
+	}
+}
+
+func TestEscapeHTMLDefaults(t *testing.T) {
+	type op struct {
+		Name string `json:"name"`
+		Cmp  string `json:"cmp" tron:"cmp,default=<="`
+	}
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode([]op{{"a", "<="}, {"b", ">"}}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "class A: name,cmp=\"<=\"\n\n[A(\"a\"),A(\"b\",\">\")]\n"; buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,63 @@
+package tron
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtendedNumbers(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"0xFF", "255"},
+		{"-0x10", "-16"},
+		{"0b1010", "10"},
+		{"0o17", "15"},
+		{"0xFFFF_FFFF_FFFF_FFFF_FF", "4722366482869645213695"},
+		{"1_000_000", "1000000"},
+		{"-1_000.000_5e1_0", "-1000.0005e10"},
+		{"42", "42"},
+	}
+	for _, tt := range tests {
+		tokens, err := tokenizeWith([]byte(tt.input), decodeOptions{extendedNumbers: true})
+		if err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+		if tokens[0].Type != TokenNumber || tokens[0].Value != tt.want {
+			t.Errorf("%s: got %s %q, want %s", tt.input, tokens[0].Type, tokens[0].Value, tt.want)
+		}
+
+		if tt.input != tt.want {
+			var v interface{}
+			if err := Unmarshal([]byte(tt.input), &v); err == nil {
+				t.Errorf("%s: accepted without AllowExtendedNumbers", tt.input)
+			}
+		}
+	}
+
+	if _, err := tokenizeWith([]byte("0b"+strings.Repeat("1", 256)), decodeOptions{extendedNumbers: true}); err != nil {
+		t.Errorf("256 binary digits: %v", err)
+	}
+
+	for _, input := range []string{"0x", "0xG", "1__0", "_1", "1_", "1_.5", "0b102", "0_1", "0x" + strings.Repeat("F", 257)} {
+		dec := NewDecoder(strings.NewReader(input))
+		dec.AllowExtendedNumbers()
+		var v interface{}
+		if err := dec.Decode(&v); err == nil {
+			t.Errorf("%s: got %v, want error", input, v)
+		}
+	}
+
+	// Marshal writes the decimal form.
+	var v struct{ Mask uint8 }
+	dec := NewDecoder(strings.NewReader("Mask: 0b1111_0000"))
+	dec.AllowExtendedNumbers()
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := Marshal(v); string(out) != `{"Mask":240}` {
+		t.Fatalf("got %s", out)
+	}
+}
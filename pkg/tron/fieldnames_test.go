@@ -0,0 +1,55 @@
+package tron
+
+import (
+	"strings"
+	"testing"
+)
+
+type caseFields struct {
+	Lower int `json:"id"`
+	Upper int `json:"ID"`
+	Name  string
+}
+
+func TestExactFieldMatchWins(t *testing.T) {
+	input := `{"id":1,"ID":2,"name":"x"}`
+	want := caseFields{Lower: 1, Upper: 2, Name: "x"}
+
+	var direct caseFields
+	if err := Unmarshal([]byte(input), &direct); err != nil {
+		t.Fatal(err)
+	}
+	if direct != want {
+		t.Errorf("direct: got %+v, want %+v", direct, want)
+	}
+
+	// The lenient path decodes through the parsed tree.
+	var tree caseFields
+	if _, err := UnmarshalLenient([]byte(input), &tree); err != nil {
+		t.Fatal(err)
+	}
+	if tree != want {
+		t.Errorf("tree: got %+v, want %+v", tree, want)
+	}
+
+	// Without an exact match, the first field that matches wins.
+	var folded caseFields
+	if err := Unmarshal([]byte(`{"Id":3}`), &folded); err != nil {
+		t.Fatal(err)
+	}
+	if folded != (caseFields{Lower: 3}) {
+		t.Errorf("folded: got %+v, want Lower=3", folded)
+	}
+}
+
+func TestUseExactFieldNames(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"Id":3,"id":1,"NAME":"x","Name":"y"}`))
+	dec.UseExactFieldNames()
+	var v caseFields
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v != (caseFields{Lower: 1, Name: "y"}) {
+		t.Fatalf("got %+v, want Lower=1 Name=y", v)
+	}
+}
@@ -0,0 +1,107 @@
+package tron
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type orderedPoint struct {
+	Z     int               `json:"z"`
+	Y     int               `json:"y,omitempty"`
+	X     int               `json:"x"`
+	Extra map[string]string `tron:",inline"`
+}
+
+func encodeWith(t *testing.T, v interface{}, set func(*Encoder)) string {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	set(enc)
+	if err := enc.Encode(v); err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func TestEncoderSetFieldOrder(t *testing.T) {
+	points := []orderedPoint{{Z: 1, Y: 2, X: 3}, {Z: 4, Y: 5, X: 6}, {Z: 7, Y: 8, X: 9, Extra: map[string]string{"w": "a"}}}
+
+	for _, tc := range []struct {
+		name string
+		set  func(*Encoder)
+		want string
+	}{
+		{"default", func(*Encoder) {}, "class A: z,y,x\n\n[A(1,2,3),A(4,5,6),{\"z\":7,\"y\":8,\"x\":9,\"w\":\"a\"}]"},
+		{"declaration", func(enc *Encoder) { enc.SetFieldOrder(FieldOrderDeclaration) }, "class A: z,y,x\n\n[A(1,2,3),A(4,5,6),{\"z\":7,\"y\":8,\"x\":9,\"w\":\"a\"}]"},
+		{"alphabetical", func(enc *Encoder) { enc.SetFieldOrder(FieldOrderAlphabetical) }, "class A: x,y,z\n\n[A(3,2,1),A(6,5,4),{\"w\":\"a\",\"x\":9,\"y\":8,\"z\":7}]"},
+		{"comparator", func(enc *Encoder) {
+			enc.SetFieldComparator(func(a, b string) int {
+				// x first, then the rest in declaration order.
+				switch {
+				case a == b:
+					return 0
+				case a == "x":
+					return -1
+				case b == "x":
+					return 1
+				}
+				return 0
+			})
+		}, "class A: x,z,y\n\n[A(3,1,2),A(6,4,5),{\"x\":9,\"z\":7,\"y\":8,\"w\":\"a\"}]"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := encodeWith(t, points, tc.set); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+
+	// A nil comparator restores declaration order.
+	got := encodeWith(t, points[0], func(enc *Encoder) {
+		enc.SetFieldOrder(FieldOrderAlphabetical)
+		enc.SetFieldComparator(nil)
+	})
+	if want := `{"z":1,"y":2,"x":3}`; got != want {
+		t.Errorf("nil comparator: got %s, want %s", got, want)
+	}
+}
+
+func TestFieldOrderOptionalProperties(t *testing.T) {
+	type point struct {
+		Z int `json:"z"`
+		Y int `json:"y,omitempty"`
+		X int `json:"x"`
+	}
+	points := []point{{Z: 1, Y: 2, X: 3}, {Z: 4, X: 6}}
+	got := encodeWith(t, points, func(enc *Encoder) {
+		enc.SetFieldOrder(FieldOrderAlphabetical)
+		enc.SetOptionalProperties(true)
+	})
+	if want := "class A: x,y?,z\n\n[A(3,2,1),A(6,null,4)]"; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	var back []point
+	if err := Unmarshal([]byte(got), &back); err != nil {
+		t.Fatal(err)
+	}
+	if back[1].X != 6 || back[1].Z != 4 || back[1].Y != 0 {
+		t.Errorf("round trip: got %+v", back)
+	}
+}
+
+func TestFieldOrderEncodeSeq(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetFieldOrder(FieldOrderAlphabetical)
+	seq := func(yield func(orderedPoint) bool) {
+		_ = yield(orderedPoint{Z: 1, Y: 2, X: 3}) && yield(orderedPoint{Z: 4, Y: 5, X: 6})
+	}
+	if err := EncodeSeq(enc, seq); err != nil {
+		t.Fatal(err)
+	}
+	if want := "class A: x,y,z\n\n[A(3,2,1),A(6,5,4)]\n"; buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
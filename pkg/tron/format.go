@@ -0,0 +1,317 @@
+package tron
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Compact appends to dst the TRON-encoded src with insignificant space and
+// comments elided. Class definitions stay on lines of their own, followed by
+// a blank line, and the members of an implicit root object stay one to a
+// line; everything else is written on a single line. Strings, numbers and
+// names are copied as they appear in src.
+//
+// If src is not a valid TRON document, Compact returns the error and leaves
+// dst unchanged.
+func Compact(dst *bytes.Buffer, src []byte) error {
+	if err := validate(src); err != nil {
+		return err
+	}
+	dst.Write(appendFormatted(nil, src, "", "", false))
+	return nil
+}
+
+// Indent appends to dst an indented form of the TRON-encoded src, in the
+// layout Compact uses but with each element of an array or object, and each
+// member of an implicit root object, on a new line. Each new line begins with
+// prefix followed by one or more copies of indent according to the nesting.
+// The arguments of a class instantiation stay together on one line, so that
+// an array of instances reads as a table. Comments are not preserved, and no
+// newline is added after the data.
+//
+// If src is not a valid TRON document, Indent returns the error and leaves
+// dst unchanged.
+func Indent(dst *bytes.Buffer, src []byte, prefix, indent string) error {
+	if err := validate(src); err != nil {
+		return err
+	}
+	dst.Write(appendFormatted(nil, src, prefix, indent, true))
+	return nil
+}
+
+// validate reports whether src is a valid TRON document under the default
+// limits.
+func validate(src []byte) error {
+	var v interface{}
+	return unmarshal(src, &v, decodeOptions{})
+}
+
+// fmtToken is a token of a valid document together with its source text.
+type fmtToken struct {
+	kind byte // the punctuation character, '"' for strings, 'w' for other words, '\n' for newlines
+	text []byte
+}
+
+// lexFormat splits the valid document src into fmtTokens, dropping spaces
+// and comments.
+func lexFormat(src []byte) []fmtToken {
+	var toks []fmtToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			j := i + 1
+			for j < len(src) && src[j] != '"' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j++
+			toks = append(toks, fmtToken{kind: '"', text: src[i:j]})
+			i = j
+		case strings.IndexByte("\n[]{}(),:", c) >= 0:
+			toks = append(toks, fmtToken{kind: c, text: src[i : i+1]})
+			i++
+		default:
+			j := i
+			for j < len(src) && !isSpace(src[j]) && strings.IndexByte("#\"[]{}(),:", src[j]) < 0 {
+				j++
+			}
+			toks = append(toks, fmtToken{kind: 'w', text: src[i:j]})
+			i = j
+		}
+	}
+	return toks
+}
+
+// formatter writes a document from its fmtTokens in canonical layout.
+type formatter struct {
+	toks   []fmtToken
+	pos    int
+	out    []byte
+	prefix string
+	indent string
+	pretty bool
+	inline int // depth of class instantiations, whose arguments stay on one line
+}
+
+// appendFormatted appends the valid document src to dst in the layout of
+// Indent if pretty is set and of Compact otherwise.
+func appendFormatted(dst, src []byte, prefix, indent string, pretty bool) []byte {
+	f := &formatter{toks: lexFormat(src), out: dst, prefix: prefix, indent: indent, pretty: pretty}
+
+	// Header: one class definition per line, then a blank line.
+	classes := false
+	for f.skipNewlines(); f.pos < len(f.toks) && string(f.toks[f.pos].text) == "class"; f.skipNewlines() {
+		if classes {
+			f.newline(0)
+		}
+		f.classDef()
+		classes = true
+	}
+	if classes {
+		f.newline(0)
+		f.newline(0)
+	}
+
+	if f.pos+1 < len(f.toks) && (f.toks[f.pos].kind == 'w' || f.toks[f.pos].kind == '"') && f.toks[f.pos+1].kind == ':' {
+		f.implicitObject()
+	} else if f.pos < len(f.toks) {
+		f.value(0)
+	}
+	return f.out
+}
+
+// appendImplicitRoot appends the compact root value src to dst, writing an
+// object with members as an implicit root object: one key: value member per
+// line, with keys that are identifiers left unquoted.
+func appendImplicitRoot(dst, src []byte) []byte {
+	f := &formatter{toks: lexFormat(src), out: dst}
+	if f.peek() != '{' || f.toks[f.pos+1].kind == '}' {
+		return append(dst, src...)
+	}
+	f.pos++
+	for first := true; f.peek() != '}'; first = false {
+		if !first {
+			f.out = append(f.out, '\n')
+		}
+		key := f.next().text
+		if name := key[1 : len(key)-1]; isBareKey(string(name)) {
+			key = name
+		}
+		f.out = append(f.out, key...)
+		f.next() // ':'
+		f.out = append(f.out, ": "...)
+		f.value(0)
+		if f.peek() == ',' {
+			f.pos++
+		}
+	}
+	return f.out
+}
+
+// isBareKey reports whether the key s can be written without quotes: it is
+// an identifier and not a keyword.
+func isBareKey(s string) bool {
+	if tok, _ := identifierToken([]byte(s)); tok != TokenIdentifier {
+		return false
+	}
+	return isValidIdentifier(s)
+}
+
+// skipNewlines advances past newline tokens.
+func (f *formatter) skipNewlines() {
+	for f.pos < len(f.toks) && f.toks[f.pos].kind == '\n' {
+		f.pos++
+	}
+}
+
+// next returns the next token that is not a newline and advances past it.
+func (f *formatter) next() fmtToken {
+	f.skipNewlines()
+	tok := f.toks[f.pos]
+	f.pos++
+	return tok
+}
+
+// peek returns the kind of the next token that is not a newline.
+func (f *formatter) peek() byte {
+	f.skipNewlines()
+	if f.pos == len(f.toks) {
+		return 0
+	}
+	return f.toks[f.pos].kind
+}
+
+// newline starts a new line indented to depth.
+func (f *formatter) newline(depth int) {
+	f.out = append(f.out, '\n')
+	f.out = append(f.out, f.prefix...)
+	for range depth {
+		f.out = append(f.out, f.indent...)
+	}
+}
+
+// breakLine starts a new line indented to depth if the output is indented
+// and not inside a class instantiation.
+func (f *formatter) breakLine(depth int) {
+	if f.pretty && f.inline == 0 {
+		f.newline(depth)
+	}
+}
+
+// classDef writes the class definition at the current position.
+func (f *formatter) classDef() {
+	f.out = append(f.out, "class"...)
+	for f.pos++; f.toks[f.pos].kind != ':'; f.pos++ {
+		// The name, and "extends" and the parent's name if given.
+		f.out = append(f.out, ' ')
+		f.out = append(f.out, f.toks[f.pos].text...)
+	}
+	f.out = append(f.out, ": "...)
+	f.pos++
+
+	// Properties are separated by commas; the words of one property, such
+	// as its name and type in a typed class, by spaces.
+	word := false
+	for f.pos < len(f.toks) && f.toks[f.pos].kind != '\n' {
+		tok := f.toks[f.pos]
+		switch {
+		case tok.kind == ',':
+			f.out = append(f.out, ',')
+			word = false
+		case word:
+			f.out = append(f.out, ' ')
+			fallthrough
+		default:
+			f.out = append(f.out, tok.text...)
+			word = true
+		}
+		f.pos++
+	}
+}
+
+// implicitObject writes the members of an implicit root object, one per line.
+func (f *formatter) implicitObject() {
+	for first := true; f.peek() != 0; first = false {
+		if !first {
+			f.newline(0)
+		}
+		f.member(0)
+		if f.peek() == ',' {
+			f.pos++
+		}
+	}
+}
+
+// member writes a key, a colon and a value.
+func (f *formatter) member(depth int) {
+	f.out = append(f.out, f.next().text...)
+	f.next() // ':'
+	f.out = append(f.out, ':')
+	if f.pretty && f.inline == 0 {
+		f.out = append(f.out, ' ')
+	}
+	f.value(depth)
+}
+
+// value writes the value at the current position, at the given depth.
+func (f *formatter) value(depth int) {
+	tok := f.next()
+	switch tok.kind {
+	case '[':
+		f.out = append(f.out, '[')
+		f.elements(']', depth, func() { f.value(depth + 1) })
+	case '{':
+		f.out = append(f.out, '{')
+		f.elements('}', depth, func() { f.member(depth + 1) })
+	case 'w':
+		f.out = append(f.out, tok.text...)
+		if f.peek() == '(' {
+			f.pos++
+			f.out = append(f.out, '(')
+			f.inline++
+			f.elements(')', depth, func() { f.argument(depth + 1) })
+			f.inline--
+		}
+	default:
+		f.out = append(f.out, tok.text...)
+	}
+}
+
+// argument writes a class argument, which may be named.
+func (f *formatter) argument(depth int) {
+	f.skipNewlines()
+	if f.pos+1 < len(f.toks) && f.toks[f.pos+1].kind == ':' {
+		f.member(depth)
+	} else {
+		f.value(depth)
+	}
+}
+
+// elements writes the comma-separated elements of a container whose opening
+// bracket has been written, up to and including the closing one.
+func (f *formatter) elements(closing byte, depth int, element func()) {
+	if f.peek() == closing {
+		f.pos++
+		f.out = append(f.out, closing)
+		return
+	}
+	for {
+		f.breakLine(depth + 1)
+		element()
+		if f.next().kind != ',' {
+			break
+		}
+		f.out = append(f.out, ',')
+	}
+	f.breakLine(depth)
+	f.out = append(f.out, closing)
+}
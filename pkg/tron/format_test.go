@@ -0,0 +1,83 @@
+package tron
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompactAndIndent(t *testing.T) {
+	src := []byte(`# header
+class A:  x , "y z"
+
+{ "rows" : [ A(1, [ 2 ,3 ]) , A( -1.5e3, "\u0041<") ], "empty": {}, "n" : null }
+`)
+	var buf bytes.Buffer
+	if err := Compact(&buf, src); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	want := "class A: x,\"y z\"\n\n{\"rows\":[A(1,[2,3]),A(-1.5e3,\"\\u0041<\")],\"empty\":{},\"n\":null}"
+	if buf.String() != want {
+		t.Fatalf("Compact = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := Indent(&buf, src, "", "\t"); err != nil {
+		t.Fatalf("Indent: %v", err)
+	}
+	want = "class A: x,\"y z\"\n\n{\n\t\"rows\": [\n\t\tA(1,[2,3]),\n\t\tA(-1.5e3,\"\\u0041<\")\n\t],\n\t\"empty\": {},\n\t\"n\": null\n}"
+	if buf.String() != want {
+		t.Fatalf("Indent = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestIndentImplicitRootObject(t *testing.T) {
+	src := []byte("name: \"app\", port: 80\nlimits: {\"a\": [1]}\n")
+	var buf bytes.Buffer
+	if err := Indent(&buf, src, "> ", "  "); err != nil {
+		t.Fatalf("Indent: %v", err)
+	}
+	want := "name: \"app\"\n> port: 80\n> limits: {\n>   \"a\": [\n>     1\n>   ]\n> }"
+	if buf.String() != want {
+		t.Fatalf("Indent = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestCompactRejectsInvalid(t *testing.T) {
+	buf := bytes.NewBufferString("keep")
+	if err := Compact(buf, []byte("[1,")); err == nil {
+		t.Fatal("expected error")
+	}
+	if buf.String() != "keep" {
+		t.Fatalf("dst modified on error: %q", buf.String())
+	}
+}
+
+func TestMarshalIndentRoundTrips(t *testing.T) {
+	type point struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	}
+	v := map[string][]point{"pts": {{1, 2}, {3, 4}}}
+	got, err := MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("MarshalIndent: %v", err)
+	}
+	want := "class A: x,y\n\n{\n  \"pts\": [\n    A(1,2),\n    A(3,4)\n  ]\n}"
+	if string(got) != want {
+		t.Fatalf("MarshalIndent = %q, want %q", got, want)
+	}
+
+	compact, _ := Marshal(v)
+	var buf bytes.Buffer
+	if err := Compact(&buf, got); err != nil || buf.String() != string(compact) {
+		t.Fatalf("Compact(MarshalIndent) = %q, %v; want %q", buf.String(), err, compact)
+	}
+
+	got, err = MarshalIndent(v, "> ", "\t")
+	if want := "class A: x,y\n> \n> {\n> \t\"pts\": [\n> \t\tA(1,2),\n> \t\tA(3,4)\n> \t]\n> }"; err != nil || string(got) != want {
+		t.Fatalf("MarshalIndent with prefix = %q, %v; want %q", got, err, want)
+	}
+	if got, err := MarshalIndent(v, "", ""); err != nil || string(got) != string(compact) {
+		t.Fatalf("MarshalIndent without indent = %q, %v; want %q", got, err, compact)
+	}
+}
@@ -0,0 +1,113 @@
+package tron
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// frameDelimiter is the line that ends each message under FramingDelimiter.
+const frameDelimiter = "---"
+
+// frameReader splits a stream into the messages written by an Encoder with
+// framing.
+type frameReader struct {
+	r       *bufio.Reader
+	framing Framing
+}
+
+// next returns the next message, or io.EOF at the end of the stream. A
+// message cut short by the end of the stream is an io.ErrUnexpectedEOF
+// under FramingLength; under FramingDelimiter the final delimiter is
+// optional.
+func (fr *frameReader) next(limit int) ([]byte, error) {
+	if fr.framing == FramingLength {
+		return fr.nextPrefixed(limit)
+	}
+	return fr.nextDelimited(limit)
+}
+
+func (fr *frameReader) nextPrefixed(limit int) ([]byte, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(fr.r, prefix[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(prefix[:])
+	if uint64(n) > uint64(limit) {
+		return nil, &SyntaxError{msg: "message too large", err: ErrTooLarge}
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(fr.r, msg); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (fr *frameReader) nextDelimited(limit int) ([]byte, error) {
+	var msg []byte
+	start := 0 // offset of the current line in msg
+	for {
+		chunk, err := fr.r.ReadSlice('\n')
+		msg = append(msg, chunk...)
+		if len(msg) > limit {
+			return nil, &SyntaxError{msg: "message too large", err: ErrTooLarge}
+		}
+		switch err {
+		case bufio.ErrBufferFull:
+			continue
+		case nil, io.EOF:
+		default:
+			return nil, err
+		}
+		if string(bytes.TrimSpace(msg[start:])) == frameDelimiter {
+			msg = msg[:start]
+			if len(bytes.TrimSpace(msg)) > 0 {
+				return msg, nil
+			}
+			msg = msg[:0] // skip empty messages
+		}
+		if err == io.EOF {
+			if len(bytes.TrimSpace(msg)) == 0 {
+				return nil, io.EOF
+			}
+			return msg, nil
+		}
+		start = len(msg)
+	}
+}
+
+// encodeFramed writes the encoding of v to the stream as one message framed
+// as enc.framing requires.
+func (enc *Encoder) encodeFramed(v interface{}) error {
+	e := newEncoder(enc.opts)
+	defer e.release()
+	out := e.out[:0]
+	if enc.framing == FramingLength {
+		out = append(out, 0, 0, 0, 0)
+	}
+	var err error
+	if v == nil {
+		out = append(out, "null"...)
+	} else if out, err = e.marshal(out, v); err != nil {
+		return err
+	}
+	switch enc.framing {
+	case FramingLength:
+		n := len(out) - 4
+		if uint64(n) > math.MaxUint32 {
+			return fmt.Errorf("tron: message of %d bytes too large for its length prefix", n)
+		}
+		binary.BigEndian.PutUint32(out, uint32(n))
+	case FramingDelimiter:
+		out = append(out, "\n"+frameDelimiter+"\n"...)
+	}
+	e.out = out
+	_, err = enc.w.Write(out)
+	return err
+}
@@ -0,0 +1,124 @@
+package tron
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type frameMsg struct {
+	ID   int      `json:"id"`
+	Tags []string `json:"tags"`
+}
+
+func TestFramingRoundTrip(t *testing.T) {
+	msgs := []any{
+		[]frameMsg{{1, []string{"a"}}, {2, nil}},
+		"---",
+		nil,
+		map[string]any{"multi": "line\n---\n"},
+	}
+	for _, f := range []Framing{FramingLength, FramingDelimiter} {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetFraming(f)
+		for _, m := range msgs {
+			if err := enc.Encode(m); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		dec := NewDecoder(bytes.NewReader(buf.Bytes()))
+		dec.SetFraming(f)
+		var got []any
+		for {
+			var v any
+			err := dec.Decode(&v)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("framing %d: %v", f, err)
+			}
+			got = append(got, v)
+		}
+		want := []any{
+			[]any{map[string]any{"id": float64(1), "tags": []any{"a"}}, map[string]any{"id": float64(2), "tags": nil}},
+			"---",
+			nil,
+			map[string]any{"multi": "line\n---\n"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("framing %d: got %v, want %v", f, got, want)
+		}
+		if dec.InputOffset() != int64(buf.Len()) {
+			t.Errorf("framing %d: InputOffset() = %d, want %d", f, dec.InputOffset(), buf.Len())
+		}
+	}
+}
+
+func TestFramingFormat(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetFraming(FramingDelimiter)
+	if err := enc.Encode([]frameMsg{{1, nil}, {2, nil}}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "class A: id,tags\n\n[A(1,null),A(2,null)]\n---\n"; buf.String() != want {
+		t.Errorf("delimited:\ngot  %q\nwant %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	enc = NewEncoder(&buf)
+	enc.SetFraming(FramingLength)
+	if err := enc.Encode(true); err != nil {
+		t.Fatal(err)
+	}
+	if want := "\x00\x00\x00\x04true"; buf.String() != want {
+		t.Errorf("length-prefixed: got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestFramingDecodeErrors(t *testing.T) {
+	// Hand-written delimited streams may omit the last delimiter and leave
+	// empty messages.
+	dec := NewDecoder(strings.NewReader("---\n1\n---\r\n  ---\n\n2"))
+	dec.SetFraming(FramingDelimiter)
+	var got []int
+	for {
+		var n int
+		if err := dec.Decode(&n); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, n)
+	}
+	if !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("got %v, want [1 2]", got)
+	}
+
+	var v any
+	dec = NewDecoder(strings.NewReader("\x00\x00\x00\x05tru"))
+	dec.SetFraming(FramingLength)
+	if err := dec.Decode(&v); err != io.ErrUnexpectedEOF {
+		t.Errorf("truncated message: got %v, want io.ErrUnexpectedEOF", err)
+	}
+
+	dec = NewDecoder(strings.NewReader("\x00\x10\x00\x00"))
+	dec.SetFraming(FramingLength)
+	dec.SetLimits(Limits{MaxInputBytes: 1024})
+	if err := dec.Decode(&v); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("oversized message: got %v, want ErrTooLarge", err)
+	}
+
+	dec = NewDecoder(strings.NewReader(strings.Repeat("[1,", 400) + "\n---\n"))
+	dec.SetFraming(FramingDelimiter)
+	dec.SetLimits(Limits{MaxInputBytes: 1024})
+	if err := dec.Decode(&v); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("oversized delimited message: got %v, want ErrTooLarge", err)
+	}
+}
@@ -412,3 +412,90 @@ func FuzzStringEscaping(f *testing.F) {
 		_ = err
 	})
 }
+
+// FuzzRepair tests that Repair never panics, and that whatever it accepts is
+// valid TRON and needs no further repair.
+func FuzzRepair(f *testing.F) {
+	seeds := []string{
+		`[1, 2,]`,
+		`{"a": [1, {"b": "x`,
+		"{“name”: “Ada”}",
+		"class P: a,b\n\nx: P(foo bar, 1)\ny: baz # c",
+		`{"a" 1}]]`,
+		`"trailing\`,
+	}
+
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		out, _, err := Repair([]byte(input))
+		if err != nil {
+			return
+		}
+		again, fixes, err := Repair(out)
+		if err != nil || string(again) != string(out) || len(fixes) != 0 {
+			t.Fatalf("repaired %q to %q, which repairs to %q with %v, %v", input, out, again, fixes, err)
+		}
+	})
+}
+
+// compareRecord is a struct exercising the tag options shared with
+// encoding/json, for FuzzCompareJSON.
+type compareRecord struct {
+	Name     string                 `json:"name"`
+	Count    int64                  `json:"count,omitempty"`
+	Ratio    float64                `json:"ratio"`
+	Enabled  bool                   `json:"enabled,omitempty"`
+	Data     []byte                 `json:"data"`
+	Tags     []string               `json:"tags,omitempty"`
+	Extra    map[string]interface{} `json:"extra"`
+	Next     *compareRecord         `json:"next,omitempty"`
+	Skipped  string                 `json:"-"`
+	Untagged uint8
+	hidden   int
+}
+
+// FuzzCompareJSON tests that Go values built from random input mean the same
+// in TRON as in encoding/json
+func FuzzCompareJSON(f *testing.F) {
+	f.Add("Alice", int64(30), 1.5, true, []byte("data"))
+	f.Add("", int64(0), 0.0, false, []byte(nil))
+	f.Add("line\nbreak \"quoted\" <html>  ", int64(-1<<63), -1e300, true, []byte{0, 255})
+	f.Add("class", int64(1<<53+1), 1e21, false, []byte("x"))
+	f.Add("\xff\xfe", int64(42), 5e-324, true, []byte{})
+
+	f.Fuzz(func(t *testing.T, s string, n int64, x float64, b bool, data []byte) {
+		record := compareRecord{
+			Name:     s,
+			Count:    n,
+			Ratio:    x,
+			Enabled:  b,
+			Data:     data,
+			Tags:     []string{s, strings.ToUpper(s)},
+			Extra:    map[string]interface{}{s: n, "ratio": x, "list": []interface{}{b, nil, s}},
+			Next:     &compareRecord{Name: s, Ratio: -x},
+			Skipped:  s,
+			Untagged: uint8(n),
+			hidden:   int(n),
+		}
+		values := []interface{}{
+			s, n, x, b, data,
+			record,
+			[]compareRecord{record, {}},
+			map[string]interface{}{s: record, "n": n},
+			map[int64]string{n: s},
+			[]interface{}{s, n, x, b, nil, []interface{}{}, map[string]interface{}{}},
+		}
+		for _, v := range values {
+			diffs, err := CompareJSON(v)
+			if err != nil {
+				t.Fatalf("CompareJSON(%#v): %v", v, err)
+			}
+			for _, d := range diffs {
+				t.Errorf("CompareJSON(%#v): %v", v, d)
+			}
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package tron
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalAs(t *testing.T) {
+	type point struct{ X, Y int }
+	got, err := UnmarshalAs[[]point]([]byte("class P: X,Y\n\n[P(1,2),P(3,4)]"))
+	if err != nil {
+		t.Fatalf("UnmarshalAs: %v", err)
+	}
+	if len(got) != 2 || got[1] != (point{3, 4}) {
+		t.Fatalf("UnmarshalAs = %+v", got)
+	}
+
+	n, err := UnmarshalAs[int]([]byte(`"x"`))
+	var typeErr *UnmarshalTypeError
+	if !errors.As(err, &typeErr) || n != 0 {
+		t.Fatalf("expected type error, got %v, %v", n, err)
+	}
+}
+
+func TestDecodeAs(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"a": [1, 2]}`))
+	got, err := DecodeAs[map[string][]int](dec)
+	if err != nil {
+		t.Fatalf("DecodeAs: %v", err)
+	}
+	if len(got["a"]) != 2 || got["a"][1] != 2 {
+		t.Fatalf("DecodeAs = %v", got)
+	}
+	if _, err := DecodeAs[map[string][]int](dec); err != io.EOF {
+		t.Fatalf("expected io.EOF at end of input, got %v", err)
+	}
+}
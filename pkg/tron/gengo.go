@@ -0,0 +1,393 @@
+package tron
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GenerateGo returns the source of a Go file, in package pkg, that declares
+// a struct type for each class defined in the TRON document data, with json
+// tags naming the class's properties so that Unmarshal and Marshal map the
+// structs to the document. Properties with a default get a tron tag with the
+// default option.
+//
+// The type of each field is taken from the class definition if it is typed,
+// as in "class User: id int", and otherwise inferred from the arguments the
+// document gives the property: int if they are all integers, float64 if they
+// are all numbers, string, bool, the struct type of a class, a slice of one
+// of those for arrays, map[string]any for objects, and any if the arguments
+// disagree or are all null. Property names are turned into exported Go
+// identifiers, such as ID for id and CreatedAt for created_at.
+//
+// A field whose struct type would contain itself, directly or through other
+// classes, is a pointer instead.
+//
+// GenerateGo returns an error if pkg is not a Go identifier or data is not a
+// valid TRON document.
+func GenerateGo(data []byte, pkg string) ([]byte, error) {
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("tron: invalid Go package name %q", pkg)
+	}
+	p, err := newDocumentParser(data, decodeOptions{typedClasses: true})
+	if err != nil {
+		return nil, err
+	}
+	g := &goGenerator{p: p, props: make(map[string][]*inferredType)}
+	if err := g.document(); err != nil {
+		return nil, err
+	}
+	return g.source(pkg)
+}
+
+// Kinds of value seen for a property, as bits of inferredType.kinds.
+const (
+	seenInt uint8 = 1 << iota
+	seenFloat
+	seenString
+	seenBool
+	seenArray
+	seenObject
+	seenClass
+)
+
+// inferredType accumulates the kinds of value seen in one place.
+type inferredType struct {
+	kinds uint8
+	class string        // the class of instances, or "" if they disagree
+	elem  *inferredType // the element type of arrays
+}
+
+// merge adds the kinds seen in u to t.
+func (t *inferredType) merge(u *inferredType) {
+	if u == nil {
+		return
+	}
+	if u.kinds&seenClass != 0 {
+		if t.kinds&seenClass == 0 {
+			t.class = u.class
+		} else if t.class != u.class {
+			t.class = ""
+		}
+	}
+	if u.elem != nil {
+		if t.elem == nil {
+			t.elem = &inferredType{}
+		}
+		t.elem.merge(u.elem)
+	}
+	t.kinds |= u.kinds
+}
+
+// goGenerator walks a document, inferring the types of class properties.
+type goGenerator struct {
+	p     *parser
+	order []string                   // class names, in order of definition
+	props map[string][]*inferredType // the inferred type of each property, by class
+}
+
+// document records the classes of the header and walks the data.
+func (g *goGenerator) document() error {
+	p := g.p
+	p.skipNewlines()
+	for p.current().Type == TokenClass {
+		name := p.peek(1).Value
+		if err := p.parseClassDefinition(); err != nil {
+			return err
+		}
+		p.skipNewlines()
+		if _, ok := g.props[name]; !ok {
+			g.order = append(g.order, name)
+		}
+		// A redefinition starts over, as its properties may differ.
+		g.props[name] = make([]*inferredType, len(p.classes[name].props))
+		for i := range g.props[name] {
+			g.props[name][i] = &inferredType{}
+		}
+	}
+
+	p.skipNewlines()
+	if p.current().Type == TokenEOF {
+		return nil
+	}
+	if (p.current().Type == TokenIdentifier || p.current().Type == TokenString) && p.peek(1).Type == TokenColon {
+		return p.parseImplicitObjectWith(1, func(string) error {
+			_, err := g.value(2)
+			return err
+		})
+	}
+	if _, err := g.value(0); err != nil {
+		return err
+	}
+	p.skipNewlines()
+	if p.current().Type != TokenEOF {
+		return p.syntaxError("unexpected trailing tokens")
+	}
+	return nil
+}
+
+// value walks the value at the current position and returns its type.
+// depth follows the same accounting as parseValue.
+func (g *goGenerator) value(depth int) (*inferredType, error) {
+	p := g.p
+	if depth > p.limits().MaxDepth {
+		return nil, p.categoryError(ErrTooDeep, "maximum parse depth exceeded")
+	}
+
+	tok := p.current()
+	switch tok.Type {
+	case TokenLBracket:
+		t := &inferredType{kinds: seenArray, elem: &inferredType{}}
+		err := p.parseArrayWith(func() error {
+			elem, err := g.value(depth + 2)
+			t.elem.merge(elem)
+			return err
+		})
+		return t, err
+	case TokenLBrace:
+		err := p.parseObjectWith(depth+1, func(string) error {
+			_, err := g.value(depth + 2)
+			return err
+		})
+		return &inferredType{kinds: seenObject}, err
+	case TokenIdentifier:
+		if !p.atNonFinite() {
+			return g.instance(depth)
+		}
+	}
+
+	var t inferredType
+	switch tok.Type {
+	case TokenNumber, TokenIdentifier:
+		if strings.ContainsAny(tok.Value, ".eE") || p.atNonFinite() {
+			t.kinds = seenFloat
+		} else {
+			t.kinds = seenInt
+		}
+	case TokenString:
+		t.kinds = seenString
+	case TokenTrue, TokenFalse:
+		t.kinds = seenBool
+	}
+	return &t, p.skipValue(depth)
+}
+
+// instance walks the class instantiation at the current position, merging
+// the type of each argument into that of its property.
+func (g *goGenerator) instance(depth int) (*inferredType, error) {
+	p := g.p
+	name := p.current().Value
+	def := p.classes[name]
+	err := p.parseClassInstantiationWith(depth+1, func(prop string) error {
+		t, err := g.value(depth + 2)
+		if i := indexOf(def.props, prop); i >= 0 {
+			g.props[name][i].merge(t)
+		}
+		return err
+	})
+	return &inferredType{kinds: seenClass, class: name}, err
+}
+
+// source returns the formatted Go source for the classes.
+func (g *goGenerator) source(pkg string) ([]byte, error) {
+	typeNames := make(map[string]string, len(g.order))
+	used := make(map[string]bool)
+	for _, class := range g.order {
+		typeNames[class] = uniqueName(goName(class), used)
+	}
+
+	types := make(map[string][]string, len(g.order))
+	for _, class := range g.order {
+		def := g.p.classes[class]
+		types[class] = make([]string, len(def.props))
+		for i := range def.props {
+			if i < len(def.types) && def.types[i] != typeAny {
+				types[class][i] = declaredGoType(def.types[i])
+			} else {
+				types[class][i] = g.props[class][i].goType(typeNames)
+			}
+		}
+	}
+	g.breakCycles(types, typeNames)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated from TRON class definitions. DO NOT EDIT.\n\npackage %s\n", pkg)
+	for _, class := range g.order {
+		def := g.p.classes[class]
+		fmt.Fprintf(&out, "\ntype %s struct {\n", typeNames[class])
+		fields := make(map[string]bool)
+		for i, prop := range def.props {
+			typ := types[class][i]
+			tag := "json:" + strconv.Quote(prop)
+			if i < len(def.defaults) && def.defaults[i] != nil {
+				tag += goDefaultTag(prop, *def.defaults[i])
+			}
+			fmt.Fprintf(&out, "\t%s %s `%s`\n", uniqueName(goName(prop), fields), typ, tag)
+		}
+		out.WriteString("}\n")
+	}
+	return format.Source(out.Bytes())
+}
+
+// breakCycles makes a pointer of each field in types that closes a cycle of
+// structs containing one another, since a struct cannot contain itself. The
+// cycles are found by a depth-first search of the classes, in which such a
+// field is an edge back to a class still being searched.
+func (g *goGenerator) breakCycles(types map[string][]string, typeNames map[string]string) {
+	classOf := make(map[string]string, len(typeNames))
+	for class, name := range typeNames {
+		classOf[name] = class
+	}
+
+	const (
+		unvisited = iota
+		searching
+		searched
+	)
+	state := make(map[string]int, len(g.order))
+	var search func(class string)
+	search = func(class string) {
+		state[class] = searching
+		for i, typ := range types[class] {
+			next, ok := classOf[typ]
+			if !ok {
+				continue
+			}
+			switch state[next] {
+			case unvisited:
+				search(next)
+			case searching:
+				types[class][i] = "*" + typ
+			}
+		}
+		state[class] = searched
+	}
+	for _, class := range g.order {
+		if state[class] == unvisited {
+			search(class)
+		}
+	}
+}
+
+// goType returns the Go spelling of t, with classes named by typeNames.
+func (t *inferredType) goType(typeNames map[string]string) string {
+	switch t.kinds {
+	case seenInt:
+		return "int"
+	case seenInt | seenFloat, seenFloat:
+		return "float64"
+	case seenString:
+		return "string"
+	case seenBool:
+		return "bool"
+	case seenArray:
+		if t.elem.kinds == 0 {
+			return "[]any"
+		}
+		return "[]" + t.elem.goType(typeNames)
+	case seenObject:
+		return "map[string]any"
+	case seenClass:
+		if t.class != "" {
+			return typeNames[t.class]
+		}
+		return "map[string]any"
+	case seenObject | seenClass:
+		return "map[string]any"
+	}
+	return "any"
+}
+
+// declaredGoType returns the Go type for a property declared to have type t.
+func declaredGoType(t propType) string {
+	switch t {
+	case typeInt:
+		return "int"
+	case typeFloat:
+		return "float64"
+	case typeString:
+		return "string"
+	case typeBool:
+		return "bool"
+	case typeArray:
+		return "[]any"
+	case typeObject:
+		return "map[string]any"
+	}
+	return "any"
+}
+
+// goDefaultTag returns the tags, after a leading space, that give property
+// prop the default tok: omitempty for null, and a tron tag with the default
+// option otherwise. It returns "" for a default the tag cannot hold.
+func goDefaultTag(prop string, tok Token) string {
+	if tok.Type == TokenNull {
+		return ` tron:` + strconv.Quote(prop+",omitempty")
+	}
+	if strings.ContainsAny(tok.Value, ",`") || !strconv.CanBackquote(strconv.Quote(tok.Value)) {
+		return ""
+	}
+	return ` tron:` + strconv.Quote(prop+",default="+tok.Value)
+}
+
+// commonInitialisms are words Go spells in capitals within identifiers.
+var commonInitialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "https": true, "id": true, "ip": true, "json": true,
+	"sql": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// goName turns a class or property name into an exported Go identifier:
+// words, split at characters other than letters and digits and at lower to
+// upper case changes, are capitalized and joined.
+func goName(s string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	for _, r := range s {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(word) > 0 && unicode.IsLower(word[len(word)-1]):
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+
+	var b strings.Builder
+	for _, w := range words {
+		if commonInitialisms[strings.ToLower(w)] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		r := []rune(w)
+		b.WriteRune(unicode.ToUpper(r[0]))
+		b.WriteString(string(r[1:]))
+	}
+	name := b.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// uniqueName returns name, or name with a number appended if used already
+// has it, and adds the result to used.
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for n := 2; used[unique]; n++ {
+		unique = name + strconv.Itoa(n)
+	}
+	used[unique] = true
+	return unique
+}
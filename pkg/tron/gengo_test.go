@@ -0,0 +1,73 @@
+package tron
+
+import "testing"
+
+func TestGenerateGo(t *testing.T) {
+	input := "class Point: x,y,label\n" +
+		"class shape_def: kind string, points, parent, \"user-id\", score, ok=true\n\n" +
+		"[shape_def(\"line\",[Point(1,2,null),Point(1.5,2,\"b\")],null,7,1,false),\n" +
+		" shape_def(\"dot\",[],shape_def(\"x\",[],null,\"8\",2.5),null,{})]"
+	got, err := GenerateGo([]byte(input), "shapes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `// Code generated from TRON class definitions. DO NOT EDIT.
+
+package shapes
+
+type Point struct {
+	X     float64 ` + "`json:\"x\"`" + `
+	Y     int     ` + "`json:\"y\"`" + `
+	Label string  ` + "`json:\"label\"`" + `
+}
+
+type ShapeDef struct {
+	Kind   string    ` + "`json:\"kind\"`" + `
+	Points []Point   ` + "`json:\"points\"`" + `
+	Parent *ShapeDef ` + "`json:\"parent\"`" + `
+	UserID any       ` + "`json:\"user-id\"`" + `
+	Score  any       ` + "`json:\"score\"`" + `
+	Ok     bool      ` + "`json:\"ok\" tron:\"ok,default=true\"`" + `
+}
+`
+	if string(got) != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := GenerateGo([]byte("class A: x\n\nB(1)"), "p"); err == nil {
+		t.Error("invalid document: got no error")
+	}
+
+	if _, err := GenerateGo([]byte("class A: x\n\nA(1)"), "my-pkg"); err == nil {
+		t.Error("invalid package name: got no error")
+	}
+}
+
+func TestGenerateGoCycles(t *testing.T) {
+	input := "class A: b\nclass B: c\nclass C: a,self\n\n" +
+		"A(B(C(A(null),C(null,null))))"
+	got, err := GenerateGo([]byte(input), "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `// Code generated from TRON class definitions. DO NOT EDIT.
+
+package p
+
+type A struct {
+	B B ` + "`json:\"b\"`" + `
+}
+
+type B struct {
+	C C ` + "`json:\"c\"`" + `
+}
+
+type C struct {
+	A    *A ` + "`json:\"a\"`" + `
+	Self *C ` + "`json:\"self\"`" + `
+}
+`
+	if string(got) != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
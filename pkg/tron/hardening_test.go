@@ -1,6 +1,7 @@
 package tron
 
 import (
+	"errors"
 	"strings"
 	"sync"
 	"testing"
@@ -63,15 +64,10 @@ func TestStringUnicodeEscapes_ExtraHexDigitIsLiteral(t *testing.T) {
 
 func TestStringUnicodeEscapes_Invalid(t *testing.T) {
 	cases := []string{
-		"\"\\u12G4\"",        // bad hex
-		"\"\\uD83D\"",        // lone high surrogate
-		"\"\\uDE00\"",        // lone low surrogate
-		"\"\\uD83D\\u0041\"", // high surrogate not followed by low surrogate
-		"\"\\uD83D\\uD83D\"", // two highs
-		"\"\\uDE00\\uDE00\"", // two lows
-		"\"\\u\"",            // too short
-		"\"\\u123\"",         // too short
-		"\"\\uD83D\\uDE0\"",  // too short second
+		"\"\\u12G4\"",       // bad hex
+		"\"\\u\"",           // too short
+		"\"\\u123\"",        // too short
+		"\"\\uD83D\\uDE0\"", // too short second
 	}
 
 	for _, input := range cases {
@@ -85,6 +81,37 @@ func TestStringUnicodeEscapes_Invalid(t *testing.T) {
 	}
 }
 
+func TestStringUnpairedSurrogates(t *testing.T) {
+	cases := map[string]string{
+		"\"\\uD83D\"":               "\ufffd",       // lone high surrogate
+		"\"\\uDE00\"":               "\ufffd",       // lone low surrogate
+		"\"\\uD83D\\u0041\"":        "\ufffdA",      // high surrogate not followed by low surrogate
+		"\"\\uD83D\\uD83D\"":        "\ufffd\ufffd", // two highs
+		"\"\\uDE00\\uDE00\"":        "\ufffd\ufffd", // two lows
+		"\"\\uD83D\\uD83D\\uDE00\"": "\ufffd\U0001F600",
+		"\"a\xffb\"":                "a\ufffdb", // invalid UTF-8
+	}
+
+	for input, want := range cases {
+		t.Run(input, func(t *testing.T) {
+			var v string
+			if err := Unmarshal([]byte(input), &v); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if v != want {
+				t.Fatalf("got %q, want %q", v, want)
+			}
+
+			dec := NewDecoder(strings.NewReader(input))
+			dec.UseStrictUTF8()
+			var syntaxErr *SyntaxError
+			if err := dec.Decode(&v); !errors.As(err, &syntaxErr) {
+				t.Fatalf("strict: got %v, want SyntaxError", err)
+			}
+		})
+	}
+}
+
 func TestNumberGrammar_Valid(t *testing.T) {
 	cases := []string{
 		"0",
@@ -216,3 +243,53 @@ func TestSyntaxErrorOffsetCountsBytesWithMultibyteUTF8(t *testing.T) {
 		t.Fatalf("unexpected error message: %q", syn.Error())
 	}
 }
+
+func TestSyntaxErrorLocatesParserErrors(t *testing.T) {
+	var v interface{}
+	input := "class A: x,y\n\n[A(1,2),\n  名(3)]"
+	err := Unmarshal([]byte(input), &v)
+	syn, ok := err.(*SyntaxError)
+	if !ok {
+		t.Fatalf("expected *SyntaxError, got %T (%v)", err, err)
+	}
+	// The undefined class 名 starts on line 4, column 3, after the 23 bytes
+	// of the lines before it and two spaces.
+	if syn.Line != 4 || syn.Column != 3 || syn.Offset != 25 {
+		t.Fatalf("got line %d, column %d, offset %d; want 4, 3, 25", syn.Line, syn.Column, syn.Offset)
+	}
+	if !strings.HasSuffix(syn.Error(), " at line 4, column 3") {
+		t.Fatalf("unexpected error message: %q", syn.Error())
+	}
+
+	err = Unmarshal([]byte("{\"a\": 1,\n\"b\" 2}"), &v)
+	if syn, ok := err.(*SyntaxError); !ok || syn.Line != 2 || syn.Column != 5 || syn.Offset != 13 {
+		t.Fatalf("got %#v", err)
+	}
+}
+
+func TestSyntaxErrorSnippet(t *testing.T) {
+	src := []byte("class A: x,y\n\n[A(1,2),\n\t名(3)]\r\n")
+	var v interface{}
+	syn, ok := Unmarshal(src, &v).(*SyntaxError)
+	if !ok {
+		t.Fatal("expected *SyntaxError")
+	}
+	want := "4 | \t名(3)]\n  | \t^\n"
+	if got := syn.Snippet(src); got != want {
+		t.Fatalf("Snippet = %q, want %q", got, want)
+	}
+
+	src = []byte("[1,\n  \"é\" x]")
+	syn, ok = Unmarshal(src, &v).(*SyntaxError)
+	if !ok {
+		t.Fatal("expected *SyntaxError")
+	}
+	want = "2 |   \"é\" x]\n  |       ^\n"
+	if got := syn.Snippet(src); got != want {
+		t.Fatalf("Snippet = %q, want %q", got, want)
+	}
+
+	if got := (&SyntaxError{msg: "input too large"}).Snippet(src); got != "" {
+		t.Fatalf("Snippet without location = %q", got)
+	}
+}
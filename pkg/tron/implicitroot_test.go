@@ -0,0 +1,93 @@
+package tron
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type implicitConfig struct {
+	Name   string            `json:"name"`
+	Port   int               `json:"port"`
+	Labels map[string]string `json:"labels"`
+	Peers  []implicitPeer    `json:"peers"`
+}
+
+type implicitPeer struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+func TestEncoderSetImplicitRoot(t *testing.T) {
+	cfg := implicitConfig{
+		Name:   "svc",
+		Port:   8080,
+		Labels: map[string]string{"tier": "web", "null": "x", "a b": "y"},
+		Peers:  []implicitPeer{{"a", 1}, {"b", 2}},
+	}
+	got := encodeWith(t, cfg, func(enc *Encoder) { enc.SetImplicitRoot(true) })
+	want := "class A: host,port\n\n" +
+		"name: \"svc\"\n" +
+		"port: 8080\n" +
+		"labels: {\"a b\":\"y\",\"null\":\"x\",\"tier\":\"web\"}\n" +
+		"peers: [A(\"a\",1),A(\"b\",2)]"
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+	var back implicitConfig
+	if err := Unmarshal([]byte(got), &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, cfg) {
+		t.Errorf("round trip: got %+v", back)
+	}
+
+	// Map keys that are keywords or not identifiers stay quoted.
+	got = encodeWith(t, map[string]int{"true": 1, "x-y": 2, "ok": 3}, func(enc *Encoder) { enc.SetImplicitRoot(true) })
+	if want := "ok: 3\n\"true\": 1\n\"x-y\": 2"; got != want {
+		t.Errorf("map root: got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Other roots are unchanged.
+	for _, v := range []any{[]int{1}, "s", map[string]int{}, nil} {
+		plain := encodeWith(t, v, func(*Encoder) {})
+		if got := encodeWith(t, v, func(enc *Encoder) { enc.SetImplicitRoot(true) }); got != plain {
+			t.Errorf("%v: got %s, want %s", v, got, plain)
+		}
+	}
+}
+
+func TestImplicitRootClassShape(t *testing.T) {
+	// The root shares its shape with a nested value, so the shape has a
+	// class, but the root still spells out its keys.
+	type node struct {
+		Name string `json:"name"`
+		Next *node  `json:"next"`
+	}
+	v := node{"a", &node{"b", &node{"c", nil}}}
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetImplicitRoot(true)
+	if err := enc.Encode(v); err != nil {
+		t.Fatal(err)
+	}
+	if want := "class A: name,next\n\nname: \"a\"\nnext: A(\"b\",A(\"c\",null))\n"; buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+	var back node
+	if err := Unmarshal(buf.Bytes(), &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Next.Next.Name != "c" {
+		t.Errorf("round trip: got %+v", back)
+	}
+
+	// Indent keeps the implicit form.
+	var out bytes.Buffer
+	if err := Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	if want := "class A: name,next\n\nname: \"a\"\nnext: A(\"b\",A(\"c\",null))"; out.String() != want {
+		t.Errorf("Indent:\ngot  %q\nwant %q", out.String(), want)
+	}
+}
@@ -0,0 +1,70 @@
+package tron
+
+import (
+	"reflect"
+	"testing"
+)
+
+type withInline struct {
+	Name  string            `json:"name"`
+	Extra map[string]string `tron:",inline"`
+}
+
+func TestInlineMapEmitsTopLevelKeys(t *testing.T) {
+	v := withInline{Name: "a", Extra: map[string]string{"z": "1", "b": "2", "name": "shadowed"}}
+	out, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(out) != `{"name":"a","b":"2","z":"1"}` {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
+
+func TestInlineMapNilOrEmpty(t *testing.T) {
+	out, err := Marshal(withInline{Name: "a"})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(out) != `{"name":"a"}` {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
+
+func TestInlineMapInClassInstantiation(t *testing.T) {
+	in := []withInline{
+		{Name: "a", Extra: map[string]string{"color": "red"}},
+		{Name: "b", Extra: map[string]string{"color": "blue"}},
+	}
+	out, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := "class A: name,color\n\n[A(\"a\",\"red\"),A(\"b\",\"blue\")]"
+	if string(out) != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+
+	var got []withInline
+	if err := Unmarshal(out, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(got, in) {
+		t.Fatalf("round trip mismatch: %#v", got)
+	}
+}
+
+func TestRemainRoundTrip(t *testing.T) {
+	input := `{"name":"a","age":3,"tags":["x"]}`
+	var v withRemain
+	if err := Unmarshal([]byte(input), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	out, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(out) != `{"name":"a","age":3,"tags":["x"]}` {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
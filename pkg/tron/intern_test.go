@@ -0,0 +1,54 @@
+package tron
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestDecoderInternStrings(t *testing.T) {
+	type row struct {
+		ID     int    `json:"id"`
+		Status string `json:"status"`
+	}
+	input := "class R: id,status\n\n[R(1,\"open\"),R(2,\"closed\"),R(3,\"open\"),R(4,\"open\")]"
+	long := `"` + strings.Repeat("x", maxInterned+1) + `"`
+
+	decode := func(intern bool, src string, v any) {
+		t.Helper()
+		dec := NewDecoder(strings.NewReader(src))
+		if intern {
+			dec.InternStrings()
+		}
+		if err := dec.Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var rows []row
+	decode(true, input, &rows)
+	if rows[2].Status != "open" || unsafe.StringData(rows[0].Status) != unsafe.StringData(rows[3].Status) {
+		t.Errorf("repeated status not shared: %+v", rows)
+	}
+	decode(false, input, &rows)
+	if unsafe.StringData(rows[0].Status) == unsafe.StringData(rows[3].Status) {
+		t.Error("strings shared without InternStrings")
+	}
+
+	// Keys are interned too, but long strings are not.
+	var v []map[string]any
+	decode(true, "[{name:"+long+"},{name:"+long+"}]", &v)
+	var k0, k1 string
+	for k := range v[0] {
+		k0 = k
+	}
+	for k := range v[1] {
+		k1 = k
+	}
+	if unsafe.StringData(k0) != unsafe.StringData(k1) {
+		t.Error("repeated key not shared")
+	}
+	if unsafe.StringData(v[0]["name"].(string)) == unsafe.StringData(v[1]["name"].(string)) {
+		t.Error("long string interned")
+	}
+}
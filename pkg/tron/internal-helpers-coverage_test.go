@@ -103,7 +103,7 @@ func TestSerializeMapKey_ErrorBranches(t *testing.T) {
 
 	// TextMarshaler error
 	{
-		_, err := e.serializeMapKey(reflect.ValueOf(testTextKeyErr{S: "x"}))
+		_, err := e.appendMapKey(nil, reflect.ValueOf(testTextKeyErr{S: "x"}))
 		if err == nil {
 			t.Fatalf("expected error")
 		}
@@ -111,7 +111,7 @@ func TestSerializeMapKey_ErrorBranches(t *testing.T) {
 
 	// Unsupported key type
 	{
-		_, err := e.serializeMapKey(reflect.ValueOf([]int{1}))
+		_, err := e.appendMapKey(nil, reflect.ValueOf([]int{1}))
 		if err == nil {
 			t.Fatalf("expected error")
 		}
@@ -0,0 +1,153 @@
+package tron
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// jsonUUID mimics types like uuid.UUID that only implement the JSON interfaces.
+type jsonUUID [4]byte
+
+func (u jsonUUID) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"%x"`, u[:])), nil
+}
+
+func (u *jsonUUID) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	copy(u[:], b)
+	return nil
+}
+
+// jsonPoint is a struct whose JSON form differs from its fields.
+type jsonPoint struct {
+	X, Y int
+}
+
+func (p jsonPoint) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`{ "coords": [%d, %d] }`, p.X, p.Y)), nil
+}
+
+func (p *jsonPoint) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Coords []int `json:"coords"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if len(v.Coords) != 2 {
+		return errors.New("expected two coords")
+	}
+	p.X, p.Y = v.Coords[0], v.Coords[1]
+	return nil
+}
+
+type jsonBadOutput struct{}
+
+func (jsonBadOutput) MarshalJSON() ([]byte, error) { return []byte("{not json"), nil }
+
+// tronAndJSON implements both interfaces; the TRON ones must win.
+type tronAndJSON struct{ S string }
+
+func (tronAndJSON) MarshalTRON() ([]byte, error) { return []byte(`"tron"`), nil }
+func (tronAndJSON) MarshalJSON() ([]byte, error) { return []byte(`"json"`), nil }
+func (t *tronAndJSON) UnmarshalTRON(b []byte) error {
+	t.S = "tron:" + string(b)
+	return nil
+}
+func (t *tronAndJSON) UnmarshalJSON(b []byte) error {
+	t.S = "json:" + string(b)
+	return nil
+}
+
+func TestMarshalFallsBackToMarshalJSON(t *testing.T) {
+	v := struct {
+		ID    jsonUUID  `json:"id"`
+		Where jsonPoint `json:"where"`
+	}{ID: jsonUUID{0xde, 0xad, 0xbe, 0xef}, Where: jsonPoint{1, 2}}
+
+	out, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `{"id":"deadbeef","where":{"coords":[1,2]}}`
+	if string(out) != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestMarshalJSONValuesDoNotDefineClasses(t *testing.T) {
+	out, err := Marshal([]jsonPoint{{1, 2}, {3, 4}})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if strings.Contains(string(out), "class") {
+		t.Fatalf("unexpected class header: %s", out)
+	}
+}
+
+func TestMarshalJSONInvalidOutput(t *testing.T) {
+	if _, err := Marshal(jsonBadOutput{}); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestUnmarshalFallsBackToUnmarshalJSON(t *testing.T) {
+	input := "class P: id,where\n\n[P(\"deadbeef\",{coords:[1,2]}),P(\"00000001\",{coords:[3,4]})]"
+	var v []struct {
+		ID    jsonUUID  `json:"id"`
+		Where jsonPoint `json:"where"`
+	}
+	if err := Unmarshal([]byte(input), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if v[0].ID != (jsonUUID{0xde, 0xad, 0xbe, 0xef}) || v[1].Where != (jsonPoint{3, 4}) {
+		t.Fatalf("unexpected value: %+v", v)
+	}
+}
+
+func TestUnmarshalJSONKeepsNumberPrecision(t *testing.T) {
+	var n big.Int
+	if err := Unmarshal([]byte("123456789012345678901234567890"), &n); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if n.String() != "123456789012345678901234567890" {
+		t.Fatalf("unexpected value: %s", n.String())
+	}
+}
+
+func TestTRONInterfacesTakePrecedenceOverJSON(t *testing.T) {
+	out, err := Marshal(tronAndJSON{})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(out) != `"tron"` {
+		t.Fatalf("unexpected output: %s", out)
+	}
+
+	var v tronAndJSON
+	if err := Unmarshal([]byte("class A: a,b\n\nA(1,\"x\")"), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if v.S != `tron:{"a":1,"b":"x"}` {
+		t.Fatalf("unexpected value: %q", v.S)
+	}
+
+	if err := Unmarshal([]byte("null"), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if v.S != "tron:null" {
+		t.Fatalf("expected UnmarshalTRON to be called with null, got %q", v.S)
+	}
+}
@@ -0,0 +1,108 @@
+package tron
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// UnmarshalLenient is like Unmarshal but makes a best effort with malformed
+// input, such as TRON written by a language model: an array element, object
+// member or class definition that fails to parse is skipped, and the error
+// is added to the returned issues instead of aborting the decode. Parsing
+// resumes at the next comma or closing bracket of the enclosing array or
+// object, or at the next line of the header or of an implicit root object.
+// Trailing tokens after the value are reported and ignored.
+//
+// Errors that cannot be skipped, such as exceeded limits, a missing closing
+// bracket at the end of the input or an unterminated string, are returned as
+// err along with the issues found before them. Type errors are handled as by
+// Unmarshal and are not issues. Each issue can be shown in context with
+// SyntaxError.Snippet.
+func UnmarshalLenient(data []byte, v interface{}) (issues []*SyntaxError, err error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil, &InvalidUnmarshalError{Type: reflect.TypeOf(v)}
+	}
+
+	p, err := newDocumentParser(data, decodeOptions{lenient: true})
+	if err != nil {
+		return nil, err
+	}
+	// Parse the whole tree first so that skipped elements never reach v.
+	tree, err := p.parse()
+	if err != nil {
+		return p.issues, err
+	}
+	d := &decoder{decodeOptions: p.opts}
+	return p.issues, joinErrors(d.decode(tree, rv.Elem()))
+}
+
+// recoverFrom handles err from parsing the element, member or class
+// definition that starts at token start. In lenient mode, if err is a syntax
+// error that can be skipped, it records err as an issue and moves to the next
+// separator at the same nesting level, or to closer, and returns nil.
+// Otherwise it returns err.
+func (p *parser) recoverFrom(err error, start int, closer TokenType) error {
+	var syntaxErr *SyntaxError
+	if !p.opts.lenient || !errors.As(err, &syntaxErr) || isLimitError(syntaxErr) {
+		return err
+	}
+	p.issues = append(p.issues, syntaxErr)
+	p.pos = start
+	p.skipTo(closer)
+	return nil
+}
+
+// recoverSeparator checks in lenient mode that an element or member is
+// followed by a comma or closer, skipping and recording anything else.
+func (p *parser) recoverSeparator(closer TokenType) error {
+	if !p.opts.lenient {
+		return nil
+	}
+	if t := p.current().Type; t == TokenComma || t == closer || t == TokenEOF {
+		return nil
+	}
+	closing := "]"
+	if closer == TokenRBrace {
+		closing = "}"
+	}
+	err := p.syntaxError(fmt.Sprintf("expected , or %s, got %s", closing, p.current().Type))
+	return p.recoverFrom(err, p.pos, closer)
+}
+
+// skipTo advances to the next comma, or to closer, that is not nested in
+// brackets, or to a closing bracket that ends the enclosing value. A closer of
+// TokenNewline, used at the top level, stops only at the end of a line
+// outside brackets, skipping commas and stray closing brackets.
+func (p *parser) skipTo(closer TokenType) {
+	depth := 0
+	for {
+		switch p.current().Type {
+		case TokenEOF:
+			return
+		case TokenLBracket, TokenLBrace, TokenLParen:
+			depth++
+		case TokenRBracket, TokenRBrace, TokenRParen:
+			if depth == 0 && closer != TokenNewline {
+				return
+			}
+			depth = max(depth-1, 0)
+		case TokenComma:
+			if depth == 0 && closer != TokenNewline {
+				return
+			}
+		case TokenNewline:
+			if depth == 0 && closer == TokenNewline {
+				return
+			}
+		}
+		p.advance()
+	}
+}
+
+// isLimitError reports whether err enforces a resource limit, which lenient
+// mode must not skip.
+func isLimitError(err *SyntaxError) bool {
+	return err.err == ErrTooLarge || err.err == ErrTooDeep || err.err == ErrTooManyTokens
+}
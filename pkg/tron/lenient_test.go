@@ -0,0 +1,78 @@
+package tron
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type lenientItem struct {
+	Name string `json:"name"`
+	Qty  int    `json:"qty"`
+}
+
+func TestUnmarshalLenientSkipsMalformedParts(t *testing.T) {
+	input := `class I: name,qty
+class Broken name
+
+items: [I("a",1), I("b"), I("c",3) I("d",4), , {"name":"e" "qty":5}, {"name":"f","qty":6}]
+bad line here
+total: 10
+`
+	var v struct {
+		Items []lenientItem `json:"items"`
+		Total int           `json:"total"`
+	}
+	issues, err := UnmarshalLenient([]byte(input), &v)
+	if err != nil {
+		t.Fatalf("UnmarshalLenient: %v", err)
+	}
+	wantItems := []lenientItem{{"a", 1}, {"c", 3}, {"e", 0}, {"f", 6}}
+	if !reflect.DeepEqual(v.Items, wantItems) || v.Total != 10 {
+		t.Fatalf("got %+v", v)
+	}
+
+	var msgs []string
+	for _, issue := range issues {
+		msgs = append(msgs, issue.Error())
+	}
+	want := []string{
+		"expected COLON, got IDENTIFIER at line 2, column 14",
+		"class I expects 2 arguments, got 1 at line 4, column 25",
+		"expected , or ], got IDENTIFIER at line 4, column 36",
+		"unexpected token: COMMA at line 4, column 46",
+		"expected , or }, got STRING at line 4, column 60",
+		"unexpected token: IDENTIFIER at line 5, column 1",
+	}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Fatalf("issues:\n%s\nwant:\n%s", strings.Join(msgs, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestUnmarshalLenientStopsOnFatalErrors(t *testing.T) {
+	var v interface{}
+	issues, err := UnmarshalLenient([]byte(`[1,,2`), &v)
+	if err == nil || len(issues) != 1 {
+		t.Fatalf("got %v, %v; want a fatal error after one issue", issues, err)
+	}
+
+	// Stray closing brackets at the top level must not stall recovery.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		issues, err = UnmarshalLenient([]byte("a: 1\n]]\nb: 2\n"), &v)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("UnmarshalLenient did not return")
+	}
+	if err != nil || len(issues) != 1 || !reflect.DeepEqual(v, map[string]interface{}{"a": float64(1), "b": float64(2)}) {
+		t.Fatalf("got %v, %v, %v", v, issues, err)
+	}
+
+	if _, err := UnmarshalLenient([]byte(`[1,2]`), v); err == nil {
+		t.Fatal("expected error for non-pointer")
+	}
+}
@@ -1,9 +1,11 @@
 package tron
 
+import "math"
+
 // Internal safety limits to reduce worst-case CPU/memory usage on adversarial inputs.
 //
-// These are intentionally conservative defaults. If you need to process larger
-// payloads, consider adding an exported Decoder API with configurable limits.
+// These are the conservative defaults used by Unmarshal and by Decoders whose
+// Limits leave a field at zero. Use Decoder.SetLimits to process larger payloads.
 //
 // NOTE: these are vars (not const) so tests can temporarily override them.
 var (
@@ -11,4 +13,105 @@ var (
 	maxTokens     = 1_000_000 // hard cap on token count
 	maxParseDepth = 1_000     // nested arrays/objects/class instantiations
 	maxWalkDepth  = 1_000     // reflect graph depth for Marshal
+	maxClasses    = 10_000    // class definitions per document
+	maxProperties = 1_000     // properties per class definition
+	maxArguments  = 1_000     // arguments per class instantiation, defaults included
+
+	// Strings, arrays and objects are only bounded by the limits above
+	// unless Limits says otherwise.
+	maxStringBytes   = math.MaxInt
+	maxArrayLength   = math.MaxInt
+	maxObjectMembers = math.MaxInt
 )
+
+// valuesPerToken sets the default of Limits.MaxValues as a multiple of
+// MaxTokens. Every value written out takes at least one token, so the
+// margin is what class defaults may add to a document.
+const valuesPerToken = 2
+
+// Limits bounds the resources used to decode a single TRON document.
+// A zero field means the package default for that limit.
+type Limits struct {
+	MaxInputBytes int // maximum input size in bytes
+	MaxTokens     int // maximum number of tokens, and of properties inherited with extends
+	MaxDepth      int // maximum nesting of arrays, objects and class instantiations
+	MaxClasses    int // maximum number of class definitions
+	MaxProperties int // maximum number of properties in one class definition
+
+	MaxStringBytes   int // maximum length of a quoted string in bytes, once unescaped
+	MaxArrayLength   int // maximum number of elements of an array
+	MaxObjectMembers int // maximum number of members of an object
+	MaxArguments     int // maximum number of arguments of a class instantiation
+
+	// MaxValues is the maximum number of values in a document: array
+	// elements, object members and class instantiation arguments, counting
+	// the arguments that property defaults supply. It bounds how far a small
+	// input can expand in memory through class defaults, which the limits on
+	// input size and tokens do not. It defaults to twice MaxTokens.
+	MaxValues int
+}
+
+// DefaultLimits returns the limits used by Unmarshal and new Decoders.
+func DefaultLimits() Limits {
+	return Limits{}.withDefaults()
+}
+
+// withDefaults returns l with zero fields replaced by the package defaults.
+func (l Limits) withDefaults() Limits {
+	if l.MaxInputBytes <= 0 {
+		l.MaxInputBytes = maxInputBytes
+	}
+	if l.MaxTokens <= 0 {
+		l.MaxTokens = maxTokens
+	}
+	if l.MaxDepth <= 0 {
+		l.MaxDepth = maxParseDepth
+	}
+	if l.MaxClasses <= 0 {
+		l.MaxClasses = maxClasses
+	}
+	if l.MaxProperties <= 0 {
+		l.MaxProperties = maxProperties
+	}
+	if l.MaxStringBytes <= 0 {
+		l.MaxStringBytes = maxStringBytes
+	}
+	if l.MaxArrayLength <= 0 {
+		l.MaxArrayLength = maxArrayLength
+	}
+	if l.MaxObjectMembers <= 0 {
+		l.MaxObjectMembers = maxObjectMembers
+	}
+	if l.MaxArguments <= 0 {
+		l.MaxArguments = maxArguments
+	}
+	if l.MaxValues <= 0 {
+		l.MaxValues = math.MaxInt
+		if l.MaxTokens <= math.MaxInt/valuesPerToken {
+			l.MaxValues = valuesPerToken * l.MaxTokens
+		}
+	}
+	return l
+}
+
+// SecureLimits returns tight limits for decoding untrusted input, such as the
+// output of a language model or a request body: 1 MiB of input in at most
+// 100,000 tokens, nesting up to 64 deep, 64 classes of up to 64 properties,
+// strings of up to 64 KiB, arrays and objects of up to 10,000 elements and
+// 1,000 members, instantiations of up to 64 arguments, and 200,000 values in
+// all. SecureDecoder applies them; adjust a field and pass the
+// result to Decoder.SetLimits where they are too tight.
+func SecureLimits() Limits {
+	return Limits{
+		MaxInputBytes:    1 << 20,
+		MaxTokens:        100_000,
+		MaxDepth:         64,
+		MaxClasses:       64,
+		MaxProperties:    64,
+		MaxStringBytes:   64 << 10,
+		MaxArrayLength:   10_000,
+		MaxObjectMembers: 1_000,
+		MaxArguments:     64,
+		MaxValues:        200_000,
+	}
+}
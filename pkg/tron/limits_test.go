@@ -0,0 +1,172 @@
+package tron
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDefaultLimits(t *testing.T) {
+	l := DefaultLimits()
+	if l.MaxInputBytes != maxInputBytes || l.MaxTokens != maxTokens || l.MaxDepth != maxParseDepth ||
+		l.MaxClasses != maxClasses || l.MaxProperties != maxProperties {
+		t.Fatalf("unexpected defaults: %+v", l)
+	}
+	if got := (Limits{MaxDepth: 3}).withDefaults(); got.MaxDepth != 3 || got.MaxTokens != maxTokens {
+		t.Fatalf("unexpected merged limits: %+v", got)
+	}
+}
+
+func decodeWithLimits(input string, l Limits) error {
+	dec := NewDecoder(strings.NewReader(input))
+	dec.SetLimits(l)
+	var v interface{}
+	return dec.Decode(&v)
+}
+
+func TestDecoderLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		limits Limits
+		msg    string
+	}{
+		{"input bytes", `"0123456789"`, Limits{MaxInputBytes: 5}, "input too large"},
+		{"tokens", `[1,2,3,4,5]`, Limits{MaxTokens: 5}, "too many tokens"},
+		{"depth", `[[[[1]]]]`, Limits{MaxDepth: 3}, "maximum parse depth exceeded"},
+		{"implicit object depth", "a: [[1]]", Limits{MaxDepth: 2}, "maximum parse depth exceeded"},
+		{"classes", "class A: a\nclass B: b\nclass C: c\n\n1", Limits{MaxClasses: 2}, "too many class definitions"},
+		{"properties", "class A: a,b,c\n\n1", Limits{MaxProperties: 2}, "class A has too many properties"},
+		{"string bytes", `["abc","abcdef"]`, Limits{MaxStringBytes: 5}, "string too long"},
+		{"escaped string bytes", `"\u00e9\u00e9\u00e9"`, Limits{MaxStringBytes: 5}, "string too long"},
+		{"array length", `[[1,2],[1,2,3]]`, Limits{MaxArrayLength: 2}, "array has too many elements"},
+		{"object members", `[{"a":1,"b":2,"c":3}]`, Limits{MaxObjectMembers: 2}, "object has too many members"},
+		{"implicit object members", "a: 1\nb: 2\nc: 3", Limits{MaxObjectMembers: 2}, "object has too many members"},
+		{"arguments", "class A: a,b,c\n\nA(1,2,3)", Limits{MaxArguments: 2}, "class A instantiated with too many arguments"},
+		{"named arguments", "class A: a,b,c\n\nA(a:1,b:2,c:3)", Limits{MaxArguments: 2}, "class A instantiated with too many arguments"},
+		{"values", `[[1,2],{"a":3}]`, Limits{MaxValues: 4}, "too many values"},
+		{"values from defaults", "class A: a,b=0,c=0\n\n[A(1),A(2)]", Limits{MaxValues: 5}, "too many values"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := decodeWithLimits(tt.input, tt.limits)
+			syn, ok := err.(*SyntaxError)
+			if !ok {
+				t.Fatalf("expected *SyntaxError, got %T (%v)", err, err)
+			}
+			if !strings.Contains(syn.Error(), tt.msg) {
+				t.Fatalf("expected %q in %q", tt.msg, syn.Error())
+			}
+			// The same input decodes with default limits.
+			if err := decodeWithLimits(tt.input, Limits{}); err != nil {
+				t.Fatalf("default limits: %v", err)
+			}
+		})
+	}
+}
+
+func TestCardinalityLimitsAreTooLarge(t *testing.T) {
+	for _, tt := range []struct {
+		input  string
+		limits Limits
+	}{
+		{"class A: a\nclass B: b\n\n1", Limits{MaxClasses: 1}},
+		{"class A: a,b\n\n1", Limits{MaxProperties: 1}},
+		{"class A: a,b\n\nA(1,2)", Limits{MaxArguments: 1}},
+		{"[1,2,3]", Limits{MaxValues: 2}},
+	} {
+		if err := decodeWithLimits(tt.input, tt.limits); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("%q with %+v: %v, want ErrTooLarge", tt.input, tt.limits, err)
+		}
+	}
+
+	// The values a document expands to through defaults are counted, and
+	// those of the array being decoded in parallel are not lost.
+	props := make([]string, 64)
+	for i := range props {
+		props[i] = fmt.Sprintf("p%d=0", i)
+	}
+	input := "class A: " + strings.Join(props, ",") + "\n\n[" + strings.Repeat("A(),", 10_000) + "A()]"
+	dec := SecureDecoder(strings.NewReader(input))
+	dec.SetParallelism(4)
+	var v []map[string]int
+	if err := dec.Decode(&v); !errors.Is(err, ErrTooLarge) || !strings.Contains(err.Error(), "too many values") {
+		t.Fatalf("Decode = %v, want too many values", err)
+	}
+}
+
+func TestDefaultLimitsBoundDefaultExpansion(t *testing.T) {
+	// 87 KB that would expand to 20 million values through defaults.
+	props := make([]string, 1000)
+	for i := range props {
+		props[i] = fmt.Sprintf("p%d=0", i)
+	}
+	input := "class A: " + strings.Join(props, ",") + "\n\n[" + strings.Repeat("A(),", 19_999) + "A()]"
+	var v interface{}
+	if err := Unmarshal([]byte(input), &v); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Unmarshal = %v, want ErrTooLarge", err)
+	}
+	if l := DefaultLimits(); l.MaxValues != valuesPerToken*maxTokens || l.MaxArguments != maxArguments {
+		t.Fatalf("unbounded defaults: %+v", l)
+	}
+}
+
+func TestDecoderLimitsCanBeRaised(t *testing.T) {
+	input := "[" + strings.Repeat("[", 20) + strings.Repeat("]", 20) + "]"
+	withLimits(t, maxInputBytes, maxTokens, 10, maxWalkDepth)
+	if err := decodeWithLimits(input, Limits{}); err == nil {
+		t.Fatalf("expected default depth limit to reject input")
+	}
+	if err := decodeWithLimits(input, Limits{MaxDepth: 100}); err != nil {
+		t.Fatalf("raised limit: %v", err)
+	}
+}
+
+func TestRedefiningClassDoesNotCountTowardsLimit(t *testing.T) {
+	input := "class A: a\nclass A: b\n\nA(1)"
+	dec := NewDecoder(strings.NewReader(input))
+	dec.SetLimits(Limits{MaxClasses: 1})
+	dec.AllowClassShadowing()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+}
+
+func TestErrorSentinels(t *testing.T) {
+	tests := []struct {
+		input  string
+		limits Limits
+		want   error
+	}{
+		{`[1,2,3]`, Limits{MaxInputBytes: 4}, ErrTooLarge},
+		{`[[[1]]]`, Limits{MaxDepth: 2}, ErrTooDeep},
+		{`[1,2,3]`, Limits{MaxTokens: 3}, ErrTooManyTokens},
+		{"class A: a\n\nB(1)", Limits{}, ErrUndefinedClass},
+		{"class A: a,b\n\nA(1)", Limits{}, ErrArgCountMismatch},
+		{"class A: a\n\nA()", Limits{}, ErrArgCountMismatch},
+	}
+	for _, tt := range tests {
+		err := decodeWithLimits(tt.input, tt.limits)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.input, err, tt.want)
+		}
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("%q: got %T, want *SyntaxError", tt.input, err)
+		}
+	}
+
+	if err := decodeWithLimits(`[1,}`, Limits{}); errors.Unwrap(err) != nil {
+		t.Errorf("plain syntax error unwraps to %v", errors.Unwrap(err))
+	}
+
+	var deep interface{} = 1
+	for range maxWalkDepth + 2 {
+		deep = []interface{}{deep}
+	}
+	if _, err := Marshal(deep); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("Marshal of deep value: got %v, want ErrTooDeep", err)
+	}
+}
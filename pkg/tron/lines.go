@@ -0,0 +1,157 @@
+package tron
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+)
+
+// encodeLine writes v as the next line of a TRONL stream, preceded by the
+// header if it is the first value.
+func (enc *Encoder) encodeLine(v interface{}) error {
+	e := enc.stream
+	first := e == nil
+	if first {
+		opts := enc.opts
+		opts.noPool = true
+		e = newEncoder(opts)
+	}
+	e.resetElement()
+	if err := e.serialize(reflect.ValueOf(v), 0); err != nil {
+		return err
+	}
+
+	out := e.out[:0]
+	if first {
+		// The header must serve every line, so any shape that could recur
+		// gets a class, not just those that recur within the first value.
+		for _, s := range e.schemaOrder {
+			if len(s.keys) > 1 && len(e.classes) < maxClasses {
+				s.class = generateClassName(len(e.classes))
+				e.addClass(ClassDef{Name: s.class, Keys: s.keys, Defaults: s.defaults})
+			}
+		}
+		out = appendHeader(out, e.classes, e.escapes())
+		enc.stream = e
+	}
+	out = e.render(out, 0, len(e.buf), 0, len(e.objects))
+	out = append(out, '\n')
+	e.out = out
+
+	_, err := enc.w.Write(out)
+	return err
+}
+
+// lineDecoder reads a stream of newline-terminated records, each a value or
+// a class definition, as written in line mode and by SessionEncoder.
+type lineDecoder struct {
+	s       streamScanner
+	classes map[string]*classDef // classes defined so far
+	header  bool                 // classes may only be defined before the first value
+	started bool                 // a value has been read
+
+	// documents is set by Decoder.UseMultipleDocuments: a root object
+	// without braces spans consecutive records, the first of which that is
+	// not a member is kept in pending for the next call.
+	documents bool
+	pending   []byte
+}
+
+// decode reads records until one holds a value and decodes it into v.
+func (ld *lineDecoder) decode(v interface{}, opts decodeOptions) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &InvalidUnmarshalError{Type: reflect.TypeOf(v)}
+	}
+
+	ld.s.opts = opts
+	for {
+		record, err := ld.nextRecord()
+		if err != nil {
+			return err
+		}
+		p, err := newDocumentParser(record, opts)
+		if err != nil {
+			return err
+		}
+		p.classes = ld.classes
+		p.skipNewlines()
+
+		if p.current().Type != TokenClass {
+			ld.started = true
+			if ld.documents && startsMember(p) {
+				p.release()
+				if p, err = ld.joinMembers(record, opts); err != nil {
+					return err
+				}
+			}
+			d := &decoder{decodeOptions: opts}
+			err := d.decodeDocument(p, rv.Elem())
+			p.release()
+			return err
+		}
+		if ld.header && ld.started {
+			err = p.syntaxError("class definition after the first value")
+		} else if err = p.parseHeader(); err == nil && p.current().Type != TokenEOF {
+			err = p.syntaxError("expected newline after class definition")
+		}
+		p.release()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// nextRecord returns the record read ahead, if any, or the next one.
+func (ld *lineDecoder) nextRecord() ([]byte, error) {
+	if record := ld.pending; record != nil {
+		ld.pending = nil
+		return record, nil
+	}
+	return ld.s.nextRecord()
+}
+
+// joinMembers reads the records following record, the first member of a
+// root object without braces, while they are members too, and returns a
+// parser for the whole object. Limits.MaxInputBytes applies to the object as
+// it grows, not just to each record.
+func (ld *lineDecoder) joinMembers(record []byte, opts decodeOptions) (*parser, error) {
+	limit := opts.limits.withDefaults().MaxInputBytes
+	doc := bytes.Clone(record)
+	for {
+		next, err := ld.s.nextRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		p, err := newDocumentParser(next, opts)
+		member := err == nil && startsMember(p)
+		if err == nil {
+			p.release()
+		}
+		if !member {
+			ld.pending = bytes.Clone(next)
+			break
+		}
+		if len(doc)+1+len(next) > limit {
+			return nil, &SyntaxError{msg: "input too large", err: ErrTooLarge}
+		}
+		doc = append(append(doc, '\n'), next...)
+	}
+	p, err := newDocumentParser(doc, opts)
+	if err != nil {
+		return nil, err
+	}
+	p.classes = ld.classes
+	p.skipNewlines()
+	return p, nil
+}
+
+// startsMember reports whether p is at a key followed by a colon, as a
+// member of a root object without braces is.
+func startsMember(p *parser) bool {
+	p.skipNewlines()
+	return (p.current().Type == TokenIdentifier || p.current().Type == TokenString) && p.peek(1).Type == TokenColon
+}
@@ -0,0 +1,95 @@
+package tron
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+type lineRecord struct {
+	Level string `json:"level"`
+	Msg   string `json:"msg"`
+}
+
+func TestEncoderLineMode(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetLineMode(true)
+	for _, v := range []interface{}{
+		lineRecord{"info", "started"},
+		lineRecord{"warn", "slow\nrequest"},
+		map[string]int{"n": 1},
+		[]lineRecord{{"info", "done"}},
+	} {
+		if err := enc.Encode(v); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+	}
+
+	want := "class A: level,msg\n\n" +
+		"A(\"info\",\"started\")\n" +
+		"A(\"warn\",\"slow\\nrequest\")\n" +
+		"{\"n\":1}\n" +
+		"[A(\"info\",\"done\")]\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestDecoderLineMode(t *testing.T) {
+	input := "# log\nclass A: level,msg\n\nA(\"info\",\"a\")\n\n{\"level\":\"warn\",\"msg\":\"b\"}\nA(\"error\",\n  \"c\") # wrapped\n"
+	dec := NewDecoder(strings.NewReader(input))
+	dec.UseLineMode()
+
+	want := []lineRecord{{"info", "a"}, {"warn", "b"}, {"error", "c"}}
+	for i := range want {
+		var got lineRecord
+		if err := dec.Decode(&got); err != nil {
+			t.Fatalf("Decode %d: %v", i, err)
+		}
+		if got != want[i] {
+			t.Fatalf("line %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	var extra lineRecord
+	if err := dec.Decode(&extra); err != io.EOF {
+		t.Fatalf("Decode after end = %v, want io.EOF", err)
+	}
+}
+
+func TestLineModeRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetLineMode(true)
+	records := []lineRecord{{"info", "x"}, {"debug", ""}, {"info", "y"}}
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+	}
+
+	dec := NewDecoder(&buf)
+	dec.UseLineMode()
+	for i, want := range records {
+		got, err := DecodeAs[lineRecord](dec)
+		if err != nil {
+			t.Fatalf("Decode %d: %v", i, err)
+		}
+		if got != want {
+			t.Fatalf("record %d = %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestDecoderLineModeRejectsLateClass(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("1\nclass A: a,b\nA(1,2)\n"))
+	dec.UseLineMode()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if err := dec.Decode(&v); err == nil || !strings.Contains(err.Error(), "after the first value") {
+		t.Fatalf("got %v, want late class error", err)
+	}
+}
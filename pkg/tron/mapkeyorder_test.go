@@ -0,0 +1,45 @@
+package tron
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncoderSetMapKeyOrder(t *testing.T) {
+	ints := map[int]string{10: "a", 2: "b", -1: "c", 1: "d"}
+	uints := map[uint8]bool{200: true, 30: false}
+	strs := map[string]int{"10": 1, "2": 2}
+
+	for _, tc := range []struct {
+		order MapKeyOrder
+		want  string
+	}{
+		{MapKeysSorted, `[{"-1":"c","1":"d","10":"a","2":"b"},{"200":true,"30":false},{"10":1,"2":2}]` + "\n"},
+		{MapKeysNumeric, `[{"-1":"c","1":"d","2":"b","10":"a"},{"30":false,"200":true},{"10":1,"2":2}]` + "\n"},
+	} {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetMapKeyOrder(tc.order)
+		if err := enc.Encode([]any{ints, uints, strs}); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tc.want {
+			t.Errorf("order %d:\ngot  %s\nwant %s", tc.order, buf.String(), tc.want)
+		}
+	}
+
+	// Unsorted output holds the same entries.
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetMapKeyOrder(MapKeysUnsorted)
+	if err := enc.Encode(ints); err != nil {
+		t.Fatal(err)
+	}
+	var back map[int]string
+	if err := Unmarshal(buf.Bytes(), &back); err != nil {
+		t.Fatal(err)
+	}
+	if len(back) != len(ints) || back[10] != "a" || back[-1] != "c" {
+		t.Errorf("unsorted round trip: got %v", back)
+	}
+}
@@ -39,6 +39,14 @@ func TestMarshal_ByteSliceBranch(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Marshal: %v", err)
 	}
+	if string(b) != "\"YWJj\"" {
+		t.Fatalf("unexpected: %q", string(b))
+	}
+
+	b, err = marshal([]byte("abc"), encodeOptions{rawBytes: true})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
 	if string(b) != "\"abc\"" {
 		t.Fatalf("unexpected: %q", string(b))
 	}
@@ -1,427 +1,1042 @@
 package tron
 
 import (
+	"bytes"
+	"cmp"
 	"encoding"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
 	"reflect"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
+	"unicode/utf8"
+)
+
+// Helper variables for marshaler interface types.
+var (
+	marshalerType       = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	binaryMarshalerType = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
 )
 
 // ClassDef represents a class definition with name and property keys.
 type ClassDef struct {
 	Name string
 	Keys []string
+
+	// Defaults holds the TRON text of the default value of each key, as in
+	// "class P: x,y,z=0", or "" for a key without one. It is nil if no key
+	// has a default.
+	Defaults []string
+
+	// Extends names the class whose keys, and their defaults, begin Keys,
+	// as in "class B extends A: z", or is "" if the class extends none.
+	// Keys always lists every key, inherited or not.
+	Extends string
+}
+
+// encodeOptions configures an encoder. The zero value gives the defaults
+// used by Marshal.
+type encodeOptions struct {
+	prefix        string
+	indent        string
+	timeLayout    string          // layout for time.Time values; RFC 3339 when empty
+	rawBytes      bool            // encode []byte as a plain string instead of base64
+	strictUTF8    bool            // fail on invalid UTF-8 instead of replacing it
+	nonFinite     NonFinitePolicy // handling of NaN and infinite floats
+	noPool        bool            // allocate fresh encoder state instead of using encoderPool
+	optionalProps bool            // let structs that omit fields share a class with optional properties
+	inheritance   bool            // define classes as extending earlier ones that share a key prefix
+	noEscapeHTML  bool            // leave '<', '>' and '&' unescaped in strings
+	asciiOnly     bool            // escape every non-ASCII rune
+	mapKeyOrder   MapKeyOrder     // order of the entries of maps
+	nilAsEmpty    bool            // encode nil slices and maps as [] and {}, nil []byte as ""
+	implicitRoot  bool            // write a root object as key: value lines without braces
+	parallelMin   int             // encode root slices of this many elements concurrently; 0 never
+
+	fieldCmp func(a, b string) int // order of struct keys; declaration order when nil
+
+	tokenCounter TokenCounter // when set, classes are only defined if they save tokens
 }
 
-// marshal is the internal implementation of Marshal and MarshalIndent.
-func marshal(v interface{}, prefix, indent string) ([]byte, error) {
+// marshal is the internal implementation of Marshal, MarshalIndent and Encoder.Encode.
+func marshal(v interface{}, opts encodeOptions) ([]byte, error) {
+	return appendMarshal(nil, v, opts)
+}
+
+// appendMarshal appends the TRON encoding of v to dst. On error dst is
+// returned unchanged.
+func appendMarshal(dst []byte, v interface{}, opts encodeOptions) ([]byte, error) {
 	if v == nil {
-		return []byte("null"), nil
+		return append(dst, "null"...), nil
 	}
 
-	// Create encoder state
-	e := &encoder{
-		classes:       make([]ClassDef, 0),
-		schemaToClass: make(map[string]ClassDef),
-		schemaCounts:  make(map[string]int),
-		visited:       make(map[uintptr]bool),
-		prefix:        prefix,
-		indent:        indent,
+	e := newEncoder(opts)
+	defer e.release()
+	out, err := e.marshal(dst, v)
+	if err != nil || opts.prefix == "" && opts.indent == "" {
+		return out, err
+	}
+	indented := appendFormatted(nil, out[len(dst):], opts.prefix, opts.indent, true)
+	return append(out[:len(dst)], indented...), nil
+}
+
+// marshalTo writes the TRON encoding of v to w. The header is written once
+// v has been serialized and its classes are known; the data follows in
+// pieces as it is rendered, so the complete document is never held.
+func marshalTo(w io.Writer, v interface{}, opts encodeOptions) error {
+	if v == nil {
+		_, err := io.WriteString(w, "null")
+		return err
 	}
 
-	// Phase 1: Discover classes through DFS
-	if err := e.discoverClasses(reflect.ValueOf(v), 0); err != nil {
-		return nil, err
+	e := newEncoder(opts)
+	defer e.release()
+	if err := e.serialize(reflect.ValueOf(v), 0); err != nil {
+		return err
+	}
+	e.w = w
+	e.out = e.finish(e.out[:0])
+	if e.werr == nil && len(e.out) > 0 {
+		_, e.werr = w.Write(e.out)
 	}
+	return e.werr
+}
 
-	// Phase 2: Filter classes based on property count and occurrence
-	e.filterClasses()
+// marshal appends the header and data for v to dst.
+func (e *encoder) marshal(dst []byte, v interface{}) ([]byte, error) {
+	if rv := reflect.ValueOf(v); e.parallelizes(rv) {
+		return e.marshalParallel(dst, rv)
+	}
+	// Phase 1: Serialize values in a single walk, counting struct schemas
+	if err := e.serialize(reflect.ValueOf(v), 0); err != nil {
+		return dst, err
+	}
+	return e.finish(dst), nil
+}
 
-	// Phase 3: Generate output
-	var output strings.Builder
+// finish appends the header and the framed contents of e.buf to dst, once
+// everything has been serialized.
+func (e *encoder) finish(dst []byte) []byte {
+	// Phase 2: Assign classes based on property count and occurrence
+	e.assignClasses()
+
+	// Phase 3: Generate output, sized for all of it unless it is flushed to
+	// a writer as it goes
+	output := dst
+	if e.w == nil {
+		output = slices.Grow(dst, e.headerSize()+e.renderSize())
+	}
 
 	// Generate header (class definitions)
-	for _, cls := range e.filteredClasses {
-		output.WriteString("class ")
-		output.WriteString(cls.Name)
-		output.WriteString(": ")
+	output = appendHeader(output, e.classes, e.escapes())
 
-		for i, key := range cls.Keys {
-			if i > 0 {
-				output.WriteString(",")
-			}
-			if isValidIdentifier(key) {
-				output.WriteString(key)
-			} else {
-				// Quote keys with special characters
-				quoted, _ := json.Marshal(key)
-				output.Write(quoted)
-			}
-		}
-		output.WriteString("\n")
+	// Generate data, framing each struct as a class instantiation or object
+	if !e.implicitRoot {
+		return e.render(output, 0, len(e.buf), 0, len(e.objects))
+	}
+	var root []byte
+	if len(e.objects) > 0 && e.objects[0].start == 0 && e.objects[0].end == len(e.buf) {
+		// A root struct written without braces must spell out its keys,
+		// even if its shape has a class.
+		root = e.renderLiteral(nil, 0)
+	} else {
+		root = e.render(nil, 0, len(e.buf), 0, len(e.objects))
 	}
+	return appendImplicitRoot(output, root)
+}
 
-	if len(e.filteredClasses) > 0 {
-		output.WriteString("\n")
+// encoder holds the state for marshaling.
+//
+// Marshal walks the value once. Scalars, arrays and maps are written to buf as
+// they are met, but a struct cannot be framed until every struct has been
+// counted, since its schema only becomes a class if it occurs twice. So for
+// structs only the member values are written, and an entry in objects records
+// where they are; render adds the framing once classes are known.
+type encoder struct {
+	encodeOptions
+
+	buf         []byte
+	objects     []deferredObject   // structs in buf, in order of their start offset
+	schemas     map[string]*schema // schema signature -> schema
+	schemaOrder []*schema          // schemas in order of first appearance
+	classes     []ClassDef         // classes emitted in the header
+	given       int                // leading classes given in advance, as by Document.Set
+	spans       [][2]int           // value spans of all deferred objects
+	out         []byte             // output scratch reused by Encoder.Encode
+	key         []byte             // scratch for schemaFor
+	order       []int              // scratch for schemaFor
+
+	w    io.Writer // when set, render flushes its output here as it grows; see emit
+	werr error     // the first error writing to w
+
+	seen map[cycleKey]struct{} // pointers, maps and slices being serialized; see enter
+}
+
+// encoderPool recycles encoder state, buffers included, between calls.
+var encoderPool = sync.Pool{
+	New: func() interface{} {
+		return &encoder{schemas: make(map[string]*schema)}
+	},
+}
+
+// maxPooledBuffer caps the buffer size returned to encoderPool so that one
+// very large document does not stay pinned in memory.
+const maxPooledBuffer = 1 << 20
+
+// newEncoder returns encoder state for opts, from encoderPool unless pooling
+// is disabled.
+func newEncoder(opts encodeOptions) *encoder {
+	if opts.noPool {
+		return &encoder{encodeOptions: opts, schemas: make(map[string]*schema)}
 	}
+	e := encoderPool.Get().(*encoder)
+	e.encodeOptions = opts
+	return e
+}
 
-	// Generate data
-	data, err := e.serialize(reflect.ValueOf(v), make(map[uintptr]bool), 0)
-	if err != nil {
-		return nil, err
+// release resets e and returns it to encoderPool.
+func (e *encoder) release() {
+	if e.noPool || cap(e.buf) > maxPooledBuffer || cap(e.out) > maxPooledBuffer {
+		return
 	}
-	output.WriteString(data)
+	e.encodeOptions = encodeOptions{}
+	e.buf = e.buf[:0]
+	e.out = e.out[:0]
+	clear(e.objects)
+	e.objects = e.objects[:0]
+	clear(e.schemas)
+	clear(e.schemaOrder)
+	e.schemaOrder = e.schemaOrder[:0]
+	clear(e.classes)
+	e.classes = e.classes[:0]
+	e.given = 0
+	e.spans = e.spans[:0]
+	e.w, e.werr = nil, nil
+	clear(e.seen)
+	encoderPool.Put(e)
+}
 
-	return []byte(output.String()), nil
+// schema is a set of struct keys seen during serialization.
+type schema struct {
+	keys     []string // keys in the order they were first seen
+	defaults []string // default value text of each key, or nil; see ClassDef
+	count    int
+	class    string // class name, or "" to encode as an object
 }
 
-// encoder holds the state for marshaling.
-type encoder struct {
-	classes           []ClassDef
-	schemaToClass     map[string]ClassDef
-	schemaCounts      map[string]int
-	filteredClasses   []ClassDef
-	filteredSchemaMap map[string]ClassDef
-	visited           map[uintptr]bool
-	prefix            string
-	indent            string
-	classCounter      int
+// deferredObject records a struct whose member values are in buf but whose
+// framing is chosen by render. spans[values+i] is the span of the value for
+// keys[i].
+type deferredObject struct {
+	schema     *schema
+	keys       []string
+	values     int
+	start, end int
+	next       int // index in objects just past this struct's nested structs
+}
 
-	structCache sync.Map // map[reflect.Type]*structTypeInfo
+// schemaKey identifies a set of keys and their defaults regardless of their
+// order. Objects whose keys have different defaults need different classes.
+func schemaKey(keys, defaults []string) string {
+	return string(appendSchemaKey(nil, nil, keys, defaults))
 }
 
-// discoverClasses performs DFS to discover all object schemas.
-func (e *encoder) discoverClasses(v reflect.Value, depth int) error {
-	if depth > maxWalkDepth {
-		return fmt.Errorf("maximum walk depth exceeded")
+// appendSchemaKey appends the schemaKey of keys and defaults to dst: the
+// keys in sorted order, each followed by its default if there are defaults.
+// order is scratch space for the sort.
+func appendSchemaKey(dst []byte, order []int, keys, defaults []string) []byte {
+	order = order[:0]
+	for i := range keys {
+		order = append(order, i)
 	}
-	if !v.IsValid() {
-		return nil
+	slices.SortFunc(order, func(i, j int) int { return strings.Compare(keys[i], keys[j]) })
+	for n, i := range order {
+		if n > 0 {
+			dst = append(dst, 0)
+		}
+		dst = append(dst, keys[i]...)
+		if defaults != nil {
+			dst = append(dst, 1)
+			dst = append(dst, defaults[i]...)
+		}
 	}
+	return dst
+}
 
-	// Handle pointers and interfaces
-	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
-		if v.IsNil() {
-			return nil
+// appendHeader appends the class definitions for classes to out, followed by
+// the blank line separating them from the data.
+func appendHeader(out []byte, classes []ClassDef, esc escapeFlags) []byte {
+	for i, cls := range classes {
+		out = appendClassDef(out, cls, classes[:i], esc)
+	}
+
+	if len(classes) > 0 {
+		out = append(out, '\n')
+	}
+	return out
+}
+
+// appendClassDef appends the definition line for cls to out, quoting keys
+// with the escaping esc. earlier holds the classes already defined, among
+// which cls.Extends is looked up.
+func appendClassDef(out []byte, cls ClassDef, earlier []ClassDef, esc escapeFlags) []byte {
+	out = append(out, "class "...)
+	out = append(out, cls.Name...)
+	inherited := 0
+	if cls.Extends != "" {
+		if i := slices.IndexFunc(earlier, func(c ClassDef) bool { return c.Name == cls.Extends }); i >= 0 {
+			out = append(out, " extends "...)
+			out = append(out, cls.Extends...)
+			inherited = len(earlier[i].Keys)
 		}
-		v = v.Elem()
 	}
+	out = append(out, ": "...)
 
-	// Check for cycles
-	if v.CanAddr() {
-		addr := v.UnsafeAddr()
-		if e.visited[addr] {
-			return nil
+	for i, key := range cls.Keys[inherited:] {
+		i += inherited
+		if i > inherited {
+			out = append(out, ',')
+		}
+		if isValidIdentifier(key) && (esc&escapeNonASCII == 0 || utf8.RuneCountInString(key) == len(key)) {
+			out = append(out, key...)
+		} else {
+			// Quote keys with special characters
+			out = appendQuotedWith(out, key, esc)
+		}
+		if cls.Defaults != nil {
+			out = appendKeyDefault(out, cls.Defaults[i])
 		}
-		e.visited[addr] = true
-		defer func() { delete(e.visited, addr) }()
 	}
+	return append(out, '\n')
+}
 
-	switch v.Kind() {
-	case reflect.Array, reflect.Slice:
-		for i := 0; i < v.Len(); i++ {
-			if err := e.discoverClasses(v.Index(i), depth+1); err != nil {
-				return err
+// appendKeyDefault appends the marker for a class property with default
+// value text def to out: "=def", or "?" for an optional property, whose
+// default is null.
+func appendKeyDefault(out []byte, def string) []byte {
+	switch def {
+	case "":
+		return out
+	case "null":
+		return append(out, '?')
+	default:
+		out = append(out, '=')
+		return append(out, def...)
+	}
+}
+
+// schemaFor returns the schema for an object with the given keys and key
+// defaults, counting one more occurrence of it.
+func (e *encoder) schemaFor(keys, defaults []string) *schema {
+	// The key is built in scratch space, and only copied into a string for
+	// a schema not seen before.
+	e.order = slices.Grow(e.order[:0], len(keys))
+	e.key = appendSchemaKey(e.key[:0], e.order, keys, defaults)
+	sc, exists := e.schemas[string(e.key)]
+	if !exists {
+		sc = &schema{keys: keys, defaults: defaults}
+		e.schemas[string(e.key)] = sc
+		e.schemaOrder = append(e.schemaOrder, sc)
+	}
+	sc.count++
+	return sc
+}
+
+// assignClasses names each schema with 2+ properties that occurs 2+ times,
+// in order of first appearance.
+func (e *encoder) assignClasses() {
+	for _, s := range e.schemaOrder {
+		if i := slices.IndexFunc(e.classes[:e.given], s.matches); i >= 0 {
+			s.class = e.classes[i].Name
+			continue
+		}
+		if len(s.keys) > 1 && s.count > 1 {
+			name := e.newClassName()
+			if e.tokenCounter != nil && !e.classSavesTokens(s, name) {
+				continue
 			}
+			s.class = name
+			e.addClass(ClassDef{Name: s.class, Keys: s.keys, Defaults: s.defaults})
 		}
+	}
+}
 
-	case reflect.Map:
-		for _, key := range v.MapKeys() {
-			if err := e.discoverClasses(v.MapIndex(key), depth+1); err != nil {
-				return err
+// matches reports whether cls can frame the objects of s: it has the same
+// keys, with the same defaults.
+func (s *schema) matches(cls ClassDef) bool {
+	n := len(s.keys)
+	return slices.Equal(cls.Keys, s.keys) && slices.Equal(defaultsPrefix(cls.Defaults, n), defaultsPrefix(s.defaults, n))
+}
+
+// newClassName returns the generated name for the next class, skipping
+// names already taken when classes were given in advance.
+func (e *encoder) newClassName() string {
+	n := len(e.classes)
+	if e.given == 0 {
+		return generateClassName(n)
+	}
+	for ; ; n++ {
+		name := generateClassName(n)
+		if !slices.ContainsFunc(e.classes, func(c ClassDef) bool { return c.Name == name }) {
+			return name
+		}
+	}
+}
+
+// addClass appends cls to the classes of the output. With class inheritance
+// on, cls extends the earlier class with the most keys that begin its own,
+// if any do.
+func (e *encoder) addClass(cls ClassDef) {
+	if e.inheritance {
+		best := 0
+		for _, c := range e.classes {
+			if n := len(c.Keys); n > best && n < len(cls.Keys) && slices.Equal(c.Keys, cls.Keys[:n]) &&
+				slices.Equal(defaultsPrefix(c.Defaults, n), defaultsPrefix(cls.Defaults, n)) {
+				best = n
+				cls.Extends = c.Name
 			}
 		}
+	}
+	e.classes = append(e.classes, cls)
+}
 
-	case reflect.Struct:
-		// Get field information
-		keys, err := e.getStructKeys(v)
-		if err != nil {
-			return err
+// defaultsPrefix returns the defaults of the first n keys of a class whose
+// key defaults are defaults, with "" for each key without one.
+func defaultsPrefix(defaults []string, n int) []string {
+	if defaults == nil {
+		return make([]string, n)
+	}
+	return defaults[:n]
+}
+
+// classSavesTokens reports whether defining class name for s costs fewer
+// tokens than writing its instances as object literals. Member values are the
+// same either way, so only the framing is counted: the header line plus one
+// A(,) per instance against one {"k":,"k":} per instance.
+func (e *encoder) classSavesTokens(s *schema, name string) bool {
+	var header, instance, object []byte
+
+	header = append(header, "class "...)
+	header = append(header, name...)
+	header = append(header, ": "...)
+	instance = append(instance, name...)
+	instance = append(instance, '(')
+	object = append(object, '{')
+	for i, key := range s.keys {
+		if i > 0 {
+			header = append(header, ',')
+			instance = append(instance, ',')
+			object = append(object, ',')
 		}
+		if isValidIdentifier(key) {
+			header = append(header, key...)
+		} else {
+			header = e.quote(header, key)
+		}
+		if s.defaults != nil {
+			header = appendKeyDefault(header, s.defaults[i])
+		}
+		object = e.quote(object, key)
+		object = append(object, ':')
+	}
+	header = append(header, '\n')
+	if len(e.classes) == 0 {
+		header = append(header, '\n')
+	}
+	instance = append(instance, ')')
+	object = append(object, '}')
 
-		if len(keys) > 0 {
-			// Create schema signature (sorted keys for consistency)
-			sortedKeys := make([]string, len(keys))
-			copy(sortedKeys, keys)
-			sort.Strings(sortedKeys)
-			schemaSignature := strings.Join(sortedKeys, ",")
+	tc := e.tokenCounter
+	return tc.CountTokens(header)+s.count*tc.CountTokens(instance) < s.count*tc.CountTokens(object)
+}
 
-			// Track occurrence count
-			e.schemaCounts[schemaSignature]++
+// render appends buf[from:to] to out, framing the structs among
+// objects[lo:hi] that start inside the range.
+func (e *encoder) render(out []byte, from, to, lo, hi int) []byte {
+	i := lo + sort.Search(hi-lo, func(i int) bool { return e.objects[lo+i].start >= from })
+	pos := from
+	for i < hi && e.objects[i].start < to {
+		obj := &e.objects[i]
+		out = e.emit(out, e.buf[pos:obj.start])
+		out = e.renderObject(out, i)
+		pos = obj.end
+		i = obj.next
+	}
+	return e.emit(out, e.buf[pos:to])
+}
 
-			if _, exists := e.schemaToClass[schemaSignature]; !exists {
-				className := generateClassName(e.classCounter)
-				e.classCounter++
-				classDef := ClassDef{Name: className, Keys: keys}
-				e.classes = append(e.classes, classDef)
-				e.schemaToClass[schemaSignature] = classDef
-			}
+// headerSize estimates the length of the header appendHeader writes for
+// e.classes, so that the output can be allocated once.
+func (e *encoder) headerSize() int {
+	if len(e.classes) == 0 {
+		return 0
+	}
+	n := 1
+	for _, cls := range e.classes {
+		n += len("class : \n") + len(cls.Name) + len(cls.Extends)
+		for _, key := range cls.Keys {
+			n += len(key) + 1
+		}
+	}
+	return n
+}
 
-			// Recursively visit struct fields
-			for _, key := range keys {
-				fieldValue := e.getStructFieldValue(v, key)
-				if err := e.discoverClasses(fieldValue, depth+1); err != nil {
-					return err
-				}
-			}
+// renderSize estimates the length of the data render writes for all of
+// e.buf: the serialized values plus the framing of each struct, as a class
+// instantiation or an object literal with quoted keys. Escapes in keys are
+// not counted, so the estimate can fall short by a little.
+func (e *encoder) renderSize() int {
+	n := len(e.buf)
+	for i := range e.objects {
+		obj := &e.objects[i]
+		if class := obj.schema.class; class != "" {
+			n += len(class) + 2 + len(obj.schema.keys)
+			continue
+		}
+		n += 2
+		for _, key := range obj.keys {
+			n += len(key) + 4
 		}
 	}
+	return n
+}
 
-	return nil
+// flushSize is the amount of rendered output MarshalTo collects before
+// writing it out.
+const flushSize = 32 << 10
+
+// emit appends data to out. When rendering to a writer and out would grow
+// past flushSize, it writes out and data to e.w instead and returns out
+// emptied, so that large runs of serialized values are written without being
+// copied.
+func (e *encoder) emit(out, data []byte) []byte {
+	if e.w == nil || len(out)+len(data) < flushSize {
+		return append(out, data...)
+	}
+	if e.werr == nil {
+		_, e.werr = e.w.Write(out)
+	}
+	if e.werr == nil && len(data) > 0 {
+		_, e.werr = e.w.Write(data)
+	}
+	return out[:0]
 }
 
-// filterClasses filters classes based on property count and occurrence.
-func (e *encoder) filterClasses() {
-	e.filteredClasses = make([]ClassDef, 0)
-	e.filteredSchemaMap = make(map[string]ClassDef)
-	filteredClassCounter := 0
+// renderObject appends objects[i] as a class instantiation if its schema has
+// a class, and as an object literal otherwise.
+func (e *encoder) renderObject(out []byte, i int) []byte {
+	obj := &e.objects[i]
+	value := func(out []byte, k int) []byte {
+		span := e.spans[obj.values+k]
+		return e.render(out, span[0], span[1], i+1, obj.next)
+	}
+
+	if class := obj.schema.class; class != "" {
+		// Use class instantiation, with arguments in the class's key order,
+		// leaving out trailing arguments equal to their default. Optional
+		// properties the object lacks are given as null.
+		keys := obj.schema.keys
+		index := func(n int) int {
+			if n < len(obj.keys) && obj.keys[n] == keys[n] {
+				return n
+			}
+			return indexOf(obj.keys, keys[n])
+		}
+		isDefault := func(n int, def string) bool {
+			k := index(n)
+			if k < 0 {
+				return def == "null"
+			}
+			span := e.spans[obj.values+k]
+			return string(e.buf[span[0]:span[1]]) == def
+		}
+		if defaults := obj.schema.defaults; defaults != nil {
+			for len(keys) > 0 && defaults[len(keys)-1] != "" && isDefault(len(keys)-1, defaults[len(keys)-1]) {
+				keys = keys[:len(keys)-1]
+			}
+		}
+		out = append(out, class...)
+		out = append(out, '(')
+		for n := range keys {
+			if n > 0 {
+				out = append(out, ',')
+			}
+			if k := index(n); k >= 0 {
+				out = value(out, k)
+			} else {
+				out = append(out, "null"...)
+			}
+		}
+		return append(out, ')')
+	}
+	return e.renderLiteral(out, i)
+}
 
-	for schemaSignature, classDef := range e.schemaToClass {
-		propertyCount := len(classDef.Keys)
-		occurrenceCount := e.schemaCounts[schemaSignature]
+// renderLiteral appends objects[i] as an object literal.
+func (e *encoder) renderLiteral(out []byte, i int) []byte {
+	obj := &e.objects[i]
+	value := func(out []byte, k int) []byte {
+		span := e.spans[obj.values+k]
+		return e.render(out, span[0], span[1], i+1, obj.next)
+	}
+	out = append(out, '{')
+	for k, key := range obj.keys {
+		if k > 0 {
+			out = append(out, ',')
+		}
+		out = e.quote(out, key)
+		out = append(out, ':')
+		out = value(out, k)
+	}
+	return append(out, '}')
+}
 
-		// Define class if: 2+ properties AND 2+ occurrences
-		shouldDefineClass := propertyCount > 1 && occurrenceCount > 1
-		if shouldDefineClass {
-			newClassName := generateClassName(filteredClassCounter)
-			filteredClassCounter++
-			newClassDef := ClassDef{Name: newClassName, Keys: classDef.Keys}
-			e.filteredClasses = append(e.filteredClasses, newClassDef)
-			e.filteredSchemaMap[schemaSignature] = newClassDef
+// indexOf returns the index of s in list, or -1.
+func indexOf(list []string, s string) int {
+	for i, item := range list {
+		if item == s {
+			return i
 		}
 	}
+	return -1
+}
+
+// cycleCheckDepth is the depth from which serialize tracks the pointers,
+// maps and slices it is inside of. Values nested less deeply are not
+// tracked, which spares the common, shallow case the cost; a cycle is still
+// caught once it has been followed that far.
+const cycleCheckDepth = 100
+
+// cycleKey identifies a pointer, map or slice for cycle detection. The type
+// tells a pointer to a struct from one to its first field, and the length
+// a slice from a shorter slice of the same array.
+type cycleKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// enter records that serialize is inside of v, a non-nil pointer, map or
+// slice, and returns the key to pass to leave once v has been written. It
+// returns an error if serialize is inside of v already: v refers to itself.
+func (e *encoder) enter(v reflect.Value) (cycleKey, error) {
+	key := cycleKey{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	if _, ok := e.seen[key]; ok {
+		return key, &UnsupportedValueError{Value: v, Str: "converting circular structure to TRON"}
+	}
+	if e.seen == nil {
+		e.seen = make(map[cycleKey]struct{})
+	}
+	e.seen[key] = struct{}{}
+	return key, nil
 }
 
-// serialize converts a Go value to TRON format string.
-func (e *encoder) serialize(v reflect.Value, stack map[uintptr]bool, depth int) (string, error) {
+// leave undoes enter once the value of key has been written.
+func (e *encoder) leave(key cycleKey) {
+	delete(e.seen, key)
+}
+
+// serialize appends the TRON encoding of v to e.buf, leaving structs to be
+// framed by render.
+func (e *encoder) serialize(v reflect.Value, depth int) error {
 	if depth > maxWalkDepth {
-		return "", fmt.Errorf("maximum walk depth exceeded")
+		return fmt.Errorf("%w while encoding", ErrTooDeep)
 	}
 	if !v.IsValid() {
-		return "null", nil
+		e.buf = append(e.buf, "null"...)
+		return nil
 	}
 
-	marshalerType := reflect.TypeOf((*Marshaler)(nil)).Elem()
-	textMarshalerType := reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
-
 	// Handle interfaces early so we honor marshalers stored inside interface{}.
 	for v.Kind() == reflect.Interface {
 		if v.IsNil() {
-			return "null", nil
+			e.buf = append(e.buf, "null"...)
+			return nil
 		}
 		v = v.Elem()
 	}
 
-	// Prefer custom marshalers (including pointer receivers via Addr()).
-	if v.IsValid() {
-		if v.Type().Implements(marshalerType) {
-			marshaler := v.Interface().(Marshaler)
-			data, err := marshaler.MarshalTRON()
-			if err != nil {
-				return "", err
-			}
-			return string(data), nil
-		}
-		if v.CanAddr() && v.Addr().Type().Implements(marshalerType) {
-			marshaler := v.Addr().Interface().(Marshaler)
-			data, err := marshaler.MarshalTRON()
-			if err != nil {
-				return "", err
-			}
-			return string(data), nil
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		e.buf = append(e.buf, "null"...)
+		return nil
+	}
+
+	// Pointers, maps and slices are the values through which Go data can
+	// refer to itself. Ones nested deeply enough are tracked until they have
+	// been written, so that a cycle is reported rather than followed.
+	if depth >= cycleCheckDepth && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Map && !v.IsNil() || v.Kind() == reflect.Slice && v.Len() > 0) {
+		key, err := e.enter(v)
+		if err != nil {
+			return err
 		}
+		defer e.leave(key)
+	}
 
-		if v.Type().Implements(textMarshalerType) {
-			marshaler := v.Interface().(encoding.TextMarshaler)
-			text, err := marshaler.MarshalText()
-			if err != nil {
-				return "", err
-			}
-			quoted, _ := json.Marshal(string(text))
-			return string(quoted), nil
+	// time.Time is encoded natively so the layout can be configured.
+	if v.Type() == timeType || (v.Kind() == reflect.Ptr && v.Type().Elem() == timeType) {
+		e.buf = append(e.buf, e.serializeTime(reflect.Indirect(v).Interface().(time.Time))...)
+		return nil
+	}
+
+	// OrderedMap keeps its key order rather than being sorted like a map.
+	if v.Type() == orderedMapType {
+		m := v.Interface().(OrderedMap)
+		return e.serializeOrderedMap(&m, depth)
+	}
+	if v.Kind() == reflect.Ptr && v.Type().Elem() == orderedMapType {
+		return e.serializeOrderedMap(v.Interface().(*OrderedMap), depth)
+	}
+
+	// Prefer custom marshalers (including pointer receivers via Addr()).
+	if custom, ok, err := e.serializeCustom(v); ok {
+		if err != nil {
+			return err
 		}
-		if v.CanAddr() && v.Addr().Type().Implements(textMarshalerType) {
-			marshaler := v.Addr().Interface().(encoding.TextMarshaler)
-			text, err := marshaler.MarshalText()
-			if err != nil {
-				return "", err
-			}
-			quoted, _ := json.Marshal(string(text))
-			return string(quoted), nil
+		if e.asciiOnly {
+			e.buf = appendASCII(e.buf, custom)
+		} else {
+			e.buf = append(e.buf, custom...)
 		}
+		return nil
 	}
 
-	// Check for cycles in pointers BEFORE dereferencing
-	// Note: Only pointers can create cycles in Go value structures
 	if v.Kind() == reflect.Ptr {
-		if v.IsNil() {
-			return "null", nil
-		}
-		if v.CanAddr() {
-			addr := v.UnsafeAddr()
-			if stack[addr] {
-				return "", fmt.Errorf("converting circular structure to TRON")
-			}
-			stack[addr] = true
-			defer func() { delete(stack, addr) }()
-		}
 		v = v.Elem()
 	}
 
 	switch v.Kind() {
 	case reflect.Bool:
-		if v.Bool() {
-			return "true", nil
-		}
-		return "false", nil
+		e.buf = strconv.AppendBool(e.buf, v.Bool())
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return strconv.FormatInt(v.Int(), 10), nil
+		e.buf = strconv.AppendInt(e.buf, v.Int(), 10)
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return strconv.FormatUint(v.Uint(), 10), nil
+		e.buf = strconv.AppendUint(e.buf, v.Uint(), 10)
 
 	case reflect.Float32, reflect.Float64:
-		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return e.serializeNonFinite(v, f)
+		}
+		e.buf = strconv.AppendFloat(e.buf, f, 'g', -1, v.Type().Bits())
 
 	case reflect.String:
-		quoted, _ := json.Marshal(v.String())
-		return string(quoted), nil
+		if err := e.checkUTF8(v, v.String()); err != nil {
+			return err
+		}
+		e.buf = e.quote(e.buf, v.String())
 
 	case reflect.Array, reflect.Slice:
 		// Check for nil slice
-		if v.Kind() == reflect.Slice && v.IsNil() {
-			return "null", nil
+		if v.Kind() == reflect.Slice && v.IsNil() && !e.nilAsEmpty {
+			e.buf = append(e.buf, "null"...)
+			return nil
 		}
 
-		if v.Type().Elem().Kind() == reflect.Uint8 {
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
 			// Handle []byte as base64 string
 			bytes := v.Bytes()
-			quoted, _ := json.Marshal(string(bytes))
-			return string(quoted), nil
+			if e.rawBytes {
+				if err := e.checkUTF8(v, string(bytes)); err != nil {
+					return err
+				}
+				e.buf = e.quote(e.buf, string(bytes))
+				return nil
+			}
+			e.buf = append(e.buf, '"')
+			e.buf = base64.StdEncoding.AppendEncode(e.buf, bytes)
+			e.buf = append(e.buf, '"')
+			return nil
 		}
 
-		var items []string
+		if v.Kind() == reflect.Slice {
+			if ok, err := e.serializePrimitiveSlice(v); ok {
+				return err
+			}
+		}
+
+		e.buf = append(e.buf, '[')
 		for i := 0; i < v.Len(); i++ {
-			item, err := e.serialize(v.Index(i), stack, depth+1)
-			if err != nil {
-				return "", err
+			if i > 0 {
+				e.buf = append(e.buf, ',')
+			}
+			if err := e.serialize(v.Index(i), depth+1); err != nil {
+				return err
 			}
-			items = append(items, item)
 		}
-		return "[" + strings.Join(items, ",") + "]", nil
+		e.buf = append(e.buf, ']')
 
 	case reflect.Map:
 		// Check for nil map
-		if v.IsNil() {
-			return "null", nil
-		}
-		if v.Len() == 0 {
-			return "{}", nil
+		if v.IsNil() && !e.nilAsEmpty {
+			e.buf = append(e.buf, "null"...)
+			return nil
 		}
 
 		// Convert map to object notation
-		var pairs []string
 		keys := v.MapKeys()
+		e.sortMapKeys(keys)
 
-		// Sort keys for consistent output
-		sort.Slice(keys, func(i, j int) bool {
-			return fmt.Sprintf("%v", keys[i].Interface()) < fmt.Sprintf("%v", keys[j].Interface())
-		})
-
-		for _, key := range keys {
-			keyStr, err := e.serializeMapKey(key)
-			if err != nil {
-				return "", err
+		e.buf = append(e.buf, '{')
+		var err error
+		for i, key := range keys {
+			if i > 0 {
+				e.buf = append(e.buf, ',')
 			}
-			value, err := e.serialize(v.MapIndex(key), stack, depth+1)
-			if err != nil {
-				return "", err
+			if e.buf, err = e.appendMapKey(e.buf, key); err != nil {
+				return err
+			}
+			e.buf = append(e.buf, ':')
+			if err := e.serialize(v.MapIndex(key), depth+1); err != nil {
+				return err
 			}
-			pairs = append(pairs, keyStr+":"+value)
 		}
-		return "{" + strings.Join(pairs, ",") + "}", nil
+		e.buf = append(e.buf, '}')
 
 	case reflect.Struct:
 		keys, err := e.getStructKeys(v)
 		if err != nil {
-			return "", err
+			return err
 		}
 
 		if len(keys) == 0 {
-			return "{}", nil
-		}
-
-		// Check if we should use class instantiation
-		sortedKeys := make([]string, len(keys))
-		copy(sortedKeys, keys)
-		sort.Strings(sortedKeys)
-		schemaSignature := strings.Join(sortedKeys, ",")
-
-		if classDef, exists := e.filteredSchemaMap[schemaSignature]; exists {
-			// Use class instantiation
-			var args []string
-			for _, key := range classDef.Keys {
-				fieldValue := e.getStructFieldValue(v, key)
-				arg, err := e.serialize(fieldValue, stack, depth+1)
-				if err != nil {
-					return "", err
-				}
-				args = append(args, arg)
-			}
-			return classDef.Name + "(" + strings.Join(args, ",") + ")", nil
-		} else {
-			// Use JSON object syntax
-			var pairs []string
-			for _, key := range keys {
-				fieldValue := e.getStructFieldValue(v, key)
-				value, err := e.serialize(fieldValue, stack, depth+1)
-				if err != nil {
-					return "", err
-				}
-				keyStr, _ := json.Marshal(key)
-				pairs = append(pairs, string(keyStr)+":"+value)
+			e.buf = append(e.buf, "{}"...)
+			return nil
+		}
+
+		ti := e.getStructTypeInfo(v.Type())
+		sc := e.schemaFor(keys, e.escapeDefaults(ti.defaultsFor(keys)))
+		if e.optionalProps && ti.optionalKeys != nil {
+			// Share one schema, and so one class, between values of the
+			// type whatever fields they omit.
+			sc = e.schemaFor(e.orderKeys(ti.optionalKeys, e.escapeDefaults(ti.optionalDefaults)))
+		}
+
+		// Write the member values; render frames them later.
+		idx := len(e.objects)
+		values := len(e.spans)
+		e.spans = slices.Grow(e.spans, len(keys))[:values+len(keys)]
+		e.objects = append(e.objects, deferredObject{
+			schema: sc,
+			keys:   keys,
+			values: values,
+			start:  len(e.buf),
+		})
+		for i, key := range keys {
+			start := len(e.buf)
+			if err := e.serialize(e.getStructFieldValue(v, key), depth+1); err != nil {
+				return err
 			}
-			return "{" + strings.Join(pairs, ",") + "}", nil
+			e.spans[values+i] = [2]int{start, len(e.buf)}
 		}
+		e.objects[idx].end = len(e.buf)
+		e.objects[idx].next = len(e.objects)
 
 	default:
-		return "", &UnsupportedTypeError{Type: v.Type()}
+		return &UnsupportedTypeError{Type: v.Type()}
+	}
+	return nil
+}
+
+// sortMapKeys sorts the keys of a map into the order set by
+// Encoder.SetMapKeyOrder.
+func (e *encoder) sortMapKeys(keys []reflect.Value) {
+	if len(keys) < 2 || e.mapKeyOrder == MapKeysUnsorted {
+		return
+	}
+	if e.mapKeyOrder == MapKeysNumeric {
+		switch keys[0].Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			slices.SortFunc(keys, func(a, b reflect.Value) int { return cmp.Compare(a.Int(), b.Int()) })
+			return
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			slices.SortFunc(keys, func(a, b reflect.Value) int { return cmp.Compare(a.Uint(), b.Uint()) })
+			return
+		}
+	}
+
+	// Sort by the text of each key, formatting each only once.
+	texts := make([]string, len(keys))
+	for i, k := range keys {
+		texts[i] = fmt.Sprintf("%v", k.Interface())
 	}
+	sort.Sort(mapKeySorter{keys, texts})
+}
+
+// mapKeySorter sorts map keys by their texts.
+type mapKeySorter struct {
+	keys  []reflect.Value
+	texts []string
 }
 
+func (s mapKeySorter) Len() int           { return len(s.keys) }
+func (s mapKeySorter) Less(i, j int) bool { return s.texts[i] < s.texts[j] }
+func (s mapKeySorter) Swap(i, j int) {
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	s.texts[i], s.texts[j] = s.texts[j], s.texts[i]
+}
+
+// serializeCustom encodes v with its MarshalTRON, MarshalJSON, MarshalText or
+// MarshalBinary method, in that order of preference. ok reports whether v has
+// one of them.
+func (e *encoder) serializeCustom(v reflect.Value) (out string, ok bool, err error) {
+	if v.Type().Implements(marshalerType) {
+		data, err := v.Interface().(Marshaler).MarshalTRON()
+		return string(data), true, err
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(marshalerType) {
+		data, err := v.Addr().Interface().(Marshaler).MarshalTRON()
+		return string(data), true, err
+	}
+
+	// Fall back to MarshalJSON; valid JSON is also valid TRON.
+	if v.Type().Implements(jsonMarshalerType) {
+		out, err := marshalJSONValue(v.Interface().(json.Marshaler))
+		return out, true, err
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(jsonMarshalerType) {
+		out, err := marshalJSONValue(v.Addr().Interface().(json.Marshaler))
+		return out, true, err
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", true, err
+		}
+		return string(e.quote(nil, string(text))), true, nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textMarshalerType) {
+		text, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", true, err
+		}
+		return string(e.quote(nil, string(text))), true, nil
+	}
+
+	// Binary forms are encoded as base64 strings.
+	if v.Type().Implements(binaryMarshalerType) {
+		out, err := marshalBinaryValue(v.Interface().(encoding.BinaryMarshaler))
+		return out, true, err
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(binaryMarshalerType) {
+		out, err := marshalBinaryValue(v.Addr().Interface().(encoding.BinaryMarshaler))
+		return out, true, err
+	}
+	return "", false, nil
+}
+
+// structTypeCache holds the field info of every struct type encoded so far,
+// shared across Marshal calls like encoding/json's field cache.
+var structTypeCache sync.Map // map[reflect.Type]*structTypeInfo
+
 type structTypeInfo struct {
-	fields []structFieldInfo
-	byName map[string]int // json name -> field index
+	fields   []structFieldInfo
+	byName   map[string]int    // json name -> field index
+	inline   []int             // indices of map fields whose entries are emitted as keys
+	defaults map[string]string // json name -> default value text, from default= tag options
+	err      error             // an invalid default= tag option, reported when encoding
+
+	// optionalKeys and optionalDefaults give the schema used with
+	// Encoder.SetOptionalProperties: every field, with those that can be
+	// omitted marked optional unless they have a default. Both are nil if
+	// no field can be omitted, or the type has inline fields.
+	optionalKeys     []string
+	optionalDefaults []string
 }
 
 type structFieldInfo struct {
 	name      string
 	index     int
 	omitempty bool
+	omitzero  bool
 }
 
-// getStructKeys returns the field names for a struct, respecting json tags.
+// getStructKeys returns the field names for a struct, respecting tron/json tags.
 func (e *encoder) getStructKeys(v reflect.Value) ([]string, error) {
 	ti := e.getStructTypeInfo(v.Type())
+	if ti.err != nil {
+		return nil, ti.err
+	}
 	keys := make([]string, 0, len(ti.fields))
 	for _, f := range ti.fields {
 		fv := v.Field(f.index)
 		if f.omitempty && isEmptyValue(fv) {
 			continue
 		}
+		if f.omitzero && isZeroValue(fv) {
+			continue
+		}
 		keys = append(keys, f.name)
 	}
+	for _, idx := range ti.inline {
+		m := v.Field(idx)
+		if m.Len() == 0 {
+			continue
+		}
+		mapKeys := make([]string, 0, m.Len())
+		for _, k := range m.MapKeys() {
+			if err := e.checkUTF8(k, k.String()); err != nil {
+				return nil, err
+			}
+			// Declared fields win over inline entries with the same name.
+			if _, exists := ti.byName[k.String()]; !exists {
+				mapKeys = append(mapKeys, k.String())
+			}
+		}
+		sort.Strings(mapKeys)
+		keys = append(keys, mapKeys...)
+	}
+	if e.fieldCmp != nil {
+		slices.SortStableFunc(keys, e.fieldCmp)
+	}
 	return keys, nil
 }
 
+// orderKeys returns keys, and defaults with them if not nil, in the order
+// set by Encoder.SetFieldOrder. The slices of a structTypeInfo are shared,
+// so they are sorted as copies.
+func (e *encoder) orderKeys(keys, defaults []string) ([]string, []string) {
+	if e.fieldCmp == nil {
+		return keys, defaults
+	}
+	perm := make([]int, len(keys))
+	for i := range perm {
+		perm[i] = i
+	}
+	slices.SortStableFunc(perm, func(i, j int) int { return e.fieldCmp(keys[i], keys[j]) })
+	sortedKeys := make([]string, len(keys))
+	var sortedDefaults []string
+	if defaults != nil {
+		sortedDefaults = make([]string, len(defaults))
+	}
+	for i, j := range perm {
+		sortedKeys[i] = keys[j]
+		if defaults != nil {
+			sortedDefaults[i] = defaults[j]
+		}
+	}
+	return sortedKeys, sortedDefaults
+}
+
+// getStructTypeInfo returns the cached field info for struct type t,
+// computing it on first use.
 func (e *encoder) getStructTypeInfo(t reflect.Type) *structTypeInfo {
-	if v, ok := e.structCache.Load(t); ok {
+	if v, ok := structTypeCache.Load(t); ok {
 		return v.(*structTypeInfo)
 	}
 
@@ -438,7 +1053,9 @@ func (e *encoder) getStructTypeInfo(t reflect.Type) *structTypeInfo {
 
 		name := field.Name
 		omitempty := false
-		if tag := field.Tag.Get("json"); tag != "" {
+		omitzero := false
+		def := ""
+		if tag := fieldTag(field); tag != "" {
 			parts := strings.Split(tag, ",")
 			if parts[0] == "-" {
 				continue
@@ -449,54 +1066,252 @@ func (e *encoder) getStructTypeInfo(t reflect.Type) *structTypeInfo {
 			if len(parts) > 1 && contains(parts[1:], "omitempty") {
 				omitempty = true
 			}
+			if len(parts) > 1 && contains(parts[1:], "omitzero") {
+				omitzero = true
+			}
+			for _, opt := range parts[1:] {
+				if text, ok := strings.CutPrefix(opt, "default="); ok {
+					var err error
+					if def, err = classDefault(field.Type, text); err != nil && info.err == nil {
+						info.err = fmt.Errorf("tron: default for field %s.%s: %w", t.Name(), field.Name, err)
+					}
+				}
+			}
+			if len(parts) > 1 && (contains(parts[1:], "inline") || contains(parts[1:], "remain")) && isRemainType(field.Type) {
+				info.inline = append(info.inline, i)
+				continue
+			}
 		}
 
-		info.fields = append(info.fields, structFieldInfo{name: name, index: i, omitempty: omitempty})
+		info.fields = append(info.fields, structFieldInfo{name: name, index: i, omitempty: omitempty, omitzero: omitzero})
 		// First field wins for name collisions (matches encoding/json behavior).
 		if _, exists := info.byName[name]; !exists {
 			info.byName[name] = i
+			if def != "" {
+				if info.defaults == nil {
+					info.defaults = make(map[string]string)
+				}
+				info.defaults[name] = def
+			}
 		}
 	}
 
-	// Publish
-	e.structCache.Store(t, info)
-	return info
+	info.setOptionalSchema()
+
+	// Publish; if another goroutine got there first, use its copy.
+	actual, _ := structTypeCache.LoadOrStore(t, info)
+	return actual.(*structTypeInfo)
 }
 
-// getStructFieldValue returns the value of a struct field by name, respecting json tags.
+// setOptionalSchema fills in ti.optionalKeys and ti.optionalDefaults.
+func (ti *structTypeInfo) setOptionalSchema() {
+	if len(ti.inline) > 0 || !slices.ContainsFunc(ti.fields, func(f structFieldInfo) bool { return f.omitempty || f.omitzero }) {
+		return
+	}
+	ti.optionalKeys = make([]string, len(ti.fields))
+	for i, f := range ti.fields {
+		ti.optionalKeys[i] = f.name
+	}
+	ti.optionalDefaults = ti.defaultsFor(ti.optionalKeys)
+	if ti.optionalDefaults == nil {
+		ti.optionalDefaults = make([]string, len(ti.fields))
+	}
+	for i, f := range ti.fields {
+		if (f.omitempty || f.omitzero) && ti.optionalDefaults[i] == "" {
+			ti.optionalDefaults[i] = "null"
+		}
+	}
+}
+
+// defaultsFor returns the default value text of each of keys, or nil if none
+// has a default.
+func (ti *structTypeInfo) defaultsFor(keys []string) []string {
+	var defaults []string
+	for i, key := range keys {
+		if def, ok := ti.defaults[key]; ok {
+			if defaults == nil {
+				defaults = make([]string, len(keys))
+			}
+			defaults[i] = def
+		}
+	}
+	return defaults
+}
+
+// classDefault returns the canonical TRON text of text, the default= tag
+// option of a field of type t. It is written as TRON, except for string
+// fields, where it is the string itself. Only booleans, numbers and strings
+// can have defaults.
+func classDefault(t reflect.Type, text string) (string, error) {
+	v := reflect.New(t)
+	switch t.Kind() {
+	case reflect.String:
+		v.Elem().SetString(text)
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		if err := Unmarshal([]byte(text), v.Interface()); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("defaults are not supported for type %s", t)
+	}
+	out, err := Marshal(v.Elem().Interface())
+	return string(out), err
+}
+
+// fieldTag returns the encoding tag of a struct field. A "tron" tag takes
+// precedence over a "json" tag so TRON output can diverge from JSON output.
+func fieldTag(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("tron"); ok {
+		return tag
+	}
+	return field.Tag.Get("json")
+}
+
+// getStructFieldValue returns the value of a struct field by name, respecting tron/json tags.
 func (e *encoder) getStructFieldValue(v reflect.Value, name string) reflect.Value {
 	ti := e.getStructTypeInfo(v.Type())
 	idx, ok := ti.byName[name]
 	if !ok {
+		for _, idx := range ti.inline {
+			m := v.Field(idx)
+			if m.IsNil() {
+				continue
+			}
+			if mv := m.MapIndex(reflect.ValueOf(name).Convert(m.Type().Key())); mv.IsValid() {
+				return mv
+			}
+		}
 		return reflect.Value{}
 	}
 	return v.Field(idx)
 }
 
-// serializeMapKey converts a map key to a string for TRON object notation.
-func (e *encoder) serializeMapKey(key reflect.Value) (string, error) {
+// marshalJSONValue calls MarshalJSON and compacts the result for embedding in
+// TRON output.
+func marshalJSONValue(m json.Marshaler) (string, error) {
+	data, err := m.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return "", fmt.Errorf("tron: invalid MarshalJSON output: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// marshalBinaryValue calls MarshalBinary and encodes the result as a base64
+// TRON string.
+func marshalBinaryValue(m encoding.BinaryMarshaler) (string, error) {
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return `"` + base64.StdEncoding.EncodeToString(data) + `"`, nil
+}
+
+// serializeNonFinite writes f, which is NaN or infinite, according to the
+// encoder's NonFinitePolicy.
+func (e *encoder) serializeNonFinite(v reflect.Value, f float64) error {
+	switch e.nonFinite {
+	case NonFiniteLiterals:
+		switch {
+		case math.IsNaN(f):
+			e.buf = append(e.buf, "NaN"...)
+		case f > 0:
+			e.buf = append(e.buf, "Infinity"...)
+		default:
+			e.buf = append(e.buf, "-Infinity"...)
+		}
+	case NonFiniteNull:
+		e.buf = append(e.buf, "null"...)
+	default:
+		return &UnsupportedValueError{Value: v, Str: strconv.FormatFloat(f, 'g', -1, v.Type().Bits())}
+	}
+	return nil
+}
+
+// serializeTime formats t as a quoted string using the configured layout.
+func (e *encoder) serializeTime(t time.Time) string {
+	layout := e.timeLayout
+	if layout == "" {
+		layout = defaultTimeLayout
+	}
+	return string(e.quote(nil, t.Format(layout)))
+}
+
+// appendMapKey appends a map key to dst as a quoted string for TRON object
+// notation.
+func (e *encoder) appendMapKey(dst []byte, key reflect.Value) ([]byte, error) {
 	switch key.Kind() {
 	case reflect.String:
-		quoted, _ := json.Marshal(key.String())
-		return string(quoted), nil
+		if err := e.checkUTF8(key, key.String()); err != nil {
+			return dst, err
+		}
+		return e.quote(dst, key.String()), nil
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		quoted, _ := json.Marshal(strconv.FormatInt(key.Int(), 10))
-		return string(quoted), nil
+		dst = append(dst, '"')
+		return append(strconv.AppendInt(dst, key.Int(), 10), '"'), nil
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		quoted, _ := json.Marshal(strconv.FormatUint(key.Uint(), 10))
-		return string(quoted), nil
+		dst = append(dst, '"')
+		return append(strconv.AppendUint(dst, key.Uint(), 10), '"'), nil
 	default:
 		if key.Type().Implements(reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()) {
 			marshaler := key.Interface().(encoding.TextMarshaler)
 			text, err := marshaler.MarshalText()
 			if err != nil {
-				return "", err
+				return dst, err
 			}
-			quoted, _ := json.Marshal(string(text))
-			return string(quoted), nil
+			return e.quote(dst, string(text)), nil
+		}
+		return dst, &UnsupportedTypeError{Type: key.Type()}
+	}
+}
+
+// escapes returns the escaping that quoted strings get under o.
+func (o *encodeOptions) escapes() escapeFlags {
+	var esc escapeFlags
+	if !o.noEscapeHTML {
+		esc |= escapeHTML
+	}
+	if o.asciiOnly {
+		esc |= escapeNonASCII
+	}
+	return esc
+}
+
+// escapeDefaults returns defaults, the default value texts of a struct
+// type, with strings quoted as e quotes them, so that they match the values
+// serialize writes.
+func (e *encoder) escapeDefaults(defaults []string) []string {
+	if defaults == nil || e.escapes() == escapeHTML {
+		// The texts were quoted this way by Marshal.
+		return defaults
+	}
+	escaped := make([]string, len(defaults))
+	for i, def := range defaults {
+		if s, err := strconv.Unquote(def); err == nil && strings.HasPrefix(def, `"`) {
+			def = string(e.quote(nil, s))
 		}
-		return "", &UnsupportedTypeError{Type: key.Type()}
+		escaped[i] = def
 	}
+	return escaped
+}
+
+// quote appends s to dst as a quoted string, escaped as the options say.
+func (e *encoder) quote(dst []byte, s string) []byte {
+	return appendQuotedWith(dst, s, e.escapes())
+}
+
+// checkUTF8 returns an UnsupportedValueError for s, the text of v, if it is
+// not valid UTF-8 and the encoder is set to reject it.
+func (e *encoder) checkUTF8(v reflect.Value, s string) error {
+	if e.strictUTF8 && !utf8.ValidString(s) {
+		return &UnsupportedValueError{Value: v, Str: "invalid UTF-8 in string " + strconv.Quote(s)}
+	}
+	return nil
 }
 
 // generateClassName generates a class name from an index (A, B, ..., Z, A1, B1, ...).
@@ -550,6 +1365,29 @@ func isEmptyValue(v reflect.Value) bool {
 	return false
 }
 
+// isZeroer is implemented by types that define their own notion of zero,
+// such as time.Time.
+type isZeroer interface {
+	IsZero() bool
+}
+
+// isZeroValue checks if a value is considered zero for omitzero.
+// An IsZero() bool method takes precedence over the reflect zero value.
+func isZeroValue(v reflect.Value) bool {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return true
+	}
+	if z, ok := v.Interface().(isZeroer); ok {
+		return z.IsZero()
+	}
+	if v.CanAddr() {
+		if z, ok := v.Addr().Interface().(isZeroer); ok {
+			return z.IsZero()
+		}
+	}
+	return v.IsZero()
+}
+
 // contains checks if a slice contains a string.
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -0,0 +1,86 @@
+package tron
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMarshalToMatchesMarshal(t *testing.T) {
+	type item struct {
+		ID   int      `json:"id"`
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+	small := []item{{ID: 1, Name: "a"}, {ID: 2, Name: "b", Tags: []string{"x"}}}
+	var large []item
+	for i := range 5000 {
+		large = append(large, item{ID: i, Name: strings.Repeat("n", i%40), Tags: []string{"t"}})
+	}
+	blob := map[string]string{"data": strings.Repeat("z", 3*flushSize)}
+
+	for _, v := range []interface{}{nil, 42, "s", small, large, blob} {
+		want, err := Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := MarshalTo(&buf, v); err != nil {
+			t.Fatalf("MarshalTo: %v", err)
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Fatalf("MarshalTo wrote %d bytes differing from Marshal's %d", buf.Len(), len(want))
+		}
+	}
+}
+
+// countingWriter records the size of each write.
+type countingWriter struct {
+	writes []int
+	err    error
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.writes = append(w.writes, len(p))
+	return len(p), nil
+}
+
+func TestMarshalToWritesInPieces(t *testing.T) {
+	type point struct{ X, Y int }
+	v := make([]point, 20000)
+	for i := range v {
+		v[i] = point{i, -i}
+	}
+	var w countingWriter
+	if err := MarshalTo(&w, v); err != nil {
+		t.Fatalf("MarshalTo: %v", err)
+	}
+	if len(w.writes) < 2 {
+		t.Fatalf("MarshalTo made %d writes, want the output flushed in pieces", len(w.writes))
+	}
+	for _, n := range w.writes {
+		if n > 2*flushSize {
+			t.Fatalf("write of %d bytes, want at most about %d", n, flushSize)
+		}
+	}
+}
+
+func TestMarshalToErrors(t *testing.T) {
+	var w countingWriter
+	if err := MarshalTo(&w, map[string]interface{}{"f": func() {}}); err == nil {
+		t.Fatalf("expected an encoding error")
+	}
+	if len(w.writes) != 0 {
+		t.Fatalf("MarshalTo wrote %v before failing to encode", w.writes)
+	}
+
+	errWrite := errors.New("write failed")
+	w = countingWriter{err: errWrite}
+	if err := MarshalTo(&w, make([]int, 50000)); !errors.Is(err, errWrite) {
+		t.Fatalf("MarshalTo = %v, want the writer's error", err)
+	}
+}
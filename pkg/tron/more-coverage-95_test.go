@@ -19,7 +19,8 @@ func TestSerializeMapKey_AllKinds(t *testing.T) {
 
 	// string key
 	{
-		out, err := e.serializeMapKey(reflect.ValueOf("k"))
+		b, err := e.appendMapKey(nil, reflect.ValueOf("k"))
+		out := string(b)
 		if err != nil {
 			t.Fatalf("string: %v", err)
 		}
@@ -30,7 +31,8 @@ func TestSerializeMapKey_AllKinds(t *testing.T) {
 
 	// int key
 	{
-		out, err := e.serializeMapKey(reflect.ValueOf(int64(1)))
+		b, err := e.appendMapKey(nil, reflect.ValueOf(int64(1)))
+		out := string(b)
 		if err != nil {
 			t.Fatalf("int: %v", err)
 		}
@@ -41,7 +43,8 @@ func TestSerializeMapKey_AllKinds(t *testing.T) {
 
 	// uint key
 	{
-		out, err := e.serializeMapKey(reflect.ValueOf(uint64(2)))
+		b, err := e.appendMapKey(nil, reflect.ValueOf(uint64(2)))
+		out := string(b)
 		if err != nil {
 			t.Fatalf("uint: %v", err)
 		}
@@ -52,7 +55,8 @@ func TestSerializeMapKey_AllKinds(t *testing.T) {
 
 	// TextMarshaler success
 	{
-		out, err := e.serializeMapKey(reflect.ValueOf(textKeyOK{S: "txt"}))
+		b, err := e.appendMapKey(nil, reflect.ValueOf(textKeyOK{S: "txt"}))
+		out := string(b)
 		if err != nil {
 			t.Fatalf("text: %v", err)
 		}
@@ -0,0 +1,103 @@
+package tron
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecoderMore(t *testing.T) {
+	input := "# users\nclass U: id,name\n\n[U(1,\"a\"), {\"id\":2,\"name\":\"b\"},\n U(3,\"c\")]\n"
+	type user struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	dec := NewDecoder(strings.NewReader(input))
+	var got []user
+	var offsets []int64
+	for dec.More() {
+		var u user
+		if err := dec.Decode(&u); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, u)
+		offsets = append(offsets, dec.InputOffset())
+	}
+	if want := []user{{1, "a"}, {2, "b"}, {3, "c"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	// Each offset is just past the element's delimiter.
+	want := []int64{
+		int64(strings.Index(input, `"a"),`) + 5),
+		int64(strings.Index(input, `"b"},`) + 5),
+		int64(strings.LastIndex(input, "]") + 1),
+	}
+	if !reflect.DeepEqual(offsets, want) {
+		t.Errorf("offsets = %v, want %v", offsets, want)
+	}
+	if err := dec.Decode(new(user)); err != io.EOF {
+		t.Errorf("Decode after the end = %v, want io.EOF", err)
+	}
+	if dec.InputOffset() != int64(len(input)) {
+		t.Errorf("final offset = %d, want %d", dec.InputOffset(), len(input))
+	}
+
+	for _, tc := range []struct {
+		input string
+		want  error
+	}{
+		{"", io.EOF},
+		{"[]", io.EOF},
+		{"{\"a\":1}", &SyntaxError{}},
+		{"[1,2", &SyntaxError{}},
+		{"[1] 2", &SyntaxError{}},
+	} {
+		dec := NewDecoder(strings.NewReader(tc.input))
+		var err error
+		for dec.More() {
+			var v int
+			if err = dec.Decode(&v); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = dec.Decode(new(int))
+		}
+		var syntaxErr *SyntaxError
+		if tc.want == io.EOF && err != io.EOF || tc.want != io.EOF && !errors.As(err, &syntaxErr) {
+			t.Errorf("%q: got %v, want %T", tc.input, err, tc.want)
+		}
+	}
+}
+
+func TestDecoderInputOffset(t *testing.T) {
+	input := "a: 1\nb: 2\n"
+	dec := NewDecoder(strings.NewReader(input))
+	if dec.InputOffset() != 0 {
+		t.Errorf("offset before Decode = %d", dec.InputOffset())
+	}
+	var v map[string]int
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if dec.InputOffset() != int64(len(input)) {
+		t.Errorf("offset after Decode = %d, want %d", dec.InputOffset(), len(input))
+	}
+
+	lines := "class P: x,y\nP(1,2)\nP(3,4)\n"
+	dec = NewDecoder(strings.NewReader(lines))
+	dec.UseLineMode()
+	if dec.More() {
+		t.Error("More in line mode = true")
+	}
+	var p struct{ X, Y int }
+	if err := dec.Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(strings.Index(lines, "P(3")); dec.InputOffset() != want {
+		t.Errorf("line mode offset = %d, want %d", dec.InputOffset(), want)
+	}
+}
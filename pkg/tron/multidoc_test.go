@@ -0,0 +1,105 @@
+package tron
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecoderUseMultipleDocuments(t *testing.T) {
+	type event struct {
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+	}
+	input := "class E: level,msg\n\n[E(\"info\",\"start\")]\n" +
+		"class E: level,msg\n[E(\"warn\",\"slow\"),E(\"info\",\"done\")]\n" +
+		"level: \"error\"\nmsg: \"boom\"\n" +
+		"[]\n"
+
+	dec := NewDecoder(strings.NewReader(input))
+	dec.UseMultipleDocuments()
+	var got [][]event
+	for {
+		var v any
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var events []event
+		if obj, ok := v.(map[string]interface{}); ok {
+			events = []event{{obj["level"].(string), obj["msg"].(string)}}
+		} else {
+			for _, e := range v.([]interface{}) {
+				obj := e.(map[string]interface{})
+				events = append(events, event{obj["level"].(string), obj["msg"].(string)})
+			}
+		}
+		got = append(got, events)
+	}
+	want := [][]event{
+		{{"info", "start"}},
+		{{"warn", "slow"}, {"info", "done"}},
+		{{"error", "boom"}},
+		nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMultipleDocumentsSharedHeader(t *testing.T) {
+	input := "class P: x,y\n\nP(1,2)\nP(3,4)\n"
+	dec := NewDecoder(strings.NewReader(input))
+	dec.UseMultipleDocuments()
+	var points []struct{ X, Y int }
+	for {
+		var p struct {
+			X int `json:"x"`
+			Y int `json:"y"`
+		}
+		if err := dec.Decode(&p); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		points = append(points, struct{ X, Y int }{p.X, p.Y})
+	}
+	if want := []struct{ X, Y int }{{1, 2}, {3, 4}}; !reflect.DeepEqual(points, want) {
+		t.Errorf("got %v, want %v", points, want)
+	}
+
+	// Redefining a class differently needs AllowClassShadowing.
+	dec = NewDecoder(strings.NewReader("class P: x\nP(1)\nclass P: y\nP(2)\n"))
+	dec.UseMultipleDocuments()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if err := dec.Decode(&v); err == nil {
+		t.Error("redefinition: expected error")
+	}
+
+	// Without the option, a second document is trailing tokens.
+	if err := NewDecoder(strings.NewReader(input)).Decode(&v); err == nil {
+		t.Error("expected error for trailing document")
+	}
+}
+
+func TestMultipleDocumentsLimitJoinedMembers(t *testing.T) {
+	// Each line is small, but together they exceed MaxInputBytes long
+	// before the read error that ends the stream.
+	input := strings.Repeat("key: \"0123456789\"\n", 100)
+	dec := NewDecoder(io.MultiReader(strings.NewReader(input), iotest.ErrReader(errors.New("read too far"))))
+	dec.UseMultipleDocuments()
+	dec.SetLimits(Limits{MaxInputBytes: 1000})
+	var v interface{}
+	if err := dec.Decode(&v); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("got %v, want ErrTooLarge", err)
+	}
+}
@@ -0,0 +1,66 @@
+package tron
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNamedClassArguments(t *testing.T) {
+	type person struct {
+		Name   string `json:"name"`
+		Age    int    `json:"age"`
+		Status string `json:"status"`
+	}
+	input := "class A: name,age,status=\"new\"\n\n" +
+		"[A(name:\"Alice\",age:30),A(age:41,\"name\":\"Bob\",status:\"old\"),A(\"Cy\",7)]"
+
+	var people []person
+	if err := Unmarshal([]byte(input), &people); err != nil {
+		t.Fatal(err)
+	}
+	want := []person{{"Alice", 30, "new"}, {"Bob", 41, "old"}, {"Cy", 7, "new"}}
+	if !reflect.DeepEqual(people, want) {
+		t.Fatalf("got %+v, want %+v", people, want)
+	}
+
+	// Properties without a default may be left out, as from an object.
+	var tree interface{}
+	if err := Unmarshal([]byte("class A: name,age\n\nA(age:30)"), &tree); err != nil {
+		t.Fatal(err)
+	}
+	if got := tree.(map[string]interface{}); !reflect.DeepEqual(got, map[string]interface{}{"age": float64(30)}) {
+		t.Fatalf("partial instance = %v", got)
+	}
+
+	var buf bytes.Buffer
+	if err := Compact(&buf, []byte("class A: name,age\n\nA( name : \"Alice\", age: 30 )")); err != nil {
+		t.Fatal(err)
+	}
+	if want := "class A: name,age\n\nA(name:\"Alice\",age:30)"; buf.String() != want {
+		t.Fatalf("Compact = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestNamedClassArgumentErrors(t *testing.T) {
+	for _, tc := range []struct {
+		input, msg string
+	}{
+		{"class A: a,b\n\nA(a:1,c:2)", "class A has no property c"},
+		{"class A: a,b\n\nA(a:1,2)", "cannot mix positional and named"},
+		{"class A: a,b\n\nA(1,b:2)", "cannot mix positional and named"},
+	} {
+		var v interface{}
+		if err := Unmarshal([]byte(tc.input), &v); err == nil || !strings.Contains(err.Error(), tc.msg) {
+			t.Errorf("Unmarshal(%q) = %v, want error containing %q", tc.input, err, tc.msg)
+		}
+	}
+
+	dec := NewDecoder(strings.NewReader("class A: a,b\n\nA(a:1,a:2)"))
+	dec.SetDuplicateKeys(DuplicateKeysError)
+	var v interface{}
+	if err := dec.Decode(&v); err == nil || !strings.Contains(err.Error(), "duplicate key") {
+		t.Errorf("repeated named argument: got %v", err)
+	}
+}
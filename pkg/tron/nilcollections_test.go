@@ -0,0 +1,45 @@
+package tron
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncoderSetEmitNilCollectionsAsEmpty(t *testing.T) {
+	type payload struct {
+		Tags  []string       `json:"tags"`
+		Attrs map[string]int `json:"attrs"`
+		Data  []byte         `json:"data"`
+		Next  *payload       `json:"next"`
+		Any   any            `json:"any"`
+		Skip  []int          `json:"skip,omitempty"`
+	}
+
+	for _, tc := range []struct {
+		on   bool
+		want string
+	}{
+		{false, `{"tags":null,"attrs":null,"data":null,"next":null,"any":null}` + "\n"},
+		{true, `{"tags":[],"attrs":{},"data":"","next":null,"any":null}` + "\n"},
+	} {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetEmitNilCollectionsAsEmpty(tc.on)
+		if err := enc.Encode(payload{}); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tc.want {
+			t.Errorf("SetEmitNilCollectionsAsEmpty(%v):\ngot  %s\nwant %s", tc.on, buf.String(), tc.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetEmitNilCollectionsAsEmpty(true)
+	if err := enc.Encode([][]int{nil, {1}}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "[[],[1]]\n"; buf.String() != want {
+		t.Errorf("got %s, want %s", buf.String(), want)
+	}
+}
@@ -0,0 +1,79 @@
+package tron
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestMarshalNonFinite(t *testing.T) {
+	values := []float64{1.5, math.NaN(), math.Inf(1), math.Inf(-1)}
+
+	var valueErr *UnsupportedValueError
+	if _, err := Marshal(values); !errors.As(err, &valueErr) {
+		t.Fatalf("Marshal: got %v, want UnsupportedValueError", err)
+	}
+
+	tests := []struct {
+		policy NonFinitePolicy
+		want   string
+	}{
+		{NonFiniteLiterals, "[1.5,NaN,Infinity,-Infinity]\n"},
+		{NonFiniteNull, "[1.5,null,null,null]\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetNonFinite(tt.policy)
+		if err := enc.Encode(values); err != nil {
+			t.Fatalf("policy %d: %v", tt.policy, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("policy %d: got %q, want %q", tt.policy, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestDecodeNonFinite(t *testing.T) {
+	type point struct {
+		X float64
+		Y float32
+	}
+	input := "class P: X,Y\n\n[P(NaN,Infinity),P(-Infinity,1),{\"X\":NaN}]"
+
+	var v []point
+	if err := Unmarshal([]byte(input), &v); err == nil {
+		t.Fatal("Unmarshal accepted NaN without AllowNonFinite")
+	}
+
+	dec := NewDecoder(strings.NewReader(input))
+	dec.AllowNonFinite()
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(v) != 3 || !math.IsNaN(v[0].X) || !math.IsInf(float64(v[0].Y), 1) || !math.IsInf(v[1].X, -1) || !math.IsNaN(v[2].X) {
+		t.Fatalf("got %+v", v)
+	}
+
+	// Into interface{}, into an int, and as a class name.
+	var tree interface{}
+	dec = NewDecoder(strings.NewReader("class NaN: a,b\n\n[Infinity, NaN(1,2)]"))
+	dec.AllowNonFinite()
+	if err := dec.Decode(&tree); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	list := tree.([]interface{})
+	if !math.IsInf(list[0].(float64), 1) || list[1].(map[string]interface{})["b"] != float64(2) {
+		t.Fatalf("got %v", tree)
+	}
+
+	var n int
+	dec = NewDecoder(strings.NewReader("NaN"))
+	dec.AllowNonFinite()
+	var typeErr *UnmarshalTypeError
+	if err := dec.Decode(&n); !errors.As(err, &typeErr) {
+		t.Fatalf("int: got %v, want UnmarshalTypeError", err)
+	}
+}
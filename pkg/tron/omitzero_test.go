@@ -0,0 +1,88 @@
+package tron
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type zeroByFlag struct {
+	Set bool
+}
+
+func (z zeroByFlag) IsZero() bool { return !z.Set }
+
+type ptrZeroer struct {
+	N int
+}
+
+func (z *ptrZeroer) IsZero() bool { return z.N <= 0 }
+
+type omitZeroStruct struct {
+	When    time.Time       `json:"when,omitzero"`
+	Point   struct{ X int } `json:"point,omitzero"`
+	Custom  zeroByFlag      `json:"custom,omitzero"`
+	Ptr     *int            `json:"ptr,omitzero"`
+	Count   int             `json:"count,omitzero"`
+	Both    []int           `json:"both,omitempty,omitzero"`
+	Kept    int             `json:"kept"`
+	Neg     ptrZeroer       `json:"neg,omitzero"`
+	Visible string          `json:"visible,omitzero"`
+}
+
+func TestOmitZeroOmitsZeroFields(t *testing.T) {
+	v := omitZeroStruct{Custom: zeroByFlag{Set: false}, Both: []int{}, Neg: ptrZeroer{N: -1}}
+	out, err := Marshal(&v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(out) != `{"kept":0}` {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
+
+func TestOmitZeroKeepsNonZeroFields(t *testing.T) {
+	n := 0
+	v := omitZeroStruct{
+		When:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Custom:  zeroByFlag{Set: true},
+		Ptr:     &n,
+		Count:   3,
+		Visible: "yes",
+	}
+	v.Point.X = 1
+	out, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	s := string(out)
+	for _, want := range []string{`"when":`, `"point":`, `"custom":`, `"ptr":0`, `"count":3`, `"visible":"yes"`} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("expected %s in %s", want, s)
+		}
+	}
+}
+
+func TestIsZeroValue(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want bool
+	}{
+		{"zero time", time.Time{}, true},
+		{"non-zero time", time.Unix(1, 0), false},
+		{"zero int", 0, true},
+		{"zero struct", struct{ A int }{}, true},
+		{"custom zero", zeroByFlag{}, true},
+		{"custom non-zero", zeroByFlag{Set: true}, false},
+		{"nil pointer", (*int)(nil), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isZeroValue(reflect.ValueOf(tt.v)); got != tt.want {
+				t.Fatalf("isZeroValue(%#v) = %v, want %v", tt.v, got, tt.want)
+			}
+		})
+	}
+}
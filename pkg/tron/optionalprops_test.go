@@ -0,0 +1,78 @@
+package tron
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type optionalItem struct {
+	Title string `json:"title"`
+	Due   string `json:"due,omitempty"`
+	Done  bool   `json:"done,omitempty"`
+}
+
+func TestDecodeOptionalProperties(t *testing.T) {
+	input := "class Item: title,due?,done?\n\n[Item(\"a\"),Item(\"b\",\"today\"),Item(\"c\",null,true)]"
+
+	var items []optionalItem
+	if err := Unmarshal([]byte(input), &items); err != nil {
+		t.Fatal(err)
+	}
+	want := []optionalItem{{Title: "a"}, {Title: "b", Due: "today"}, {Title: "c", Done: true}}
+	if !reflect.DeepEqual(items, want) {
+		t.Fatalf("got %+v, want %+v", items, want)
+	}
+
+	var tree interface{}
+	if err := Unmarshal([]byte(input), &tree); err != nil {
+		t.Fatal(err)
+	}
+	first := tree.([]interface{})[0].(map[string]interface{})
+	if v, ok := first["due"]; !ok || v != nil {
+		t.Fatalf("omitted optional property: got %v, %v; want nil, true", v, ok)
+	}
+
+	for _, tc := range []struct {
+		input, msg string
+	}{
+		{"class A: a,b?\n\nA()", "expects 1 to 2 arguments"},
+		{"class A: a,b?=1\n\nA(1)", "cannot have a default"},
+	} {
+		if err := Unmarshal([]byte(tc.input), &tree); err == nil || !strings.Contains(err.Error(), tc.msg) {
+			t.Errorf("Unmarshal(%q) = %v, want error containing %q", tc.input, err, tc.msg)
+		}
+	}
+}
+
+func TestEncoderSetOptionalProperties(t *testing.T) {
+	items := []optionalItem{{Title: "a"}, {Title: "b", Due: "tomorrow"}, {Title: "c", Done: true}}
+
+	out, err := Marshal(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(out, []byte("?")) {
+		t.Fatalf("optional properties used by default:\n%s", out)
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetOptionalProperties(true)
+	if err := enc.Encode(items); err != nil {
+		t.Fatal(err)
+	}
+	want := "class A: title,due?,done?\n\n[A(\"a\"),A(\"b\",\"tomorrow\"),A(\"c\",null,true)]\n"
+	if buf.String() != want {
+		t.Fatalf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	var back []optionalItem
+	if err := Unmarshal(buf.Bytes(), &back); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(back, items) {
+		t.Fatalf("round trip: got %+v, want %+v", back, items)
+	}
+}
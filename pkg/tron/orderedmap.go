@@ -0,0 +1,139 @@
+package tron
+
+import (
+	"iter"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// An OrderedMap is an object that remembers the order of its keys. Decoding
+// into an OrderedMap keeps the members in the order the document gives them,
+// and marshaling one writes them in that order, where a map[string]any has
+// its keys sorted. This keeps round-trips of configuration files and the
+// like faithful, and their diffs small.
+//
+// Objects within the values of a decoded OrderedMap are decoded as
+// *OrderedMap too. Decoder.UseOrderedMaps makes objects decoded into any
+// interface{} value *OrderedMap. UnmarshalLenient, which decodes from a
+// parsed tree, cannot see the order of members and sorts the keys instead.
+//
+// The zero value is an empty map ready to use.
+type OrderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+var orderedMapType = reflect.TypeOf(OrderedMap{})
+
+// Len returns the number of keys in m.
+func (m *OrderedMap) Len() int {
+	return len(m.keys)
+}
+
+// Get returns the value of key and whether m has it.
+func (m *OrderedMap) Get(key string) (any, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// Set sets the value of key. A new key goes after the existing ones; a key m
+// already has keeps its position.
+func (m *OrderedMap) Set(key string, value any) {
+	if m.values == nil {
+		m.values = make(map[string]any)
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Delete removes key from m, if m has it.
+func (m *OrderedMap) Delete(key string) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+	delete(m.values, key)
+	i := slices.Index(m.keys, key)
+	m.keys = slices.Delete(m.keys, i, i+1)
+}
+
+// Keys returns the keys of m in order. The caller may modify the slice.
+func (m *OrderedMap) Keys() []string {
+	return slices.Clone(m.keys)
+}
+
+// All returns an iterator over the keys and values of m, in order.
+func (m *OrderedMap) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for _, k := range m.keys {
+			if !yield(k, m.values[k]) {
+				return
+			}
+		}
+	}
+}
+
+// UseOrderedMaps causes the Decoder to decode objects and class
+// instantiations into an interface{} value as an *OrderedMap instead of a
+// map[string]interface{}, keeping the order of their members.
+func (dec *Decoder) UseOrderedMaps() {
+	dec.opts.orderedMaps = true
+}
+
+// decodeOrderedMap decodes a parsed object into the OrderedMap dst. The
+// parsed object has lost the order of its members, so they are added in
+// sorted order, as Marshal writes a map.
+func (d *decoder) decodeOrderedMap(src map[string]interface{}, dst reflect.Value) {
+	defer d.orderObjects()()
+	m := d.orderedMapOf(src)
+	if d.clearMaps {
+		dst.Set(reflect.ValueOf(m).Elem())
+		return
+	}
+	for k, v := range m.All() {
+		dst.Addr().Interface().(*OrderedMap).Set(k, v)
+	}
+}
+
+// orderedMapOf returns the normalized values of a parsed object as an
+// *OrderedMap, with its keys sorted.
+func (d *decoder) orderedMapOf(src map[string]interface{}) *OrderedMap {
+	keys := make([]string, 0, len(src))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	m := &OrderedMap{keys: keys, values: make(map[string]any, len(src))}
+	for _, k := range keys {
+		m.values[k] = d.normalizeInterfaceValue(src[k])
+	}
+	return m
+}
+
+// orderObjects makes d decode objects into interfaces as *OrderedMap until
+// the returned function is called.
+func (d *decoder) orderObjects() (restore func()) {
+	prev := d.orderedMaps
+	d.orderedMaps = true
+	return func() { d.orderedMaps = prev }
+}
+
+// serializeOrderedMap appends the TRON encoding of m to e.buf as an object
+// with its keys in order.
+func (e *encoder) serializeOrderedMap(m *OrderedMap, depth int) error {
+	e.buf = append(e.buf, '{')
+	for i, k := range m.keys {
+		if i > 0 {
+			e.buf = append(e.buf, ',')
+		}
+		e.buf = e.quote(e.buf, k)
+		e.buf = append(e.buf, ':')
+		if err := e.serialize(reflect.ValueOf(m.values[k]), depth+1); err != nil {
+			return err
+		}
+	}
+	e.buf = append(e.buf, '}')
+	return nil
+}
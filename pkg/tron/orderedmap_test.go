@@ -0,0 +1,125 @@
+package tron
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOrderedMapRoundTrip(t *testing.T) {
+	input := "name: \"svc\"\nport: 8080\ndb: {user:\"app\",host:\"localhost\"}\nflags: [{b:1,a:2}]\n"
+	var m OrderedMap
+	if err := Unmarshal([]byte(input), &m); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.Keys(), []string{"name", "port", "db", "flags"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Keys() = %v, want %v", got, want)
+	}
+	db, _ := m.Get("db")
+	if got := db.(*OrderedMap).Keys(); !reflect.DeepEqual(got, []string{"user", "host"}) {
+		t.Errorf("nested keys = %v", got)
+	}
+	flags, _ := m.Get("flags")
+	if got := flags.([]interface{})[0].(*OrderedMap).Keys(); !reflect.DeepEqual(got, []string{"b", "a"}) {
+		t.Errorf("keys in array = %v", got)
+	}
+
+	out, err := Marshal(&m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"svc","port":8080,"db":{"user":"app","host":"localhost"},"flags":[{"b":1,"a":2}]}`
+	if string(out) != want {
+		t.Errorf("Marshal = %s, want %s", out, want)
+	}
+}
+
+func TestOrderedMapMethods(t *testing.T) {
+	var m OrderedMap
+	m.Set("z", 1)
+	m.Set("a", 2)
+	m.Set("m", 3)
+	m.Set("z", 4) // keeps its position
+	m.Delete("a")
+	m.Delete("missing")
+	if m.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", m.Len())
+	}
+	var keys []string
+	var values []any
+	for k, v := range m.All() {
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	if !reflect.DeepEqual(keys, []string{"z", "m"}) || !reflect.DeepEqual(values, []any{4, 3}) {
+		t.Errorf("All() = %v %v", keys, values)
+	}
+	if _, ok := m.Get("a"); ok {
+		t.Error("Get of deleted key reported present")
+	}
+
+	out, err := Marshal(struct {
+		Meta OrderedMap `json:"meta"`
+	}{m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"meta":{"z":4,"m":3}}`; string(out) != want {
+		t.Errorf("Marshal = %s, want %s", out, want)
+	}
+}
+
+func TestDecoderUseOrderedMaps(t *testing.T) {
+	input := "class P: y,x\n\n[P(1,2),{c:3,b:[{e:4,d:5}]}]"
+	dec := NewDecoder(strings.NewReader(input))
+	dec.UseOrderedMaps()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	items := v.([]interface{})
+	if got := items[0].(*OrderedMap).Keys(); !reflect.DeepEqual(got, []string{"y", "x"}) {
+		t.Errorf("instance keys = %v", got)
+	}
+	obj := items[1].(*OrderedMap)
+	if got := obj.Keys(); !reflect.DeepEqual(got, []string{"c", "b"}) {
+		t.Errorf("object keys = %v", got)
+	}
+	b, _ := obj.Get("b")
+	if got := b.([]interface{})[0].(*OrderedMap).Keys(); !reflect.DeepEqual(got, []string{"e", "d"}) {
+		t.Errorf("nested keys = %v", got)
+	}
+
+	// An implicit root object.
+	dec = NewDecoder(strings.NewReader("b: 1\na: 2"))
+	dec.UseOrderedMaps()
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if got := v.(*OrderedMap).Keys(); !reflect.DeepEqual(got, []string{"b", "a"}) {
+		t.Errorf("root keys = %v", got)
+	}
+
+	// Without the option, objects in an interface{} are maps.
+	if err := Unmarshal([]byte(`{"b":1}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := v.(map[string]interface{}); !ok {
+		t.Errorf("got %T, want map[string]interface{}", v)
+	}
+}
+
+func TestOrderedMapLenient(t *testing.T) {
+	// The lenient decoder works from a parsed tree, so keys come out sorted.
+	var m OrderedMap
+	if _, err := UnmarshalLenient([]byte(`{"b":1,"a":{"d":1,"c":2}}`), &m); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Keys(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Keys() = %v", got)
+	}
+	a, _ := m.Get("a")
+	if _, ok := a.(*OrderedMap); !ok {
+		t.Errorf("nested object is %T, want *OrderedMap", a)
+	}
+}
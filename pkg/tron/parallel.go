@@ -0,0 +1,219 @@
+package tron
+
+import (
+	"reflect"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// parallelizes reports whether marshal should encode v, the value at the
+// root, with marshalParallel: it is a slice or array of at least
+// parallelMin elements that Marshal encodes element by element.
+func (e *encoder) parallelizes(v reflect.Value) bool {
+	if e.parallelMin <= 0 || v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return false
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		return false
+	}
+	return v.Len() >= e.parallelMin && !marshalsItself(v.Type())
+}
+
+// marshalParallel appends the header and data for the slice or array v to
+// dst like marshal, serializing runs of its elements concurrently. Each run
+// gets an encoder of its own; their schema counts are then summed, so that
+// classes are assigned as for the whole value, and the runs rendered in
+// order. The output is the same as marshal's.
+func (e *encoder) marshalParallel(dst []byte, v reflect.Value) ([]byte, error) {
+	n := v.Len()
+	runs := make([]*encoder, min(runtime.GOMAXPROCS(0), n))
+	errs := make([]error, len(runs))
+	var wg sync.WaitGroup
+	for w := range runs {
+		r := newEncoder(e.encodeOptions)
+		defer r.release()
+		runs[w] = r
+		lo, hi := w*n/len(runs), (w+1)*n/len(runs)
+		wg.Go(func() {
+			for i := lo; i < hi; i++ {
+				if i > lo {
+					r.buf = append(r.buf, ',')
+				}
+				if errs[w] = r.serialize(v.Index(i), 1); errs[w] != nil {
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return dst, err
+		}
+	}
+
+	// Count each schema across the runs, in order of first appearance.
+	shared := make(map[*schema]*schema)
+	for _, r := range runs {
+		for _, s := range r.schemaOrder {
+			key := schemaKey(s.keys, s.defaults)
+			g, ok := e.schemas[key]
+			if !ok {
+				g = &schema{keys: s.keys, defaults: s.defaults}
+				e.schemas[key] = g
+				e.schemaOrder = append(e.schemaOrder, g)
+			}
+			g.count += s.count
+			shared[s] = g
+		}
+	}
+	e.assignClasses()
+
+	size := e.headerSize() + len(runs) + 1
+	for _, r := range runs {
+		for _, s := range r.schemaOrder {
+			s.class = shared[s].class
+		}
+		size += r.renderSize()
+	}
+	out := appendHeader(slices.Grow(dst, size), e.classes, e.escapes())
+	out = append(out, '[')
+	for w, r := range runs {
+		if w > 0 {
+			out = append(out, ',')
+		}
+		out = r.render(out, 0, len(r.buf), 0, len(r.objects))
+	}
+	return append(out, ']'), nil
+}
+
+// decodesInParallel reports whether decodeDirect should decode the array at
+// the current position of p into the slice dst with decodeParallelSlice:
+// the array is the root value and SetParallelism asked for more than one
+// goroutine.
+func (d *decoder) decodesInParallel(p *parser, depth int) bool {
+	return depth == 0 && d.parallelism > 1 && !p.opts.lenient
+}
+
+// decodeParallelSlice decodes the array at the current position of p into
+// the slice dst like decodeDirectSlice, but splits its elements between up
+// to d.parallelism goroutines once it has found where each one starts and
+// ends. Elements are independent of each other, and the classes they
+// instantiate are only read, so each goroutine needs just a parser over the
+// tokens of its elements and a decoder of its own. An array that is not
+// well formed at the level of its brackets and commas, or is too long, is
+// left to decodeDirectSlice to report. The elements count against
+// Limits.MaxValues up front; each goroutine then counts the values within
+// its elements on top of those, and their counts are added up once all are
+// done. depth is the
+// depth of the elements.
+func (d *decoder) decodeParallelSlice(p *parser, dst reflect.Value, depth int) error {
+	spans, end, ok := arrayElements(p.tokens, p.pos, p.opts.trailingCommas)
+	if !ok || len(spans) < 2 || len(spans) > p.limits().MaxArrayLength {
+		return d.decodeDirectSlice(p, dst, depth)
+	}
+
+	n := len(spans)
+	slice := reflect.MakeSlice(dst.Type(), n, n)
+	if dst.Cap() >= n {
+		slice = dst.Slice(0, n)
+	}
+	errs := make([]error, n)
+	workers := min(d.parallelism, n)
+	values := make([]int, workers) // the values each goroutine counted
+	if p.values += n; p.values > p.limits().MaxValues {
+		return p.categoryError(ErrTooLarge, "too many values")
+	}
+	var wg sync.WaitGroup
+	for w := range workers {
+		opts := d.decodeOptions
+		if opts.cancel != nil {
+			opts.cancel = &canceler{ctx: opts.cancel.ctx}
+		}
+		wd := &decoder{decodeOptions: opts, classes: d.classes}
+		wg.Go(func() {
+			for i := w * n / workers; i < (w+1)*n/workers; i++ {
+				q := &parser{tokens: p.tokens[spans[i][0]:spans[i][1]], classes: p.classes, preserveNumbers: p.preserveNumbers, opts: opts}
+				q.values = p.values + values[w]
+				elem := slice.Index(i)
+				elem.SetZero()
+				err := wd.decodeDirect(q, elem, depth)
+				values[w] = q.values - p.values
+				if err == nil || !isFatal(err) {
+					if q.skipNewlines(); q.current().Type != TokenEOF {
+						// Report it as parseArrayWith does, as the
+						// bracket missing after the last element.
+						_, err = q.expect(TokenRBracket)
+					}
+				}
+				if errs[i] = elementError(err, indexPath(i)); errs[i] != nil && isFatal(errs[i]) {
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	var all error
+	for _, err := range errs {
+		if err := d.recordError(&all, err); err != nil {
+			return err
+		}
+	}
+	for _, n := range values {
+		p.values += n
+	}
+	if p.values > p.limits().MaxValues {
+		return p.categoryError(ErrTooLarge, "too many values")
+	}
+	p.pos = end
+	dst.Set(slice)
+	return all
+}
+
+// arrayElements returns the token spans of the elements of the array that
+// opens at tokens[pos], without the newlines around them, and the position
+// after its closing bracket. ok is false if the array does not close, or has
+// an empty element other than a trailing one that trailingCommas allows.
+func arrayElements(tokens []Token, pos int, trailingCommas bool) (spans [][2]int, end int, ok bool) {
+	level := 0
+	start, last := -1, pos
+	for i := pos + 1; i < len(tokens); i++ {
+		switch tokens[i].Type {
+		case TokenNewline:
+			continue
+		case TokenEOF:
+			return nil, 0, false
+		case TokenComma, TokenRBracket:
+			if level > 0 {
+				if tokens[i].Type == TokenRBracket {
+					level--
+				}
+				break
+			}
+			closing := tokens[i].Type == TokenRBracket
+			switch {
+			case start >= 0:
+				spans = append(spans, [2]int{start, last + 1})
+			case !closing || len(spans) > 0 && !(trailingCommas && tokens[last].Type == TokenComma):
+				return nil, 0, false
+			}
+			if closing {
+				return spans, i + 1, true
+			}
+			start = -1
+		case TokenLBracket, TokenLBrace, TokenLParen:
+			level++
+		case TokenRBrace, TokenRParen:
+			if level--; level < 0 {
+				return nil, 0, false
+			}
+		}
+		if start < 0 && tokens[i].Type != TokenComma {
+			start = i
+		}
+		last = i
+	}
+	return nil, 0, false
+}
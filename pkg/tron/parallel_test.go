@@ -0,0 +1,143 @@
+package tron
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type parallelReading struct {
+	Sensor string            `json:"sensor"`
+	Value  float64           `json:"value"`
+	Tags   map[string]string `json:"tags,omitempty"`
+	Next   *parallelReading  `json:"next,omitempty"`
+}
+
+func TestEncoderSetParallelThreshold(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	readings := make([]any, 1000)
+	for i := range readings {
+		switch i % 4 {
+		case 0:
+			readings[i] = parallelReading{Sensor: fmt.Sprint("s", i), Value: float64(i)}
+		case 1:
+			readings[i] = &parallelReading{Sensor: "t", Value: 0.5, Tags: map[string]string{"k": "v"}}
+		case 2:
+			readings[i] = []int{i, i + 1}
+		default:
+			readings[i] = parallelReading{Sensor: "n", Next: &parallelReading{Sensor: "m"}}
+		}
+	}
+	// A shape that only recurs across runs still gets a class.
+	readings[0] = struct{ A, B int }{1, 2}
+	readings[999] = struct{ A, B int }{3, 4}
+
+	encode := func(v any, threshold int) (string, error) {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetParallelThreshold(threshold)
+		err := enc.Encode(v)
+		return buf.String(), err
+	}
+	want, err := encode(readings, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, threshold := range []int{1, 500, 1000} {
+		got, err := encode(readings, threshold)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("threshold %d: output differs from sequential encoding", threshold)
+		}
+	}
+
+	// Small slices, arrays and bytes take the usual path.
+	for _, v := range []any{[]int{1, 2}, [3]string{"a", "b", "c"}, []byte("abc")} {
+		seq, _ := encode(v, 0)
+		if got, err := encode(v, 2); err != nil || got != seq {
+			t.Errorf("%v: got %q, %v; want %q", v, got, err, seq)
+		}
+	}
+
+	// The first error in element order is reported.
+	bad := make([]any, 100)
+	for i := range bad {
+		bad[i] = i
+	}
+	bad[10], bad[90] = make(chan int), math.NaN()
+	_, err = encode(bad, 10)
+	var ute *UnsupportedTypeError
+	if !errors.As(err, &ute) {
+		t.Errorf("got %v, want UnsupportedTypeError", err)
+	}
+}
+
+func TestDecoderSetParallelism(t *testing.T) {
+	type point struct {
+		X    int      `json:"x"`
+		Y    int      `json:"y"`
+		Tags []string `json:"tags,omitempty"`
+	}
+	var src strings.Builder
+	src.WriteString("class P: x,y\n\n[\n")
+	for i := range 500 {
+		if i%7 == 0 {
+			fmt.Fprintf(&src, "  {\"x\":%d,\"y\":%d,\"tags\":[\"a\",\n\"b\"]},\n", i, -i)
+		} else {
+			fmt.Fprintf(&src, "  P(%d,%d),\n", i, -i)
+		}
+	}
+	src.WriteString("  P(0,0)\n]\n")
+
+	decode := func(src string, n int, v any, set func(*Decoder)) error {
+		dec := NewDecoder(strings.NewReader(src))
+		dec.SetParallelism(n)
+		if set != nil {
+			set(dec)
+		}
+		return dec.Decode(v)
+	}
+	var want, got []point
+	if err := decode(src.String(), 0, &want, nil); err != nil {
+		t.Fatal(err)
+	}
+	got = make([]point, 3, 1000) // reused, and its elements reset
+	got[0].Tags = []string{"old"}
+	if err := decode(src.String(), 4, &got, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) || len(got) != 501 {
+		t.Fatalf("parallel decode differs: got %d elements", len(got))
+	}
+
+	// Errors are those of a sequential decode.
+	for _, tc := range []struct {
+		src string
+		set func(*Decoder)
+	}{
+		{`[{"x":1},{"x":"a"},{"y":"b"},{"x":2}]`, nil},
+		{`[{"x":1},{"x":"a"},{"y":"b"},{"x":2}]`, (*Decoder).ReportAllErrors},
+		{`[{"x":1},{"x":2} 3,{"x":4}]`, nil},
+		{`[{"x":1},,{"x":4}]`, nil},
+		{`[{"x":1},{"x":2},]`, nil},
+		{`[{"x":1},{"x":2},]`, (*Decoder).AllowTrailingCommas},
+		{`[{"x":1},{"x":2}`, nil},
+		{`[{"x":1},Q(1),{"x":4}]`, nil},
+		{`[{"x":1},{"x":[1,}]`, nil},
+	} {
+		var seq, par []point
+		seqErr := decode(tc.src, 1, &seq, tc.set)
+		parErr := decode(tc.src, 3, &par, tc.set)
+		if fmt.Sprint(seqErr) != fmt.Sprint(parErr) || !reflect.DeepEqual(seq, par) {
+			t.Errorf("%s:\nsequential %v, %v\nparallel   %v, %v", tc.src, seq, seqErr, par, parErr)
+		}
+	}
+}
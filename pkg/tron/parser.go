@@ -2,6 +2,7 @@ package tron
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 )
 
@@ -9,8 +10,27 @@ import (
 type parser struct {
 	tokens          []Token
 	pos             int
-	classes         map[string][]string // className -> propertyNames
-	preserveNumbers bool                // when true, keep number tokens as numberLiteral
+	classes         map[string]*classDef // className -> definition
+	preserveNumbers bool                 // when true, keep number tokens as numberLiteral
+	opts            decodeOptions
+	issues          []*SyntaxError // errors skipped over in lenient mode
+	pooled          *[]Token       // where release returns tokens to tokenPool, if they came from it
+	values          int            // values parsed so far, for Limits.MaxValues
+	maxValues       int            // the effective Limits.MaxValues, once countValue has looked it up
+	inherited       int            // properties copied by extends so far, for Limits.MaxTokens
+	arg             int            // the property index of the class argument passed to the walker's callback
+}
+
+// classDef is a class definition from a document header.
+type classDef struct {
+	props []string   // property names, in argument order
+	types []propType // declared types of props, or nil if none are declared
+
+	// defaults holds the default value of each property that declares one,
+	// as the single token of a scalar literal; nil if none declare one.
+	defaults []*Token
+
+	extends string // the class whose properties come first, or "" if none
 }
 
 // newParser creates a new parser from tokens.
@@ -18,11 +38,24 @@ func newParser(tokens []Token) *parser {
 	return &parser{
 		tokens:          tokens,
 		pos:             0,
-		classes:         make(map[string][]string),
+		classes:         make(map[string]*classDef),
 		preserveNumbers: false,
 	}
 }
 
+// release returns the tokens of p to tokenPool once the document has been
+// decoded. Nothing may refer to them afterwards: values hold copies of the
+// tokens' strings, and class defaults copies of the tokens.
+func (p *parser) release() {
+	if p.pooled == nil || cap(p.tokens) > maxPooledTokens {
+		return
+	}
+	clear(p.tokens) // drop the strings, which the decoded value may not keep
+	*p.pooled = p.tokens[:0]
+	tokenPool.Put(p.pooled)
+	p.tokens, p.pooled = nil, nil
+}
+
 // current returns the current token without advancing.
 func (p *parser) current() Token {
 	if p.pos >= len(p.tokens) {
@@ -66,11 +99,44 @@ func (p *parser) skipNewlines() {
 	}
 }
 
+// limits returns the effective decode limits.
+func (p *parser) limits() Limits {
+	return p.opts.limits.withDefaults()
+}
+
+// countValue counts a value about to be parsed, or supplied by a class
+// default, against Limits.MaxValues. The root value is not counted.
+func (p *parser) countValue() error {
+	if p.maxValues == 0 {
+		p.maxValues = p.limits().MaxValues
+	}
+	p.values++
+	if p.values > p.maxValues {
+		return p.categoryError(ErrTooLarge, "too many values")
+	}
+	return nil
+}
+
 // syntaxError creates a SyntaxError with the current position.
 func (p *parser) syntaxError(msg string) error {
+	return syntaxErrorAt(p.current(), nil, msg)
+}
+
+// categoryError creates a SyntaxError with the current position that wraps
+// the sentinel kind.
+func (p *parser) categoryError(kind error, msg string) error {
+	return syntaxErrorAt(p.current(), kind, msg)
+}
+
+// syntaxErrorAt returns a SyntaxError located at tok, wrapping kind if it is
+// not nil.
+func syntaxErrorAt(tok Token, kind error, msg string) error {
 	return &SyntaxError{
 		msg:    msg,
-		Offset: int64(p.pos),
+		err:    kind,
+		Offset: int64(tok.Offset),
+		Line:   tok.Line,
+		Column: tok.Column,
 	}
 }
 
@@ -102,7 +168,12 @@ func (p *parser) parse() (interface{}, error) {
 	}
 	p.skipNewlines()
 	if p.current().Type != TokenEOF {
-		return nil, p.syntaxError("unexpected trailing tokens")
+		err := p.syntaxError("unexpected trailing tokens")
+		if p.opts.lenient {
+			p.issues = append(p.issues, err.(*SyntaxError))
+			return v, nil
+		}
+		return nil, err
 	}
 	return v, nil
 }
@@ -112,8 +183,11 @@ func (p *parser) parseHeader() error {
 	p.skipNewlines()
 
 	for p.current().Type == TokenClass {
+		start := p.pos
 		if err := p.parseClassDefinition(); err != nil {
-			return err
+			if err := p.recoverFrom(err, start, TokenNewline); err != nil {
+				return err
+			}
 		}
 		p.skipNewlines()
 	}
@@ -121,7 +195,9 @@ func (p *parser) parseHeader() error {
 	return nil
 }
 
-// parseClassDefinition parses a single class definition: class A: prop1,prop2
+// parseClassDefinition parses a single class definition: class A: prop1,prop2,
+// or class B extends A: prop3 to give B the properties of A followed by its
+// own.
 func (p *parser) parseClassDefinition() error {
 	// Consume "class" keyword
 	if _, err := p.expect(TokenClass); err != nil {
@@ -134,24 +210,76 @@ func (p *parser) parseClassDefinition() error {
 		return p.syntaxError("expected class name")
 	}
 
+	def := &classDef{props: []string{}}
+	var seen map[string]bool
+	if tok := p.current(); tok.Type == TokenIdentifier && tok.Value == "extends" && p.peek(1).Type == TokenIdentifier {
+		p.advance()
+		parentTok := p.current()
+		parent, ok := p.classes[parentTok.Value]
+		if !ok {
+			return syntaxErrorAt(parentTok, ErrUndefinedClass, fmt.Sprintf("class %s extends undefined class %s", className.Value, parentTok.Value))
+		}
+		p.advance()
+		// Copying the parent's properties costs as much as writing them out,
+		// so they count against the token limit that bounds the header.
+		p.inherited += len(parent.props)
+		if p.inherited > p.limits().MaxTokens {
+			return p.categoryError(ErrTooLarge, fmt.Sprintf("class %s inherits too many properties", className.Value))
+		}
+		def.extends = parentTok.Value
+		def.props = slices.Clone(parent.props)
+		def.types = slices.Clone(parent.types)
+		def.defaults = slices.Clone(parent.defaults)
+		if p.opts.duplicateKeys == DuplicateKeysError {
+			seen = make(map[string]bool, len(def.props))
+			for _, prop := range def.props {
+				seen[prop] = true
+			}
+		}
+	}
+
 	// Consume colon
 	if _, err := p.expect(TokenColon); err != nil {
 		return err
 	}
 
 	// Parse property list
-	properties := []string{}
 	for {
 		prop := p.current()
-		if prop.Type == TokenIdentifier {
-			properties = append(properties, prop.Value)
-			p.advance()
-		} else if prop.Type == TokenString {
-			properties = append(properties, prop.Value)
-			p.advance()
-		} else {
+		if prop.Type != TokenIdentifier && prop.Type != TokenString {
 			break
 		}
+		if p.opts.duplicateKeys == DuplicateKeysError {
+			if _, err := p.checkKey(&seen, prop); err != nil {
+				return err
+			}
+		}
+		def.props = append(def.props, prop.Value)
+		p.advance()
+
+		// A question mark makes the property optional.
+		optional := p.current().Type == TokenQuestion
+		if optional {
+			mark := p.current()
+			def.setDefault(Token{Type: TokenNull, Value: "null", Line: mark.Line, Column: mark.Column, Offset: mark.Offset})
+			p.advance()
+		}
+
+		// An optional type follows the name in a typed class.
+		if p.opts.typedClasses && p.current().Type == TokenIdentifier {
+			if err := p.parsePropType(def, prop.Value); err != nil {
+				return err
+			}
+		}
+		// So does an optional default value.
+		if p.current().Type == TokenEquals {
+			if optional {
+				return p.syntaxError(fmt.Sprintf("optional property %s cannot have a default", prop.Value))
+			}
+			if err := p.parsePropDefault(def, prop.Value); err != nil {
+				return err
+			}
+		}
 
 		// Check for comma
 		if p.current().Type == TokenComma {
@@ -161,8 +289,20 @@ func (p *parser) parseClassDefinition() error {
 		}
 	}
 
+	limits := p.limits()
+	if len(def.props) > limits.MaxProperties {
+		return p.categoryError(ErrTooLarge, fmt.Sprintf("class %s has too many properties", className.Value))
+	}
+	previous, exists := p.classes[className.Value]
+	if !exists && len(p.classes) >= limits.MaxClasses {
+		return p.categoryError(ErrTooLarge, "too many class definitions")
+	}
+	if exists && !p.opts.classShadowing && !previous.equal(def) {
+		return syntaxErrorAt(className, nil, fmt.Sprintf("class %s redefined with different properties", className.Value))
+	}
+
 	// Store class definition
-	p.classes[className.Value] = properties
+	p.classes[className.Value] = def
 
 	// Expect newline or EOF after class definition
 	tok := p.current()
@@ -175,8 +315,11 @@ func (p *parser) parseClassDefinition() error {
 
 // parseValue is the main recursive parser for all TRON values.
 func (p *parser) parseValue(depth int) (interface{}, error) {
-	if depth > maxParseDepth {
-		return nil, p.syntaxError("maximum parse depth exceeded")
+	if depth > p.limits().MaxDepth {
+		return nil, p.categoryError(ErrTooDeep, "maximum parse depth exceeded")
+	}
+	if err := p.opts.cancel.check(); err != nil {
+		return nil, err
 	}
 	tok := p.current()
 
@@ -195,14 +338,7 @@ func (p *parser) parseValue(depth int) (interface{}, error) {
 
 	case TokenNumber:
 		p.advance()
-		if p.preserveNumbers {
-			// Validate number syntax but preserve original string to avoid float64 precision loss.
-			if _, err := strconv.ParseFloat(tok.Value, 64); err != nil {
-				return nil, p.syntaxError(fmt.Sprintf("invalid number: %s", tok.Value))
-			}
-			return numberLiteral(tok.Value), nil
-		}
-		return p.parseNumberValue(tok.Value)
+		return p.numberValue(tok)
 
 	case TokenString:
 		p.advance()
@@ -215,6 +351,10 @@ func (p *parser) parseValue(depth int) (interface{}, error) {
 		return p.parseObject(depth + 1)
 
 	case TokenIdentifier:
+		if p.atNonFinite() {
+			p.advance()
+			return p.numberValue(tok)
+		}
 		// Could be class instantiation A(...)
 		return p.parseClassInstantiation(depth + 1)
 
@@ -223,6 +363,62 @@ func (p *parser) parseValue(depth int) (interface{}, error) {
 	}
 }
 
+// numberValue converts the number token tok, which has been consumed.
+func (p *parser) numberValue(tok Token) (interface{}, error) {
+	if p.preserveNumbers {
+		// Validate number syntax but preserve original string to avoid float64 precision loss.
+		if _, err := strconv.ParseFloat(tok.Value, 64); err != nil {
+			return nil, p.syntaxError(fmt.Sprintf("invalid number: %s", tok.Value))
+		}
+		return numberLiteral(tok.Value), nil
+	}
+	return p.parseNumberValue(tok.Value)
+}
+
+// atNonFinite reports whether the current token is a bare NaN or Infinity
+// to be read as a number, which requires Decoder.AllowNonFinite. A following
+// '(' makes it a class instantiation instead.
+func (p *parser) atNonFinite() bool {
+	tok := p.current()
+	return p.opts.nonFinite && tok.Type == TokenIdentifier && isNonFinite(tok.Value) && p.peek(1).Type != TokenLParen
+}
+
+// skipValue parses and discards the value at the current position, checking
+// it as parseValue does without building it.
+func (p *parser) skipValue(depth int) error {
+	if depth > p.limits().MaxDepth {
+		return p.categoryError(ErrTooDeep, "maximum parse depth exceeded")
+	}
+	if err := p.opts.cancel.check(); err != nil {
+		return err
+	}
+	skipElement := func() error { return p.skipValue(depth + 2) }
+	skipMember := func(string) error { return p.skipValue(depth + 2) }
+
+	tok := p.current()
+	switch tok.Type {
+	case TokenTrue, TokenFalse, TokenNull, TokenString:
+		p.advance()
+		return nil
+	case TokenNumber:
+		p.advance()
+		_, err := p.numberValue(tok)
+		return err
+	case TokenLBracket:
+		return p.parseArrayWith(skipElement)
+	case TokenLBrace:
+		return p.parseObjectWith(depth+1, skipMember)
+	case TokenIdentifier:
+		if p.atNonFinite() {
+			p.advance()
+			_, err := p.numberValue(tok)
+			return err
+		}
+		return p.parseClassInstantiationWith(depth+1, skipMember)
+	}
+	return p.syntaxError(fmt.Sprintf("unexpected token: %s", tok.Type))
+}
+
 // numberLiteral preserves the original textual representation of a number.
 //
 // Used by Unmarshal to avoid float64 precision loss for large integers.
@@ -239,43 +435,101 @@ func (p *parser) parseNumberValue(s string) (float64, error) {
 
 // parseArray parses an array: [item1,item2,...]
 func (p *parser) parseArray(depth int) ([]interface{}, error) {
-	if _, err := p.expect(TokenLBracket); err != nil {
+	items := []interface{}{}
+	err := p.parseArrayWith(func() error {
+		item, err := p.parseValue(depth + 1)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
+	return items, nil
+}
 
-	items := []interface{}{}
+// parseArrayWith parses the brackets and separators of an array, calling elem
+// once per element. elem must consume exactly one value.
+func (p *parser) parseArrayWith(elem func() error) error {
+	if _, err := p.expect(TokenLBracket); err != nil {
+		return err
+	}
 
 	p.skipNewlines()
 	// Handle empty array
 	if p.current().Type == TokenRBracket {
 		p.advance()
-		return items, nil
+		return nil
 	}
 
 	// Parse array elements
-	for {
+	limit := p.limits().MaxArrayLength
+	for n := 1; ; n++ {
 		p.skipNewlines()
-		item, err := p.parseValue(depth + 1)
-		if err != nil {
-			return nil, err
+		if n > limit {
+			return p.categoryError(ErrTooLarge, "array has too many elements")
+		}
+		if err := p.countValue(); err != nil {
+			return err
+		}
+		start := p.pos
+		if err := elem(); err != nil {
+			if err := p.recoverFrom(err, start, TokenRBracket); err != nil {
+				return err
+			}
 		}
-		items = append(items, item)
 
 		p.skipNewlines()
+		if err := p.recoverSeparator(TokenRBracket); err != nil {
+			return err
+		}
 		// Check for comma
 		if p.current().Type != TokenComma {
 			break
 		}
 		p.advance() // consume comma
+		if p.atTrailingComma(TokenRBracket) {
+			break
+		}
 	}
 
 	p.skipNewlines()
 	// Expect closing bracket
-	if _, err := p.expect(TokenRBracket); err != nil {
-		return nil, err
+	_, err := p.expect(TokenRBracket)
+	return err
+}
+
+// atTrailingComma reports whether the comma just consumed is a trailing one,
+// directly followed by closer, and trailing commas are allowed.
+func (p *parser) atTrailingComma(closer TokenType) bool {
+	if !p.opts.trailingCommas {
+		return false
 	}
+	p.skipNewlines()
+	return p.current().Type == closer
+}
 
-	return items, nil
+// checkKey applies the duplicate key policy to the member key at tok, given
+// the keys seen so far in its object. It reports whether the member is a
+// duplicate to be skipped. seen is allocated on first use, and is not needed
+// under the default policy.
+func (p *parser) checkKey(seen *map[string]bool, tok Token) (skip bool, err error) {
+	if p.opts.duplicateKeys == DuplicateKeysKeepLast {
+		return false, nil
+	}
+	if *seen == nil {
+		*seen = make(map[string]bool)
+	}
+	if !(*seen)[tok.Value] {
+		(*seen)[tok.Value] = true
+		return false, nil
+	}
+	if p.opts.duplicateKeys == DuplicateKeysError {
+		return false, syntaxErrorAt(tok, nil, fmt.Sprintf("duplicate key %q", tok.Value))
+	}
+	return true, nil
 }
 
 // parseImplicitObject parses a root-level object without surrounding braces.
@@ -291,39 +545,51 @@ func (p *parser) parseImplicitObject() (map[string]interface{}, error) {
 }
 
 func (p *parser) parseImplicitObjectDepth(depth int) (map[string]interface{}, error) {
-	if depth > maxParseDepth {
-		return nil, p.syntaxError("maximum parse depth exceeded")
-	}
 	obj := make(map[string]interface{})
+	err := p.parseImplicitObjectWith(depth, func(key string) error {
+		value, err := p.parseValue(depth + 1)
+		if err != nil {
+			return err
+		}
+		obj[key] = value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
 
-	for {
+// parseImplicitObjectWith parses the keys and separators of an implicit root
+// object at depth, calling member with each key. member must consume exactly
+// one value. Members that the duplicate key policy drops are skipped without
+// calling member.
+func (p *parser) parseImplicitObjectWith(depth int, member func(key string) error) error {
+	if depth > p.limits().MaxDepth {
+		return p.categoryError(ErrTooDeep, "maximum parse depth exceeded")
+	}
+	if p.opts.strictJSON {
+		return p.syntaxError("objects without braces are not allowed in JSON")
+	}
+
+	var seen map[string]bool
+	limit := p.limits().MaxObjectMembers
+	for n := 1; ; n++ {
 		p.skipNewlines()
-		tok := p.current()
-		if tok.Type == TokenEOF {
+		if p.current().Type == TokenEOF {
 			break
 		}
-
-		// Parse key (string or identifier)
-		key := ""
-		if tok.Type == TokenString || tok.Type == TokenIdentifier {
-			key = tok.Value
-			p.advance()
-		} else {
-			return nil, p.syntaxError("expected object key")
+		if n > limit {
+			return p.categoryError(ErrTooLarge, "object has too many members")
 		}
 
-		// Expect colon
-		if _, err := p.expect(TokenColon); err != nil {
-			return nil, err
+		start := p.pos
+		if err := p.parseMember(depth, false, &seen, member); err != nil {
+			if err := p.recoverFrom(err, start, TokenNewline); err != nil {
+				return err
+			}
 		}
 
-		// Parse value
-		value, err := p.parseValue(depth + 1)
-		if err != nil {
-			return nil, err
-		}
-		obj[key] = value
-
 		// Consume optional separators
 		p.skipNewlines()
 		if p.current().Type == TokenComma {
@@ -338,110 +604,225 @@ func (p *parser) parseImplicitObjectDepth(depth int) (map[string]interface{}, er
 			break
 		}
 		// Anything else is unexpected.
-		return nil, p.syntaxError(fmt.Sprintf("unexpected token: %s", p.current().Type))
+		err := p.syntaxError(fmt.Sprintf("unexpected token: %s", p.current().Type))
+		if err := p.recoverFrom(err, p.pos, TokenNewline); err != nil {
+			return err
+		}
 	}
 
-	return obj, nil
+	return nil
+}
+
+// parseMember parses one key, colon and value of an object at depth, applying
+// the duplicate key policy given the keys seen so far, and calls member with
+// the key unless the member is to be skipped. Newlines may precede the value
+// only in a braced object.
+func (p *parser) parseMember(depth int, braced bool, seen *map[string]bool, member func(key string) error) error {
+	// Parse key (must be string or identifier)
+	tok := p.current()
+	if tok.Type != TokenString && tok.Type != TokenIdentifier {
+		return p.syntaxError("expected object key")
+	}
+	skip, err := p.checkKey(seen, tok)
+	if err != nil {
+		return err
+	}
+	p.advance()
+
+	// Expect colon
+	if _, err := p.expect(TokenColon); err != nil {
+		return err
+	}
+
+	if braced {
+		p.skipNewlines()
+	}
+	if err := p.countValue(); err != nil {
+		return err
+	}
+	// Parse value
+	if skip {
+		return p.skipValue(depth + 1)
+	}
+	return member(tok.Value)
 }
 
 // parseObject parses an object: {"key":value,"key2":value2}
 func (p *parser) parseObject(depth int) (map[string]interface{}, error) {
-	if _, err := p.expect(TokenLBrace); err != nil {
+	obj := make(map[string]interface{})
+	err := p.parseObjectWith(depth, func(key string) error {
+		value, err := p.parseValue(depth + 1)
+		if err != nil {
+			return err
+		}
+		obj[key] = value
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
+	return obj, nil
+}
 
-	obj := make(map[string]interface{})
+// parseObjectWith parses the braces, keys and separators of an object at
+// depth, calling member with each key. member must consume exactly one value.
+// Members that the duplicate key policy drops are skipped without calling
+// member.
+func (p *parser) parseObjectWith(depth int, member func(key string) error) error {
+	if _, err := p.expect(TokenLBrace); err != nil {
+		return err
+	}
 
 	p.skipNewlines()
 	// Handle empty object
 	if p.current().Type == TokenRBrace {
 		p.advance()
-		return obj, nil
+		return nil
 	}
 
 	// Parse key-value pairs
-	for {
+	var seen map[string]bool
+	limit := p.limits().MaxObjectMembers
+	for n := 1; ; n++ {
 		p.skipNewlines()
-		// Parse key (must be string or identifier)
-		key := ""
-		tok := p.current()
-		if tok.Type == TokenString {
-			key = tok.Value
-			p.advance()
-		} else if tok.Type == TokenIdentifier {
-			key = tok.Value
-			p.advance()
-		} else {
-			return nil, p.syntaxError("expected object key")
+		if n > limit {
+			return p.categoryError(ErrTooLarge, "object has too many members")
 		}
-
-		// Expect colon
-		if _, err := p.expect(TokenColon); err != nil {
-			return nil, err
+		start := p.pos
+		if err := p.parseMember(depth, true, &seen, member); err != nil {
+			if err := p.recoverFrom(err, start, TokenRBrace); err != nil {
+				return err
+			}
 		}
 
 		p.skipNewlines()
-		// Parse value
-		value, err := p.parseValue(depth + 1)
-		if err != nil {
-			return nil, err
+		if err := p.recoverSeparator(TokenRBrace); err != nil {
+			return err
 		}
-
-		obj[key] = value
-
-		p.skipNewlines()
 		// Check for comma
 		if p.current().Type != TokenComma {
 			break
 		}
 		p.advance() // consume comma
+		if p.atTrailingComma(TokenRBrace) {
+			break
+		}
 	}
 
 	p.skipNewlines()
 	// Expect closing brace
-	if _, err := p.expect(TokenRBrace); err != nil {
+	_, err := p.expect(TokenRBrace)
+	return err
+}
+
+// parseClassInstantiation parses class instantiation: A(arg1,arg2,...) or
+// A(prop1:arg1,prop2:arg2,...)
+func (p *parser) parseClassInstantiation(depth int) (map[string]interface{}, error) {
+	obj := make(map[string]interface{})
+	err := p.parseClassInstantiationWith(depth, func(prop string) error {
+		arg, err := p.parseValue(depth + 1)
+		if err != nil {
+			return err
+		}
+		obj[prop] = arg
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-
 	return obj, nil
 }
 
-// parseClassInstantiation parses class instantiation: A(arg1,arg2,...)
-func (p *parser) parseClassInstantiation(depth int) (map[string]interface{}, error) {
+// parseClassInstantiationWith parses the class name, parentheses and
+// separators of a class instantiation, calling arg with the property name of
+// each argument. arg must consume exactly one value. Surplus arguments, and
+// those for a repeated property that the duplicate key policy drops, are
+// parsed and discarded.
+func (p *parser) parseClassInstantiationWith(depth int, arg func(prop string) error) error {
 	// Get class name
-	className := p.current().Value
+	nameTok := p.current()
+	className := nameTok.Value
 	p.advance()
 
 	// Expect opening paren
 	if _, err := p.expect(TokenLParen); err != nil {
-		return nil, p.syntaxError("expected ( for class instantiation")
+		return p.syntaxError("expected ( for class instantiation")
 	}
 
 	// Look up class definition
-	properties, exists := p.classes[className]
+	def, exists := p.classes[className]
 	if !exists {
-		return nil, p.syntaxError(fmt.Sprintf("undefined class: %s", className))
+		return syntaxErrorAt(nameTok, ErrUndefinedClass, fmt.Sprintf("undefined class: %s", className))
 	}
+	properties := def.props
 
-	args := []interface{}{}
-
-	// Handle empty argument list
-	if p.current().Type == TokenRParen {
-		p.advance()
-		if len(properties) != 0 {
-			return nil, p.syntaxError(fmt.Sprintf("class %s expects %d arguments, got 0", className, len(properties)))
+	// argAt passes the argument at the current position, for property i, to
+	// arg. key identifies the property for the duplicate key policy.
+	var seen map[string]bool
+	argAt := func(i int, key Token) error {
+		p.arg = i
+		if err := p.countValue(); err != nil {
+			return err
 		}
-		return make(map[string]interface{}), nil
+		if err := p.checkArgType(className, def, i); err != nil {
+			return err
+		}
+		skip, err := p.checkKey(&seen, key)
+		if err != nil {
+			return err
+		}
+		if skip {
+			return p.skipValue(depth + 1)
+		}
+		return arg(properties[i])
 	}
 
-	// Parse arguments
-	for {
+	// Arguments are either all positional or all named, as in
+	// A(name:"Alice",age:30). A named argument may be given in any order.
+	n := 0
+	argument := func() error {
+		n++
+		if n > len(properties) {
+			return p.skipValue(depth + 1)
+		}
+		// Duplicate properties were rejected with the class definition
+		// under DuplicateKeysError, so only keep-first can skip here.
+		return argAt(n-1, Token{Value: properties[n-1]})
+	}
+	var given []bool // in a named instantiation, the properties given
+	namedArgument := func() error {
+		key := p.current()
+		i := slices.Index(properties, key.Value)
+		if i < 0 {
+			return syntaxErrorAt(key, nil, fmt.Sprintf("class %s has no property %s", className, key.Value))
+		}
+		p.advance() // key
+		p.advance() // ':'
+		given[i] = true
+		return argAt(i, key)
+	}
+	maxArgs := p.limits().MaxArguments
+	for args := 1; p.current().Type != TokenRParen; args++ {
 		p.skipNewlines()
-		arg, err := p.parseValue(depth + 1)
+		if args > maxArgs {
+			return p.categoryError(ErrTooLarge, fmt.Sprintf("class %s instantiated with too many arguments", className))
+		}
+		named := (p.current().Type == TokenIdentifier || p.current().Type == TokenString) && p.peek(1).Type == TokenColon
+		if n == 0 && given == nil && named {
+			given = make([]bool, len(properties))
+		}
+		var err error
+		switch {
+		case named != (given != nil):
+			err = p.syntaxError("cannot mix positional and named class arguments")
+		case named:
+			err = namedArgument()
+		default:
+			err = argument()
+		}
 		if err != nil {
-			return nil, err
+			return err
 		}
-		args = append(args, arg)
 
 		p.skipNewlines()
 		// Check for comma
@@ -449,27 +830,49 @@ func (p *parser) parseClassInstantiation(depth int) (map[string]interface{}, err
 			break
 		}
 		p.advance() // consume comma
+		if p.atTrailingComma(TokenRParen) {
+			break
+		}
 	}
 
 	p.skipNewlines()
 	// Expect closing paren
 	if _, err := p.expect(TokenRParen); err != nil {
-		return nil, err
+		return err
 	}
 
-	// Validate argument count
-	if len(args) != len(properties) {
-		return nil, p.syntaxError(
-			fmt.Sprintf("class %s expects %d arguments, got %d",
-				className, len(properties), len(args)),
-		)
+	// A named instantiation may leave out any property; those with a
+	// default get it, and the rest are absent, as from an object.
+	if given != nil {
+		for i, ok := range given {
+			if ok || i >= len(def.defaults) || def.defaults[i] == nil {
+				continue
+			}
+			err := p.withToken(*def.defaults[i], func() error {
+				return argAt(i, Token{Value: properties[i]})
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
-	// Convert to object using property names as keys
-	obj := make(map[string]interface{})
-	for i, prop := range properties {
-		obj[prop] = args[i]
+	// Validate argument count, then supply the defaults of omitted
+	// trailing arguments.
+	if required := def.required(); n < required || n > len(properties) {
+		want := fmt.Sprint(len(properties))
+		if required < len(properties) {
+			want = fmt.Sprintf("%d to %d", required, len(properties))
+		}
+		return p.categoryError(ErrArgCountMismatch,
+			fmt.Sprintf("class %s expects %s arguments, got %d", className, want, n))
+	}
+	for n < len(properties) {
+		if err := p.withToken(*def.defaults[n], argument); err != nil {
+			return err
+		}
 	}
 
-	return obj, nil
+	return nil
 }
@@ -71,7 +71,11 @@ func TestParseHeader(t *testing.T) {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.want, p.classes)
+				got := make(map[string][]string)
+				for name, def := range p.classes {
+					got[name] = def.props
+				}
+				assert.Equal(t, tt.want, got)
 			}
 		})
 	}
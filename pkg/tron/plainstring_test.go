@@ -0,0 +1,44 @@
+package tron
+
+import "testing"
+
+func TestParseStringPlainAndEscaped(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want string
+		end  int // column after the string
+	}{
+		{`"plain"`, "plain", 8},
+		{`"héllo"`, "héllo", 8},
+		{`"a\"b"`, `a"b`, 7},
+		{`"tab\there"`, "tab\there", 12},
+		{`"\u00fc\u00fcs"`, "üüs", 16},
+		{"\"a\xffb\"", "a�b", 6},
+		{`""`, "", 3},
+	} {
+		got, cursor, column, err := parseString([]byte(tc.in), 0, 1, 1, &decodeOptions{})
+		if err != nil {
+			t.Errorf("%s: %v", tc.in, err)
+			continue
+		}
+		if got != tc.want || cursor != len(tc.in) || column != tc.end {
+			t.Errorf("%s: got %q, cursor %d, column %d; want %q, %d, %d", tc.in, got, cursor, column, tc.want, len(tc.in), tc.end)
+		}
+	}
+
+	// Errors are found past a plain prefix.
+	for _, in := range []string{`"abc`, "\"ab\x01\"", "\"ab\xff\""} {
+		if _, _, _, err := parseString([]byte(in), 0, 1, 1, &decodeOptions{strictJSON: true, strictUTF8: true}); err == nil {
+			t.Errorf("%q: expected error", in)
+		}
+	}
+
+	// Tokens after a plain string are placed correctly.
+	tokens, err := tokenizeWith([]byte(`["héllo", x]`), decodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok := tokens[3]; tok.Value != "x" || tok.Column != 11 || tok.Offset != 11 {
+		t.Errorf("token after string: %v, offset %d", tok, tok.Offset)
+	}
+}
@@ -0,0 +1,87 @@
+package tron
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type ptrNode struct {
+	Name  string     `json:"name"`
+	Next  *ptrNode   `json:"next"`
+	Score *float64   `json:"score"`
+	Tags  *[]string  `json:"tags"`
+	Kids  []*ptrNode `json:"kids"`
+	When  *time.Time `json:"when"`
+}
+
+func TestUnmarshalAllocatesPointers(t *testing.T) {
+	input := `{"name":"a","next":{"name":"b","next":{"name":"c"}},"score":1.5,"tags":["x"],` +
+		`"kids":[{"name":"k"},null],"when":"2025-01-02T03:04:05Z"}`
+
+	for _, direct := range []bool{true, false} {
+		var n *ptrNode
+		var err error
+		if direct {
+			err = Unmarshal([]byte(input), &n)
+		} else {
+			// The lenient decoder goes through the parsed tree.
+			_, err = UnmarshalLenient([]byte(input), &n)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == nil || n.Next == nil || n.Next.Next == nil || n.Next.Next.Name != "c" || n.Next.Next.Next != nil {
+			t.Fatalf("direct=%v: nested pointers not allocated: %+v", direct, n)
+		}
+		if n.Score == nil || *n.Score != 1.5 || n.Tags == nil || !reflect.DeepEqual(*n.Tags, []string{"x"}) {
+			t.Errorf("direct=%v: score %v, tags %v", direct, n.Score, n.Tags)
+		}
+		if len(n.Kids) != 2 || n.Kids[0] == nil || n.Kids[0].Name != "k" || n.Kids[1] != nil {
+			t.Errorf("direct=%v: kids %+v", direct, n.Kids)
+		}
+		if n.When == nil || n.When.Year() != 2025 {
+			t.Errorf("direct=%v: when %v", direct, n.When)
+		}
+	}
+}
+
+func TestUnmarshalPointerToPointer(t *testing.T) {
+	var pp **int
+	if err := Unmarshal([]byte("42"), &pp); err != nil {
+		t.Fatal(err)
+	}
+	if pp == nil || *pp == nil || **pp != 42 {
+		t.Fatalf("got %v", pp)
+	}
+
+	// An existing pointer is reused, and null sets it to nil.
+	p := *pp
+	if err := Unmarshal([]byte("7"), &pp); err != nil || *pp != p || *p != 7 {
+		t.Fatalf("reuse: got %v, %v", pp, err)
+	}
+	if err := Unmarshal([]byte("null"), &pp); err != nil || pp != nil {
+		t.Fatalf("null: got %v, %v", pp, err)
+	}
+
+	// An implicit root object and a class instantiation into pointers.
+	var root *struct {
+		Point *struct{ X, Y int } `json:"point"`
+	}
+	if err := Unmarshal([]byte("class P: x,y\n\npoint: P(1,2)"), &root); err != nil {
+		t.Fatal(err)
+	}
+	if root == nil || root.Point == nil || root.Point.X != 1 || root.Point.Y != 2 {
+		t.Fatalf("got %+v", root)
+	}
+
+	// Type errors name the pointed-to type.
+	var n struct {
+		Score *int `json:"score"`
+	}
+	err := Unmarshal([]byte(`{"score":"high"}`), &n)
+	if err == nil || !strings.Contains(err.Error(), "Score") {
+		t.Fatalf("got %v, want a type error for Score", err)
+	}
+}
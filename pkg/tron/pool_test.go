@@ -0,0 +1,137 @@
+package tron
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPooledEncoderStateDoesNotLeakBetweenCalls(t *testing.T) {
+	type pair struct {
+		A int `json:"a"`
+		B int `json:"b"`
+	}
+	first, err := Marshal([]pair{{1, 2}, {3, 4}})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !strings.HasPrefix(string(first), "class A: a,b\n") {
+		t.Fatalf("unexpected first output: %q", first)
+	}
+
+	second, err := Marshal(pair{5, 6})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(second) != `{"a":5,"b":6}` {
+		t.Fatalf("second output reused state: %q", second)
+	}
+}
+
+func TestEncoderBufferPoolingOptOut(t *testing.T) {
+	values := []interface{}{map[string]int{"x": 1}, []string{"a"}, nil}
+
+	var pooled, fresh bytes.Buffer
+	pe := NewEncoder(&pooled)
+	fe := NewEncoder(&fresh)
+	fe.SetBufferPooling(false)
+	for _, v := range values {
+		if err := pe.Encode(v); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+		if err := fe.Encode(v); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+	}
+	if pooled.String() != fresh.String() {
+		t.Fatalf("pooled output %q differs from unpooled %q", pooled.String(), fresh.String())
+	}
+	if want := "{\"x\":1}\n[\"a\"]\nnull\n"; pooled.String() != want {
+		t.Fatalf("Encode output = %q, want %q", pooled.String(), want)
+	}
+}
+
+func TestReleaseDropsOversizedBuffers(t *testing.T) {
+	e := newEncoder(encodeOptions{})
+	e.buf = make([]byte, 0, maxPooledBuffer+1)
+	e.release()
+	if got := encoderPool.Get().(*encoder); cap(got.buf) > maxPooledBuffer {
+		t.Fatalf("oversized buffer returned to the pool")
+	}
+}
+
+func TestPooledTokensDoNotLeakIntoValues(t *testing.T) {
+	// Classes with defaults outlive the records of a line-mode stream, whose
+	// tokens go back to the pool after each Decode.
+	input := "class P: name,kind=\"std\"\n\nP(\"a\")\nP(\"b\",\"big\")\n[\"x\",\"y\",\"z\",\"w\"]\nP(\"c\")\n"
+	dec := NewDecoder(strings.NewReader(input))
+	dec.UseLineMode()
+	var got []interface{}
+	for i := 0; i < 4; i++ {
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		got = append(got, v)
+	}
+	if p := got[3].(map[string]interface{}); p["name"] != "c" || p["kind"] != "std" {
+		t.Errorf("last record = %v", p)
+	}
+	if p := got[0].(map[string]interface{}); p["name"] != "a" || p["kind"] != "std" {
+		t.Errorf("first record changed after later decodes: %v", p)
+	}
+}
+
+func TestReleaseDropsOversizedTokenSlices(t *testing.T) {
+	p := newParser(make([]Token, 0, maxPooledTokens+1))
+	p.pooled = new([]Token)
+	p.release()
+	if got := tokenPool.Get().(*[]Token); cap(*got) > maxPooledTokens {
+		t.Fatalf("oversized token slice returned to the pool")
+	}
+
+	// Released tokens no longer refer to their strings.
+	tokens := []Token{{Type: TokenString, Value: "kept"}}
+	p = newParser(tokens)
+	p.pooled = new([]Token)
+	p.release()
+	if tokens[0].Value != "" {
+		t.Errorf("released token still holds %q", tokens[0].Value)
+	}
+}
+
+func TestTokensSizedByCount(t *testing.T) {
+	// A long string is one token, however many bytes it takes.
+	input := []byte(`"` + strings.Repeat("x", 4*maxPooledTokens) + `"`)
+	tokens, err := tokenizeWith(input, decodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cap(tokens) > 16 {
+		t.Fatalf("%d tokens in a slice of capacity %d", len(tokens), cap(tokens))
+	}
+}
+
+func TestLineModeReleasesTokensOnHeaderErrors(t *testing.T) {
+	news := 0
+	saved := tokenPool.New
+	tokenPool.New = func() interface{} {
+		news++
+		return saved()
+	}
+	defer func() { tokenPool.New = saved }()
+
+	dec := NewDecoder(strings.NewReader(strings.Repeat("class A: a b\nclass B: ,\n", 50)))
+	dec.UseLineMode()
+	for range 100 {
+		var v interface{}
+		if err := dec.Decode(&v); err == nil {
+			t.Fatal("expected an error for a bad header")
+		}
+	}
+	// The race detector drops some of what is put in a pool, so allow for
+	// more than one.
+	if news > 50 {
+		t.Errorf("%d token slices allocated for 100 records", news)
+	}
+}
@@ -0,0 +1,51 @@
+package tron
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPreferInt64(t *testing.T) {
+	input := `{"id":9007199254740993,"ratio":0.5,"exp":1e3,"neg":-7,"big":99999999999999999999,"list":[1,2.5]}`
+
+	dec := NewDecoder(strings.NewReader(input))
+	dec.PreferInt64()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := map[string]interface{}{
+		"id":    int64(9007199254740993),
+		"ratio": 0.5,
+		"exp":   float64(1000),
+		"neg":   int64(-7),
+		"big":   float64(99999999999999999999),
+		"list":  []interface{}{int64(1), 2.5},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Fatalf("unexpected value:\n%#v\nwant:\n%#v", v, want)
+	}
+}
+
+func TestPreferInt64TopLevelNumber(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("42"))
+	dec.PreferInt64()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if v != int64(42) {
+		t.Fatalf("expected int64(42), got %#v", v)
+	}
+}
+
+func TestDefaultDecodesFloat64(t *testing.T) {
+	var v interface{}
+	if err := Unmarshal([]byte("42"), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if v != float64(42) {
+		t.Fatalf("expected float64(42), got %#v", v)
+	}
+}
@@ -0,0 +1,42 @@
+package tron
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOutputSizeEstimate(t *testing.T) {
+	type inner struct {
+		Label string `json:"label"`
+	}
+	type row struct {
+		ID    int      `json:"id"`
+		Name  string   `json:"name"`
+		Inner inner    `json:"inner"`
+		Tags  []string `json:"tags"`
+	}
+	rows := make([]row, 100)
+	for i := range rows {
+		rows[i] = row{ID: i, Name: "row", Inner: inner{Label: "x"}, Tags: []string{"a", "b"}}
+	}
+	values := []interface{}{
+		42,
+		"text",
+		rows,
+		map[string]interface{}{"one": inner{Label: "only"}, "list": []int{1, 2, 3}},
+		struct {
+			A inner `json:"a"`
+		}{inner{"y"}},
+	}
+	for _, v := range values {
+		e := newEncoder(encodeOptions{noPool: true})
+		if err := e.serialize(reflect.ValueOf(v), 0); err != nil {
+			t.Fatalf("serialize: %v", err)
+		}
+		got := e.finish(nil)
+		size := e.headerSize() + e.renderSize()
+		if size < len(got) || size > len(got)+len(got)/4+8 {
+			t.Errorf("%T: estimated %d bytes for output of %d", v, size, len(got))
+		}
+	}
+}
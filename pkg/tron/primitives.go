@@ -0,0 +1,164 @@
+package tron
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"unicode/utf8"
+)
+
+// primitiveSliceTypes are the slice types serializePrimitiveSlice and
+// decodePrimitiveSlice handle.
+var primitiveSliceTypes = map[reflect.Type]bool{
+	reflect.TypeOf([]int(nil)):     true,
+	reflect.TypeOf([]int64(nil)):   true,
+	reflect.TypeOf([]float64(nil)): true,
+	reflect.TypeOf([]string(nil)):  true,
+	reflect.TypeOf([]bool(nil)):    true,
+}
+
+// serializePrimitiveSlice writes v if it is a []int, []int64, []float64,
+// []string or []bool, looping over the elements directly rather than calling
+// serialize with a reflect.Value for each, which makes long numeric arrays
+// much cheaper to encode. The output is the same as serialize's. It reports
+// false, having written nothing, for any other slice, including those of
+// named element types, which may have marshalers of their own.
+func (e *encoder) serializePrimitiveSlice(v reflect.Value) (bool, error) {
+	if !primitiveSliceTypes[v.Type()] || !v.CanInterface() {
+		return false, nil
+	}
+	switch s := v.Interface().(type) {
+	case []int:
+		e.buf = append(e.buf, '[')
+		for i, n := range s {
+			if i > 0 {
+				e.buf = append(e.buf, ',')
+			}
+			e.buf = strconv.AppendInt(e.buf, int64(n), 10)
+		}
+	case []int64:
+		e.buf = append(e.buf, '[')
+		for i, n := range s {
+			if i > 0 {
+				e.buf = append(e.buf, ',')
+			}
+			e.buf = strconv.AppendInt(e.buf, n, 10)
+		}
+	case []float64:
+		e.buf = append(e.buf, '[')
+		for i, f := range s {
+			if i > 0 {
+				e.buf = append(e.buf, ',')
+			}
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				if err := e.serializeNonFinite(v.Index(i), f); err != nil {
+					return true, err
+				}
+				continue
+			}
+			e.buf = strconv.AppendFloat(e.buf, f, 'g', -1, 64)
+		}
+	case []string:
+		e.buf = append(e.buf, '[')
+		for i, str := range s {
+			if i > 0 {
+				e.buf = append(e.buf, ',')
+			}
+			if e.strictUTF8 && !utf8.ValidString(str) {
+				return true, e.checkUTF8(v.Index(i), str)
+			}
+			e.buf = e.quote(e.buf, str)
+		}
+	case []bool:
+		e.buf = append(e.buf, '[')
+		for i, b := range s {
+			if i > 0 {
+				e.buf = append(e.buf, ',')
+			}
+			e.buf = strconv.AppendBool(e.buf, b)
+		}
+	default:
+		return false, nil
+	}
+	e.buf = append(e.buf, ']')
+	return true, nil
+}
+
+// decodePrimitiveSlice decodes the array at the current position of p into
+// dst if it is one of the slice types serializePrimitiveSlice handles.
+// Elements whose token has the obvious form for the element type, such as a
+// string for []string or an integer literal for []int, are converted and
+// appended to the slice directly; any others, like nulls or numbers out of
+// range, are decoded through decodeDirect one by one, so the results and
+// errors are those of decodeDirectSlice. It reports false, having read
+// nothing, for other destinations.
+func (d *decoder) decodePrimitiveSlice(p *parser, dst reflect.Value, depth int) (bool, error) {
+	if !primitiveSliceTypes[dst.Type()] || !dst.CanInterface() || !p.preserveNumbers || depth > p.limits().MaxDepth {
+		return false, nil
+	}
+	switch s := dst.Interface().(type) {
+	case []int:
+		return true, decodeElements(d, p, dst, s, depth, func(tok Token) (int, bool) {
+			if tok.Type != TokenNumber {
+				return 0, false
+			}
+			n, err := strconv.ParseInt(tok.Value, 10, 64)
+			return int(n), err == nil
+		})
+	case []int64:
+		return true, decodeElements(d, p, dst, s, depth, func(tok Token) (int64, bool) {
+			if tok.Type != TokenNumber {
+				return 0, false
+			}
+			n, err := strconv.ParseInt(tok.Value, 10, 64)
+			return n, err == nil
+		})
+	case []float64:
+		return true, decodeElements(d, p, dst, s, depth, func(tok Token) (float64, bool) {
+			if tok.Type != TokenNumber {
+				return 0, false
+			}
+			f, err := strconv.ParseFloat(tok.Value, 64)
+			return f, err == nil
+		})
+	case []string:
+		return true, decodeElements(d, p, dst, s, depth, func(tok Token) (string, bool) {
+			return tok.Value, tok.Type == TokenString
+		})
+	case []bool:
+		return true, decodeElements(d, p, dst, s, depth, func(tok Token) (bool, bool) {
+			return tok.Type == TokenTrue, tok.Type == TokenTrue || tok.Type == TokenFalse
+		})
+	}
+	return false, nil
+}
+
+// decodeElements decodes an array into the slice dst, whose value is s,
+// reusing its backing array. convert returns the element for a token it can
+// handle alone.
+func decodeElements[T any](d *decoder, p *parser, dst reflect.Value, s []T, depth int, convert func(tok Token) (T, bool)) error {
+	s = s[:0]
+	var errs error
+	err := p.parseArrayWith(func() error {
+		if err := d.cancel.check(); err != nil {
+			return err
+		}
+		if v, ok := convert(p.current()); ok {
+			p.advance()
+			s = append(s, v)
+			return nil
+		}
+		var zero T
+		s = append(s, zero)
+		i := len(s) - 1
+		return d.recordError(&errs, elementError(d.decodeDirect(p, reflect.ValueOf(&s[i]).Elem(), depth), indexPath(i)))
+	})
+	if err != nil {
+		return err
+	}
+	if len(s) == 0 {
+		s = []T{}
+	}
+	dst.Set(reflect.ValueOf(s))
+	return errs
+}
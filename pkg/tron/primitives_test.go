@@ -0,0 +1,135 @@
+package tron
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPrimitiveSlicesMatchGeneralEncoding(t *testing.T) {
+	// Named element types take the general path, one element at a time.
+	type myInt int
+	type myInt64 int64
+	type myFloat float64
+	type myString string
+	type myBool bool
+	cases := []struct {
+		fast, slow interface{}
+	}{
+		{[]int{1, -2, 300}, []myInt{1, -2, 300}},
+		{[]int64{math.MaxInt64, math.MinInt64}, []myInt64{math.MaxInt64, math.MinInt64}},
+		{[]float64{0, 1.5, -2e-9, 1e21}, []myFloat{0, 1.5, -2e-9, 1e21}},
+		{[]string{"a", "<b>", "é\n", ""}, []myString{"a", "<b>", "é\n", ""}},
+		{[]bool{true, false}, []myBool{true, false}},
+		{[]int{}, []myInt{}},
+	}
+	for _, c := range cases {
+		want, err := Marshal(c.slow)
+		if err != nil {
+			t.Fatalf("Marshal(%T): %v", c.slow, err)
+		}
+		got, err := Marshal(c.fast)
+		if err != nil {
+			t.Fatalf("Marshal(%T): %v", c.fast, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("Marshal(%T) = %s, want %s", c.fast, got, want)
+		}
+	}
+
+	// Nested in structs and maps, and as nil slices.
+	v := struct {
+		Samples []float64 `json:"samples"`
+		Names   []string  `json:"names"`
+		Missing []int     `json:"missing"`
+	}{Samples: []float64{1, 2}, Names: []string{"x"}}
+	got, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if want := `{"samples":[1,2],"names":["x"],"missing":null}`; string(got) != want {
+		t.Errorf("Marshal = %s, want %s", got, want)
+	}
+}
+
+func TestPrimitiveSlicesOptions(t *testing.T) {
+	samples := []float64{1, math.NaN(), math.Inf(-1)}
+	var uerr *UnsupportedValueError
+	if _, err := Marshal(samples); !errors.As(err, &uerr) {
+		t.Fatalf("Marshal(NaN) = %v, want UnsupportedValueError", err)
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetNonFinite(NonFiniteNull)
+	if err := enc.Encode(samples); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if got := buf.String(); got != "[1,null,null]\n" {
+		t.Errorf("Encode = %q, want [1,null,null]", got)
+	}
+
+	buf.Reset()
+	enc = NewEncoder(&buf)
+	enc.SetStrictUTF8(true)
+	if err := enc.Encode([]string{"ok", "bad\xff"}); !errors.As(err, &uerr) {
+		t.Fatalf("Encode(invalid UTF-8) = %v, want UnsupportedValueError", err)
+	}
+}
+
+func TestPrimitiveSlicesDecodeLikeGeneralPath(t *testing.T) {
+	// Named element types take the general path, one element at a time.
+	type myInt int
+	type myFloat float64
+	type myString string
+	type myBool bool
+	inputs := []string{
+		`[1,-2,300]`,
+		`[]`,
+		`[1.5,2e3,null,4]`,
+		`["a","b\n",null]`,
+		`[true,false,null]`,
+		`[1,"x",3]`,
+		`[99999999999999999999,2]`,
+		`[1,[2],3]`,
+		"[\n  1,\n  2\n]",
+		`[1,2`,
+	}
+	unnamed := strings.NewReplacer("tron.myInt", "int", "tron.myFloat", "float64", "tron.myString", "string", "tron.myBool", "bool")
+	for _, in := range inputs {
+		pairs := []struct{ fast, slow interface{} }{
+			{new([]int), new([]myInt)},
+			{new([]float64), new([]myFloat)},
+			{new([]string), new([]myString)},
+			{new([]bool), new([]myBool)},
+		}
+		for _, pair := range pairs {
+			errFast := Unmarshal([]byte(in), pair.fast)
+			errSlow := Unmarshal([]byte(in), pair.slow)
+			if (errFast == nil) != (errSlow == nil) || errFast != nil && errFast.Error() != unnamed.Replace(errSlow.Error()) {
+				t.Errorf("Unmarshal(%s, %T) error = %v, want %v", in, pair.fast, errFast, errSlow)
+				continue
+			}
+			got, _ := Marshal(pair.fast)
+			want, _ := Marshal(pair.slow)
+			if string(got) != string(want) {
+				t.Errorf("Unmarshal(%s, %T) = %s, want %s", in, pair.fast, got, want)
+			}
+		}
+	}
+}
+
+func TestPrimitiveSlicesDecodeReuseBackingArray(t *testing.T) {
+	s := make([]int, 0, 8)
+	if err := Unmarshal([]byte(`[1,2,3]`), &s); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(s) != 3 || cap(s) != 8 || s[2] != 3 {
+		t.Fatalf("Unmarshal = %v (cap %d), want [1 2 3] in the existing array", s, cap(s))
+	}
+	if err := Unmarshal([]byte(`[]`), &s); err != nil || s == nil || len(s) != 0 {
+		t.Fatalf("Unmarshal([]) = %v, %v, want an empty slice", s, err)
+	}
+}
@@ -0,0 +1,124 @@
+package tron
+
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// AppendString appends s to dst as a quoted TRON string literal, escaped
+// exactly as Marshal escapes string values, and returns the extended buffer.
+// It is intended for hand-written and generated MarshalTRON methods.
+func AppendString(dst []byte, s string) []byte {
+	return appendQuoted(dst, s)
+}
+
+const hexDigits = "0123456789abcdef"
+
+// escapeFlags selects the characters a quoted string escapes beyond those
+// TRON requires.
+type escapeFlags uint8
+
+const (
+	escapeHTML     escapeFlags = 1 << iota // '<', '>' and '&'
+	escapeNonASCII                         // every rune from U+0080 up
+)
+
+// appendQuoted appends s as a quoted string using the same escaping as
+// encoding/json with HTML escaping on: control characters, '<', '>' and '&'
+// become \u escapes, U+2028 and U+2029 are escaped, and invalid UTF-8 is
+// replaced by U+FFFD.
+func appendQuoted(dst []byte, s string) []byte {
+	return appendQuotedWith(dst, s, escapeHTML)
+}
+
+// appendQuotedWith appends s as a quoted string like appendQuoted, escaping
+// '<', '>' and '&' only if flags has escapeHTML, and writing every non-ASCII
+// rune as a \u escape, or a surrogate pair of them, if it has escapeNonASCII.
+func appendQuotedWith(dst []byte, s string, flags escapeFlags) []byte {
+	html := flags&escapeHTML != 0
+	ascii := flags&escapeNonASCII != 0
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && (!html || b != '<' && b != '>' && b != '&') {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '\\', '"':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if ascii {
+			dst = append(dst, s[start:i]...)
+			dst = appendRuneEscape(dst, c)
+			i += size
+			start = i
+			continue
+		}
+		if c == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if c == '\u2028' || c == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// appendRuneEscape appends the \u escape for c to dst, as a UTF-16
+// surrogate pair if c is outside the Basic Multilingual Plane.
+func appendRuneEscape(dst []byte, c rune) []byte {
+	if r1, r2 := utf16.EncodeRune(c); r1 != utf8.RuneError {
+		dst = appendRuneEscape(dst, r1)
+		c = r2
+	}
+	return append(dst, '\\', 'u', hexDigits[c>>12&0xF], hexDigits[c>>8&0xF], hexDigits[c>>4&0xF], hexDigits[c&0xF])
+}
+
+// appendASCII appends the TRON text src to dst with every non-ASCII rune
+// written as a \u escape. Such runes can only occur within strings, in the
+// output of Marshal, so the escapes keep its meaning.
+func appendASCII(dst []byte, src string) []byte {
+	start := 0
+	for i := 0; i < len(src); {
+		if src[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(src[i:])
+		dst = append(dst, src[start:i]...)
+		dst = appendRuneEscape(dst, c)
+		i += size
+		start = i
+	}
+	return append(dst, src[start:]...)
+}
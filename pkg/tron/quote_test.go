@@ -0,0 +1,60 @@
+package tron
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAppendStringMatchesJSON(t *testing.T) {
+	inputs := []string{
+		"",
+		"plain",
+		`quote " and \ backslash`,
+		"\b\f\n\r\t\x00\x1f\x7f",
+		"<script>&amp;</script>",
+		"line para ",
+		"bad \xff utf8 \xe2\x82",
+		"héllo, 世界 🌍",
+	}
+	for _, s := range inputs {
+		want, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := AppendString([]byte("x"), s); string(got) != "x"+string(want) {
+			t.Errorf("AppendString(%q) = %s, want x%s", s, got, want)
+		}
+	}
+}
+
+func TestEncoderStrictUTF8(t *testing.T) {
+	type doc struct {
+		S string
+		M map[string]int
+		B []byte
+	}
+	for _, v := range []doc{
+		{S: "a\xffb"},
+		{M: map[string]int{"k\xff": 1}},
+		{B: []byte("\xfe")},
+	} {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetRawBytes(true)
+		if err := enc.Encode(v); err != nil {
+			t.Fatalf("%+v: %v", v, err)
+		}
+		if !strings.Contains(buf.String(), "�") {
+			t.Errorf("%+v: got %s, want a replacement rune", v, buf.String())
+		}
+
+		enc.SetStrictUTF8(true)
+		var valueErr *UnsupportedValueError
+		if err := enc.Encode(v); !errors.As(err, &valueErr) {
+			t.Errorf("%+v: got %v, want UnsupportedValueError", v, err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package tron
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// A TypeRegistry maps class names to Go types, so that a Decoder can decode
+// a class instantiation into an interface-typed value as the concrete type
+// its class names. With Dog and Cat registered, for example, an []Animal
+// decodes from [Dog("Rex"),Cat("Tom")] as a Dog and a Cat, giving payloads
+// the shape of a sum type.
+//
+// Marshal does not name classes after registered types, so documents using
+// them are written by hand, by other producers or by language models.
+//
+// The zero value is an empty registry ready to use. A TypeRegistry must not
+// be modified while a Decoder is using it.
+type TypeRegistry struct {
+	types         map[string]reflect.Type
+	discriminator string // the member naming the class of an object, or ""
+}
+
+// Register maps class to the type of v, which is usually a struct or a
+// pointer to one: a struct value decodes into the interface as a struct, and
+// a pointer as a pointer to a newly allocated struct. Register panics if v is
+// nil or class is already mapped to another type.
+func (r *TypeRegistry) Register(class string, v any) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		panic("tron: Register of nil value")
+	}
+	if prev, ok := r.types[class]; ok && prev != t {
+		panic(fmt.Sprintf("tron: class %s registered as both %v and %v", class, prev, t))
+	}
+	if r.types == nil {
+		r.types = make(map[string]reflect.Type)
+	}
+	r.types[class] = t
+}
+
+// SetDiscriminator makes key the discriminator member of objects: an object
+// or class instantiation decoded into an interface-typed value whose member
+// key is a string naming a registered class decodes as that class's type, as
+// {"type":"Dog","name":"Rex"} does with Dog registered. The name of the
+// class of an instantiation takes precedence. A struct field of interface
+// type can name its own discriminator with the discriminator tag option, as
+// in `json:"pet" tron:"pet,discriminator=kind"`.
+func (r *TypeRegistry) SetDiscriminator(key string) {
+	r.discriminator = key
+}
+
+// SetTypeRegistry causes the Decoder to decode a class instantiation into an
+// interface-typed value, such as a field of type Animal or an element of an
+// []any, as the Go type r maps its class to, provided that type implements
+// the interface. Other values decode into interfaces as before, except that
+// registered classes are found within the arrays and objects stored in an
+// interface{} as well.
+func (dec *Decoder) SetTypeRegistry(r *TypeRegistry) {
+	dec.opts.types = r
+}
+
+// decodeDynamic decodes the value at the current position into the
+// interface dst, as the registered type its class names if it is a class
+// instantiation, or that the string member key names if key is not empty
+// and it is an object or instantiation. Arrays and objects stored in an
+// empty interface are decoded element by element so that such values
+// within them are found too. It returns false, having consumed nothing, if
+// the value is of none of those kinds.
+func (d *decoder) decodeDynamic(p *parser, dst reflect.Value, depth int, key string) (bool, error) {
+	tok := p.current()
+	if tok.Type != TokenLBrace && tok.Type != TokenLBracket && (tok.Type != TokenIdentifier || p.atNonFinite()) {
+		return false, nil
+	}
+
+	var t reflect.Type
+	if tok.Type == TokenIdentifier {
+		t = d.types.types[tok.Value]
+	}
+	if (t == nil || !t.AssignableTo(dst.Type())) && key != "" && tok.Type != TokenLBracket {
+		t = d.discriminatedType(p, depth, key)
+	}
+	if t != nil && t.AssignableTo(dst.Type()) {
+		v := reflect.New(t).Elem()
+		target := v
+		if t.Kind() == reflect.Ptr {
+			v.Set(reflect.New(t.Elem()))
+			target = v.Elem()
+		}
+		err := d.decodeDirect(p, target, depth)
+		if err == nil || !isFatal(err) {
+			dst.Set(v)
+		}
+		return true, err
+	}
+
+	if dst.NumMethod() != 0 {
+		return false, nil
+	}
+	return d.decodeContainer(p, dst, depth)
+}
+
+// decodeContainer decodes the array, object or class instantiation at the
+// current position into the empty interface dst element by element, so that
+// the options that apply to values within it are honored. It returns false,
+// having consumed nothing, if the value is of none of those kinds.
+func (d *decoder) decodeContainer(p *parser, dst reflect.Value, depth int) (bool, error) {
+	tok := p.current()
+	if tok.Type != TokenLBrace && tok.Type != TokenLBracket && (tok.Type != TokenIdentifier || p.atNonFinite()) {
+		return false, nil
+	}
+	if tok.Type != TokenLBracket {
+		m, target := d.newObject()
+		err := d.decodeDirect(p, target, depth)
+		dst.Set(m)
+		return true, err
+	}
+	items := []interface{}{}
+	var errs error
+	err := p.parseArrayWith(func() error {
+		items = append(items, nil)
+		i := len(items) - 1
+		return d.recordError(&errs, elementError(d.decodeDirect(p, reflect.ValueOf(items).Index(i), depth+2), indexPath(i)))
+	})
+	if err != nil {
+		return true, err
+	}
+	dst.Set(reflect.ValueOf(items))
+	return true, errs
+}
+
+// newObject returns a new value to store an object decoded into an empty
+// interface, a map[string]interface{} or an *OrderedMap, and the value to
+// decode its members into.
+func (d *decoder) newObject() (v, target reflect.Value) {
+	if d.orderedMaps {
+		v = reflect.ValueOf(&OrderedMap{})
+		return v, v.Elem()
+	}
+	v = reflect.ValueOf(map[string]interface{}{})
+	return v, v
+}
+
+// errDiscriminator stops the walk of discriminatedType at the member it
+// looks for.
+var errDiscriminator = errors.New("tron: discriminator found")
+
+// discriminatedType looks ahead at the object or class instantiation at the
+// current position and returns the registered type named by its member key,
+// or nil if it has no such string member or the name is not registered.
+// Only the members of the value are looked at, skipping what they hold, and
+// the walk stops at key unless a later duplicate key would replace it. The
+// position is left unchanged.
+func (d *decoder) discriminatedType(p *parser, depth int, key string) reflect.Type {
+	pos, values, issues := p.pos, p.values, len(p.issues)
+	defer func() {
+		p.pos, p.values, p.issues = pos, values, p.issues[:issues]
+	}()
+
+	var name string
+	member := func(k string) error {
+		if k != key {
+			return p.skipValue(depth + 2)
+		}
+		name = ""
+		if tok := p.current(); tok.Type == TokenString {
+			name = tok.Value
+		}
+		if p.opts.duplicateKeys != DuplicateKeysKeepLast {
+			return errDiscriminator
+		}
+		return p.skipValue(depth + 2)
+	}
+	var err error
+	if p.current().Type == TokenLBrace {
+		err = p.parseObjectWith(depth+1, member)
+	} else {
+		err = p.parseClassInstantiationWith(depth+1, member)
+	}
+	if err != nil && err != errDiscriminator {
+		// Decoding the value reports the error.
+		return nil
+	}
+	return d.types.types[name]
+}
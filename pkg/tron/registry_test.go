@@ -0,0 +1,170 @@
+package tron
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type registryAnimal interface{ Sound() string }
+
+type registryDog struct {
+	Name string `json:"name"`
+}
+
+func (registryDog) Sound() string { return "woof" }
+
+type registryCat struct {
+	Name  string `json:"name"`
+	Lives int    `json:"lives"`
+}
+
+func (*registryCat) Sound() string { return "meow" }
+
+type registryZoo struct {
+	Star    registryAnimal   `json:"star"`
+	Animals []registryAnimal `json:"animals"`
+	Extra   any              `json:"extra"`
+}
+
+func TestTypeRegistry(t *testing.T) {
+	var r TypeRegistry
+	r.Register("Dog", registryDog{})
+	r.Register("Cat", &registryCat{})
+
+	input := "class Dog: name\nclass Cat: name,lives\nclass Bird: name\n\n" +
+		"star: Cat(\"Tom\",9)\n" +
+		"animals: [Dog(\"Rex\"),Cat(\"Kit\",3)]\n" +
+		"extra: {pets: [Dog(\"Fido\"),Bird(\"Tweety\")], n: 1}"
+	dec := NewDecoder(strings.NewReader(input))
+	dec.SetTypeRegistry(&r)
+	var zoo registryZoo
+	if err := dec.Decode(&zoo); err != nil {
+		t.Fatal(err)
+	}
+	want := registryZoo{
+		Star:    &registryCat{"Tom", 9},
+		Animals: []registryAnimal{registryDog{"Rex"}, &registryCat{"Kit", 3}},
+		Extra: map[string]interface{}{
+			"pets": []interface{}{registryDog{"Fido"}, map[string]interface{}{"name": "Tweety"}},
+			"n":    float64(1),
+		},
+	}
+	if !reflect.DeepEqual(zoo, want) {
+		t.Fatalf("got %#v\nwant %#v", zoo, want)
+	}
+
+	// At the root, and through an implicit object.
+	dec = NewDecoder(strings.NewReader("class Dog: name\n\nDog(\"Rex\")"))
+	dec.SetTypeRegistry(&r)
+	var v any
+	if err := dec.Decode(&v); err != nil || v != (registryDog{"Rex"}) {
+		t.Fatalf("root: got %#v, %v", v, err)
+	}
+	dec = NewDecoder(strings.NewReader("class Dog: name\n\na: Dog(\"Rex\")"))
+	dec.SetTypeRegistry(&r)
+	if err := dec.Decode(&v); err != nil || !reflect.DeepEqual(v, map[string]interface{}{"a": registryDog{"Rex"}}) {
+		t.Fatalf("implicit root: got %#v, %v", v, err)
+	}
+
+	// A class whose type does not implement the interface is an error.
+	dec = NewDecoder(strings.NewReader("class Bird: name\n\n{star: Bird(\"Tweety\")}"))
+	dec.SetTypeRegistry(&r)
+	if err := dec.Decode(&zoo); err == nil {
+		t.Error("unregistered class into registryAnimal: got no error")
+	}
+
+	// Without the registry, instantiations decode into interface{} as maps.
+	if err := Unmarshal([]byte("class Dog: name\n\nDog(\"Rex\")"), &v); err != nil || !reflect.DeepEqual(v, map[string]interface{}{"name": "Rex"}) {
+		t.Fatalf("no registry: got %#v, %v", v, err)
+	}
+}
+
+func TestTypeRegistryConflict(t *testing.T) {
+	var r TypeRegistry
+	r.Register("Dog", registryDog{})
+	r.Register("Dog", registryDog{})
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a class as two types did not panic")
+		}
+	}()
+	r.Register("Dog", &registryCat{})
+}
+
+type registryEvent interface{ kind() string }
+
+type registryClick struct {
+	Type string `json:"type"`
+	X, Y int
+}
+
+func (registryClick) kind() string { return "click" }
+
+type registryKey struct {
+	Key string `json:"key"`
+}
+
+func (registryKey) kind() string { return "key" }
+
+func TestTypeRegistryDiscriminator(t *testing.T) {
+	var r TypeRegistry
+	r.Register("click", registryClick{})
+	r.Register("key", registryKey{})
+	r.SetDiscriminator("type")
+
+	type stream struct {
+		Events []registryEvent `json:"events"`
+		Last   registryEvent   `json:"last" tron:"last,discriminator=kind"`
+	}
+	input := "class E: type,x,y\n\n" +
+		"events: [{\"x\":1,\"y\":2,\"type\":\"click\"},E(\"click\",3,4),{type:\"key\",key:\"q\"}]\n" +
+		"last: {kind:\"key\",key:\"z\",type:\"click\"}"
+	dec := NewDecoder(strings.NewReader(input))
+	dec.SetTypeRegistry(&r)
+	var s stream
+	if err := dec.Decode(&s); err != nil {
+		t.Fatal(err)
+	}
+	want := stream{
+		Events: []registryEvent{registryClick{"click", 1, 2}, registryClick{"click", 3, 4}, registryKey{"q"}},
+		Last:   registryKey{"z"},
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Fatalf("got %#v\nwant %#v", s, want)
+	}
+
+	// An unknown or missing discriminator leaves nothing to decode into.
+	dec = NewDecoder(strings.NewReader(`{"events":[{"type":"scroll"}]}`))
+	dec.SetTypeRegistry(&r)
+	if err := dec.Decode(&s); err == nil {
+		t.Error("unknown discriminator: got no error")
+	}
+	dec = NewDecoder(strings.NewReader(`[{"type":"key","key":"a"},{"type":1}]`))
+	dec.SetTypeRegistry(&r)
+	var v any
+	if err := dec.Decode(&v); err != nil || !reflect.DeepEqual(v, []interface{}{registryKey{"a"}, map[string]interface{}{"type": float64(1)}}) {
+		t.Fatalf("into []any: got %#v, %v", v, err)
+	}
+
+	// Looking ahead for the discriminator counts no values, and follows the
+	// duplicate key policy.
+	dec = NewDecoder(strings.NewReader(`[{"type":"key","key":"a"}]`))
+	dec.SetTypeRegistry(&r)
+	dec.SetLimits(Limits{MaxValues: 3})
+	if err := dec.Decode(&v); err != nil {
+		t.Errorf("MaxValues: %v", err)
+	}
+	for policy, want := range map[DuplicateKeyPolicy]registryEvent{
+		DuplicateKeysKeepLast:  registryKey{"q"},
+		DuplicateKeysKeepFirst: registryClick{"click", 0, 0},
+	} {
+		dec = NewDecoder(strings.NewReader(`{"type":"click","type":"key","key":"q"}`))
+		dec.SetTypeRegistry(&r)
+		dec.SetDuplicateKeys(policy)
+		var e registryEvent
+		if err := dec.Decode(&e); err != nil || !reflect.DeepEqual(e, want) {
+			t.Errorf("policy %d: got %#v, %v, want %#v", policy, e, err, want)
+		}
+	}
+}
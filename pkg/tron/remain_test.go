@@ -0,0 +1,72 @@
+package tron
+
+import (
+	"reflect"
+	"testing"
+)
+
+type withRemain struct {
+	Name  string                 `json:"name"`
+	Extra map[string]interface{} `tron:",remain"`
+}
+
+type withTypedRemain struct {
+	ID    int            `json:"id"`
+	Other map[string]int `json:"other,remain"`
+}
+
+func TestRemainCollectsUnknownKeys(t *testing.T) {
+	var v withRemain
+	input := `{"name":"a","age":3,"tags":["x","y"],"nested":{"k":true}}`
+	if err := Unmarshal([]byte(input), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if v.Name != "a" {
+		t.Fatalf("expected name=a, got %q", v.Name)
+	}
+	want := map[string]interface{}{
+		"age":    float64(3),
+		"tags":   []interface{}{"x", "y"},
+		"nested": map[string]interface{}{"k": true},
+	}
+	if !reflect.DeepEqual(v.Extra, want) {
+		t.Fatalf("unexpected remain map: %#v", v.Extra)
+	}
+}
+
+func TestRemainFromClassInstantiation(t *testing.T) {
+	var v []withRemain
+	input := "class A: name,color\n\n[A(\"a\",\"red\"),A(\"b\",\"blue\")]"
+	if err := Unmarshal([]byte(input), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(v) != 2 || v[1].Name != "b" || v[1].Extra["color"] != "blue" {
+		t.Fatalf("unexpected value: %#v", v)
+	}
+}
+
+func TestRemainTypedMap(t *testing.T) {
+	var v withTypedRemain
+	if err := Unmarshal([]byte(`{"id":1,"x":2,"other":3}`), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	want := map[string]int{"x": 2, "other": 3}
+	if v.ID != 1 || !reflect.DeepEqual(v.Other, want) {
+		t.Fatalf("unexpected value: %#v", v)
+	}
+
+	err := Unmarshal([]byte(`{"id":1,"x":"nope"}`), &v)
+	if _, ok := err.(*UnmarshalTypeError); !ok {
+		t.Fatalf("expected *UnmarshalTypeError, got %T (%v)", err, err)
+	}
+}
+
+func TestRemainKeepsExistingEntries(t *testing.T) {
+	v := withRemain{Extra: map[string]interface{}{"keep": true}}
+	if err := Unmarshal([]byte(`{"new":1}`), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if v.Extra["keep"] != true || v.Extra["new"] != float64(1) {
+		t.Fatalf("unexpected remain map: %#v", v.Extra)
+	}
+}
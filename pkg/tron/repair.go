@@ -0,0 +1,304 @@
+package tron
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Fix describes one change made by Repair.
+type Fix struct {
+	Offset  int    // byte offset in the original input where the change was made
+	Line    int    // 1-based line of Offset
+	Column  int    // 1-based column, in runes, of Offset
+	Message string // what was changed, such as "removed trailing comma"
+}
+
+func (f Fix) String() string {
+	return fmt.Sprintf("line %d, column %d: %s", f.Line, f.Column, f.Message)
+}
+
+// Repair fixes the mistakes most often found in TRON written by language
+// models, and returns the repaired document together with the changes it
+// made:
+//
+//   - trailing commas before a closing bracket are removed;
+//   - brackets, braces and parentheses left open at the end of the input are
+//     closed, as is an unterminated string, and closing brackets that match
+//     nothing are removed;
+//   - strings delimited by smart quotes (“ and ”) get straight quotes;
+//   - bare words in value position that are not numbers, true, false, null
+//     or class instantiations are quoted, up to the next comma, closing
+//     bracket, comment or end of line.
+//
+// Class definitions, comments and well-formed values are copied unchanged.
+// If the repaired document is still not valid TRON, Repair returns it along
+// with the fixes and the error reported by Unmarshal. Valid input is returned
+// as is, with no fixes.
+func Repair(data []byte) ([]byte, []Fix, error) {
+	if validate(data) == nil {
+		return data, nil, nil
+	}
+	r := &repairer{src: data, out: make([]byte, 0, len(data)+16)}
+	r.run()
+	for i := range r.fixes {
+		r.fixes[i].Line, r.fixes[i].Column = position(data, r.fixes[i].Offset)
+	}
+	return r.out, r.fixes, validate(r.out)
+}
+
+// repairer holds the state of one Repair call.
+type repairer struct {
+	src     []byte
+	out     []byte
+	fixes   []Fix
+	closers []byte // closing characters of the open brackets, innermost last
+}
+
+// fix records a change made at offset i of the input.
+func (r *repairer) fix(i int, format string, args ...interface{}) {
+	r.fixes = append(r.fixes, Fix{Offset: i, Message: fmt.Sprintf(format, args...)})
+}
+
+// run copies r.src to r.out, repairing it on the way.
+func (r *repairer) run() {
+	src := r.src
+	lineStart := true
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			r.out = append(r.out, c)
+			i++
+			lineStart = true
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			r.out = append(r.out, c)
+			i++
+			continue
+		case c == '#':
+			i = r.copyLine(i)
+		case c == '"':
+			i = r.copyString(i)
+		case c == '[' || c == '{' || c == '(':
+			r.closers = append(r.closers, closerFor(c))
+			r.out = append(r.out, c)
+			i++
+		case c == ']' || c == '}' || c == ')':
+			r.closeBracket(i)
+			i++
+		case c == ',':
+			if next := r.skipSpace(i + 1); next == len(src) && len(r.closers) > 0 || next < len(src) && isCloser(src[next]) {
+				r.fix(i, "removed trailing comma")
+			} else {
+				r.out = append(r.out, c)
+			}
+			i++
+		case c == ':':
+			r.out = append(r.out, c)
+			i++
+		default:
+			if q, size := utf8.DecodeRune(src[i:]); q == '“' || q == '”' {
+				i = r.smartString(i + size)
+			} else if lineStart && len(r.closers) == 0 && bytes.HasPrefix(src[i:], []byte("class ")) {
+				i = r.copyLine(i)
+			} else {
+				i = r.word(i)
+			}
+		}
+		lineStart = false
+	}
+
+	for j := len(r.closers) - 1; j >= 0; j-- {
+		r.fix(len(src), "inserted missing %c", r.closers[j])
+		r.out = append(r.out, r.closers[j])
+	}
+}
+
+// copyLine copies the input from i up to the end of the line, excluding the
+// newline, and returns the offset after it.
+func (r *repairer) copyLine(i int) int {
+	end := bytes.IndexByte(r.src[i:], '\n')
+	if end < 0 {
+		end = len(r.src) - i
+	}
+	r.out = append(r.out, r.src[i:i+end]...)
+	return i + end
+}
+
+// copyString copies the quoted string starting at i, closing it if the
+// input ends inside it, and returns the offset after it.
+func (r *repairer) copyString(i int) int {
+	src := r.src
+	j := i + 1
+	for j < len(src) && src[j] != '"' {
+		if src[j] == '\\' {
+			j++
+		}
+		j++
+	}
+	if j >= len(src) {
+		r.out = append(r.out, src[i:]...)
+		if j > len(src) {
+			// The input ends with a backslash; drop it so the quote
+			// added below is not escaped.
+			r.out = r.out[:len(r.out)-1]
+		}
+		r.fix(i, "closed unterminated string")
+		r.out = append(r.out, '"')
+		return len(src)
+	}
+	r.out = append(r.out, src[i:j+1]...)
+	return j + 1
+}
+
+// smartString converts the string whose opening smart quote ends at i into a
+// quoted string, ending at the next smart or straight quote or at the end of
+// the line, and returns the offset after it.
+func (r *repairer) smartString(i int) int {
+	src := r.src
+	j := i
+	for j < len(src) && src[j] != '\n' && src[j] != '"' {
+		if q, size := utf8.DecodeRune(src[j:]); q == '“' || q == '”' {
+			r.fix(i-len("“"), "replaced smart quotes")
+			r.out = appendQuoted(r.out, string(src[i:j]))
+			return j + size
+		}
+		j++
+	}
+	if j < len(src) && src[j] == '"' {
+		r.fix(i-len("“"), "replaced smart quotes")
+		r.out = appendQuoted(r.out, string(src[i:j]))
+		return j + 1
+	}
+	r.fix(i-len("“"), "replaced smart quote and closed string")
+	r.out = appendQuoted(r.out, strings.TrimRight(string(src[i:j]), " \t\r"))
+	return j
+}
+
+// closeBracket handles the closing bracket at i. One that matches an open
+// bracket closes it, along with any left open inside it; one that matches
+// none is dropped.
+func (r *repairer) closeBracket(i int) {
+	c := r.src[i]
+	j := bytes.LastIndexByte(r.closers, c)
+	if j < 0 {
+		r.fix(i, "removed unmatched %c", c)
+		return
+	}
+	for k := len(r.closers) - 1; k > j; k-- {
+		r.fix(i, "inserted missing %c", r.closers[k])
+		r.out = append(r.out, r.closers[k])
+	}
+	r.closers = r.closers[:j]
+	r.out = append(r.out, c)
+}
+
+// word handles the bare word at i: a number, keyword, object key or class
+// name is copied, and anything else is quoted as a string. It returns the
+// offset after what it consumed.
+func (r *repairer) word(i int) int {
+	src := r.src
+	end := i
+	for end < len(src) && !isSpace(src[end]) && strings.IndexByte(",[]{}():#\"", src[end]) < 0 {
+		if q, _ := utf8.DecodeRune(src[end:]); q == '“' || q == '”' {
+			break
+		}
+		end++
+	}
+	w := src[i:end]
+	next := byte(0)
+	if end < len(src) {
+		next = src[end]
+	}
+
+	// Keys and class instantiations are followed directly by ':' or '('.
+	if end > i && isIdentifier(w) && (next == ':' || next == '(') {
+		r.out = append(r.out, w...)
+		return end
+	}
+	// Numbers and keywords must stand alone before the next delimiter.
+	if end > i && r.endsValue(end) {
+		if s := string(w); s == "true" || s == "false" || s == "null" {
+			r.out = append(r.out, w...)
+			return end
+		}
+		if _, n, _, ok := parseNumberJSON(w, 0, 0); ok && n == len(w) {
+			r.out = append(r.out, w...)
+			return end
+		}
+	}
+
+	// Quote everything up to the next delimiter.
+	for end < len(src) && src[end] != '\n' && strings.IndexByte(",]})#", src[end]) < 0 {
+		end++
+	}
+	if end == i {
+		// A stray character such as '(' or ':' that cannot start a value.
+		r.out = append(r.out, src[i])
+		return i + 1
+	}
+	s := strings.TrimRight(string(src[i:end]), " \t\r")
+	r.fix(i, "quoted bare string %q", s)
+	r.out = appendQuoted(r.out, s)
+	return i + len(s)
+}
+
+// endsValue reports whether only spaces separate offset i from the end of a
+// value: a comma, closing bracket, comment, newline or the end of the input.
+func (r *repairer) endsValue(i int) bool {
+	for i < len(r.src) && (r.src[i] == ' ' || r.src[i] == '\t' || r.src[i] == '\r') {
+		i++
+	}
+	return i == len(r.src) || strings.IndexByte(",]})#\n", r.src[i]) >= 0
+}
+
+// skipSpace returns the offset of the first character at or after i that is
+// not whitespace or part of a comment.
+func (r *repairer) skipSpace(i int) int {
+	for i < len(r.src) {
+		switch {
+		case isSpace(r.src[i]):
+			i++
+		case r.src[i] == '#':
+			for i < len(r.src) && r.src[i] != '\n' {
+				i++
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// closerFor returns the closing character for the opening bracket c.
+func closerFor(c byte) byte {
+	switch c {
+	case '[':
+		return ']'
+	case '{':
+		return '}'
+	default:
+		return ')'
+	}
+}
+
+// isCloser reports whether c is a closing bracket, brace or parenthesis.
+func isCloser(c byte) bool {
+	return c == ']' || c == '}' || c == ')'
+}
+
+// isIdentifier reports whether w is a TRON identifier.
+func isIdentifier(w []byte) bool {
+	raw, n, _ := parseIdentifierUTF8(w, 0, 0)
+	return n == len(w) && len(raw) > 0
+}
+
+// position returns the 1-based line and rune column of offset i in src.
+func position(src []byte, i int) (line, column int) {
+	before := src[:i]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = utf8.RuneCount(before[bytes.LastIndexByte(before, '\n')+1:]) + 1
+	return line, column
+}
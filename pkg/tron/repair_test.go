@@ -0,0 +1,78 @@
+package tron
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		fixes []string
+	}{
+		{
+			name:  "trailing commas",
+			input: "[1, 2, {\"a\": 3,},\n]",
+			want:  "[1, 2, {\"a\": 3}\n]",
+			fixes: []string{"line 1, column 15: removed trailing comma", "line 1, column 17: removed trailing comma"},
+		},
+		{
+			name:  "missing brackets",
+			input: `{"a": [1, 2, {"b": "x`,
+			want:  `{"a": [1, 2, {"b": "x"}]}`,
+			fixes: []string{"line 1, column 20: closed unterminated string", "line 1, column 22: inserted missing }", "line 1, column 22: inserted missing ]", "line 1, column 22: inserted missing }"},
+		},
+		{
+			name:  "mismatched brackets",
+			input: `{"a": [1, 2}]`,
+			want:  `{"a": [1, 2]}`,
+			fixes: []string{"line 1, column 12: inserted missing ]", "line 1, column 13: removed unmatched ]"},
+		},
+		{
+			name:  "smart quotes",
+			input: "{“name”: “Ada”}",
+			want:  `{"name": "Ada"}`,
+			fixes: []string{"line 1, column 2: replaced smart quotes", "line 1, column 10: replaced smart quotes"},
+		},
+		{
+			name:  "bare strings",
+			input: "class P: name,status\n\nowner: Ada Lovelace # author\nitems: [P(widget, done), P(\"x\", 2)]\nok: true\n",
+			want:  "class P: name,status\n\nowner: \"Ada Lovelace\" # author\nitems: [P(\"widget\", \"done\"), P(\"x\", 2)]\nok: true\n",
+			fixes: []string{`line 3, column 8: quoted bare string "Ada Lovelace"`, `line 4, column 11: quoted bare string "widget"`, `line 4, column 19: quoted bare string "done"`},
+		},
+		{
+			name:  "valid input",
+			input: "class A: x\n\n# c\n[A(1), A(-2.5e3), {\"k\": null}]",
+			want:  "class A: x\n\n# c\n[A(1), A(-2.5e3), {\"k\": null}]",
+		},
+	}
+	for _, tt := range tests {
+		out, fixes, err := Repair([]byte(tt.input))
+		if err != nil {
+			t.Errorf("%s: Repair: %v (output %q)", tt.name, err, out)
+			continue
+		}
+		if string(out) != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, out, tt.want)
+		}
+		var got []string
+		for _, f := range fixes {
+			got = append(got, f.String())
+		}
+		if !reflect.DeepEqual(got, tt.fixes) {
+			t.Errorf("%s: fixes %q, want %q", tt.name, got, tt.fixes)
+		}
+	}
+}
+
+func TestRepairReportsRemainingErrors(t *testing.T) {
+	out, _, err := Repair([]byte(`{"a" 1}`))
+	if err == nil {
+		t.Fatalf("expected error, got %q", out)
+	}
+	if string(out) != `{"a" 1}` {
+		t.Fatalf("got %q", out)
+	}
+}
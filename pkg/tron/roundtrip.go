@@ -0,0 +1,50 @@
+package tron
+
+import "reflect"
+
+// TestingT is the part of testing.TB used by CheckRoundTrip, so that this
+// package does not depend on package testing.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// CheckRoundTrip marshals v, unmarshals the result into a new value of the
+// same type, and reports each way the two differ with t.Errorf, as well as
+// any error marshaling or unmarshaling. It returns the differences, which
+// are none for types that are TRON-safe, so a test suite can check them with
+//
+//	tron.CheckRoundTrip(t, Order{ID: 7, Items: []Item{{SKU: "a"}}})
+//
+// Struct fields that are not encoded, being unexported or tagged "-", are not
+// compared. Numbers held by interface{} values are compared by value, as they
+// decode as float64, and time.Time values with Equal. An empty slice or map
+// in a field tagged omitempty comes back nil, which is reported.
+func CheckRoundTrip(t TestingT, v interface{}) []Difference {
+	t.Helper()
+	data, err := Marshal(v)
+	if err != nil {
+		t.Errorf("tron: cannot marshal %T: %v", v, err)
+		return nil
+	}
+	want := reflect.ValueOf(v)
+	if !want.IsValid() {
+		return nil
+	}
+	got := reflect.New(want.Type())
+	if err := Unmarshal(data, got.Interface()); err != nil {
+		t.Errorf("tron: cannot unmarshal %s into %T: %v", data, v, err)
+		return nil
+	}
+	return reportDifferences(t, v, appendDifferences(nil, "", want, got.Elem()))
+}
+
+// reportDifferences reports each of diffs, found round-tripping v, with
+// t.Errorf and returns them.
+func reportDifferences(t TestingT, v interface{}, diffs []Difference) []Difference {
+	t.Helper()
+	for _, d := range diffs {
+		t.Errorf("tron: %T changed by round trip at %v", v, d)
+	}
+	return diffs
+}
@@ -0,0 +1,91 @@
+package tron
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// recordingT is a TestingT that records the errors reported to it.
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+type roundTripTags struct {
+	Plain    string
+	Renamed  int               `json:"renamed"`
+	Optional *float64          `json:"optional,omitempty"`
+	Zero     time.Time         `json:"zero,omitzero"`
+	Override bool              `json:"json_name" tron:"tron_name"`
+	Level    int               `json:"level,default=3"`
+	Skipped  string            `json:"-"`
+	Extra    map[string]string `json:"extra,inline"`
+	Any      interface{}       `json:"any"`
+	Nested   []roundTripTags   `json:"nested,omitempty"`
+	private  int
+}
+
+func TestCheckRoundTrip(t *testing.T) {
+	ratio := 0.25
+	values := []interface{}{
+		nil,
+		"text",
+		[]int{1, 2},
+		map[string]bool{"x": true},
+		roundTripTags{},
+		roundTripTags{
+			Plain:    "p",
+			Renamed:  -4,
+			Optional: &ratio,
+			Zero:     time.Date(2025, 1, 2, 3, 4, 5, 0, time.FixedZone("X", 3600)),
+			Override: true,
+			Level:    3,
+			Skipped:  "not encoded",
+			Extra:    map[string]string{"more": "yes"},
+			Any:      map[string]interface{}{"n": 7, "list": []interface{}{1, "a"}},
+			Nested:   []roundTripTags{{Level: 1}, {Any: 2.5}},
+			private:  9,
+		},
+	}
+	for _, v := range values {
+		if diffs := CheckRoundTrip(t, v); len(diffs) != 0 {
+			t.Errorf("CheckRoundTrip(%#v) = %v, want none", v, diffs)
+		}
+	}
+}
+
+func TestCheckRoundTripReportsDifferences(t *testing.T) {
+	type lossy struct {
+		Items []string          `json:"items,omitempty"`
+		Any   interface{}       `json:"any"`
+		Big   map[string]uint64 `json:"big"`
+	}
+	v := lossy{
+		Items: []string{},
+		Any:   []interface{}{int64(1<<53 + 1)},
+		Big:   map[string]uint64{"ok": 1},
+	}
+	rec := &recordingT{}
+	diffs := CheckRoundTrip(rec, v)
+	if len(diffs) != 2 || diffs[0].Path != "Items" || diffs[1].Path != "Any[0]" {
+		t.Fatalf("differences = %v, want Items and Any[0]", diffs)
+	}
+	if len(rec.errors) != 2 {
+		t.Fatalf("errors = %q, want 2", rec.errors)
+	}
+	want := "tron: tron.lossy changed by round trip at Any[0]: got 9.007199254740992e+15, want 9007199254740993"
+	if rec.errors[1] != want {
+		t.Errorf("error = %q, want %q", rec.errors[1], want)
+	}
+
+	rec = &recordingT{}
+	if diffs := CheckRoundTrip(rec, make(chan int)); diffs != nil || len(rec.errors) != 1 {
+		t.Errorf("CheckRoundTrip(chan) = %v with errors %q, want one error", diffs, rec.errors)
+	}
+}
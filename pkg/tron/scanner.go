@@ -0,0 +1,193 @@
+package tron
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// ErrNeedInput is returned by Scanner.Next when every token of the input
+// written so far has been returned, and more is needed to complete the next.
+var ErrNeedInput = errors.New("tron: scanner needs more input")
+
+// A Scanner tokenizes TRON text that arrives in chunks, such as a document
+// being read from a network connection, returning each token as soon as the
+// input that completes it has been written, without buffering the whole
+// document. Tokens are the same as from Tokens, positions included:
+//
+//	s := tron.NewScanner()
+//	s.Write(chunk)
+//	for {
+//		tok, err := s.Next()
+//		if err == tron.ErrNeedInput {
+//			break // write the next chunk
+//		}
+//		...
+//	}
+//
+// A token is complete once a delimiter, whitespace or the closing quote of a
+// string follows it, or Close has been called. Only the input after the last
+// complete token is kept. The default Limits apply, MaxInputBytes to that
+// incomplete tail rather than the whole input.
+type Scanner struct {
+	buf     []byte  // input not yet tokenized
+	cut     int     // length of the prefix of buf that holds only complete tokens
+	scanned int     // length of the prefix of buf classified by the state below
+	state   uint8   // lexical state at buf[scanned]: one of the scan constants
+	tokens  []Token // tokens ready to return
+	next    int     // index in tokens of the next one to return
+
+	offset, line, column int // position of buf[0] in the input
+
+	closed bool
+	done   bool // the TokenEOF token has been returned
+	err    error
+}
+
+// Lexical states of a Scanner between bytes.
+const (
+	scanToken   = iota // between tokens, or within a number or name
+	scanString         // within a string
+	scanEscape         // just after a backslash within a string
+	scanComment        // within a comment
+)
+
+// NewScanner returns a Scanner with no input.
+func NewScanner() *Scanner {
+	return &Scanner{line: 1, column: 1}
+}
+
+// Write adds p to the input. It fails once Close has been called, and with
+// the error Next will return if the input so far cannot be tokenized.
+func (s *Scanner) Write(p []byte) (int, error) {
+	if s.closed {
+		return 0, errors.New("tron: write to closed Scanner")
+	}
+	if s.err != nil {
+		return 0, s.err
+	}
+	s.buf = append(s.buf, p...)
+	s.scan()
+	if len(s.buf)-s.cut > (Limits{}).withDefaults().MaxInputBytes {
+		s.err = &SyntaxError{msg: "input too large", err: ErrTooLarge, Offset: int64(s.offset + s.cut)}
+		return len(p), s.err
+	}
+	return len(p), nil
+}
+
+// Close marks the end of the input, so that Next returns the last token and
+// then TokenEOF.
+func (s *Scanner) Close() error {
+	s.closed = true
+	s.cut = len(s.buf)
+	return nil
+}
+
+// Next returns the next token. It returns ErrNeedInput if the input written
+// so far holds no further complete token, and io.EOF once the TokenEOF token
+// has been returned. If the input cannot be tokenized, Next returns the
+// tokens before the problem and then a *SyntaxError, which it keeps
+// returning.
+func (s *Scanner) Next() (Token, error) {
+	if s.next == len(s.tokens) && s.err == nil && !s.done {
+		s.tokenize()
+	}
+	switch {
+	case s.next < len(s.tokens):
+		tok := s.tokens[s.next]
+		s.next++
+		s.done = tok.Type == TokenEOF
+		return tok, nil
+	case s.err != nil:
+		return Token{}, s.err
+	case s.done:
+		return Token{}, io.EOF
+	}
+	return Token{}, ErrNeedInput
+}
+
+// scan classifies the bytes of s.buf not scanned yet, moving s.cut past each
+// one that ends a token: whitespace and delimiters outside strings and
+// comments, and the closing quotes of strings. Such bytes are ASCII, so the
+// cut never splits a rune.
+func (s *Scanner) scan() {
+	for i := s.scanned; i < len(s.buf); i++ {
+		c := s.buf[i]
+		switch s.state {
+		case scanString:
+			switch c {
+			case '\\':
+				s.state = scanEscape
+			case '"':
+				s.state = scanToken
+				s.cut = i + 1
+			}
+		case scanEscape:
+			s.state = scanString
+		case scanComment:
+			if c == '\n' {
+				s.state = scanToken
+				s.cut = i + 1
+			}
+		default:
+			switch c {
+			case '"':
+				s.state = scanString
+			case '#':
+				s.state = scanComment
+			case ' ', '\t', '\r', '\n', '(', ')', '[', ']', '{', '}', ',', ':', ';', '=', '?':
+				s.cut = i + 1
+			}
+		}
+	}
+	s.scanned = len(s.buf)
+}
+
+// tokenize tokenizes the complete tokens in s.buf into s.tokens, and drops
+// their input.
+func (s *Scanner) tokenize() {
+	s.tokens, s.next = s.tokens[:0], 0
+	if s.cut == 0 && !s.closed {
+		return
+	}
+	chunk := s.buf[:s.cut]
+	tokens, err := appendTokens(s.tokens, chunk, decodeOptions{})
+	if err == nil && !s.closed {
+		tokens = tokens[:len(tokens)-1] // the EOF of the chunk
+	}
+	for i := range tokens {
+		tokens[i].Offset += s.offset
+		if tokens[i].Line == 1 {
+			tokens[i].Column += s.column - 1
+		}
+		tokens[i].Line += s.line - 1
+	}
+	s.tokens = tokens
+	if err != nil {
+		if serr, ok := err.(*SyntaxError); ok {
+			serr.Offset += int64(s.offset)
+			if serr.Line == 1 {
+				serr.Column += s.column - 1
+			}
+			serr.Line += s.line - 1
+		}
+		s.err = err
+		return
+	}
+
+	// Move the position past the chunk.
+	s.offset += len(chunk)
+	if n := bytes.Count(chunk, []byte{'\n'}); n > 0 {
+		s.line += n
+		s.column = 1 + utf8.RuneCount(chunk[bytes.LastIndexByte(chunk, '\n')+1:])
+	} else {
+		s.column += utf8.RuneCount(chunk)
+	}
+	s.buf = s.buf[:copy(s.buf, s.buf[s.cut:])]
+	s.scanned -= s.cut
+	s.cut = 0
+	if s.closed {
+		s.cut = len(s.buf)
+	}
+}
@@ -0,0 +1,125 @@
+package tron
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+// scanChunks writes input to a Scanner in chunks of size n, collecting the
+// tokens available after each write, and returns them with the first error
+// other than ErrNeedInput and io.EOF.
+func scanChunks(input string, n int) ([]Token, error) {
+	s := NewScanner()
+	var tokens []Token
+	drain := func() error {
+		for {
+			tok, err := s.Next()
+			if err == ErrNeedInput || err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			tokens = append(tokens, tok)
+		}
+	}
+	for i := 0; i < len(input); i += n {
+		if _, err := s.Write([]byte(input[i:min(i+n, len(input))])); err != nil {
+			return tokens, err
+		}
+		if err := drain(); err != nil {
+			return tokens, err
+		}
+	}
+	s.Close()
+	return tokens, drain()
+}
+
+func TestScannerMatchesTokens(t *testing.T) {
+	inputs := []string{
+		"class A: x,y # a point\n\n[A(1, -2.5e3), A(\"é\\\"]\", true), {\"k\": null}]",
+		"name: \"x\"\nlist: [1,2,3]\n",
+		"12345",
+		"",
+		"# only a comment",
+		"[1, @]",
+		"\"unterminated",
+	}
+	for _, input := range inputs {
+		var want []Token
+		var wantErr error
+		for tok, err := range Tokens([]byte(input)) {
+			if err != nil {
+				wantErr = err
+				break
+			}
+			want = append(want, tok)
+		}
+		for n := 1; n <= len(input)+1; n++ {
+			got, err := scanChunks(input, n)
+			if (err == nil) != (wantErr == nil) || err != nil && err.Error() != wantErr.Error() {
+				t.Errorf("%q in chunks of %d: error %v, want %v", input, n, err, wantErr)
+				continue
+			}
+			if len(got) != len(want) {
+				t.Errorf("%q in chunks of %d: %v, want %v", input, n, got, want)
+				continue
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("%q in chunks of %d: token %d = %v, want %v", input, n, i, got[i], want[i])
+				}
+			}
+		}
+	}
+}
+
+func TestScannerReturnsTokensEarly(t *testing.T) {
+	s := NewScanner()
+	s.Write([]byte(`[1, "ab`))
+	for _, want := range []TokenType{TokenLBracket, TokenNumber, TokenComma} {
+		if tok, err := s.Next(); err != nil || tok.Type != want {
+			t.Fatalf("Next = %v, %v, want %v", tok, err, want)
+		}
+	}
+	if _, err := s.Next(); err != ErrNeedInput {
+		t.Fatalf("Next = %v, want ErrNeedInput", err)
+	}
+	s.Write([]byte(`c"]`))
+	if tok, err := s.Next(); err != nil || tok.Type != TokenString || tok.Value != "abc" {
+		t.Fatalf("Next = %v, %v, want the string", tok, err)
+	}
+	if tok, err := s.Next(); err != nil || tok.Type != TokenRBracket {
+		t.Fatalf("Next = %v, %v, want ]", tok, err)
+	}
+	if len(s.buf) != 0 {
+		t.Fatalf("Scanner holds %q after returning every token", s.buf)
+	}
+	s.Close()
+	if tok, err := s.Next(); err != nil || tok.Type != TokenEOF {
+		t.Fatalf("Next = %v, %v, want EOF token", tok, err)
+	}
+	if _, err := s.Next(); err != io.EOF {
+		t.Fatalf("Next = %v, want io.EOF", err)
+	}
+	if _, err := s.Write([]byte("1")); err == nil {
+		t.Fatalf("Write after Close succeeded")
+	}
+}
+
+func TestScannerLimitsIncompleteInput(t *testing.T) {
+	s := NewScanner()
+	chunk := make([]byte, 1<<20)
+	for i := range chunk {
+		chunk[i] = 'a'
+	}
+	s.Write([]byte(`"`))
+	var err error
+	for i := 0; err == nil && i < 1000; i++ {
+		_, err = s.Write(chunk)
+	}
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Write = %v, want ErrTooLarge", err)
+	}
+}
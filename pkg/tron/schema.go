@@ -0,0 +1,309 @@
+package tron
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A Schema describes the classes a TRON document is expected to use: the
+// properties of each, optionally typed as in "class User: id int, name
+// string", and constraints on the values of those properties. It lets a
+// service check an inbound document with ValidateAgainst before decoding it.
+//
+// A Schema must not be modified while it is in use by ValidateAgainst.
+type Schema struct {
+	classes map[string]*classDef
+
+	// constraints holds the constraints of each class, by class name and
+	// then property index.
+	constraints map[string][]*Constraint
+}
+
+// A Constraint restricts the values of one property of a Schema class.
+type Constraint struct {
+	// Required means that every instantiation must give the property a
+	// value other than null. A default from the class definition counts.
+	Required bool
+	// Enum, if not nil, lists the values the property may take, such as
+	// []any{"admin", "user"}. Values are compared as they unmarshal into
+	// interface{}, so 1 and 1.0 are the same value. Null is always allowed
+	// unless Required is set.
+	Enum []any
+	// Min and Max, if not nil, are inclusive bounds on a number.
+	Min, Max *float64
+}
+
+// ParseSchema returns a Schema with the classes defined in header, which
+// holds class definitions in the syntax of a TRON header, including property
+// types and defaults. Its constraints can then be added with Constrain.
+func ParseSchema(header []byte) (*Schema, error) {
+	p, err := newDocumentParser(header, decodeOptions{typedClasses: true})
+	if err != nil {
+		return nil, err
+	}
+	p.skipNewlines()
+	s := &Schema{classes: p.classes, constraints: make(map[string][]*Constraint)}
+	for p.current().Type == TokenClass {
+		if err := p.parseClassDefinition(); err != nil {
+			return nil, err
+		}
+		p.skipNewlines()
+	}
+	if p.current().Type != TokenEOF {
+		return nil, p.syntaxError("expected class definition")
+	}
+	return s, nil
+}
+
+// Constrain sets the constraint on property prop of class. It returns an
+// error if s has no such class or property, or if c.Enum holds a value that
+// cannot be marshaled.
+func (s *Schema) Constrain(class, prop string, c Constraint) error {
+	def, ok := s.classes[class]
+	if !ok {
+		return fmt.Errorf("tron: schema has no class %s", class)
+	}
+	i := slices.Index(def.props, prop)
+	if i < 0 {
+		return fmt.Errorf("tron: schema class %s has no property %s", class, prop)
+	}
+	if c.Enum != nil {
+		// Normalize the allowed values to their unmarshaled form.
+		enum := make([]any, len(c.Enum))
+		for j, v := range c.Enum {
+			data, err := Marshal(v)
+			if err != nil {
+				return err
+			}
+			if err := Unmarshal(data, &enum[j]); err != nil {
+				return err
+			}
+		}
+		c.Enum = enum
+	}
+	if s.constraints[class] == nil {
+		s.constraints[class] = make([]*Constraint, len(def.props))
+	}
+	s.constraints[class][i] = &c
+	return nil
+}
+
+// A Violation is one way in which a document fails to conform to a Schema.
+type Violation struct {
+	Path    string // the value concerned, such as $.users[2].id, or "class User" for a definition
+	Line    int    // 1-based line of the value or definition
+	Column  int    // 1-based column, in runes, of the value or definition
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Path, v.Message)
+}
+
+// ValidateAgainst checks the TRON document data against schema and returns
+// the violations it finds, in document order, or nil if there are none.
+//
+// The document may define the schema's classes in its header, in which case
+// the definitions must match, or leave them out and instantiate them as if
+// they were defined. Classes the schema does not know are reported, as are
+// instantiations that break the types or constraints of a schema class.
+// Objects written as object literals are not checked, since nothing ties them
+// to a class.
+//
+// ValidateAgainst returns an error, and no violations, if data is not a
+// valid TRON document.
+func ValidateAgainst(schema *Schema, data []byte) ([]Violation, error) {
+	p, err := newDocumentParser(data, decodeOptions{typedClasses: true})
+	if err != nil {
+		return nil, err
+	}
+	v := &validator{p: p, schema: schema}
+	if err := v.document(); err != nil {
+		return nil, err
+	}
+	return v.violations, nil
+}
+
+// validator walks a document, recording where it breaks its schema.
+type validator struct {
+	p          *parser
+	schema     *Schema
+	mismatched map[string]bool // classes the document defines differently
+	violations []Violation
+}
+
+func (v *validator) report(path string, tok Token, format string, args ...any) {
+	v.violations = append(v.violations, Violation{Path: path, Line: tok.Line, Column: tok.Column, Message: fmt.Sprintf(format, args...)})
+}
+
+// document checks the header of the document against the schema, then
+// walks its data.
+func (v *validator) document() error {
+	p := v.p
+	p.skipNewlines()
+	for p.current().Type == TokenClass {
+		nameTok := p.peek(1)
+		if err := p.parseClassDefinition(); err != nil {
+			return err
+		}
+		p.skipNewlines()
+		def, ok := v.schema.classes[nameTok.Value]
+		switch {
+		case !ok:
+			v.report("class "+nameTok.Value, nameTok, "class is not in the schema")
+		case !slices.Equal(p.classes[nameTok.Value].props, def.props):
+			if v.mismatched == nil {
+				v.mismatched = make(map[string]bool)
+			}
+			v.mismatched[nameTok.Value] = true
+			v.report("class "+nameTok.Value, nameTok, "class has properties %s, want %s",
+				strings.Join(p.classes[nameTok.Value].props, ","), strings.Join(def.props, ","))
+		}
+	}
+
+	// Instantiations are checked against the schema's types here, rather
+	// than by the parser, so that a mismatch is a violation and not an
+	// error; the parser gets the classes untyped.
+	for name, def := range v.schema.classes {
+		if _, ok := p.classes[name]; !ok {
+			p.classes[name] = def
+		}
+	}
+	for name, def := range p.classes {
+		if def.types != nil {
+			untyped := *def
+			untyped.types = nil
+			p.classes[name] = &untyped
+		}
+	}
+
+	p.skipNewlines()
+	if p.current().Type == TokenEOF {
+		return nil
+	}
+	var err error
+	if (p.current().Type == TokenIdentifier || p.current().Type == TokenString) && p.peek(1).Type == TokenColon {
+		err = p.parseImplicitObjectWith(1, func(key string) error {
+			return v.value(schemaPath("$", key), 2)
+		})
+	} else {
+		err = v.value("$", 0)
+	}
+	if err != nil {
+		return err
+	}
+	p.skipNewlines()
+	if p.current().Type != TokenEOF {
+		return p.syntaxError("unexpected trailing tokens")
+	}
+	return nil
+}
+
+// value walks the value at the current position, at path. depth follows
+// the same accounting as parseValue.
+func (v *validator) value(path string, depth int) error {
+	p := v.p
+	if depth > p.limits().MaxDepth {
+		return p.categoryError(ErrTooDeep, "maximum parse depth exceeded")
+	}
+
+	switch p.current().Type {
+	case TokenLBracket:
+		n := 0
+		return p.parseArrayWith(func() error {
+			n++
+			return v.value(path+"["+strconv.Itoa(n-1)+"]", depth+2)
+		})
+	case TokenLBrace:
+		return p.parseObjectWith(depth+1, func(key string) error {
+			return v.value(schemaPath(path, key), depth+2)
+		})
+	case TokenIdentifier:
+		if !p.atNonFinite() {
+			return v.instance(path, depth)
+		}
+	}
+	return p.skipValue(depth)
+}
+
+// instance walks the class instantiation at the current position, checking
+// its arguments if the schema has the class and the document does not
+// define it differently.
+func (v *validator) instance(path string, depth int) error {
+	p := v.p
+	nameTok := p.current()
+	def := v.schema.classes[nameTok.Value]
+	constraints := v.schema.constraints[nameTok.Value]
+	if v.mismatched[nameTok.Value] {
+		def, constraints = nil, nil
+	}
+	given := make(map[string]bool)
+	err := p.parseClassInstantiationWith(depth+1, func(prop string) error {
+		argPath := schemaPath(path, prop)
+		if def != nil {
+			if i := slices.Index(def.props, prop); i >= 0 {
+				if err := p.checkArgType(nameTok.Value, def, i); err != nil {
+					v.report(argPath, p.current(), "%s", err.(*SyntaxError).msg)
+				} else if i < len(constraints) && constraints[i] != nil {
+					v.check(argPath, constraints[i])
+				}
+			}
+		}
+		given[prop] = p.current().Type != TokenNull
+		return v.value(argPath, depth+2)
+	})
+	if err != nil {
+		return err
+	}
+	for i, c := range constraints {
+		if c != nil && c.Required && !given[def.props[i]] {
+			v.report(path, nameTok, "class %s requires property %s", nameTok.Value, def.props[i])
+		}
+	}
+	return nil
+}
+
+// check reports the ways the value at the current position, at path, breaks
+// constraint c.
+func (v *validator) check(path string, c *Constraint) {
+	tok := v.p.current()
+	var value any
+	switch tok.Type {
+	case TokenNull:
+		return
+	case TokenNumber:
+		f, _ := strconv.ParseFloat(tok.Value, 64)
+		value = f
+	case TokenString:
+		value = tok.Value
+	case TokenTrue, TokenFalse:
+		value = tok.Type == TokenTrue
+	}
+
+	if c.Enum != nil && !slices.ContainsFunc(c.Enum, func(e any) bool { return reflect.DeepEqual(e, value) }) {
+		v.report(path, tok, "%s is not one of the allowed values", tokenText(tok))
+	}
+	if c.Min == nil && c.Max == nil {
+		return
+	}
+	f, ok := value.(float64)
+	switch {
+	case !ok:
+		v.report(path, tok, "expects a number, got %s", describeToken(tok))
+	case c.Min != nil && f < *c.Min:
+		v.report(path, tok, "%s is less than the minimum %v", tokenText(tok), *c.Min)
+	case c.Max != nil && f > *c.Max:
+		v.report(path, tok, "%s is greater than the maximum %v", tokenText(tok), *c.Max)
+	}
+}
+
+// schemaPath returns the path of member key of the object at path.
+func schemaPath(path, key string) string {
+	if isValidIdentifier(key) {
+		return path + "." + key
+	}
+	return path + "[" + strconv.Quote(key) + "]"
+}
@@ -0,0 +1,80 @@
+package tron
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testSchema(t *testing.T) *Schema {
+	t.Helper()
+	s, err := ParseSchema([]byte("class User: id int, name string, role, age number=0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	minAge, maxAge := 0.0, 130.0
+	for prop, c := range map[string]Constraint{
+		"id":   {Required: true},
+		"role": {Enum: []any{"admin", "user"}},
+		"age":  {Min: &minAge, Max: &maxAge},
+	} {
+		if err := s.Constrain("User", prop, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestValidateAgainst(t *testing.T) {
+	s := testSchema(t)
+
+	valid := "class User: id,name,role,age\n\n[User(1,\"ann\",\"admin\",30),User(2,\"bob\",null,0)]"
+	if got, err := ValidateAgainst(s, []byte(valid)); err != nil || got != nil {
+		t.Fatalf("valid document: got %v, %v", got, err)
+	}
+
+	// The document may rely on the schema's definition of User.
+	input := "users: [\n" +
+		"  User(null,\"ann\",\"root\"),\n" +
+		"  User(2,3,\"user\",150),\n" +
+		"  {\"id\":null}\n" +
+		"]"
+	got, err := ValidateAgainst(s, []byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Violation{
+		{Path: "$.users[0].role", Line: 2, Column: 19, Message: `"root" is not one of the allowed values`},
+		{Path: "$.users[0]", Line: 2, Column: 3, Message: "class User requires property id"},
+		{Path: "$.users[1].name", Line: 3, Column: 10, Message: "class User property name expects string, got number"},
+		{Path: "$.users[1].age", Line: 3, Column: 19, Message: "150 is greater than the maximum 130"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestValidateAgainstHeader(t *testing.T) {
+	s := testSchema(t)
+	input := "class User: id,name\nclass Extra: a,b\n\n[User(1,2),Extra(1,2)]"
+	got, err := ValidateAgainst(s, []byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Violation{
+		{Path: "class User", Line: 1, Column: 7, Message: "class has properties id,name, want id,name,role,age"},
+		{Path: "class Extra", Line: 2, Column: 7, Message: "class is not in the schema"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got\n%#v\nwant\n%#v", got, want)
+	}
+
+	if _, err := ValidateAgainst(s, []byte("[User(1,")); err == nil {
+		t.Error("invalid document: got no error")
+	}
+	if err := s.Constrain("User", "email", Constraint{Required: true}); err == nil {
+		t.Error("Constrain with unknown property: got no error")
+	}
+	if err := s.Constrain("Admin", "id", Constraint{Required: true}); err == nil {
+		t.Error("Constrain with unknown class: got no error")
+	}
+}
@@ -0,0 +1,63 @@
+package tron
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSecureDecoder(t *testing.T) {
+	type task struct {
+		Title string   `json:"title"`
+		Tags  []string `json:"tags"`
+	}
+	var tasks []task
+	ok := "class T: title,tags\n\n[T(\"write\",[\"a\"]),T(\"test\",[])]"
+	if err := SecureDecoder(strings.NewReader(ok)).Decode(&tasks); err != nil || len(tasks) != 2 {
+		t.Fatalf("Decode = %v, %v", tasks, err)
+	}
+
+	for name, tt := range map[string]struct {
+		input string
+		want  error
+	}{
+		"deep":   {strings.Repeat("[", 100) + strings.Repeat("]", 100), ErrTooDeep},
+		"string": {`"` + strings.Repeat("x", 70<<10) + `"`, ErrTooLarge},
+		"array":  {"[" + strings.Repeat("0,", 20_000) + "0]", ErrTooLarge},
+		"object": {"{" + strings.Repeat(`"k":0,`, 2_000) + `"k":0}`, ErrTooLarge},
+		"input":  {"[" + strings.Repeat(" ", 2<<20) + "]", ErrTooLarge},
+	} {
+		var v interface{}
+		err := SecureDecoder(strings.NewReader(tt.input)).Decode(&v)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: Decode = %v, want %v", name, err, tt.want)
+		}
+	}
+
+	var v interface{}
+	err := SecureDecoder(strings.NewReader(classDefs(100) + "\n1")).Decode(&v)
+	if err == nil || !strings.Contains(err.Error(), "too many class definitions") {
+		t.Errorf("classes: Decode = %v, want too many class definitions", err)
+	}
+}
+
+// classDefs returns n class definitions with distinct names.
+func classDefs(n int) string {
+	var b strings.Builder
+	for i := range n {
+		b.WriteString("class ")
+		b.WriteString(generateClassName(i + 1))
+		b.WriteString(": a,b\n")
+	}
+	return b.String()
+}
+
+func TestArrayLengthLimitWithParallelism(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("[1,2,3,4,5]"))
+	dec.SetLimits(Limits{MaxArrayLength: 4})
+	dec.SetParallelism(4)
+	var v []int
+	if err := dec.Decode(&v); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Decode = %v, want ErrTooLarge", err)
+	}
+}
@@ -0,0 +1,149 @@
+package tron
+
+import (
+	"iter"
+	"reflect"
+)
+
+// seqFlushSize is how much encoded output EncodeSeq buffers before writing it
+// to the underlying writer.
+const seqFlushSize = 32 << 10
+
+// EncodeSeq writes the values of seq to enc's stream as a single TRON array,
+// followed by a newline, encoding and writing elements as they are produced
+// instead of collecting them first. This suits exporting database cursors and
+// other sources too large to hold in memory.
+//
+// Because the header must precede the data, classes are chosen from the
+// declared element type T rather than from the values: if T is a struct, or
+// a pointer to one, with two or more fields, the header defines a class for
+// its fields and every element that encodes all of them is written as an
+// instantiation of it. Elements missing fields through omitempty or
+// omitzero, and all nested structs of other types, are written as objects.
+//
+// If encoding an element fails, EncodeSeq stops and returns the error; the
+// output written so far is left incomplete.
+//
+// EncodeSeq is a function rather than an Encoder method because Go methods
+// cannot have type parameters.
+func EncodeSeq[T any](enc *Encoder, seq iter.Seq[T]) error {
+	e := newEncoder(enc.opts)
+	defer e.release()
+
+	out := e.declareClass(e.out[:0], reflect.TypeFor[T]())
+	out = append(out, '[')
+	if _, err := enc.w.Write(out); err != nil {
+		return err
+	}
+	out = out[:0]
+
+	first := true
+	for v := range seq {
+		if !first {
+			out = append(out, ',')
+		}
+		first = false
+
+		e.resetElement()
+		// Elements are encoded at the depth of the root array's children.
+		if err := e.serialize(reflect.ValueOf(v), 1); err != nil {
+			return err
+		}
+		out = e.render(out, 0, len(e.buf), 0, len(e.objects))
+
+		if len(out) >= seqFlushSize {
+			if _, err := enc.w.Write(out); err != nil {
+				return err
+			}
+			out = out[:0]
+		}
+	}
+
+	out = append(out, "]\n"...)
+	e.out = out
+	_, err := enc.w.Write(out)
+	return err
+}
+
+// EncodeChan is like EncodeSeq but takes its elements from ch, stopping when
+// ch is closed.
+func EncodeChan[T any](enc *Encoder, ch <-chan T) error {
+	return EncodeSeq(enc, func(yield func(T) bool) {
+		for v := range ch {
+			if !yield(v) {
+				return
+			}
+		}
+	})
+}
+
+// declareClass appends the header for a stream of values of type t to out:
+// a class for t's fields if t is a struct with two or more of them, and
+// nothing otherwise. The class is registered as e's only schema so that
+// serialize and render use it for matching values.
+func (e *encoder) declareClass(out []byte, t reflect.Type) []byte {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType || hasCustomMarshaler(t) {
+		return out
+	}
+
+	ti := e.getStructTypeInfo(t)
+	if len(ti.fields) < 2 {
+		return out
+	}
+	keys := make([]string, len(ti.fields))
+	for i, f := range ti.fields {
+		keys[i] = f.name
+	}
+
+	defaults := ti.defaultsFor(keys)
+	if e.optionalProps && ti.optionalKeys != nil {
+		defaults = ti.optionalDefaults
+	}
+	keys, defaults = e.orderKeys(keys, e.escapeDefaults(defaults))
+	cls := ClassDef{Name: generateClassName(0), Keys: keys, Defaults: defaults}
+	e.classes = append(e.classes, cls)
+	e.schemas[schemaKey(keys, cls.Defaults)] = &schema{keys: keys, defaults: cls.Defaults, class: cls.Name}
+
+	return appendHeader(out, e.classes, e.escapes())
+}
+
+// resetElement clears the per-value state of e so the next stream element
+// can be serialized, keeping only the schema of the declared class.
+func (e *encoder) resetElement() {
+	e.resetMessage()
+	e.dropUnclassed()
+}
+
+// resetMessage clears the per-value state of e, keeping its schemas and
+// classes.
+func (e *encoder) resetMessage() {
+	e.buf = e.buf[:0]
+	clear(e.objects)
+	e.objects = e.objects[:0]
+	e.spans = e.spans[:0]
+	clear(e.schemaOrder)
+	e.schemaOrder = e.schemaOrder[:0]
+}
+
+// dropUnclassed forgets the schemas that have not been given a class.
+func (e *encoder) dropUnclassed() {
+	for sig, sc := range e.schemas {
+		if sc.class == "" {
+			delete(e.schemas, sig)
+		}
+	}
+}
+
+// hasCustomMarshaler reports whether values of type t encode themselves
+// through one of the marshaler interfaces serializeCustom checks.
+func hasCustomMarshaler(t reflect.Type) bool {
+	for _, it := range []reflect.Type{marshalerType, jsonMarshalerType, textMarshalerType, binaryMarshalerType} {
+		if t.Implements(it) || reflect.PointerTo(t).Implements(it) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package tron
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type seqRow struct {
+	ID   int     `json:"id"`
+	Name string  `json:"name,omitempty"`
+	Next *seqRow `json:"next"`
+}
+
+func TestEncodeSeq(t *testing.T) {
+	rows := []seqRow{
+		{ID: 1, Name: "a"},
+		{ID: 2, Name: "b", Next: &seqRow{ID: 3, Name: "c"}},
+		{ID: 4},
+	}
+	var buf bytes.Buffer
+	if err := EncodeSeq(NewEncoder(&buf), slices.Values(rows)); err != nil {
+		t.Fatalf("EncodeSeq: %v", err)
+	}
+	want := "class A: id,name,next\n\n" +
+		`[A(1,"a",null),A(2,"b",A(3,"c",null)),{"id":4,"next":null}]` + "\n"
+	if buf.String() != want {
+		t.Fatalf("EncodeSeq =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	var back []map[string]interface{}
+	for v, err := range Values[map[string]interface{}](NewDecoder(&buf)) {
+		if err != nil {
+			t.Fatalf("Values: %v", err)
+		}
+		back = append(back, v)
+	}
+	if len(back) != 3 || back[1]["next"].(map[string]interface{})["name"] != "c" || back[2]["id"] != 4.0 {
+		t.Fatalf("round trip = %+v", back)
+	}
+}
+
+func TestEncodeSeqWithoutClass(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeSeq(NewEncoder(&buf), slices.Values([]interface{}{1, "x", map[string]int{"k": 1}})); err != nil {
+		t.Fatalf("EncodeSeq: %v", err)
+	}
+	if want := `[1,"x",{"k":1}]` + "\n"; buf.String() != want {
+		t.Fatalf("EncodeSeq = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := EncodeSeq(NewEncoder(&buf), slices.Values([]*seqRow(nil))); err != nil {
+		t.Fatalf("EncodeSeq: %v", err)
+	}
+	if want := "class A: id,name,next\n\n[]\n"; buf.String() != want {
+		t.Fatalf("EncodeSeq = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestEncodeChan(t *testing.T) {
+	ch := make(chan seqRow)
+	go func() {
+		for i := range 3 {
+			ch <- seqRow{ID: i, Name: "n"}
+		}
+		close(ch)
+	}()
+	var buf bytes.Buffer
+	if err := EncodeChan(NewEncoder(&buf), ch); err != nil {
+		t.Fatalf("EncodeChan: %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), `[A(0,"n",null),A(1,"n",null),A(2,"n",null)]`+"\n") {
+		t.Fatalf("EncodeChan = %q", buf.String())
+	}
+}
+
+func TestEncodeSeqFlushesLargeStreams(t *testing.T) {
+	var buf bytes.Buffer
+	n := 0
+	err := EncodeSeq(NewEncoder(&buf), func(yield func(seqRow) bool) {
+		for i := 0; i < 10000; i++ {
+			if i == 5000 {
+				n = buf.Len()
+			}
+			if !yield(seqRow{ID: i, Name: "row"}) {
+				return
+			}
+		}
+	})
+	if err != nil {
+		t.Fatalf("EncodeSeq: %v", err)
+	}
+	if n == 0 {
+		t.Fatal("expected output to be written while the sequence was running")
+	}
+	var rows []seqRow
+	if err := Unmarshal(buf.Bytes(), &rows); err != nil || len(rows) != 10000 {
+		t.Fatalf("Unmarshal: %d rows, %v", len(rows), err)
+	}
+}
+
+func TestEncodeSeqError(t *testing.T) {
+	var buf bytes.Buffer
+	err := EncodeSeq(NewEncoder(&buf), slices.Values([]interface{}{1, make(chan int)}))
+	var typeErr *UnsupportedTypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("expected UnsupportedTypeError, got %v", err)
+	}
+}
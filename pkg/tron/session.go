@@ -0,0 +1,163 @@
+package tron
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
+)
+
+// maxSessionSchemas bounds how many object shapes a SessionEncoder remembers
+// without having given them a class. Past it, the unclassed ones are
+// forgotten so that a session of ever-changing shapes runs in bounded memory.
+const maxSessionSchemas = 20_000
+
+// A SessionEncoder writes a sequence of values to a connection, sharing class
+// definitions between them in the manner of encoding/gob: a class is
+// transmitted once, just before the first message that uses it, and later
+// messages refer to it by name. This amortizes the cost of the header over
+// the whole session, which pays off for many small messages of the same
+// shapes, such as RPC requests and replies.
+//
+// A shape gets a class once it has occurred twice in the session, whether in
+// the same message or in different ones. Each message is written as one line,
+// preceded by the definitions of any classes it introduces.
+//
+// The stream is only meaningful to a SessionDecoder that has read it from the
+// start. A SessionEncoder is not safe for concurrent use.
+type SessionEncoder struct {
+	w io.Writer
+	e *encoder
+}
+
+// NewSessionEncoder returns a new session encoder that writes to w.
+func NewSessionEncoder(w io.Writer) *SessionEncoder {
+	return &SessionEncoder{w: w, e: newEncoder(encodeOptions{noPool: true})}
+}
+
+// Encode writes the TRON encoding of v as the next message of the session.
+// If v cannot be encoded, nothing is written and the session stays usable.
+func (enc *SessionEncoder) Encode(v interface{}) error {
+	e := enc.e
+	e.resetMessage()
+	if len(e.schemas) > maxSessionSchemas {
+		e.dropUnclassed()
+	}
+	if err := e.serialize(reflect.ValueOf(v), 0); err != nil {
+		return err
+	}
+
+	known := len(e.classes)
+	e.assignSessionClasses()
+	out := e.out[:0]
+	for i := known; i < len(e.classes); i++ {
+		out = appendClassDef(out, e.classes[i], e.classes[:i], e.escapes())
+	}
+	out = e.render(out, 0, len(e.buf), 0, len(e.objects))
+	out = append(out, '\n')
+	e.out = out
+
+	_, err := enc.w.Write(out)
+	return err
+}
+
+// assignSessionClasses names each schema with 2+ properties that has now
+// occurred 2+ times in the session, in the order its objects appear in the
+// current message. Schemas named by earlier messages keep their classes.
+func (e *encoder) assignSessionClasses() {
+	for _, obj := range e.objects {
+		s := obj.schema
+		if s.class == "" && len(s.keys) > 1 && s.count > 1 && len(e.classes) < maxClasses {
+			s.class = generateClassName(len(e.classes))
+			e.addClass(ClassDef{Name: s.class, Keys: s.keys, Defaults: s.defaults})
+		}
+	}
+}
+
+// A SessionDecoder reads the values written by a SessionEncoder, keeping the
+// classes defined so far in the session so that later messages can refer to
+// them. Limits apply to each message separately, except that MaxClasses
+// bounds the classes of the whole session.
+//
+// A SessionDecoder is not safe for concurrent use.
+type SessionDecoder struct {
+	d    lineDecoder
+	opts decodeOptions
+}
+
+// NewSessionDecoder returns a new session decoder that reads from r.
+func NewSessionDecoder(r io.Reader) *SessionDecoder {
+	dec := &SessionDecoder{d: lineDecoder{classes: make(map[string]*classDef)}}
+	dec.d.s.r = bufio.NewReader(r)
+	return dec
+}
+
+// SetLimits sets the resource limits for decoding. Zero fields keep the
+// defaults reported by DefaultLimits.
+func (dec *SessionDecoder) SetLimits(l Limits) {
+	dec.opts.limits = l
+}
+
+// Decode reads the next message of the session and stores it in the value
+// pointed to by v, following the rules of Unmarshal. It returns io.EOF when
+// the session has ended cleanly.
+func (dec *SessionDecoder) Decode(v interface{}) error {
+	return dec.d.decode(v, dec.opts)
+}
+
+// nextRecord returns the source of the next record: the text up to a newline
+// outside any string or brackets. Blank records and comments are skipped. It
+// returns io.EOF once the input is exhausted.
+func (s *streamScanner) nextRecord() ([]byte, error) {
+	s.buf = s.buf[:0]
+	depth := 0
+	for {
+		c, err := s.r.ReadByte()
+		if err == io.EOF {
+			if len(bytes.TrimSpace(s.buf)) == 0 {
+				return nil, io.EOF
+			}
+			if depth > 0 {
+				return nil, &SyntaxError{msg: "unexpected end of input"}
+			}
+			return s.buf, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch c {
+		case '"':
+			if err := s.readString(); err != nil {
+				return nil, err
+			}
+			continue
+		case '#':
+			n := len(s.buf)
+			if err := s.skipComment(); err != nil {
+				return nil, err
+			}
+			// skipComment keeps the newline ending the comment; handle it
+			// here like any other.
+			if len(s.buf) == n {
+				continue
+			}
+			s.buf = s.buf[:n]
+			c = '\n'
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		}
+		if c == '\n' && depth <= 0 {
+			if len(bytes.TrimSpace(s.buf)) > 0 {
+				return s.buf, nil
+			}
+			s.buf = s.buf[:0]
+			continue
+		}
+		s.buf = append(s.buf, c)
+		if err := s.checkSize(); err != nil {
+			return nil, err
+		}
+	}
+}
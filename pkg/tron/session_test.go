@@ -0,0 +1,110 @@
+package tron
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+type sessionMsg struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestSessionEncoderSendsClassesOnce(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewSessionEncoder(&buf)
+	for _, v := range []interface{}{
+		sessionMsg{1, "a"},
+		sessionMsg{2, "b"},
+		[]sessionMsg{{3, "c"}},
+		map[string]int{"x": 1},
+	} {
+		if err := enc.Encode(v); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+	}
+
+	want := "{\"id\":1,\"name\":\"a\"}\n" +
+		"class A: id,name\n" +
+		"A(2,\"b\")\n" +
+		"[A(3,\"c\")]\n" +
+		"{\"x\":1}\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSessionRoundTripOverConn(t *testing.T) {
+	client, server := net.Pipe()
+	msgs := []sessionMsg{{1, "a"}, {2, "b\nc"}, {3, ""}}
+
+	go func() {
+		enc := NewSessionEncoder(client)
+		for _, m := range msgs {
+			if err := enc.Encode(m); err != nil {
+				t.Errorf("Encode: %v", err)
+			}
+		}
+		client.Close()
+	}()
+
+	dec := NewSessionDecoder(server)
+	for i, want := range msgs {
+		var got sessionMsg
+		if err := dec.Decode(&got); err != nil {
+			t.Fatalf("Decode %d: %v", i, err)
+		}
+		if got != want {
+			t.Fatalf("message %d = %+v, want %+v", i, got, want)
+		}
+	}
+	var extra sessionMsg
+	if err := dec.Decode(&extra); err != io.EOF {
+		t.Fatalf("Decode after end = %v, want io.EOF", err)
+	}
+}
+
+func TestSessionDecoderRecords(t *testing.T) {
+	input := "# session\nclass A: id,name\n\n[\n  A(1,\"x\"),\n  A(2,\"y\") # two\n]\nA(3,\"z\")\n"
+	dec := NewSessionDecoder(strings.NewReader(input))
+
+	var list []sessionMsg
+	if err := dec.Decode(&list); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(list) != 2 || list[1] != (sessionMsg{2, "y"}) {
+		t.Fatalf("got %+v", list)
+	}
+	var one sessionMsg
+	if err := dec.Decode(&one); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if one != (sessionMsg{3, "z"}) {
+		t.Fatalf("got %+v", one)
+	}
+	if err := dec.Decode(&one); err != io.EOF {
+		t.Fatalf("got %v, want io.EOF", err)
+	}
+}
+
+func TestSessionDecoderErrors(t *testing.T) {
+	var v sessionMsg
+	if err := NewSessionDecoder(strings.NewReader("A(1,\"x\")\n")).Decode(&v); err == nil {
+		t.Fatal("expected error for undefined class")
+	}
+	if err := NewSessionDecoder(strings.NewReader("[1,2\n")).Decode(&v); err == nil {
+		t.Fatal("expected error for truncated message")
+	}
+	if err := NewSessionDecoder(strings.NewReader("1\n")).Decode(v); err == nil {
+		t.Fatal("expected error for non-pointer")
+	}
+
+	dec := NewSessionDecoder(strings.NewReader("class A: a,b\nclass B: a,c\n"))
+	dec.SetLimits(Limits{MaxClasses: 1})
+	if err := dec.Decode(&v); err == nil {
+		t.Fatal("expected error past MaxClasses")
+	}
+}
@@ -0,0 +1,70 @@
+package tron
+
+import (
+	"reflect"
+	"testing"
+)
+
+type spInner struct {
+	P int `json:"p"`
+	Q int `json:"q"`
+}
+
+type spOuter struct {
+	In   spInner `json:"in"`
+	Name string  `json:"name"`
+}
+
+type spXY struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type spYX struct {
+	Y int `json:"y"`
+	X int `json:"x"`
+}
+
+func TestSinglePassFramesNestedLeadingStructs(t *testing.T) {
+	v := []spOuter{{In: spInner{1, 2}, Name: "a"}, {In: spInner{3, 4}, Name: "b"}}
+	out, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := "class A: in,name\nclass B: p,q\n\n[A(B(1,2),\"a\"),A(B(3,4),\"b\")]"
+	if string(out) != want {
+		t.Fatalf("Marshal =\n%s\nwant\n%s", out, want)
+	}
+
+	var got []spOuter
+	if err := Unmarshal(out, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(got, v) {
+		t.Fatalf("round trip = %+v, want %+v", got, v)
+	}
+}
+
+func TestSinglePassReordersArgumentsToClassKeys(t *testing.T) {
+	v := []interface{}{spXY{X: 1, Y: 2}, spYX{Y: 4, X: 3}}
+	out, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := "class A: x,y\n\n[A(1,2),A(3,4)]"
+	if string(out) != want {
+		t.Fatalf("Marshal = %q, want %q", out, want)
+	}
+}
+
+func TestSinglePassKeepsUncountedStructsAsObjects(t *testing.T) {
+	v := map[string]interface{}{"one": spXY{X: 1, Y: 2}, "many": []spInner{{1, 2}, {3, 4}}}
+	out, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := "class A: p,q\n\n{\"many\":[A(1,2),A(3,4)],\"one\":{\"x\":1,\"y\":2}}"
+	if string(out) != want {
+		t.Fatalf("Marshal = %q, want %q", out, want)
+	}
+}
@@ -0,0 +1,59 @@
+package tron
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalReusesSliceCapacity(t *testing.T) {
+	type item struct {
+		ID   int    `json:"id"`
+		Note string `json:"note"`
+	}
+	inputs := map[string]string{
+		"direct": "class I: id,note\n\n[I(1,\"a\"),{\"id\":2}]",
+		"tree":   `[{"id":1,"note":"a"},{"id":2}]`,
+	}
+	for name, input := range inputs {
+		items := make([]item, 3, 8)
+		items[1] = item{9, "stale"}
+		backing := &items[:cap(items)][0]
+
+		var err error
+		if name == "direct" {
+			err = Unmarshal([]byte(input), &items)
+		} else {
+			_, err = UnmarshalLenient([]byte(input), &items)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []item{{1, "a"}, {2, ""}}; !reflect.DeepEqual(items, want) {
+			t.Errorf("%s: got %+v, want %+v", name, items, want)
+		}
+		if &items[0] != backing {
+			t.Errorf("%s: backing array was not reused", name)
+		}
+	}
+
+	// A slice without room grows, and an empty array gives a new empty slice.
+	small := make([]int, 0, 1)
+	if err := Unmarshal([]byte("[1,2,3]"), &small); err != nil || !reflect.DeepEqual(small, []int{1, 2, 3}) {
+		t.Fatalf("got %v, %v", small, err)
+	}
+	full := []int{1, 2}
+	if err := Unmarshal([]byte("[]"), &full); err != nil || full == nil || len(full) != 0 || cap(full) != 0 {
+		t.Fatalf("empty array: got %v (cap %d), %v", full, cap(full), err)
+	}
+}
+
+func TestUnmarshalSliceReuseAllocations(t *testing.T) {
+	data := []byte("[1,2,3,4,5,6,7,8]")
+	dst := make([]int, 0, 8)
+	var fresh []int
+	reused := testing.AllocsPerRun(50, func() { _ = Unmarshal(data, &dst) })
+	allocated := testing.AllocsPerRun(50, func() { fresh = nil; _ = Unmarshal(data, &fresh) })
+	if reused >= allocated {
+		t.Errorf("decoding into a slice with capacity: %v allocations, into nil: %v", reused, allocated)
+	}
+}
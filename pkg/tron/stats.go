@@ -0,0 +1,84 @@
+package tron
+
+import "encoding/json"
+
+// Statistics compares the TRON encoding of a value with its JSON encoding.
+type Statistics struct {
+	JSONBytes      int     // length of the encoding/json encoding
+	TRONBytes      int     // length of the Marshal encoding
+	SavingsPercent float64 // bytes saved by TRON, as a percentage of JSONBytes
+	ClassCount     int     // classes defined in the TRON header
+
+	// Token counts are only filled in by StatsWithTokenizer.
+	JSONTokens          int
+	TRONTokens          int
+	TokenSavingsPercent float64
+}
+
+// Stats encodes v as both JSON and TRON and reports the sizes, so that
+// applications can measure the gain from switching formats.
+func Stats(v interface{}) (Statistics, error) {
+	return StatsWithTokenizer(v, nil)
+}
+
+// A TokenCounter counts the tokens a language model's tokenizer produces for
+// an encoded document, such as a tiktoken-style BPE count. TRON exists to save
+// model tokens, and raw byte counts only approximate them.
+type TokenCounter interface {
+	CountTokens(data []byte) int
+}
+
+// The TokenCounterFunc type is an adapter to allow the use of ordinary
+// functions as token counters.
+type TokenCounterFunc func(data []byte) int
+
+// CountTokens returns f(data).
+func (f TokenCounterFunc) CountTokens(data []byte) int {
+	return f(data)
+}
+
+// StatsWithTokenizer is like Stats but also counts the tokens of both
+// encodings with tc, and measures the TRON encoding produced by an Encoder
+// configured with SetTokenCounter(tc). A nil tc is the same as Stats.
+//
+// A json.RawMessage is measured as the JSON document it holds, converted to
+// TRON as by FromJSON, so that the shapes of its objects can become classes.
+func StatsWithTokenizer(v interface{}, tc TokenCounter) (Statistics, error) {
+	jsonData, err := json.Marshal(v)
+	if err != nil {
+		return Statistics{}, err
+	}
+
+	e := newEncoder(encodeOptions{tokenCounter: tc})
+	defer e.release()
+	var tronData []byte
+	if raw, ok := v.(json.RawMessage); ok {
+		tronData, err = e.fromJSON(raw)
+	} else {
+		tronData, err = e.marshal(nil, v)
+	}
+	if err != nil {
+		return Statistics{}, err
+	}
+
+	s := Statistics{
+		JSONBytes:      len(jsonData),
+		TRONBytes:      len(tronData),
+		SavingsPercent: savings(len(jsonData), len(tronData)),
+		ClassCount:     len(e.classes),
+	}
+	if tc != nil {
+		s.JSONTokens = tc.CountTokens(jsonData)
+		s.TRONTokens = tc.CountTokens(tronData)
+		s.TokenSavingsPercent = savings(s.JSONTokens, s.TRONTokens)
+	}
+	return s, nil
+}
+
+// savings returns how much smaller after is than before, in percent.
+func savings(before, after int) float64 {
+	if before == 0 {
+		return 0
+	}
+	return 100 * (1 - float64(after)/float64(before))
+}
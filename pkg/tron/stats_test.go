@@ -0,0 +1,119 @@
+package tron
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	type point struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	}
+	v := []point{{1, 2}, {3, 4}, {5, 6}}
+
+	s, err := Stats(v)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	jsonData, _ := json.Marshal(v)
+	tronData, _ := Marshal(v)
+	if s.JSONBytes != len(jsonData) || s.TRONBytes != len(tronData) {
+		t.Fatalf("sizes = %d/%d, want %d/%d", s.JSONBytes, s.TRONBytes, len(jsonData), len(tronData))
+	}
+	if s.ClassCount != 1 {
+		t.Fatalf("ClassCount = %d, want 1", s.ClassCount)
+	}
+	want := 100 * (1 - float64(len(tronData))/float64(len(jsonData)))
+	if s.SavingsPercent != want || s.SavingsPercent <= 0 {
+		t.Fatalf("SavingsPercent = %v, want %v", s.SavingsPercent, want)
+	}
+	if s.JSONTokens != 0 || s.TRONTokens != 0 {
+		t.Fatalf("token counts should be zero without a tokenizer: %+v", s)
+	}
+}
+
+func TestStatsWithTokenizer(t *testing.T) {
+	words := TokenCounterFunc(func(data []byte) int { return len(bytes.Fields(bytes.Map(separatorToSpace, data))) })
+	s, err := StatsWithTokenizer(map[string]interface{}{"a": []int{1, 2, 3}}, words)
+	if err != nil {
+		t.Fatalf("StatsWithTokenizer: %v", err)
+	}
+	if s.JSONTokens != 4 || s.TRONTokens != 4 || s.TokenSavingsPercent != 0 {
+		t.Fatalf("unexpected token stats: %+v", s)
+	}
+}
+
+func TestStatsRawMessage(t *testing.T) {
+	raw := json.RawMessage(`[{"x": 1, "y": 2}, {"x": 3, "y": 4}, {"x": 5, "y": 6}]`)
+	s, err := Stats(raw)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	tronData, _ := FromJSON(raw)
+	if s.TRONBytes != len(tronData) || s.ClassCount != 1 {
+		t.Fatalf("TRONBytes = %d, ClassCount = %d, want %d, 1", s.TRONBytes, s.ClassCount, len(tronData))
+	}
+	if s.JSONBytes != len(`[{"x":1,"y":2},{"x":3,"y":4},{"x":5,"y":6}]`) {
+		t.Fatalf("JSONBytes = %d, want the size of the compacted document", s.JSONBytes)
+	}
+}
+
+func separatorToSpace(r rune) rune {
+	switch r {
+	case '{', '}', '[', ']', ',', ':':
+		return ' '
+	}
+	return r
+}
+
+func TestStatsErrors(t *testing.T) {
+	if _, err := Stats(make(chan int)); err == nil {
+		t.Fatal("expected error for unsupported type")
+	}
+}
+
+func TestTokenCounterDecidesClasses(t *testing.T) {
+	type point struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	}
+	v := []point{{1, 2}, {3, 4}, {5, 6}}
+
+	// Counting bytes, the class pays for itself from three instances on.
+	byteCount := TokenCounterFunc(func(data []byte) int { return len(data) })
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetTokenCounter(byteCount)
+	if err := enc.Encode(v); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if got, want := buf.String(), "class A: x,y\n\n[A(1,2),A(3,4),A(5,6)]\n"; got != want {
+		t.Fatalf("Encode = %q, want %q", got, want)
+	}
+
+	// A tokenizer that makes the header expensive turns the class off.
+	costlyHeader := TokenCounterFunc(func(data []byte) int {
+		if bytes.HasPrefix(data, []byte("class ")) {
+			return 100
+		}
+		return 1
+	})
+	buf.Reset()
+	enc.SetTokenCounter(costlyHeader)
+	if err := enc.Encode(v); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if got, want := buf.String(), `[{"x":1,"y":2},{"x":3,"y":4},{"x":5,"y":6}]`+"\n"; got != want {
+		t.Fatalf("Encode = %q, want %q", got, want)
+	}
+
+	s, err := StatsWithTokenizer(v, costlyHeader)
+	if err != nil {
+		t.Fatalf("StatsWithTokenizer: %v", err)
+	}
+	if s.ClassCount != 0 {
+		t.Fatalf("ClassCount = %d, want 0", s.ClassCount)
+	}
+}
@@ -0,0 +1,662 @@
+package tron
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+)
+
+// A Decoder reads and decodes TRON values from an input stream.
+type Decoder struct {
+	r    io.Reader
+	in   *countingReader // r, counting the bytes read
+	opts decodeOptions
+
+	lines  *lineDecoder // non-nil in line mode
+	array  *arrayStream // non-nil once More has been called
+	frames *frameReader // non-nil with framing
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	in := &countingReader{r: r}
+	return &Decoder{r: in, in: in}
+}
+
+// SecureDecoder returns a new decoder that reads from r with the limits of
+// SecureLimits, for decoding untrusted input in one call. Input that goes
+// beyond a limit is rejected with a *SyntaxError wrapping ErrTooLarge,
+// ErrTooDeep or ErrTooManyTokens before it can use much memory or time.
+func SecureDecoder(r io.Reader) *Decoder {
+	dec := NewDecoder(r)
+	dec.SetLimits(SecureLimits())
+	return dec
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// InputOffset returns the number of bytes of input the Decoder has consumed:
+// the whole document once Decode has read it, and the records or array
+// elements read so far in line mode or after More. It lets long imports
+// report their progress and errors their position in the stream. Bytes read
+// ahead into the Decoder's buffer are not counted.
+func (dec *Decoder) InputOffset() int64 {
+	n := dec.in.n
+	switch {
+	case dec.lines != nil:
+		n -= int64(dec.lines.s.r.Buffered())
+	case dec.array != nil:
+		n -= int64(dec.array.s.r.Buffered())
+	case dec.frames != nil:
+		n -= int64(dec.frames.r.Buffered())
+	}
+	return n
+}
+
+// SetLimits sets the resource limits for decoding. Zero fields keep the
+// defaults reported by DefaultLimits.
+func (dec *Decoder) SetLimits(l Limits) {
+	dec.opts.limits = l
+}
+
+// SetTimeLayout sets the layout used to parse TRON strings into time.Time
+// values (see time.Parse). The default is time.RFC3339Nano, which also
+// accepts timestamps without fractional seconds.
+func (dec *Decoder) SetTimeLayout(layout string) {
+	dec.opts.timeLayout = layout
+}
+
+// UseRawBytes causes the Decoder to store TRON strings decoded into a []byte
+// verbatim instead of decoding them as base64. This matches the behavior of
+// earlier versions of this package.
+func (dec *Decoder) UseRawBytes() {
+	dec.opts.rawBytes = true
+}
+
+// PreferInt64 causes the Decoder to unmarshal integral numbers into an
+// interface{} as an int64 instead of as a float64, avoiding precision loss
+// for values beyond 2^53. Numbers with a fraction or exponent, and integers
+// that overflow int64, are still decoded as float64.
+func (dec *Decoder) PreferInt64() {
+	dec.opts.preferInt64 = true
+}
+
+// UseStrictJSON causes the Decoder to accept only JSON, rejecting the syntax
+// TRON adds to it: comments, class definitions and instantiations, unquoted
+// object keys and root objects without braces. Strings are held to JSON's
+// rules too, with no raw control characters or unknown escapes. This lets the
+// package serve as a strict JSON decoder and validator.
+func (dec *Decoder) UseStrictJSON() {
+	dec.opts.strictJSON = true
+}
+
+// UseStrictUTF8 causes the Decoder to reject strings that contain invalid
+// UTF-8 or a \u escape of an unpaired UTF-16 surrogate with a SyntaxError,
+// instead of replacing them with U+FFFD.
+func (dec *Decoder) UseStrictUTF8() {
+	dec.opts.strictUTF8 = true
+}
+
+// AllowNonFinite causes the Decoder to accept the bare literals NaN,
+// Infinity and -Infinity as numbers, as written by an Encoder set to
+// NonFiniteLiterals. They decode into floating-point values and interface{};
+// any other Go type reports an UnmarshalTypeError. Followed by '(', NaN and
+// Infinity are still class instantiations.
+func (dec *Decoder) AllowNonFinite() {
+	dec.opts.nonFinite = true
+}
+
+// AllowExtendedNumbers causes the Decoder to accept the number syntax of
+// hand-written configuration files as well as JSON's: hexadecimal, binary
+// and octal integers (0xFF, 0b1010, 0o17, optionally negative) and
+// underscores between digits (1_000_000). They decode exactly as the decimal
+// number they stand for. Marshal always writes decimal. A hexadecimal,
+// binary or octal literal may have at most 256 digits.
+func (dec *Decoder) AllowExtendedNumbers() {
+	dec.opts.extendedNumbers = true
+}
+
+// AllowTrailingCommas causes the Decoder to accept a comma after the last
+// element of an array, the last member of an object and the last argument
+// of a class instantiation, as in [1,2,]. A lone comma, as in [,], is still
+// an error.
+func (dec *Decoder) AllowTrailingCommas() {
+	dec.opts.trailingCommas = true
+}
+
+// AllowTypedClasses causes the Decoder to accept a type after each property
+// name in a class definition, as in
+//
+//	class User: id int, name string, active bool
+//
+// and to check every argument of an instantiation against the type of its
+// property, so that arguments given in the wrong order are reported as a
+// SyntaxError wrapping ErrArgTypeMismatch at the offending argument, rather
+// than surfacing later, if at all, as an UnmarshalTypeError. The types are
+// int (a number without fraction or exponent), float or number, string,
+// bool, array, object (including class instantiations) and any. A property
+// without a type accepts any value, and null is accepted for every type.
+func (dec *Decoder) AllowTypedClasses() {
+	dec.opts.typedClasses = true
+}
+
+// A DuplicateKeyPolicy says how a Decoder handles an object that has the same
+// key more than once. It applies equally to a class definition that lists the
+// same property more than once.
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeysKeepLast stores every member in turn, so that the last
+	// of a repeated key wins. This is the default, and matches
+	// encoding/json.
+	DuplicateKeysKeepLast DuplicateKeyPolicy = iota
+	// DuplicateKeysKeepFirst skips members whose key has already occurred
+	// in the same object.
+	DuplicateKeysKeepFirst
+	// DuplicateKeysError rejects the document with a SyntaxError located at
+	// the repeated key.
+	DuplicateKeysError
+)
+
+// SetDuplicateKeys sets how the Decoder handles repeated object keys and
+// repeated properties in class definitions. The default is
+// DuplicateKeysKeepLast.
+func (dec *Decoder) SetDuplicateKeys(policy DuplicateKeyPolicy) {
+	dec.opts.duplicateKeys = policy
+}
+
+// UseExactFieldNames causes the Decoder to match object keys to struct
+// fields only when they are spelled exactly like the field's name or tag. By
+// default a key that matches no field exactly is matched case-insensitively,
+// which can route a value into the wrong field when two fields differ only by
+// case.
+func (dec *Decoder) UseExactFieldNames() {
+	dec.opts.exactNames = true
+}
+
+// AllowClassShadowing causes the Decoder to accept a class definition that
+// repeats the name of an earlier one with different properties, replacing it
+// for the rest of the document. By default such a redefinition is a
+// SyntaxError, since it almost always means the document is malformed.
+// Repeating a definition unchanged is always allowed.
+func (dec *Decoder) AllowClassShadowing() {
+	dec.opts.classShadowing = true
+}
+
+// ClearMaps causes the Decoder to delete the existing entries of a non-nil
+// map, or OrderedMap, before storing the members of an object into it, so
+// that the map holds exactly the object's members. By default existing
+// entries are kept, like encoding/json, and only those whose keys the object
+// has are replaced.
+func (dec *Decoder) ClearMaps() {
+	dec.opts.clearMaps = true
+}
+
+// InternStrings causes the Decoder to keep one copy of each string value
+// and object key that repeats within a document, such as the enum-like
+// values in the rows of a table of class instances, instead of allocating
+// it afresh every time. The strings of the decoded value then share their
+// memory, which can reduce its size considerably. Only strings of up to 64
+// bytes are interned, and the table lasts for one call to Decode.
+func (dec *Decoder) InternStrings() {
+	dec.opts.internStrings = true
+}
+
+// SetParallelism causes the Decoder to decode the elements of an array at
+// the root of a document into a slice on up to n goroutines, once the array
+// has been tokenized and its elements found. This speeds up the
+// reflection-heavy part of decoding large arrays, such as exports of
+// millions of records; the result, errors included, is the same as with
+// one goroutine. UnmarshalTRON and UnmarshalJSON methods of the elements may
+// then be called concurrently. The default, 0 or 1, decodes on the calling
+// goroutine.
+func (dec *Decoder) SetParallelism(n int) {
+	dec.opts.parallelism = n
+}
+
+// UseUnsafeFieldSetters causes the Decoder to store strings, numbers and
+// booleans into struct fields of the predeclared types string, bool, intN,
+// uintN and floatN by writing to the field's offset through package unsafe,
+// rather than through reflect.Value, which saves the time reflection spends
+// on each member of large arrays of flat structs. The results and errors are
+// the same; the tradeoff is that this path bypasses the checks of package
+// reflect, so a bug in it could corrupt memory instead of panicking, and it
+// cannot be used where package unsafe is not allowed. Other fields, and
+// values that need converting or report an error, take the usual path.
+func (dec *Decoder) UseUnsafeFieldSetters() {
+	dec.opts.unsafeFields = true
+}
+
+// ReportAllErrors causes Decode to return every UnmarshalTypeError and other
+// non-fatal error met while storing a value, joined with errors.Join in input
+// order, instead of only the first. Each one names the path to the value that
+// failed. Either way, decoding carries on past such errors and stores as much
+// of the value as it can.
+func (dec *Decoder) ReportAllErrors() {
+	dec.opts.allErrors = true
+}
+
+// UseLineMode causes the Decoder to read newline-delimited TRON (TRONL): a
+// header of class definitions at the start of the stream, then one value per
+// line, each read by its own call to Decode. The classes of the header apply
+// to every line. Blank lines and comments are skipped, and a value may only
+// continue onto the next line while one of its brackets is open.
+//
+// UseLineMode must be called before the first Decode.
+func (dec *Decoder) UseLineMode() {
+	dec.lines = &lineDecoder{classes: make(map[string]*classDef), header: true}
+	dec.lines.s.r = bufio.NewReader(dec.r)
+}
+
+// Buffered returns a reader of the data remaining in the Decoder's buffer:
+// input read from the underlying reader but not yet consumed by Decode, such
+// as the lines after the last one decoded in line mode, or the input after
+// the element that More read last. A caller that shares the stream with
+// another protocol can continue from there once it stops decoding. Decode
+// outside those modes reads its input to the end, so nothing remains. The
+// reader is valid until the next call to Decode or More.
+func (dec *Decoder) Buffered() io.Reader {
+	var r *bufio.Reader
+	switch {
+	case dec.lines != nil:
+		r = dec.lines.s.r
+	case dec.array != nil:
+		r = dec.array.s.r
+	case dec.frames != nil:
+		r = dec.frames.r
+	}
+	if r == nil {
+		return bytes.NewReader(nil)
+	}
+	data, _ := r.Peek(r.Buffered())
+	return bytes.NewReader(data)
+}
+
+// UseMultipleDocuments causes the Decoder to read a stream of TRON
+// documents, one per call to Decode, rather than rejecting what follows the
+// first as trailing tokens; Decode returns io.EOF at the end of the stream.
+// This suits log-style files that are appended to one document at a time.
+// Each document is an optional header of class definitions and then a
+// value, which must start on a new line. Classes stay defined for the rest
+// of the stream, so a document can repeat the header of an earlier one, add
+// classes of its own or use those already defined. Redefining a class
+// differently is a SyntaxError unless AllowClassShadowing is set. A root
+// object without braces extends over consecutive lines of key: value members.
+func (dec *Decoder) UseMultipleDocuments() {
+	dec.lines = &lineDecoder{classes: make(map[string]*classDef), documents: true}
+	dec.lines.s.r = bufio.NewReader(dec.r)
+}
+
+// Framing selects how messages are delimited on a stream that carries
+// several TRON documents, such as a message bus connection without framing
+// of its own.
+type Framing int
+
+const (
+	// FramingNone writes and reads a single document, or one per line in
+	// line mode. It is the default.
+	FramingNone Framing = iota
+	// FramingLength precedes each message with its length in bytes, as a
+	// 4-byte big-endian unsigned integer.
+	FramingLength
+	// FramingDelimiter follows each message with a line holding "---".
+	FramingDelimiter
+)
+
+// SetFraming causes the Decoder to read a stream of messages framed as f,
+// as an Encoder with the same framing writes them, decoding one per call to
+// Decode and returning io.EOF at the end of the stream. Each message is a
+// complete document with its own header. Under FramingDelimiter, empty
+// messages are skipped and the delimiter after the last message may be
+// omitted. Limits.MaxInputBytes applies to each message.
+//
+// SetFraming must be called before the first Decode, and is not combined
+// with line mode, UseMultipleDocuments or More.
+func (dec *Decoder) SetFraming(f Framing) {
+	dec.frames = nil
+	if f != FramingNone {
+		dec.frames = &frameReader{r: bufio.NewReader(dec.r), framing: f}
+	}
+}
+
+// Decode reads the TRON document from its input and stores it in the value
+// pointed to by v. If the input is empty, Decode returns io.EOF. In line
+// mode, Decode reads the next line's value instead, returning io.EOF at the
+// end of the stream, as it does for each document after
+// UseMultipleDocuments; once More has been called it decodes the next
+// element of the top-level array.
+//
+// See the documentation for Unmarshal for details about the conversion of
+// TRON into a Go value.
+func (dec *Decoder) Decode(v interface{}) error {
+	if dec.frames != nil {
+		data, err := dec.frames.next(dec.opts.limits.withDefaults().MaxInputBytes)
+		if err != nil {
+			return err
+		}
+		return unmarshal(data, v, dec.opts)
+	}
+	if dec.lines != nil {
+		return dec.lines.decode(v, dec.opts)
+	}
+	if dec.array != nil {
+		dec.More()
+		return dec.array.decode(v, dec.opts)
+	}
+	data, err := dec.readAll()
+	if err != nil {
+		return err
+	}
+	return unmarshal(data, v, dec.opts)
+}
+
+// DecodeContext is like Decode but stops early and returns ctx.Err() if ctx
+// is canceled or its deadline passes while the document is being decoded.
+// Reading from the underlying reader is not interrupted.
+func (dec *Decoder) DecodeContext(ctx context.Context, v interface{}) error {
+	if dec.array != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return dec.Decode(v)
+	}
+	var data []byte
+	var err error
+	if dec.frames != nil {
+		data, err = dec.frames.next(dec.opts.limits.withDefaults().MaxInputBytes)
+	} else {
+		data, err = dec.readAll()
+	}
+	if err != nil {
+		return err
+	}
+	return unmarshalContext(ctx, data, v, dec.opts)
+}
+
+// DecodeAs is like dec.Decode but decodes into a new value of type T and
+// returns it.
+func DecodeAs[T any](dec *Decoder) (T, error) {
+	var v T
+	err := dec.Decode(&v)
+	return v, err
+}
+
+// readAll reads the rest of the input, up to one byte past the input limit.
+// It returns io.EOF if the input holds no document.
+func (dec *Decoder) readAll() ([]byte, error) {
+	limit := dec.opts.limits.withDefaults().MaxInputBytes
+	data, err := io.ReadAll(io.LimitReader(dec.r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, io.EOF
+	}
+	return data, nil
+}
+
+// An Encoder writes TRON values to an output stream.
+type Encoder struct {
+	w    io.Writer
+	opts encodeOptions
+
+	lines   bool
+	stream  *encoder // line mode state, kept between calls to Encode
+	framing Framing
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// SetTimeLayout sets the layout used to format time.Time values
+// (see time.Time.Format). The default is time.RFC3339Nano.
+func (enc *Encoder) SetTimeLayout(layout string) {
+	enc.opts.timeLayout = layout
+}
+
+// SetRawBytes controls whether []byte values are encoded as plain TRON strings
+// instead of base64. Raw strings are shorter but cannot represent arbitrary
+// binary data: invalid UTF-8 is replaced by the Unicode replacement rune.
+func (enc *Encoder) SetRawBytes(on bool) {
+	enc.opts.rawBytes = on
+}
+
+// SetStrictUTF8 controls whether strings and map keys containing invalid
+// UTF-8 are reported as an UnsupportedValueError instead of having the
+// invalid bytes replaced by the Unicode replacement rune. It also applies to
+// []byte values encoded with SetRawBytes.
+func (enc *Encoder) SetStrictUTF8(on bool) {
+	enc.opts.strictUTF8 = on
+}
+
+// SetEscapeHTML controls whether '<', '>' and '&' in strings are escaped as
+// \u003c, \u003e and \u0026, so that the output can be embedded in HTML and
+// <script> elements. It is on by default, matching encoding/json; turning it
+// off makes strings that contain those characters shorter and more readable.
+// Output of MarshalTRON and MarshalJSON methods is written as they return it.
+func (enc *Encoder) SetEscapeHTML(on bool) {
+	enc.opts.noEscapeHTML = !on
+}
+
+// SetASCIIOnly controls whether the Encoder writes only ASCII, escaping
+// every other rune in strings as \u followed by four hex digits, or as a
+// surrogate pair of such escapes outside the Basic Multilingual Plane. This
+// suits transports and log systems that mangle UTF-8, at the cost of longer
+// output. Keys that would otherwise be written bare in class definitions are
+// quoted, and the output of MarshalTRON and MarshalJSON methods is escaped
+// as well.
+func (enc *Encoder) SetASCIIOnly(on bool) {
+	enc.opts.asciiOnly = on
+}
+
+// A MapKeyOrder says in what order an Encoder writes the entries of maps.
+type MapKeyOrder int
+
+const (
+	// MapKeysSorted sorts entries by the text of their keys, so that
+	// integer keys are in lexical order: "10" before "2". This is the
+	// default, and matches encoding/json.
+	MapKeysSorted MapKeyOrder = iota
+	// MapKeysNumeric sorts the entries of maps with integer keys by the
+	// value of the key, and those of other maps as MapKeysSorted does.
+	MapKeysNumeric
+	// MapKeysUnsorted writes entries in Go's map iteration order, which is
+	// random, skipping the cost of sorting. The output is then no longer
+	// deterministic.
+	MapKeysUnsorted
+)
+
+// SetMapKeyOrder sets the order in which the Encoder writes map entries. The
+// default is MapKeysSorted. Entries of inline map fields are always sorted,
+// as they become the properties of classes.
+func (enc *Encoder) SetMapKeyOrder(order MapKeyOrder) {
+	enc.opts.mapKeyOrder = order
+}
+
+// SetEmitNilCollectionsAsEmpty controls whether nil slices and maps are
+// written as [] and {}, and a nil []byte as "", instead of as null, for
+// consumers that reject null in place of a collection. It is off by default,
+// matching encoding/json. Nil pointers and interfaces are still null.
+func (enc *Encoder) SetEmitNilCollectionsAsEmpty(on bool) {
+	enc.opts.nilAsEmpty = on
+}
+
+// A NonFinitePolicy says how an Encoder writes floating-point values that
+// are NaN or infinite, which TRON and JSON numbers cannot represent.
+type NonFinitePolicy int
+
+const (
+	// NonFiniteError fails with an UnsupportedValueError. This is the
+	// default, and matches encoding/json.
+	NonFiniteError NonFinitePolicy = iota
+	// NonFiniteLiterals writes the bare literals NaN, Infinity and
+	// -Infinity, which only a Decoder set to AllowNonFinite accepts.
+	NonFiniteLiterals
+	// NonFiniteNull writes null, losing the value but keeping the output
+	// readable by any decoder.
+	NonFiniteNull
+)
+
+// SetNonFinite sets how the Encoder writes NaN and infinite floating-point
+// values. The default is NonFiniteError.
+func (enc *Encoder) SetNonFinite(policy NonFinitePolicy) {
+	enc.opts.nonFinite = policy
+}
+
+// SetOptionalProperties controls whether struct values that leave out some
+// of their omitempty or omitzero fields share one class with the values that
+// have them, instead of needing a class or object literal of their own. The
+// class marks such fields optional, as in "class A: title,due?", and an
+// instantiation gives null for each one it lacks, or leaves it out if it is
+// among the trailing arguments. Decoding gives them the zero value.
+func (enc *Encoder) SetOptionalProperties(on bool) {
+	enc.opts.optionalProps = on
+}
+
+// SetClassInheritance controls whether a class whose keys begin with all the
+// keys of an earlier class, such as one for a struct that embeds another, is
+// defined as extending it, as in "class B extends A: permissions", instead of
+// repeating the shared keys. Decoders that predate class inheritance cannot
+// read such definitions, so it is off by default.
+func (enc *Encoder) SetClassInheritance(on bool) {
+	enc.opts.inheritance = on
+}
+
+// A FieldOrder says in what order an Encoder writes the fields of structs,
+// both in the class definitions it generates and in object literals.
+type FieldOrder int
+
+const (
+	// FieldOrderDeclaration writes fields in the order the struct type
+	// declares them. This is the default, and matches encoding/json.
+	FieldOrderDeclaration FieldOrder = iota
+	// FieldOrderAlphabetical writes fields sorted by their TRON names, so
+	// that reordering the fields of a type does not change the output.
+	FieldOrderAlphabetical
+)
+
+// SetFieldOrder sets the order in which the Encoder writes struct fields. The
+// default is FieldOrderDeclaration. Entries of inline map fields are written
+// after the declared fields in declaration order, and among them otherwise.
+func (enc *Encoder) SetFieldOrder(order FieldOrder) {
+	enc.opts.fieldCmp = nil
+	if order == FieldOrderAlphabetical {
+		enc.opts.fieldCmp = strings.Compare
+	}
+}
+
+// SetFieldComparator makes the Encoder write struct fields sorted by cmp,
+// which compares two field names and returns a negative number, zero or a
+// positive number as strings.Compare does. Fields that cmp reports equal keep
+// their declaration order. A nil cmp restores FieldOrderDeclaration.
+func (enc *Encoder) SetFieldComparator(cmp func(a, b string) int) {
+	enc.opts.fieldCmp = cmp
+}
+
+// SetBufferPooling controls whether the encoder borrows its working buffers
+// from a package-wide pool, which is the default and the behavior of Marshal.
+// Turning pooling off allocates fresh buffers for every Encode, trading
+// throughput for not retaining pooled memory between calls.
+func (enc *Encoder) SetBufferPooling(on bool) {
+	enc.opts.noPool = !on
+}
+
+// SetTokenCounter makes the encoder optimize for the tokens counted by tc
+// rather than for bytes: a repeated struct schema is only defined as a class
+// when that lowers the token count of the output. A nil tc restores the
+// default of defining a class for every schema with two or more keys that
+// occurs at least twice.
+func (enc *Encoder) SetTokenCounter(tc TokenCounter) {
+	enc.opts.tokenCounter = tc
+}
+
+// SetLineMode controls whether the Encoder writes newline-delimited TRON
+// (TRONL), the TRON counterpart of NDJSON, suited to log pipelines and bulk
+// export. In line mode the first call to Encode writes a header defining a
+// class for every object shape with two or more keys in its value; that value
+// and all later ones are then written on a line each, using the header's
+// classes wherever their shapes recur. Shapes first seen after the header
+// are written as objects.
+//
+// SetLineMode must be called before the first Encode.
+func (enc *Encoder) SetLineMode(on bool) {
+	enc.lines = on
+}
+
+// SetImplicitRoot controls whether a map or struct at the root of a value is
+// written as an implicit root object, one key: value member per line without
+// braces, as in a configuration file:
+//
+//	name: "svc"
+//	port: 8080
+//	db: {"host":"localhost"}
+//
+// Keys that are identifiers are written without quotes. The root is written
+// this way even if its shape has a class; an empty root object is still
+// written as {}. The option does not apply in line mode.
+func (enc *Encoder) SetImplicitRoot(on bool) {
+	enc.opts.implicitRoot = on
+}
+
+// SetParallelThreshold causes the Encoder to encode a slice or array of n or
+// more elements at the root of a value on several goroutines, up to
+// GOMAXPROCS, each serializing a run of the elements into a buffer of its
+// own. The output is the same as without the option; only CPU-bound
+// encoding of large values, such as long arrays of telemetry records, gets
+// faster. MarshalTRON and the other marshaling methods of the elements may
+// then be called concurrently. The default, 0, encodes on the calling
+// goroutine. The option does not apply in line mode.
+func (enc *Encoder) SetParallelThreshold(n int) {
+	enc.opts.parallelMin = n
+}
+
+// SetFraming causes each call to Encode to write its document as a message
+// framed as f, for a Decoder with the same framing to split the stream
+// again. Under FramingLength the message holds the document without a
+// trailing newline. Framing does not apply in line mode, where each value is
+// already a line.
+func (enc *Encoder) SetFraming(f Framing) {
+	enc.framing = f
+}
+
+// Encode writes the TRON encoding of v to the stream,
+// followed by a newline character.
+//
+// See the documentation for Marshal for details about the
+// conversion of Go values to TRON.
+func (enc *Encoder) Encode(v interface{}) error {
+	if enc.lines {
+		return enc.encodeLine(v)
+	}
+	if enc.framing != FramingNone {
+		return enc.encodeFramed(v)
+	}
+	if v == nil {
+		_, err := io.WriteString(enc.w, "null\n")
+		return err
+	}
+
+	e := newEncoder(enc.opts)
+	defer e.release()
+	b, err := e.marshal(e.out[:0], v)
+	if err != nil {
+		return err
+	}
+	e.out = append(b, '\n')
+	_, err = enc.w.Write(e.out)
+	return err
+}
@@ -0,0 +1,45 @@
+package tron
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEncoderEncode(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.Encode([]int{1, 2}); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if buf.String() != "[1,2]\n" {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+	if err := enc.Encode(make(chan int)); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestDecoderDecode(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("class A: a,b\n\n[A(1,2),A(3,4)]\n"))
+	var v []struct{ A, B int }
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(v) != 2 || v[1].A != 3 || v[1].B != 4 {
+		t.Fatalf("unexpected value: %+v", v)
+	}
+	if err := dec.Decode(&v); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestDecoderInputTooLarge(t *testing.T) {
+	withLimits(t, 8, maxTokens, maxParseDepth, maxWalkDepth)
+	var v interface{}
+	err := NewDecoder(strings.NewReader(`"0123456789"`)).Decode(&v)
+	if _, ok := err.(*SyntaxError); !ok {
+		t.Fatalf("expected *SyntaxError, got %T (%v)", err, err)
+	}
+}
@@ -0,0 +1,72 @@
+package tron
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func decodeStrict(input string) (interface{}, error) {
+	dec := NewDecoder(strings.NewReader(input))
+	dec.UseStrictJSON()
+	var v interface{}
+	err := dec.Decode(&v)
+	return v, err
+}
+
+func TestStrictJSONAcceptsJSON(t *testing.T) {
+	input := "{\n  \"a\": [1, -2.5e3, true, null],\n  \"b\": {\"c\": \"x\\u00e9\\n\"}\n}\n"
+	got, err := decodeStrict(input)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	var want interface{}
+	if err := Unmarshal([]byte(input), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestStrictJSONRejectsExtensions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"[1] # note", "comments"},
+		{"class A: a\n\n[A(1)]", "class definitions"},
+		{`[A(1)]`, "unquoted name A"},
+		{`{a: 1}`, "unquoted name a"},
+		{"\"a\": 1\n\"b\": 2", "without braces"},
+		{`"\q"`, "invalid escape"},
+		{"\"a\tb\"", "control character"},
+	}
+	for _, tt := range tests {
+		_, err := decodeStrict(tt.input)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: got %v, want error mentioning %q", tt.input, err, tt.want)
+		}
+		// The same input is valid TRON.
+		if tt.want != "unquoted name A" && tt.want != "invalid escape" {
+			var v interface{}
+			if err := Unmarshal([]byte(tt.input), &v); err != nil {
+				t.Errorf("%q: not valid TRON either: %v", tt.input, err)
+			}
+		}
+	}
+
+	dec := NewDecoder(strings.NewReader("[1, # one\n2]"))
+	dec.UseStrictJSON()
+	var err error
+	for _, err = range Values[int](dec) {
+		if err != nil {
+			break
+		}
+	}
+	if err == nil || !strings.Contains(err.Error(), "comments") {
+		t.Fatalf("Values: got %v, want comment error", err)
+	}
+}
@@ -0,0 +1,68 @@
+package tron
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type cachedStruct struct {
+	A int    `json:"a"`
+	B string `json:"b,omitempty"`
+}
+
+func TestStructTypeInfoIsSharedAcrossEncoders(t *testing.T) {
+	typ := reflect.TypeOf(cachedStruct{})
+	first := (&encoder{}).getStructTypeInfo(typ)
+	second := (&encoder{}).getStructTypeInfo(typ)
+	if first != second {
+		t.Fatalf("expected the same cached *structTypeInfo for separate encoders")
+	}
+	if _, ok := structTypeCache.Load(typ); !ok {
+		t.Fatalf("struct type info not stored in package cache")
+	}
+}
+
+func TestStructTypeInfoCacheConcurrentMarshal(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			out, err := Marshal(cachedStruct{A: i})
+			if err != nil {
+				t.Errorf("Marshal: %v", err)
+				return
+			}
+			var got cachedStruct
+			if err := Unmarshal(out, &got); err != nil || got.A != i {
+				t.Errorf("round trip %d: got %+v, err %v", i, got, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestStructFieldsAreSharedAcrossDecodes(t *testing.T) {
+	type folded struct {
+		Name  string `json:"name"`
+		NAME  string `json:"NAME"`
+		Count int
+	}
+	typ := reflect.TypeOf(folded{})
+	d := &decoder{}
+	if d.structFields(typ) != (&decoder{}).structFields(typ) {
+		t.Fatalf("expected the same cached *structFields for separate decoders")
+	}
+
+	// Both the exact and the case-insensitive indexes come from the cache.
+	for range 2 {
+		var v []folded
+		if err := Unmarshal([]byte(`[{"name":"a","NAME":"b","count":1},{"Name":"c","COUNT":2}]`), &v); err != nil {
+			t.Fatal(err)
+		}
+		if want := []folded{{"a", "b", 1}, {"c", "", 2}}; !reflect.DeepEqual(v, want) {
+			t.Errorf("got %+v, want %+v", v, want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package tron
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type tronTagged struct {
+	ID       int    `json:"id" tron:"i"`
+	Name     string `json:"name"`
+	Internal string `json:"internal" tron:"-"`
+	Note     string `json:"-" tron:"note"`
+	Empty    string `json:"empty" tron:",omitempty"`
+}
+
+func TestTronTagTakesPrecedenceOverJSON(t *testing.T) {
+	v := tronTagged{ID: 7, Name: "x", Internal: "secret", Note: "n"}
+
+	out, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	s := string(out)
+	for _, want := range []string{`"i":7`, `"name":"x"`, `"note":"n"`} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("expected %s in %s", want, s)
+		}
+	}
+	for _, unwanted := range []string{"internal", "secret", `"id"`, "Empty", `"empty"`} {
+		if strings.Contains(s, unwanted) {
+			t.Fatalf("did not expect %s in %s", unwanted, s)
+		}
+	}
+
+	// JSON output is unaffected by tron tags.
+	js, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json marshal: %v", err)
+	}
+	if !strings.Contains(string(js), `"internal":"secret"`) || !strings.Contains(string(js), `"id":7`) {
+		t.Fatalf("unexpected json output: %s", js)
+	}
+}
+
+func TestTronTagUnmarshal(t *testing.T) {
+	var v tronTagged
+	input := `{"i":3,"name":"x","internal":"ignored","note":"n","id":99}`
+	if err := Unmarshal([]byte(input), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if v.ID != 3 || v.Name != "x" || v.Note != "n" {
+		t.Fatalf("unexpected value: %+v", v)
+	}
+	if v.Internal != "" {
+		t.Fatalf("expected tron:\"-\" field to be skipped, got %q", v.Internal)
+	}
+}
+
+func TestTronTagRoundTripWithClasses(t *testing.T) {
+	in := []tronTagged{{ID: 1, Name: "a", Note: "x"}, {ID: 2, Name: "b", Note: "y"}}
+	out, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.HasPrefix(string(out), "class A: i,name,note\n") {
+		t.Fatalf("unexpected header: %s", out)
+	}
+	var got []tronTagged
+	if err := Unmarshal(out, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(got) != 2 || got[0] != in[0] || got[1] != in[1] {
+		t.Fatalf("round trip mismatch: %+v", got)
+	}
+}
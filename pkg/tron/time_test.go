@@ -0,0 +1,98 @@
+package tron
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+type event struct {
+	Name    string         `json:"name"`
+	At      time.Time      `json:"at"`
+	Ends    *time.Time     `json:"ends"`
+	Timeout time.Duration  `json:"timeout"`
+	Sub     []time.Time    `json:"sub,omitempty"`
+	Meta    map[string]any `json:"meta,omitempty"`
+}
+
+func TestMarshalTimeRFC3339(t *testing.T) {
+	at := time.Date(2025, 3, 4, 5, 6, 7, 800, time.UTC)
+	out, err := Marshal(event{Name: "x", At: at, Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `{"name":"x","at":"2025-03-04T05:06:07.0000008Z","ends":null,"timeout":1000000000}`
+	if string(out) != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestMarshalTimePointer(t *testing.T) {
+	at := time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)
+	out, err := Marshal(&at)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(out) != `"2025-03-04T00:00:00Z"` {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
+
+func TestUnmarshalTimeAndDuration(t *testing.T) {
+	var v event
+	input := `{"name":"x","at":"2025-03-04T05:06:07Z","timeout":"1m30s","sub":["2025-01-01T00:00:00.5+02:00"]}`
+	if err := Unmarshal([]byte(input), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !v.At.Equal(time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)) {
+		t.Fatalf("unexpected time: %v", v.At)
+	}
+	if v.Timeout != 90*time.Second {
+		t.Fatalf("unexpected duration: %v", v.Timeout)
+	}
+	if len(v.Sub) != 1 || v.Sub[0].Nanosecond() != 500000000 {
+		t.Fatalf("unexpected sub: %v", v.Sub)
+	}
+
+	// Numbers are still accepted as nanoseconds.
+	if err := Unmarshal([]byte(`{"timeout":5}`), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if v.Timeout != 5 {
+		t.Fatalf("unexpected duration: %v", v.Timeout)
+	}
+}
+
+func TestUnmarshalTimeErrors(t *testing.T) {
+	var v event
+	for _, input := range []string{`{"at":"yesterday"}`, `{"timeout":"soon"}`} {
+		err := Unmarshal([]byte(input), &v)
+		if _, ok := err.(*UnmarshalTypeError); !ok {
+			t.Fatalf("%s: expected *UnmarshalTypeError, got %T (%v)", input, err, err)
+		}
+	}
+}
+
+func TestTimeLayoutOption(t *testing.T) {
+	const layout = "2006-01-02"
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetTimeLayout(layout)
+	if err := enc.Encode(event{Name: "x", At: time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)}); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"at":"2025-03-04"`) {
+		t.Fatalf("unexpected output: %s", buf.String())
+	}
+
+	dec := NewDecoder(&buf)
+	dec.SetTimeLayout(layout)
+	var v event
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !v.At.Equal(time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected time: %v", v.At)
+	}
+}
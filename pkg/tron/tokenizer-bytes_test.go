@@ -0,0 +1,35 @@
+package tron
+
+import "testing"
+
+func TestTokenizeBytesDoesNotAliasInput(t *testing.T) {
+	input := []byte(`A name "str" 12`)
+	tokens, err := tokenizeWith(input, decodeOptions{})
+	if err != nil {
+		t.Fatalf("tokenize: %v", err)
+	}
+	for i := range input {
+		input[i] = 'x'
+	}
+	got := []string{tokens[0].Value, tokens[1].Value, tokens[2].Value, tokens[3].Value}
+	want := []string{"A", "name", "str", "12"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("token %d = %q after mutating input, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestIdentifierTokenKeywordsDoNotAllocate(t *testing.T) {
+	raw := [][]byte{[]byte("class"), []byte("true"), []byte("false"), []byte("null")}
+	allocs := testing.AllocsPerRun(100, func() {
+		for _, b := range raw {
+			if typ, _ := identifierToken(b); typ == TokenIdentifier {
+				t.Fatalf("%s: unexpected identifier", b)
+			}
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("keywords allocated %v times, want 0", allocs)
+	}
+}
@@ -1,14 +1,31 @@
 package tron
 
 import (
+	"bytes"
 	"fmt"
+	"iter"
+	"math/big"
 	"strconv"
 	"strings"
+	"sync"
 	"unicode"
 	"unicode/utf16"
 	"unicode/utf8"
+	"unsafe"
 )
 
+// tokenPool recycles token slices between documents, so that a server
+// decoding many of them does not allocate a new slice for each.
+var tokenPool = sync.Pool{
+	New: func() interface{} {
+		return new([]Token)
+	},
+}
+
+// maxPooledTokens caps the size of the token slices returned to tokenPool so
+// that one very large document does not stay pinned in memory.
+const maxPooledTokens = 1 << 16
+
 // TokenType represents the type of a token in TRON format.
 type TokenType int
 
@@ -51,6 +68,8 @@ const (
 	TokenNewline
 	// TokenEOF represents end of input
 	TokenEOF
+	// TokenQuestion represents "?", which marks an optional class property
+	TokenQuestion
 )
 
 // String returns a string representation of the token type.
@@ -94,6 +113,8 @@ func (t TokenType) String() string {
 		return "NEWLINE"
 	case TokenEOF:
 		return "EOF"
+	case TokenQuestion:
+		return "QUESTION"
 	default:
 		return "UNKNOWN"
 	}
@@ -103,8 +124,9 @@ func (t TokenType) String() string {
 type Token struct {
 	Type   TokenType
 	Value  string
-	Line   int
-	Column int
+	Line   int // 1-based line of the token's first character
+	Column int // 1-based column, in runes, of the token's first character
+	Offset int // byte offset of the token's first character
 }
 
 // String returns a string representation of the token.
@@ -112,25 +134,76 @@ func (t Token) String() string {
 	return fmt.Sprintf("%s(%q) at %d:%d", t.Type, t.Value, t.Line, t.Column)
 }
 
-// tokenize parses the input string and returns a slice of tokens.
+// Tokens returns an iterator over the tokens of the TRON text data with their
+// positions, for tools such as syntax highlighters, partial parsers and
+// security scanners that want to build on the package's tokenizer. The last
+// token is TokenEOF. Comments and whitespace other than newlines are left
+// out; string tokens hold the unescaped string, and number tokens the text of
+// the number.
+//
+// If data cannot be tokenized, the iterator yields the tokens before the
+// problem and then a *SyntaxError giving its position. The default Limits
+// apply.
+func Tokens(data []byte) iter.Seq2[Token, error] {
+	return func(yield func(Token, error) bool) {
+		tokens, err := tokenizeWith(data, decodeOptions{})
+		for _, tok := range tokens {
+			if !yield(tok, nil) {
+				return
+			}
+		}
+		if err != nil {
+			yield(Token{}, err)
+		}
+	}
+}
+
+// tokenize parses the input string and returns a slice of tokens. The
+// tokenizer only reads its input, so the string's bytes are used in place.
 func tokenize(input string) ([]Token, error) {
-	var tokens []Token
+	return tokenizeWith(unsafe.Slice(unsafe.StringData(input), len(input)), decodeOptions{})
+}
+
+// tokenizeWith tokenizes input in place, honoring the decode options. Only
+// tokens carrying a value (identifiers, numbers and strings) allocate; the
+// input itself is never copied.
+func tokenizeWith(input []byte, opts decodeOptions) ([]Token, error) {
+	return appendTokens(nil, input, opts)
+}
+
+// appendTokens is like tokenizeWith but appends the tokens to tokens, whose
+// capacity it reuses. The slice is not sized ahead from the length of input,
+// which would overestimate for documents of long strings and leave slices
+// too large for tokenPool; append grows it as the tokens need. On error it
+// returns the tokens read so far.
+func appendTokens(tokens []Token, input []byte, opts decodeOptions) ([]Token, error) {
+	limits := opts.limits.withDefaults()
+	limit, maxString := limits.MaxTokens, limits.MaxStringBytes
 	cursor := 0 // byte index
 	line := 1
 	column := 1 // rune column within line
 
+	var interned map[string]string // see Decoder.InternStrings
+	if opts.internStrings {
+		interned = make(map[string]string)
+	}
+
 	appendToken := func(tok Token) error {
-		if len(tokens) >= maxTokens {
-			return &SyntaxError{msg: "too many tokens", Offset: int64(cursor)}
+		if len(tokens) >= limit {
+			return &SyntaxError{msg: "too many tokens", err: ErrTooManyTokens, Offset: int64(cursor), Line: line, Column: column}
+		}
+		if err := opts.cancel.check(); err != nil {
+			return err
 		}
+		tok.Offset = cursor
 		tokens = append(tokens, tok)
 		return nil
 	}
 
 	for cursor < len(input) {
-		r, size := utf8.DecodeRuneInString(input[cursor:])
+		r, size := utf8.DecodeRune(input[cursor:])
 		if r == utf8.RuneError && size == 1 {
-			return nil, &SyntaxError{msg: "invalid UTF-8", Offset: int64(cursor)}
+			return tokens, &SyntaxError{msg: "invalid UTF-8", Offset: int64(cursor), Line: line, Column: column}
 		}
 
 		// Handle whitespace (except newlines)
@@ -143,7 +216,7 @@ func tokenize(input string) ([]Token, error) {
 		// Handle newlines
 		if r == '\n' {
 			if err := appendToken(Token{Type: TokenNewline, Value: "\n", Line: line, Column: column}); err != nil {
-				return nil, err
+				return tokens, err
 			}
 			cursor += size
 			line++
@@ -153,13 +226,16 @@ func tokenize(input string) ([]Token, error) {
 
 		// Handle comments
 		if r == '#' {
+			if opts.strictJSON {
+				return tokens, &SyntaxError{msg: "comments are not allowed in JSON", Offset: int64(cursor), Line: line, Column: column}
+			}
 			// Consume until newline or EOF
 			cursor += size
 			column++
 			for cursor < len(input) {
-				r2, s2 := utf8.DecodeRuneInString(input[cursor:])
+				r2, s2 := utf8.DecodeRune(input[cursor:])
 				if r2 == utf8.RuneError && s2 == 1 {
-					return nil, &SyntaxError{msg: "invalid UTF-8", Offset: int64(cursor)}
+					return tokens, &SyntaxError{msg: "invalid UTF-8", Offset: int64(cursor), Line: line, Column: column}
 				}
 				if r2 == '\n' {
 					break
@@ -174,70 +250,77 @@ func tokenize(input string) ([]Token, error) {
 		switch r {
 		case '(':
 			if err := appendToken(Token{Type: TokenLParen, Value: "(", Line: line, Column: column}); err != nil {
-				return nil, err
+				return tokens, err
 			}
 			cursor += size
 			column++
 			continue
 		case ')':
 			if err := appendToken(Token{Type: TokenRParen, Value: ")", Line: line, Column: column}); err != nil {
-				return nil, err
+				return tokens, err
 			}
 			cursor += size
 			column++
 			continue
 		case '[':
 			if err := appendToken(Token{Type: TokenLBracket, Value: "[", Line: line, Column: column}); err != nil {
-				return nil, err
+				return tokens, err
 			}
 			cursor += size
 			column++
 			continue
 		case ']':
 			if err := appendToken(Token{Type: TokenRBracket, Value: "]", Line: line, Column: column}); err != nil {
-				return nil, err
+				return tokens, err
 			}
 			cursor += size
 			column++
 			continue
 		case '{':
 			if err := appendToken(Token{Type: TokenLBrace, Value: "{", Line: line, Column: column}); err != nil {
-				return nil, err
+				return tokens, err
 			}
 			cursor += size
 			column++
 			continue
 		case '}':
 			if err := appendToken(Token{Type: TokenRBrace, Value: "}", Line: line, Column: column}); err != nil {
-				return nil, err
+				return tokens, err
 			}
 			cursor += size
 			column++
 			continue
 		case ',':
 			if err := appendToken(Token{Type: TokenComma, Value: ",", Line: line, Column: column}); err != nil {
-				return nil, err
+				return tokens, err
 			}
 			cursor += size
 			column++
 			continue
 		case ':':
 			if err := appendToken(Token{Type: TokenColon, Value: ":", Line: line, Column: column}); err != nil {
-				return nil, err
+				return tokens, err
 			}
 			cursor += size
 			column++
 			continue
 		case ';':
 			if err := appendToken(Token{Type: TokenSemicolon, Value: ";", Line: line, Column: column}); err != nil {
-				return nil, err
+				return tokens, err
 			}
 			cursor += size
 			column++
 			continue
 		case '=':
 			if err := appendToken(Token{Type: TokenEquals, Value: "=", Line: line, Column: column}); err != nil {
-				return nil, err
+				return tokens, err
+			}
+			cursor += size
+			column++
+			continue
+		case '?':
+			if err := appendToken(Token{Type: TokenQuestion, Value: "?", Line: line, Column: column}); err != nil {
+				return tokens, err
 			}
 			cursor += size
 			column++
@@ -246,26 +329,48 @@ func tokenize(input string) ([]Token, error) {
 
 		// Handle strings
 		if r == '"' {
-			value, newCursor, newColumn, err := parseString(input, cursor, line, column)
+			value, newCursor, newColumn, err := parseString(input, cursor, line, column, &opts)
 			if err != nil {
-				return nil, err
+				return tokens, err
+			}
+			if len(value) > maxString {
+				return tokens, &SyntaxError{msg: "string too long", err: ErrTooLarge, Offset: int64(cursor), Line: line, Column: column}
+			}
+			if interned != nil {
+				value = intern(interned, value)
 			}
 			if err := appendToken(Token{Type: TokenString, Value: value, Line: line, Column: column}); err != nil {
-				return nil, err
+				return tokens, err
 			}
 			cursor = newCursor
 			column = newColumn
 			continue
 		}
 
+		// -Infinity is a number where non-finite literals are allowed.
+		if r == '-' && opts.nonFinite {
+			if raw, _, _ := parseIdentifierUTF8(input, cursor+1, column+1); string(raw) == "Infinity" {
+				if err := appendToken(Token{Type: TokenNumber, Value: "-Infinity", Line: line, Column: column}); err != nil {
+					return tokens, err
+				}
+				cursor += len("-Infinity")
+				column += len("-Infinity")
+				continue
+			}
+		}
+
 		// Handle numbers (JSON-style)
 		if r == '-' || (r >= '0' && r <= '9') {
-			value, newCursor, newColumn, ok := parseNumberJSON(input, cursor, column)
+			scan := parseNumberJSON
+			if opts.extendedNumbers {
+				scan = parseNumberExtended
+			}
+			value, newCursor, newColumn, ok := scan(input, cursor, column)
 			if !ok {
-				return nil, &SyntaxError{msg: "invalid number", Offset: int64(cursor)}
+				return tokens, &SyntaxError{msg: "invalid number", Offset: int64(cursor), Line: line, Column: column}
 			}
 			if err := appendToken(Token{Type: TokenNumber, Value: value, Line: line, Column: column}); err != nil {
-				return nil, err
+				return tokens, err
 			}
 			cursor = newCursor
 			column = newColumn
@@ -274,42 +379,82 @@ func tokenize(input string) ([]Token, error) {
 
 		// Handle identifiers and keywords
 		if unicode.IsLetter(r) || r == '_' {
-			value, newCursor, newColumn := parseIdentifierUTF8(input, cursor, column)
-			tokenType := getKeywordType(value)
+			raw, newCursor, newColumn := parseIdentifierUTF8(input, cursor, column)
+			tokenType, value := identifierToken(raw)
+			if interned != nil && tokenType == TokenIdentifier {
+				value = intern(interned, value)
+			}
+			if opts.strictJSON && tokenType == TokenClass {
+				return tokens, &SyntaxError{msg: "class definitions are not allowed in JSON", Offset: int64(cursor), Line: line, Column: column}
+			}
+			if opts.strictJSON && tokenType == TokenIdentifier && !(opts.nonFinite && isNonFinite(value)) {
+				return tokens, &SyntaxError{msg: fmt.Sprintf("unquoted name %s is not allowed in JSON", value), Offset: int64(cursor), Line: line, Column: column}
+			}
 			if err := appendToken(Token{Type: tokenType, Value: value, Line: line, Column: column}); err != nil {
-				return nil, err
+				return tokens, err
 			}
 			cursor = newCursor
 			column = newColumn
 			continue
 		}
 
-		return nil, &SyntaxError{msg: fmt.Sprintf("Unexpected character '%c' at %d:%d", r, line, column), Offset: int64(cursor)}
+		return tokens, &SyntaxError{msg: fmt.Sprintf("Unexpected character '%c'", r), Offset: int64(cursor), Line: line, Column: column}
 	}
 
 	if err := appendToken(Token{Type: TokenEOF, Value: "", Line: line, Column: column}); err != nil {
-		return nil, err
+		return tokens, err
 	}
 	return tokens, nil
 }
 
-// parseString parses a quoted string literal starting at the given cursor position.
-func parseString(input string, cursor, line, column int) (string, int, int, error) {
+// maxInterned is the length of the longest string Decoder.InternStrings
+// interns. Longer strings rarely repeat, and would only grow the table.
+const maxInterned = 64
+
+// intern returns the copy of s in table, adding s if it is new.
+func intern(table map[string]string, s string) string {
+	if len(s) > maxInterned {
+		return s
+	}
+	if t, ok := table[s]; ok {
+		return t
+	}
+	table[s] = s
+	return s
+}
+
+// parseString parses a quoted string literal starting at the given cursor
+// position. Invalid UTF-8 and unpaired surrogate escapes become U+FFFD unless
+// opts.strictUTF8 is set. With opts.strictJSON, it rejects what JSON does not
+// allow: control characters and escapes other than JSON's.
+func parseString(input []byte, cursor, line, column int, opts *decodeOptions) (string, int, int, error) {
 	var value strings.Builder
+	strict := opts.strictJSON
 
 	// Consume opening quote
-	r, size := utf8.DecodeRuneInString(input[cursor:])
+	r, size := utf8.DecodeRune(input[cursor:])
 	if r != '"' {
-		return "", 0, 0, &SyntaxError{msg: "expected string", Offset: int64(cursor)}
+		return "", 0, 0, &SyntaxError{msg: "expected string", Offset: int64(cursor), Line: line, Column: column}
 	}
 	cursor += size
 	column++
 
+	// Most strings have no escapes: their value is the text up to the
+	// closing quote. Scan as far as that holds, then build the rest.
+	end, runes, plain := scanPlainString(input, cursor, strict)
+	if plain {
+		return string(input[cursor:end]), end + 1, column + runes + 1, nil
+	}
+	value.Grow(end - cursor + 16)
+	value.Write(input[cursor:end])
+	cursor = end
+	column += runes
+
 	closed := false
 	for cursor < len(input) {
-		r, size := utf8.DecodeRuneInString(input[cursor:])
-		if r == utf8.RuneError && size == 1 {
-			return "", 0, 0, &SyntaxError{msg: "invalid UTF-8", Offset: int64(cursor)}
+		r, size := utf8.DecodeRune(input[cursor:])
+		if r == utf8.RuneError && size == 1 && opts.strictUTF8 {
+			return "", 0, 0, &SyntaxError{msg: "invalid UTF-8", Offset: int64(cursor), Line: line, Column: column}
 		}
 		if r == '"' {
 			cursor += size
@@ -322,11 +467,11 @@ func parseString(input string, cursor, line, column int) (string, int, int, erro
 			cursor += size
 			column++
 			if cursor >= len(input) {
-				return "", 0, 0, &SyntaxError{msg: fmt.Sprintf("Unexpected end of input in string at %d:%d", line, column), Offset: int64(cursor)}
+				return "", 0, 0, &SyntaxError{msg: "Unexpected end of input in string", Offset: int64(cursor), Line: line, Column: column}
 			}
-			r2, s2 := utf8.DecodeRuneInString(input[cursor:])
-			if r2 == utf8.RuneError && s2 == 1 {
-				return "", 0, 0, &SyntaxError{msg: "invalid UTF-8", Offset: int64(cursor)}
+			r2, s2 := utf8.DecodeRune(input[cursor:])
+			if r2 == utf8.RuneError && s2 == 1 && opts.strictUTF8 {
+				return "", 0, 0, &SyntaxError{msg: "invalid UTF-8", Offset: int64(cursor), Line: line, Column: column}
 			}
 			cursor += s2
 			column++
@@ -346,53 +491,46 @@ func parseString(input string, cursor, line, column int) (string, int, int, erro
 			case 'u':
 				// \uXXXX (optionally surrogate pairs)
 				if cursor+4 > len(input) {
-					return "", 0, 0, &SyntaxError{msg: "invalid unicode escape", Offset: int64(cursor)}
+					return "", 0, 0, &SyntaxError{msg: "invalid unicode escape", Offset: int64(cursor), Line: line, Column: column}
 				}
 				hex := input[cursor : cursor+4]
 				if !isValidHex(hex) {
-					return "", 0, 0, &SyntaxError{msg: "invalid unicode escape", Offset: int64(cursor)}
+					return "", 0, 0, &SyntaxError{msg: "invalid unicode escape", Offset: int64(cursor), Line: line, Column: column}
 				}
-				cp, err := strconv.ParseInt(hex, 16, 32)
+				cp, err := strconv.ParseInt(string(hex), 16, 32)
 				if err != nil {
-					return "", 0, 0, &SyntaxError{msg: "invalid unicode escape", Offset: int64(cursor)}
+					return "", 0, 0, &SyntaxError{msg: "invalid unicode escape", Offset: int64(cursor), Line: line, Column: column}
 				}
 				cursor += 4
 				column += 4
 				runeVal := rune(cp)
 
-				// Handle surrogate pairs. Unpaired surrogates are invalid.
+				// Handle surrogate pairs. An unpaired surrogate becomes
+				// U+FFFD, or is an error with opts.strictUTF8.
 				if utf16.IsSurrogate(runeVal) {
-					// Must be a high surrogate followed by a low surrogate.
-					if runeVal < 0xD800 || runeVal > 0xDBFF {
-						return "", 0, 0, &SyntaxError{msg: "invalid unicode escape", Offset: int64(cursor)}
-					}
-					if !(cursor+6 <= len(input) && input[cursor] == '\\' && input[cursor+1] == 'u') {
-						return "", 0, 0, &SyntaxError{msg: "invalid unicode escape", Offset: int64(cursor)}
-					}
-					hex2 := input[cursor+2 : cursor+6]
-					if !isValidHex(hex2) {
-						return "", 0, 0, &SyntaxError{msg: "invalid unicode escape", Offset: int64(cursor)}
-					}
-					cp2, err2 := strconv.ParseInt(hex2, 16, 32)
-					if err2 != nil {
-						return "", 0, 0, &SyntaxError{msg: "invalid unicode escape", Offset: int64(cursor)}
-					}
-					r2v := rune(cp2)
-					if r2v < 0xDC00 || r2v > 0xDFFF {
-						return "", 0, 0, &SyntaxError{msg: "invalid unicode escape", Offset: int64(cursor)}
+					runeVal = utf8.RuneError
+					if low, ok := lowSurrogate(input, cursor); ok && cp < 0xDC00 {
+						runeVal = utf16.DecodeRune(rune(cp), low)
+						// consume \\uXXXX
+						cursor += 6
+						column += 6
+					} else if opts.strictUTF8 {
+						return "", 0, 0, &SyntaxError{msg: "invalid unicode escape", Offset: int64(cursor), Line: line, Column: column}
 					}
-					runeVal = utf16.DecodeRune(runeVal, r2v)
-					// consume \\uXXXX
-					cursor += 6
-					column += 6
 				}
 				value.WriteRune(runeVal)
 			default:
+				if strict {
+					return "", 0, 0, &SyntaxError{msg: "invalid escape in string", Offset: int64(cursor - s2 - 1), Line: line, Column: column - 2}
+				}
 				// Non-standard escapes are kept as-is
 				value.WriteRune(r2)
 			}
 			continue
 		}
+		if strict && r < 0x20 {
+			return "", 0, 0, &SyntaxError{msg: "control character in string", Offset: int64(cursor), Line: line, Column: column}
+		}
 
 		// Regular rune
 		value.WriteRune(r)
@@ -401,14 +539,121 @@ func parseString(input string, cursor, line, column int) (string, int, int, erro
 	}
 
 	if !closed {
-		return "", 0, 0, &SyntaxError{msg: "unterminated string", Offset: int64(cursor)}
+		return "", 0, 0, &SyntaxError{msg: "unterminated string", Offset: int64(cursor), Line: line, Column: column}
 	}
 	return value.String(), cursor, column, nil
 }
 
+// scanPlainString scans the string contents starting at input[start] up to
+// its closing quote, and reports whether they need no unescaping: they hold
+// no backslash and no invalid UTF-8, nor control characters if strict is
+// set. It returns the offset of the closing quote and the number of runes
+// before it, or else the offset and rune count of the first byte that needs
+// attention.
+func scanPlainString(input []byte, start int, strict bool) (end, runes int, plain bool) {
+	i := start
+	for i < len(input) {
+		c := input[i]
+		switch {
+		case c == '"':
+			return i, runes, true
+		case c == '\\' || strict && c < 0x20:
+			return i, runes, false
+		case c < utf8.RuneSelf:
+			i++
+		default:
+			r, size := utf8.DecodeRune(input[i:])
+			if r == utf8.RuneError && size == 1 {
+				return i, runes, false
+			}
+			i += size
+		}
+		runes++
+	}
+	return i, runes, false
+}
+
+// maxPrefixedDigits caps the digits of a hexadecimal, binary or octal
+// literal, since converting one to decimal takes time quadratic in its
+// length. It admits any 256-bit integer.
+const maxPrefixedDigits = 256
+
+// parseNumberExtended scans a number literal that may also be written in
+// hexadecimal (0xFF), binary (0b1010) or octal (0o17), or use underscores
+// between digits (1_000_000), as accepted by Decoder.AllowExtendedNumbers.
+// It returns the number in the decimal form of parseNumberJSON.
+func parseNumberExtended(input []byte, cursor, column int) (string, int, int, bool) {
+	i := cursor
+	if i < len(input) && input[i] == '-' {
+		i++
+	}
+	if i+1 < len(input) && input[i] == '0' && strings.IndexByte("xXbBoO", input[i+1]) >= 0 {
+		end, digits := i+2, 0
+		for end < len(input) && (isHexDigit(input[end]) || input[end] == '_') {
+			if input[end] != '_' {
+				digits++
+			}
+			end++
+		}
+		if digits > maxPrefixedDigits {
+			return "", cursor, column, false
+		}
+		n, ok := new(big.Int).SetString(string(input[cursor:end]), 0)
+		if !ok {
+			return "", cursor, column, false
+		}
+		return n.String(), end, column + (end - cursor), true
+	}
+
+	// A decimal number; underscores must sit between two digits.
+	end := i
+	for end < len(input) {
+		c := input[end]
+		if c == '_' {
+			if end == i || !isDigit(input[end-1]) || end+1 >= len(input) || !isDigit(input[end+1]) {
+				return "", cursor, column, false
+			}
+		} else if !isDigit(c) && strings.IndexByte(".eE", c) < 0 && !((c == '+' || c == '-') && (input[end-1] == 'e' || input[end-1] == 'E')) {
+			break
+		}
+		end++
+	}
+	text := input[cursor:end]
+	if bytes.IndexByte(text, '_') < 0 {
+		return parseNumberJSON(input, cursor, column)
+	}
+	text = bytes.ReplaceAll(text, []byte("_"), nil)
+	value, n, _, ok := parseNumberJSON(text, 0, 0)
+	if !ok || n != len(text) {
+		return "", cursor, column, false
+	}
+	return value, end, column + (end - cursor), true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// lowSurrogate reports whether input holds a \uXXXX escape of a low
+// surrogate at cursor, and returns the surrogate.
+func lowSurrogate(input []byte, cursor int) (rune, bool) {
+	if cursor+6 > len(input) || input[cursor] != '\\' || input[cursor+1] != 'u' || !isValidHex(input[cursor+2:cursor+6]) {
+		return 0, false
+	}
+	cp, err := strconv.ParseUint(string(input[cursor+2:cursor+6]), 16, 32)
+	if err != nil || cp < 0xDC00 || cp > 0xDFFF {
+		return 0, false
+	}
+	return rune(cp), true
+}
+
 // parseNumberJSON scans a JSON-compatible number literal.
 // Returns ok=false if the prefix does not match the JSON number grammar.
-func parseNumberJSON(input string, cursor, column int) (string, int, int, bool) {
+func parseNumberJSON(input []byte, cursor, column int) (string, int, int, bool) {
 	start := cursor
 	i := cursor
 
@@ -458,19 +703,19 @@ func parseNumberJSON(input string, cursor, column int) (string, int, int, bool)
 
 	// Column counts ASCII runes in the number.
 	newColumn := column + (i - start)
-	return input[start:i], i, newColumn, true
+	return string(input[start:i]), i, newColumn, true
 }
 
 // parseIdentifierUTF8 parses an identifier starting at the given cursor position.
 // Identifiers support Unicode letters/digits and underscore.
-func parseIdentifierUTF8(input string, cursor, column int) (string, int, int) {
+func parseIdentifierUTF8(input []byte, cursor, column int) ([]byte, int, int) {
 	start := cursor
 	i := cursor
 	col := column
 	first := true
 
 	for i < len(input) {
-		r, size := utf8.DecodeRuneInString(input[i:])
+		r, size := utf8.DecodeRune(input[i:])
 		if r == utf8.RuneError && size == 1 {
 			break
 		}
@@ -491,28 +736,36 @@ func parseIdentifierUTF8(input string, cursor, column int) (string, int, int) {
 	return input[start:i], i, col
 }
 
-// getKeywordType returns the appropriate token type for a keyword, or TokenIdentifier for non-keywords.
-func getKeywordType(value string) TokenType {
-	switch value {
+// identifierToken returns the token type and value for an identifier,
+// reusing constant strings for keywords.
+func identifierToken(raw []byte) (TokenType, string) {
+	switch string(raw) {
 	case "class":
-		return TokenClass
+		return TokenClass, "class"
 	case "true":
-		return TokenTrue
+		return TokenTrue, "true"
 	case "false":
-		return TokenFalse
+		return TokenFalse, "false"
 	case "null":
-		return TokenNull
+		return TokenNull, "null"
 	default:
-		return TokenIdentifier
+		return TokenIdentifier, string(raw)
 	}
 }
 
+// isNonFinite reports whether s is one of the NaN and Infinity literals
+// accepted by Decoder.AllowNonFinite.
+func isNonFinite(s string) bool {
+	return s == "NaN" || s == "Infinity" || s == "-Infinity"
+}
+
 // isValidHex checks if a string contains exactly 4 hexadecimal characters.
-func isValidHex(s string) bool {
+func isValidHex[T ~string | ~[]byte](s T) bool {
 	if len(s) != 4 {
 		return false
 	}
-	for _, char := range s {
+	for i := 0; i < len(s); i++ {
+		char := s[i]
 		if !((char >= '0' && char <= '9') || (char >= 'a' && char <= 'f') || (char >= 'A' && char <= 'F')) {
 			return false
 		}
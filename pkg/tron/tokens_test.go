@@ -0,0 +1,77 @@
+package tron
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	input := "class A: x # point\n\nA(1, \"é\\n\")"
+	type tok struct {
+		typ          TokenType
+		value        string
+		line, column int
+		offset       int
+	}
+	want := []tok{
+		{TokenClass, "class", 1, 1, 0},
+		{TokenIdentifier, "A", 1, 7, 6},
+		{TokenColon, ":", 1, 8, 7},
+		{TokenIdentifier, "x", 1, 10, 9},
+		{TokenNewline, "\n", 1, 19, 18},
+		{TokenNewline, "\n", 2, 1, 19},
+		{TokenIdentifier, "A", 3, 1, 20},
+		{TokenLParen, "(", 3, 2, 21},
+		{TokenNumber, "1", 3, 3, 22},
+		{TokenComma, ",", 3, 4, 23},
+		{TokenString, "é\n", 3, 6, 25},
+		{TokenRParen, ")", 3, 11, 31},
+		{TokenEOF, "", 3, 12, 32},
+	}
+	var got []tok
+	for token, err := range Tokens([]byte(input)) {
+		if err != nil {
+			t.Fatalf("Tokens: %v", err)
+		}
+		got = append(got, tok{token.Type, token.Value, token.Line, token.Column, token.Offset})
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Tokens = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("token %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTokensError(t *testing.T) {
+	var got []TokenType
+	var err error
+	for tok, e := range Tokens([]byte("[1, @]")) {
+		if e != nil {
+			err = e
+			break
+		}
+		got = append(got, tok.Type)
+	}
+	if len(got) != 3 || got[0] != TokenLBracket || got[1] != TokenNumber || got[2] != TokenComma {
+		t.Errorf("tokens before the error = %v, want [ 1 ,", got)
+	}
+	var serr *SyntaxError
+	if !errors.As(err, &serr) || serr.Offset != 4 || serr.Column != 5 {
+		t.Fatalf("error = %#v, want a SyntaxError at offset 4", err)
+	}
+
+	// Stopping early ends the iteration.
+	n := 0
+	for range Tokens([]byte("[1,2,3]")) {
+		n++
+		if n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Fatalf("iterated %d times after break", n)
+	}
+}
@@ -0,0 +1,58 @@
+package tron
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAllowTrailingCommas(t *testing.T) {
+	type item struct {
+		A int
+		B []int
+	}
+	input := "class X: A,B\n\n[\n  X(1, [2, 3,],),\n  {\"A\": 4, \"B\": [],},\n]\n"
+	want := []item{{A: 1, B: []int{2, 3}}, {A: 4, B: []int{}}}
+
+	var got []item
+	if err := Unmarshal([]byte(input), &got); err == nil {
+		t.Fatal("Unmarshal accepted trailing commas by default")
+	}
+
+	dec := NewDecoder(strings.NewReader(input))
+	dec.AllowTrailingCommas()
+	if err := dec.Decode(&got); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	var tree interface{}
+	dec = NewDecoder(strings.NewReader(input))
+	dec.AllowTrailingCommas()
+	if err := dec.Decode(&tree); err != nil {
+		t.Fatalf("Decode into interface{}: %v", err)
+	}
+
+	var streamed []item
+	dec = NewDecoder(strings.NewReader(input))
+	dec.AllowTrailingCommas()
+	for v, err := range Values[item](dec) {
+		if err != nil {
+			t.Fatalf("Values: %v", err)
+		}
+		streamed = append(streamed, v)
+	}
+	if !reflect.DeepEqual(streamed, want) {
+		t.Fatalf("Values: got %+v, want %+v", streamed, want)
+	}
+
+	for _, bad := range []string{"[,]", "[1,,]", "{,}", `{"a":1,,}`} {
+		dec := NewDecoder(strings.NewReader(bad))
+		dec.AllowTrailingCommas()
+		if err := dec.Decode(&tree); err == nil {
+			t.Errorf("%s: got %v, want error", bad, tree)
+		}
+	}
+}
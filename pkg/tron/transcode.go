@@ -0,0 +1,518 @@
+package tron
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// FromJSON converts a JSON document to TRON without decoding it into Go
+// values first. Objects that share the same set of two or more keys and occur
+// at least twice become class instantiations, exactly as structs do in
+// Marshal. Unlike a round trip through Unmarshal and Marshal, object members
+// keep their order and numbers keep their original text, though numbers
+// beyond the float64 range are rejected as they are in TRON. If an object
+// repeats a key, the last value wins, as in encoding/json.
+func FromJSON(jsonData []byte) ([]byte, error) {
+	e := newEncoder(encodeOptions{})
+	defer e.release()
+	return e.fromJSON(jsonData)
+}
+
+// fromJSON converts the JSON document jsonData to TRON as FromJSON does,
+// with the options of e.
+func (e *encoder) fromJSON(jsonData []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(jsonData))
+	dec.UseNumber()
+	if err := e.serializeJSON(dec, 0); err != nil {
+		return nil, err
+	}
+	if err := expectJSONEnd(dec); err != nil {
+		return nil, err
+	}
+	return e.finish(nil), nil
+}
+
+// readJSONToken returns the next token from dec, treating the end of the
+// input as an error.
+func readJSONToken(dec *json.Decoder) (json.Token, error) {
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return tok, err
+}
+
+// expectJSONEnd reports an error unless dec has reached the end of its input.
+func expectJSONEnd(dec *json.Decoder) error {
+	_, err := dec.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err == nil {
+		err = errors.New("tron: invalid JSON: data after top-level value")
+	}
+	return err
+}
+
+// serializeJSON reads one JSON value from dec and appends its TRON encoding
+// to e.buf. Objects are deferred like structs so that render can frame them
+// as class instantiations.
+func (e *encoder) serializeJSON(dec *json.Decoder, depth int) error {
+	tok, err := readJSONToken(dec)
+	if err != nil {
+		return err
+	}
+	return e.serializeJSONToken(dec, tok, depth)
+}
+
+// serializeJSONToken is like serializeJSON for a value whose first token,
+// tok, has already been read.
+func (e *encoder) serializeJSONToken(dec *json.Decoder, tok json.Token, depth int) error {
+	if depth > maxWalkDepth {
+		return fmt.Errorf("%w while encoding", ErrTooDeep)
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '[' {
+			e.buf = append(e.buf, '[')
+			for i := 0; dec.More(); i++ {
+				if i > 0 {
+					e.buf = append(e.buf, ',')
+				}
+				if err := e.serializeJSON(dec, depth+1); err != nil {
+					return err
+				}
+			}
+			e.buf = append(e.buf, ']')
+		} else {
+			if err := e.serializeJSONObject(dec, depth); err != nil {
+				return err
+			}
+		}
+		// Consume the closing delimiter
+		_, err := dec.Token()
+		return err
+	case string:
+		e.buf = appendQuoted(e.buf, t)
+	case json.Number:
+		// TRON only accepts numbers that fit in a float64.
+		if _, err := strconv.ParseFloat(string(t), 64); err != nil {
+			return fmt.Errorf("tron: number %s out of range", t)
+		}
+		e.buf = append(e.buf, t...)
+	case bool:
+		e.buf = strconv.AppendBool(e.buf, t)
+	case nil:
+		e.buf = append(e.buf, "null"...)
+	}
+	return nil
+}
+
+// maxScannedKeys is the number of keys up to which serializeJSONObject looks
+// for repeated keys by scanning them, rather than through a map.
+const maxScannedKeys = 16
+
+// serializeJSONObject appends the members of the object whose '{' dec has
+// just returned, recording them as a deferred object.
+func (e *encoder) serializeJSONObject(dec *json.Decoder, depth int) error {
+	idx := len(e.objects)
+	order := len(e.schemaOrder)
+	e.objects = append(e.objects, deferredObject{start: len(e.buf)})
+
+	var keys []string
+	var spans [][2]int
+	var nested [][2]int      // range of e.objects holding the objects within each value
+	var index map[string]int // index of each key in keys, once there are many
+	for dec.More() {
+		tok, err := readJSONToken(dec)
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		start, objects := len(e.buf), len(e.objects)
+		if err := e.serializeJSON(dec, depth+1); err != nil {
+			return err
+		}
+		span := [2]int{start, len(e.buf)}
+		inner := [2]int{objects, len(e.objects)}
+
+		// The last of several values for the same key wins; the earlier
+		// ones stay in buf but are never rendered, so the objects within
+		// them no longer count toward classes.
+		i, ok := index[key]
+		if index == nil {
+			i = indexOf(keys, key)
+			ok = i >= 0
+		}
+		if ok {
+			for _, obj := range e.objects[nested[i][0]:nested[i][1]] {
+				obj.schema.count--
+			}
+			spans[i], nested[i] = span, inner
+			continue
+		}
+		keys = append(keys, key)
+		spans = append(spans, span)
+		nested = append(nested, inner)
+		if index != nil {
+			index[key] = len(keys) - 1
+		} else if len(keys) == maxScannedKeys {
+			index = make(map[string]int, 2*len(keys))
+			for i, k := range keys {
+				index[k] = i
+			}
+		}
+	}
+
+	if len(keys) == 0 {
+		e.objects = e.objects[:idx]
+		e.buf = append(e.buf, "{}"...)
+		return nil
+	}
+
+	sc := e.schemaFor(keys, nil)
+	if n := len(e.schemaOrder); n > order && e.schemaOrder[n-1] == sc {
+		// A new schema is first seen where the object starts, before the
+		// schemas of its members, matching the order Marshal uses.
+		copy(e.schemaOrder[order+1:], e.schemaOrder[order:n-1])
+		e.schemaOrder[order] = sc
+	}
+
+	obj := &e.objects[idx]
+	obj.schema = sc
+	obj.keys = keys
+	obj.values = len(e.spans)
+	obj.end = len(e.buf)
+	obj.next = len(e.objects)
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+// ToJSON converts a TRON document to JSON, expanding class instantiations
+// into plain objects so that consumers that only understand JSON can read it.
+// Object members keep their order, numbers keep their original text, and an
+// implicit root object becomes a JSON object. An empty document converts to
+// null, as it unmarshals to nil.
+func ToJSON(tronData []byte) ([]byte, error) {
+	p, err := newDocumentParser(tronData, decodeOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return p.appendJSONDocument(nil)
+}
+
+// appendJSONDocument appends the JSON encoding of the document p is
+// positioned at to out.
+func (p *parser) appendJSONDocument(out []byte) ([]byte, error) {
+	if err := p.parseHeader(); err != nil {
+		return nil, err
+	}
+
+	// Skip blank lines between header and data
+	p.skipNewlines()
+	if p.current().Type == TokenEOF {
+		return append(out, "null"...), nil
+	}
+
+	var err error
+	if (p.current().Type == TokenIdentifier || p.current().Type == TokenString) && p.peek(1).Type == TokenColon {
+		// Implicit root object: key: value lines.
+		out, err = p.appendJSONMembers(out, 2, func(member func(key string) error) error {
+			return p.parseImplicitObjectWith(1, member)
+		})
+	} else {
+		out, err = p.appendJSON(out, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipNewlines()
+	if p.current().Type != TokenEOF {
+		return nil, p.syntaxError("unexpected trailing tokens")
+	}
+	return out, nil
+}
+
+// appendJSON appends the JSON encoding of the value at the current position
+// to out. depth follows the same accounting as parseValue.
+func (p *parser) appendJSON(out []byte, depth int) ([]byte, error) {
+	if depth > p.limits().MaxDepth {
+		return nil, p.categoryError(ErrTooDeep, "maximum parse depth exceeded")
+	}
+
+	switch p.current().Type {
+	case TokenLBracket:
+		out = append(out, '[')
+		n := 0
+		err := p.parseArrayWith(func() error {
+			if n > 0 {
+				out = append(out, ',')
+			}
+			n++
+			var err error
+			out, err = p.appendJSON(out, depth+2)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return append(out, ']'), nil
+	case TokenLBrace:
+		return p.appendJSONMembers(out, depth+2, func(member func(key string) error) error {
+			return p.parseObjectWith(depth+1, member)
+		})
+	case TokenIdentifier:
+		return p.appendJSONMembers(out, depth+2, func(member func(key string) error) error {
+			return p.parseClassInstantiationWith(depth+1, member)
+		})
+	}
+
+	v, err := p.parseValue(depth)
+	if err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case string:
+		return appendQuoted(out, v), nil
+	case numberLiteral:
+		return append(out, v...), nil
+	case bool:
+		return strconv.AppendBool(out, v), nil
+	case nil:
+		return append(out, "null"...), nil
+	}
+	data, err := marshalParsed(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, data...), nil
+}
+
+// appendJSONMembers appends the members produced by parse as a JSON object.
+// parse is one of the parser's object, class instantiation or implicit object
+// walkers; depth is the depth of the member values.
+func (p *parser) appendJSONMembers(out []byte, depth int, parse func(member func(key string) error) error) ([]byte, error) {
+	out = append(out, '{')
+	n := 0
+	err := parse(func(key string) error {
+		if n > 0 {
+			out = append(out, ',')
+		}
+		n++
+		out = appendQuoted(out, key)
+		out = append(out, ':')
+		var err error
+		out, err = p.appendJSON(out, depth)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '}'), nil
+}
+
+// jsonClassWindow is how many elements of a top-level JSON array
+// NewJSONToTRONReader examines before it commits to a class header.
+const jsonClassWindow = 64
+
+// NewJSONToTRONReader returns a reader that yields the TRON encoding of the
+// JSON document read from r, converting it on the fly. The output is what
+// FromJSON produces, except that for a top-level array with more than 64
+// elements classes are inferred from the first 64: later elements are
+// converted and passed on one at a time, as instantiations of those classes
+// where their shape matches and as objects otherwise. Other documents are
+// converted as a whole once they have been read.
+//
+// Closing the returned reader before the end of the output stops the
+// conversion.
+func NewJSONToTRONReader(r io.Reader) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(transcodeJSONToTRON(r, pw))
+	}()
+	return pr
+}
+
+// transcodeJSONToTRON converts the JSON document read from r to TRON written
+// to w.
+func transcodeJSONToTRON(r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	e := newEncoder(encodeOptions{})
+	defer e.release()
+
+	tok, err := readJSONToken(dec)
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('[') {
+		if err := e.serializeJSONToken(dec, tok, 0); err != nil {
+			return err
+		}
+		if err := expectJSONEnd(dec); err != nil {
+			return err
+		}
+		_, err := w.Write(e.finish(e.out[:0]))
+		return err
+	}
+
+	// Choose classes from the first elements, then stream the rest.
+	e.buf = append(e.buf, '[')
+	n := 0
+	for ; n < jsonClassWindow && dec.More(); n++ {
+		if n > 0 {
+			e.buf = append(e.buf, ',')
+		}
+		if err := e.serializeJSON(dec, 1); err != nil {
+			return err
+		}
+	}
+	out := e.finish(e.out[:0])
+
+	for dec.More() {
+		if len(out) >= seqFlushSize {
+			if _, err := w.Write(out); err != nil {
+				return err
+			}
+			out = out[:0]
+		}
+		out = append(out, ',')
+		e.resetElement()
+		if err := e.serializeJSON(dec, 1); err != nil {
+			return err
+		}
+		out = e.render(out, 0, len(e.buf), 0, len(e.objects))
+	}
+	// Consume the closing bracket
+	if _, err := readJSONToken(dec); err != nil {
+		return err
+	}
+	if err := expectJSONEnd(dec); err != nil {
+		return err
+	}
+	out = append(out, ']')
+	e.out = out
+	_, err = w.Write(out)
+	return err
+}
+
+// NewTRONToJSONWriter returns a writer that converts the TRON document
+// written to it into JSON written to w, as ToJSON does. For a document whose
+// data is an array, each element is converted and written as soon as it is
+// complete; other documents are converted when the writer is closed.
+//
+// Close must be called to flush the output. It reports any syntax error in
+// the document; once an error has occurred, Write returns it as well.
+func NewTRONToJSONWriter(w io.Writer) io.WriteCloser {
+	pr, pw := io.Pipe()
+	t := &tronToJSONWriter{pw: pw, done: make(chan struct{})}
+	go func() {
+		t.err = transcodeTRONToJSON(pr, w)
+		pr.CloseWithError(t.err)
+		close(t.done)
+	}()
+	return t
+}
+
+type tronToJSONWriter struct {
+	pw   *io.PipeWriter
+	done chan struct{}
+	err  error // set before done is closed
+}
+
+func (t *tronToJSONWriter) Write(p []byte) (int, error) {
+	return t.pw.Write(p)
+}
+
+func (t *tronToJSONWriter) Close() error {
+	t.pw.Close()
+	<-t.done
+	return t.err
+}
+
+// transcodeTRONToJSON converts the TRON document read from r to JSON written
+// to w.
+func transcodeTRONToJSON(r io.Reader, w io.Writer) error {
+	s := &streamScanner{r: bufio.NewReader(r)}
+	classes, err := s.readHeader()
+	if err == io.EOF {
+		_, err = io.WriteString(w, "null")
+		return err
+	}
+	if err == errNotArray {
+		// Convert the whole document, starting with what has been read.
+		data, err := io.ReadAll(io.MultiReader(bytes.NewReader(s.buf), s.r))
+		if err != nil {
+			return err
+		}
+		out, err := ToJSON(data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	out := []byte{'['}
+	for n := 0; ; n++ {
+		elem, more, err := s.nextElement()
+		if err != nil {
+			return err
+		}
+		if elem == nil {
+			break
+		}
+		if n > 0 {
+			out = append(out, ',')
+		}
+		if out, err = appendJSONElement(out, elem, classes); err != nil {
+			return err
+		}
+		if !more {
+			if err := s.expectEnd(); err != nil {
+				return err
+			}
+			break
+		}
+		if len(out) >= seqFlushSize {
+			if _, err := w.Write(out); err != nil {
+				return err
+			}
+			out = out[:0]
+		}
+	}
+	_, err = w.Write(append(out, ']'))
+	return err
+}
+
+// appendJSONElement appends the JSON encoding of the source of one array
+// element to out, resolving class instantiations against classes.
+func appendJSONElement(out, elem []byte, classes map[string]*classDef) ([]byte, error) {
+	p, err := newDocumentParser(elem, decodeOptions{})
+	if err != nil {
+		return nil, err
+	}
+	p.classes = classes
+
+	p.skipNewlines()
+	// Elements of the root array sit at depth 2, as in parseValue.
+	if out, err = p.appendJSON(out, 2); err != nil {
+		return nil, err
+	}
+	p.skipNewlines()
+	if p.current().Type != TokenEOF {
+		return nil, p.syntaxError("expected ',' or ']' after array element")
+	}
+	return out, nil
+}
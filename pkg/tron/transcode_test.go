@@ -0,0 +1,227 @@
+package tron
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFromJSON(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`null`, `null`},
+		{` "a<b" `, `"a\u003cb"`},
+		{`[1.50, -0, 1e300, true, false, null]`, `[1.50,-0,1e300,true,false,null]`},
+		{`{}`, `{}`},
+		{`{"a":1}`, `{"a":1}`},
+		{`{"b":1,"a":{"x":[]}}`, `{"b":1,"a":{"x":[]}}`},
+		{
+			`[{"name":"a","age":1},{"age":2,"name":"b"},{"name":"c"}]`,
+			"class A: name,age\n\n" + `[A("a",1),A("b",2),{"name":"c"}]`,
+		},
+		{
+			`{"rows":[{"x":1,"y":{"p":1,"q":2}},{"x":2,"y":{"q":4,"p":3}}],"n":2}`,
+			"class A: x,y\nclass B: p,q\n\n" + `{"rows":[A(1,B(1,2)),A(2,B(3,4))],"n":2}`,
+		},
+		{`{"a":1,"b":2,"a":3}`, `{"a":3,"b":2}`},
+		{`{"a":[{"x":1,"y":2},{"x":3,"y":4}],"a":{"x":5,"y":6}}`, `{"a":{"x":5,"y":6}}`},
+		{
+			`{"k0":0,"k1":1,"k2":2,"k3":3,"k4":4,"k5":5,"k6":6,"k7":7,"k8":8,"k9":9,"k10":10,` +
+				`"k11":11,"k12":12,"k13":13,"k14":14,"k15":15,"k16":16,"k3":33,"k16":[{"x":1,"y":2}],"k16":{"x":3,"y":4}}`,
+			`{"k0":0,"k1":1,"k2":2,"k3":33,"k4":4,"k5":5,"k6":6,"k7":7,"k8":8,"k9":9,"k10":10,` +
+				`"k11":11,"k12":12,"k13":13,"k14":14,"k15":15,"k16":{"x":3,"y":4}}`,
+		},
+		{`{"a b":1,"c":2}` + "\n", `{"a b":1,"c":2}`},
+	}
+	for _, tt := range tests {
+		got, err := FromJSON([]byte(tt.in))
+		if err != nil {
+			t.Errorf("FromJSON(%s): %v", tt.in, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("FromJSON(%s) =\n%s\nwant\n%s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFromJSONRoundTrip(t *testing.T) {
+	in := `{"users":[{"id":1,"name":"Ann","tags":["a"]},{"id":2,"name":"Bo","tags":[]},{"id":3,"name":"Cy","tags":null}],"total":3}`
+	out, err := FromJSON([]byte(in))
+	if err != nil {
+		t.Fatalf("FromJSON: %v", err)
+	}
+	var fromTRON, fromJSON interface{}
+	if err := Unmarshal(out, &fromTRON); err != nil {
+		t.Fatalf("Unmarshal(%s): %v", out, err)
+	}
+	if err := json.Unmarshal([]byte(in), &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromTRON, fromJSON) {
+		t.Fatalf("round trip differs:\n%#v\n%#v", fromTRON, fromJSON)
+	}
+}
+
+func TestFromJSONErrors(t *testing.T) {
+	for _, in := range []string{``, ` `, `[1,`, `{"a"}`, `[1] [2]`, `{"a":1}x`, `tru`, `[1e400]`} {
+		if out, err := FromJSON([]byte(in)); err == nil {
+			t.Errorf("FromJSON(%q) = %q, want error", in, out)
+		}
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{``, `null`},
+		{`null`, `null`},
+		{`[1.50, -0, 1e300, true, false, "a\nb"]`, `[1.50,-0,1e300,true,false,"a\nb"]`},
+		{"class A: name,age\n\n[A(\"a\",1), A(\"b\",\n2), {\"name\":\"c\"}]", `[{"name":"a","age":1},{"name":"b","age":2},{"name":"c"}]`},
+		{"class A: x,y\nclass B: p,q\n\nrows: [A(1,B(1,2))]\nn: 2\n", `{"rows":[{"x":1,"y":{"p":1,"q":2}}],"n":2}`},
+		{`{"z":1,"a":{}}`, `{"z":1,"a":{}}`},
+		{"# comment\n[] # trailing", `[]`},
+	}
+	for _, tt := range tests {
+		got, err := ToJSON([]byte(tt.in))
+		if err != nil {
+			t.Errorf("ToJSON(%q): %v", tt.in, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("ToJSON(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+		if !json.Valid(got) {
+			t.Errorf("ToJSON(%q) produced invalid JSON %s", tt.in, got)
+		}
+	}
+}
+
+func TestToJSONInvertsFromJSON(t *testing.T) {
+	in := `{"users":[{"id":1,"name":"Ann","tags":["a"]},{"id":2,"name":"Bo","tags":[]}],"meta":{"next":null,"total":2.0}}`
+	out, err := FromJSON([]byte(in))
+	if err != nil {
+		t.Fatalf("FromJSON: %v", err)
+	}
+	back, err := ToJSON(out)
+	if err != nil {
+		t.Fatalf("ToJSON: %v", err)
+	}
+	if string(back) != in {
+		t.Fatalf("ToJSON(FromJSON(x)) =\n%s\nwant\n%s", back, in)
+	}
+}
+
+func TestToJSONErrors(t *testing.T) {
+	for _, in := range []string{`[1,`, `A(1)`, "class A: x,y\n\nA(1)", `[1] 2`, "\xff"} {
+		var syntaxErr *SyntaxError
+		if _, err := ToJSON([]byte(in)); !errors.As(err, &syntaxErr) {
+			t.Errorf("ToJSON(%q): expected *SyntaxError, got %v", in, err)
+		}
+	}
+}
+
+func TestJSONToTRONReaderMatchesFromJSON(t *testing.T) {
+	for _, in := range []string{
+		`{"a":[{"x":1,"y":2},{"x":3,"y":4}]}`,
+		`[{"x":1,"y":2},{"x":3,"y":4},5]`,
+		`[]`,
+		` "s" `,
+	} {
+		want, err := FromJSON([]byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := NewJSONToTRONReader(strings.NewReader(in))
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("reading %s: %v", in, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("NewJSONToTRONReader(%s) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestJSONToTRONReaderStreamsLongArrays(t *testing.T) {
+	var in bytes.Buffer
+	in.WriteString("[")
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			in.WriteString(",")
+		}
+		if i == 500 {
+			in.WriteString(`{"id":500}`)
+			continue
+		}
+		fmt.Fprintf(&in, `{"id":%d,"name":"n%d"}`, i, i)
+	}
+	in.WriteString("]")
+
+	got, err := io.ReadAll(NewJSONToTRONReader(&in))
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !strings.HasPrefix(string(got), "class A: id,name\n\n[A(0,\"n0\"),") || !strings.Contains(string(got), `,{"id":500},A(501,"n501"),`) {
+		t.Fatalf("unexpected output prefix %.80q", got)
+	}
+	var rows []map[string]interface{}
+	if err := Unmarshal(got, &rows); err != nil || len(rows) != 1000 || rows[999]["name"] != "n999" {
+		t.Fatalf("Unmarshal: %d rows, %v", len(rows), err)
+	}
+}
+
+func TestJSONToTRONReaderErrors(t *testing.T) {
+	for _, in := range []string{``, `[1,`, `[1] 2`, `{"a":}`} {
+		if out, err := io.ReadAll(NewJSONToTRONReader(strings.NewReader(in))); err == nil {
+			t.Errorf("NewJSONToTRONReader(%q) = %q, want error", in, out)
+		}
+	}
+}
+
+func TestTRONToJSONWriter(t *testing.T) {
+	for _, in := range []string{
+		"class A: name,age\n\n[A(\"a\",1), # one\n A(\"b, [c]\",2), {\"name\":\"c\"}]\n",
+		"class A: x,y\n\nrows: [A(1,2)]\n",
+		`{"a": [1, 2]}`,
+		"# header only comment\n[]",
+		"",
+	} {
+		want, err := ToJSON([]byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		w := NewTRONToJSONWriter(&buf)
+		// Write in small pieces to exercise the streaming path.
+		for i := 0; i < len(in); i += 3 {
+			if _, err := w.Write([]byte(in[i:min(i+3, len(in))])); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close(%q): %v", in, err)
+		}
+		if buf.String() != string(want) {
+			t.Errorf("NewTRONToJSONWriter(%q) = %s, want %s", in, buf.String(), want)
+		}
+	}
+}
+
+func TestTRONToJSONWriterErrors(t *testing.T) {
+	for _, in := range []string{`[1,`, `[B(1)]`, `[1] 2`, `a: `} {
+		w := NewTRONToJSONWriter(io.Discard)
+		w.Write([]byte(in))
+		var syntaxErr *SyntaxError
+		if err := w.Close(); !errors.As(err, &syntaxErr) {
+			t.Errorf("%q: expected *SyntaxError, got %v", in, err)
+		}
+	}
+}
@@ -22,7 +22,13 @@
 package tron
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"reflect"
+	"strconv"
+	"strings"
 )
 
 // Marshal returns the TRON encoding of v.
@@ -30,37 +36,67 @@ import (
 // Marshal traverses the value v recursively. If an encountered value implements
 // the Marshaler interface and is not a nil pointer, Marshal calls its MarshalTRON
 // method to produce TRON. If no MarshalTRON method is present but the value
+// implements json.Marshaler, Marshal calls its MarshalJSON method and embeds
+// the result, since valid JSON is also valid TRON. Otherwise, if the value
 // implements encoding.TextMarshaler, Marshal calls its MarshalText method and
-// encodes the result as a TRON string.
+// encodes the result as a TRON string. Failing that, if the value implements
+// encoding.BinaryMarshaler, Marshal calls its MarshalBinary method and encodes
+// the result as a base64 TRON string.
 //
 // Otherwise, Marshal uses the following type-dependent default encodings:
 //
 // Boolean values encode as TRON booleans.
 //
 // Floating point, integer, and Number values encode as TRON numbers.
+// NaN and infinite values cause an UnsupportedValueError, unless an
+// Encoder is set to write them otherwise (see Encoder.SetNonFinite).
 //
 // String values encode as TRON strings coerced to valid UTF-8,
-// replacing invalid bytes with the Unicode replacement rune.
+// replacing invalid bytes with the Unicode replacement rune
+// (see Encoder.SetStrictUTF8 to report them as errors instead).
 //
 // Array and slice values encode as TRON arrays, except that
-// []byte encodes as a base64-encoded string, and a nil slice
-// encodes as the null TRON value.
+// []byte encodes as a base64-encoded string (see Encoder.SetRawBytes),
+// and a nil slice encodes as the null TRON value.
 //
 // Struct values encode as TRON objects. Each exported struct field
 // becomes a member of the object, using the field name as the object
 // key, unless the field is omitted for one of the reasons given below.
 //
 // The encoding of each struct field can be customized by the format string
-// stored under the "json" key in the struct field's tag. The format string
-// gives the name of the field, possibly followed by a comma-separated
-// list of options. The name may be empty in order to specify options
-// without overriding the default field name.
+// stored under the "tron" key in the struct field's tag. If the field has no
+// "tron" key, the format string stored under the "json" key is used instead,
+// so existing structs work unchanged. The format string gives the name of the
+// field, possibly followed by a comma-separated list of options. The name may
+// be empty in order to specify options without overriding the default field
+// name.
 //
 // The "omitempty" option specifies that the field should be omitted
 // from the encoding if the field has an empty value, defined as
 // false, 0, a nil pointer, a nil interface value, and any empty array,
 // slice, map, or string.
 //
+// The "omitzero" option specifies that the field should be omitted
+// from the encoding if the field has a zero value. If the field type
+// has an IsZero() bool method, that method is used to determine whether
+// the value is zero; otherwise the value is zero if it is the zero value
+// for its type. Unlike omitempty, omitzero can omit struct-typed fields
+// such as a zero time.Time.
+//
+// The "inline" option, valid on a map field with a string key type, specifies
+// that the map's entries should be emitted as keys of the enclosing object (or
+// arguments of its class instantiation) instead of as a nested object. Declared
+// fields take precedence over map entries with the same key. The "remain"
+// option used by Unmarshal to collect unknown keys implies "inline", so such
+// structs round-trip.
+//
+// The "default=value" option, valid on boolean, number and string fields,
+// declares value as the field's default in the class definition, as in
+// "class A: x,y,z=0", and lets instantiations leave out trailing arguments
+// equal to their default, which Unmarshal supplies again. The value is
+// written as TRON, except for string fields, where it is the string itself;
+// it cannot contain a comma. An invalid default makes Marshal fail.
+//
 // As a special case, if the field tag is "-", the field is always omitted.
 // Note that a field with name "-" can still be generated using the tag "-,".
 //
@@ -72,6 +108,9 @@ import (
 //   - encoding.TextMarshalers are marshaled
 //   - integer keys are converted to strings
 //
+// time.Time values encode as TRON strings in RFC 3339 format with
+// sub-second precision (see Encoder.SetTimeLayout to change the layout).
+//
 // Pointer values encode as the value pointed to.
 // A nil pointer encodes as the null TRON value.
 //
@@ -86,14 +125,41 @@ import (
 // handle them. Passing cyclic structures to Marshal will result in
 // an error.
 func Marshal(v interface{}) ([]byte, error) {
-	return marshal(v, "", "")
+	return marshal(v, encodeOptions{})
 }
 
 // MarshalIndent is like Marshal but applies Indent to format the output.
 // Each TRON element in the output will begin on a new line beginning with prefix
 // followed by one or more copies of indent according to the indentation nesting.
+// The arguments of a class instantiation stay together on one line. With an
+// empty prefix and indent the output is the same as from Marshal; before
+// Indent was added, MarshalIndent always returned that output.
 func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
-	return marshal(v, prefix, indent)
+	return marshal(v, encodeOptions{prefix: prefix, indent: indent})
+}
+
+// Append is like Marshal but appends the TRON encoding of v to dst and
+// returns the extended buffer, in the style of strconv.AppendInt. If an
+// error occurs, dst is returned unchanged.
+func Append(dst []byte, v interface{}) ([]byte, error) {
+	return appendMarshal(dst, v, encodeOptions{})
+}
+
+// AppendIndent is like MarshalIndent but appends the encoding to dst.
+func AppendIndent(dst []byte, v interface{}, prefix, indent string) ([]byte, error) {
+	return appendMarshal(dst, v, encodeOptions{prefix: prefix, indent: indent})
+}
+
+// MarshalTo is like Marshal but writes the TRON encoding of v to w. The
+// output is written in pieces as it is generated rather than built up as one
+// slice, which suits large responses written straight to a network
+// connection. Only the class header waits until v has been walked, since the
+// classes are not known before then.
+//
+// If an error occurs while encoding v, nothing has been written to w.
+// Otherwise MarshalTo returns the first error from w, if any.
+func MarshalTo(w io.Writer, v interface{}) error {
+	return marshalTo(w, v, encodeOptions{})
 }
 
 // Unmarshal parses the TRON-encoded data and stores the result
@@ -112,13 +178,29 @@ func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
 //
 // To unmarshal TRON into a value implementing the Unmarshaler interface,
 // Unmarshal calls that value's UnmarshalTRON method, including
-// when the input is a TRON null.
+// when the input is a TRON null. The value is passed with class
+// instantiations expanded into plain objects. If the value implements
+// json.Unmarshaler instead, Unmarshal calls its UnmarshalJSON method
+// with the JSON encoding of the TRON value. A TRON string unmarshals into an
+// encoding.TextUnmarshaler via UnmarshalText, or into an
+// encoding.BinaryUnmarshaler by decoding it as base64 and calling
+// UnmarshalBinary.
 //
 // To unmarshal TRON into a struct, Unmarshal matches incoming object
 // keys to the keys used by Marshal (either the struct field name or its tag),
 // preferring an exact match but also accepting a case-insensitive match. By
 // default, object keys which don't have a corresponding struct field are
-// ignored (see Decoder.DisallowUnknownFields for an alternative).
+// ignored (see Decoder.DisallowUnknownFields for an alternative). If the struct
+// has a map field with a string key type tagged with the "remain" option
+// (for example `tron:",remain"`), unmatched keys are stored in that map instead.
+//
+// To unmarshal a TRON string into a []byte, Unmarshal decodes it as
+// standard base64 (see Decoder.UseRawBytes for an alternative).
+//
+// To unmarshal a TRON string into a time.Time, Unmarshal parses it as
+// RFC 3339 (see Decoder.SetTimeLayout to change the layout). A TRON string
+// unmarshals into a time.Duration using time.ParseDuration; a TRON number
+// is taken as a count of nanoseconds.
 //
 // To unmarshal TRON into an interface{} value,
 // Unmarshal stores one of these in the interface{} value:
@@ -144,12 +226,15 @@ func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
 //
 // To unmarshal a TRON object into a map, Unmarshal first establishes a map to
 // use. If the map is nil, Unmarshal allocates a new map. Otherwise Unmarshal
-// reuses the existing map, keeping existing entries. Unmarshal then stores
-// key-value pairs from the TRON object into the map. The map's key type must
+// reuses the existing map, keeping existing entries (see Decoder.ClearMaps
+// for an alternative). Unmarshal then stores key-value pairs from the TRON
+// object into the map, replacing the entries of keys the map already has; a
+// TRON null value stores the zero value of the element type. The map's key type must
 // either be any string type, any integer type, any unsigned integer type, or
 // an implementation of encoding.TextUnmarshaler.
 //
-// If the TRON-encoded data contain a syntax error, Unmarshal returns a SyntaxError.
+// If the TRON-encoded data contain a syntax error, Unmarshal returns a SyntaxError
+// and leaves v unchanged.
 //
 // If a TRON value is not appropriate for a given target type,
 // or if a TRON number overflows the target type, Unmarshal
@@ -167,9 +252,17 @@ func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
 // When unmarshaling quoted strings, invalid UTF-8 or
 // invalid UTF-16 surrogate pairs are not treated as an error.
 // Instead, they are replaced by the Unicode replacement
-// character U+FFFD.
+// character U+FFFD. Decoder.UseStrictUTF8 reports them as errors instead.
 func Unmarshal(data []byte, v interface{}) error {
-	return unmarshal(data, v)
+	return unmarshal(data, v, decodeOptions{})
+}
+
+// UnmarshalAs is like Unmarshal but decodes into a new value of type T and
+// returns it. On error the returned value may be partially populated.
+func UnmarshalAs[T any](data []byte) (T, error) {
+	var v T
+	err := Unmarshal(data, &v)
+	return v, err
 }
 
 // Marshaler is the interface implemented by types that
@@ -209,12 +302,87 @@ func (e *InvalidUnmarshalError) Error() string {
 
 // A SyntaxError is a description of a TRON syntax error.
 // Unmarshal will return a SyntaxError if the TRON can't be parsed.
+//
+// Line and Column locate the offending token or character, counting from 1,
+// with columns counted in runes. They are zero for errors that concern the
+// input as a whole, such as its size.
 type SyntaxError struct {
 	msg    string // description of error
+	err    error  // sentinel for the category of error, if any
 	Offset int64  // error occurred after reading Offset bytes
+	Line   int    // line of the error
+	Column int    // column of the error
 }
 
-func (e *SyntaxError) Error() string { return e.msg }
+func (e *SyntaxError) Error() string {
+	if e.Line == 0 {
+		return e.msg
+	}
+	return fmt.Sprintf("%s at line %d, column %d", e.msg, e.Line, e.Column)
+}
+
+// Unwrap returns the sentinel error for the category of e, such as
+// ErrTooDeep, so that callers can test for it with errors.Is. It returns nil
+// for errors outside the categories below.
+func (e *SyntaxError) Unwrap() error {
+	return e.err
+}
+
+// Errors that SyntaxError and the encoder wrap, for use with errors.Is.
+var (
+	// ErrTooLarge reports input over Limits.MaxInputBytes, or a string,
+	// array or object over Limits.MaxStringBytes, MaxArrayLength or
+	// MaxObjectMembers.
+	ErrTooLarge = errors.New("tron: input too large")
+	// ErrTooDeep reports values nested beyond Limits.MaxDepth when decoding,
+	// or beyond the encoder's own bound when encoding.
+	ErrTooDeep = errors.New("tron: maximum depth exceeded")
+	// ErrTooManyTokens reports input of more than Limits.MaxTokens tokens.
+	ErrTooManyTokens = errors.New("tron: too many tokens")
+	// ErrUndefinedClass reports the instantiation of a class that the
+	// document does not define.
+	ErrUndefinedClass = errors.New("tron: undefined class")
+	// ErrArgCountMismatch reports a class instantiation whose number of
+	// arguments differs from the number of properties of the class.
+	ErrArgCountMismatch = errors.New("tron: wrong number of class arguments")
+	// ErrArgTypeMismatch reports a class argument that does not match the
+	// type declared for its property in a typed class definition.
+	ErrArgTypeMismatch = errors.New("tron: class argument of wrong type")
+)
+
+// Snippet renders the line of src on which the error occurred, with a caret
+// under the offending column, the way compilers do:
+//
+//	4 |   B(3)]
+//	  |   ^
+//
+// src must be the input that produced the error. Snippet returns the empty
+// string if the error has no location or src does not contain it.
+func (e *SyntaxError) Snippet(src []byte) string {
+	if e.Line == 0 || e.Offset < 0 || e.Offset > int64(len(src)) {
+		return ""
+	}
+	start := bytes.LastIndexByte(src[:e.Offset], '\n') + 1
+	end := len(src)
+	if i := bytes.IndexByte(src[e.Offset:], '\n'); i >= 0 {
+		end = int(e.Offset) + i
+	}
+	line := bytes.TrimSuffix(src[start:end], []byte("\r"))
+
+	// Keep tabs before the caret so that it lines up however they render.
+	var pad []byte
+	for _, r := range string(src[start:e.Offset]) {
+		if r == '\t' {
+			pad = append(pad, '\t')
+		} else {
+			pad = append(pad, ' ')
+		}
+	}
+
+	num := strconv.Itoa(e.Line)
+	gutter := strings.Repeat(" ", len(num))
+	return num + " | " + string(line) + "\n" + gutter + " | " + string(pad) + "^\n"
+}
 
 // An UnmarshalTypeError describes a TRON value that was
 // not appropriate for a value of a specific Go type.
@@ -223,13 +391,16 @@ type UnmarshalTypeError struct {
 	Type   reflect.Type // type of Go value it could not be assigned to
 	Offset int64        // error occurred after reading Offset bytes
 	Struct string       // name of the struct type containing the field
-	Field  string       // the full path from root node to the field
+	Field  string       // the full path from root node to the field, as in "Items[2].Status"
 }
 
 func (e *UnmarshalTypeError) Error() string {
-	if e.Struct != "" || e.Field != "" {
+	if e.Struct != "" {
 		return "tron: cannot unmarshal " + e.Value + " into Go struct field " + e.Struct + "." + e.Field + " of type " + e.Type.String()
 	}
+	if e.Field != "" {
+		return "tron: cannot unmarshal " + e.Value + " into Go value " + e.Field + " of type " + e.Type.String()
+	}
 	return "tron: cannot unmarshal " + e.Value + " into Go value of type " + e.Type.String()
 }
 
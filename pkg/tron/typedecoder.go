@@ -0,0 +1,148 @@
+package tron
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// A TypeDecoder decodes TRON into values of type T with tables built for T
+// ahead of the values themselves. The field index of each struct type it
+// can meet is built when the TypeDecoder is created, so that the first
+// values decoded do not pay for it. The fields that the properties of a
+// class decode into are resolved the first time an instantiation of a class
+// with that property list meets the struct type, and kept for later
+// documents, so that arguments go to their fields by position rather than
+// by looking up their names. Arguments of the predeclared string, bool,
+// integer and float types are then stored with the setter built for the
+// field's kind, as with Decoder.UseUnsafeFieldSetters.
+//
+// Create one per type at startup and use it for every value of that type. A
+// TypeDecoder may be used by several goroutines at once.
+type TypeDecoder[T any] struct {
+	fields map[reflect.Type]*typeFields
+}
+
+// typeFields is a TypeDecoder's index of one struct type: its field index,
+// and the fields the properties of each class signature decode into.
+type typeFields struct {
+	*structFields
+	classes sync.Map     // classSignature -> []structField
+	count   atomic.Int32 // the signatures in classes
+}
+
+// maxClassSignatures caps the class signatures a TypeDecoder keeps for each
+// struct type, so that documents defining ever new classes do not grow it
+// without bound. Classes past the cap are resolved once per document.
+const maxClassSignatures = 256
+
+// TypeDecoderFor returns a TypeDecoder for T. It indexes the fields of T, if
+// T is a struct, and of every struct type reachable from T through fields,
+// pointers, slices, arrays and map values. Struct types only reached at run
+// time through interfaces are indexed as usual, per object.
+func TypeDecoderFor[T any]() *TypeDecoder[T] {
+	td := &TypeDecoder[T]{fields: make(map[reflect.Type]*typeFields)}
+	td.index(reflect.TypeFor[T]())
+	return td
+}
+
+// index adds the field indexes of the struct types reachable from t.
+func (td *TypeDecoder[T]) index(t reflect.Type) {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		td.index(t.Elem())
+	case reflect.Struct:
+		if _, ok := td.fields[t]; ok {
+			return
+		}
+		td.fields[t] = &typeFields{structFields: cachedStructFields(t)}
+		for i := range t.NumField() {
+			if t.Field(i).IsExported() {
+				td.index(t.Field(i).Type)
+			}
+		}
+	}
+}
+
+// options returns opts set up to decode with the tables of td.
+func (td *TypeDecoder[T]) options(opts decodeOptions) decodeOptions {
+	opts.fields = td.fields
+	opts.unsafeFields = true
+	return opts
+}
+
+// Unmarshal is like Unmarshal for a value of type T.
+func (td *TypeDecoder[T]) Unmarshal(data []byte, v *T) error {
+	return unmarshal(data, v, td.options(decodeOptions{}))
+}
+
+// Decode is like dec.Decode for a value of type T, with the options of dec.
+func (td *TypeDecoder[T]) Decode(dec *Decoder, v *T) error {
+	saved := dec.opts
+	dec.opts = td.options(dec.opts)
+	defer func() { dec.opts = saved }()
+	return dec.Decode(v)
+}
+
+// classFields returns the fields of tf that the properties of def decode
+// into, in order, with an index of -1 for properties that match no field.
+func (tf *typeFields) classFields(def *classDef, exact bool) []structField {
+	key := classSignature(def.props, exact)
+	if fields, ok := tf.classes.Load(key); ok {
+		return fields.([]structField)
+	}
+	fields := make([]structField, len(def.props))
+	for i, prop := range def.props {
+		var ok bool
+		if fields[i], ok = tf.lookup(prop, exact); !ok {
+			fields[i].index = -1
+		}
+	}
+	if tf.count.Load() < maxClassSignatures {
+		if _, loaded := tf.classes.LoadOrStore(key, fields); !loaded {
+			tf.count.Add(1)
+		}
+	}
+	return fields
+}
+
+// classSignature identifies the property list props, matched to field
+// names exactly or not.
+func classSignature(props []string, exact bool) string {
+	var b strings.Builder
+	b.WriteString(strconv.FormatBool(exact))
+	for _, prop := range props {
+		b.WriteByte(',')
+		b.WriteString(strconv.Quote(prop))
+	}
+	return b.String()
+}
+
+// classFieldsKey identifies a class of the document being decoded and the
+// struct type its instantiations decode into.
+type classFieldsKey struct {
+	def *classDef
+	typ reflect.Type
+}
+
+// classFields returns the fields that the properties of def decode into
+// for struct type t, or nil if no TypeDecoder has indexed t. They are looked
+// up once per class of the document.
+func (d *decoder) classFields(def *classDef, t reflect.Type) []structField {
+	tf, ok := d.fields[t]
+	if !ok || def == nil {
+		return nil
+	}
+	key := classFieldsKey{def, t}
+	if fields, ok := d.resolved[key]; ok {
+		return fields
+	}
+	if d.resolved == nil {
+		d.resolved = make(map[classFieldsKey][]structField)
+	}
+	fields := tf.classFields(def, d.exactNames)
+	d.resolved[key] = fields
+	return fields
+}
@@ -0,0 +1,115 @@
+package tron
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type tdLine struct {
+	SKU   string  `json:"sku"`
+	Qty   int     `json:"qty"`
+	Price float64 `json:"price"`
+}
+
+type tdOrder struct {
+	ID     int                `json:"id"`
+	Lines  []tdLine           `json:"lines"`
+	Ship   *tdAddress         `json:"ship"`
+	ByCode map[string]tdLine  `json:"byCode"`
+	Extra  map[string]any     `tron:",remain"`
+	Any    any                `json:"any"`
+	Hist   [2]map[int]*tdLine `json:"hist"`
+}
+
+type tdAddress struct {
+	City string `json:"city"`
+}
+
+func TestTypeDecoderFor(t *testing.T) {
+	td := TypeDecoderFor[[]tdOrder]()
+	var indexed []string
+	for typ := range td.fields {
+		indexed = append(indexed, typ.Name())
+	}
+	if len(indexed) != 3 {
+		t.Errorf("indexed %v, want tdOrder, tdLine and tdAddress", indexed)
+	}
+
+	input := "class L: sku,qty,price\n\n" +
+		`[{"id":1,"lines":[L("a",1,2.5),L("b",2,1)],"ship":{"city":"Oslo"},"byCode":{"a":L("a",1,2.5)},"note":"x","any":{"k":1},"hist":[{"1":L("c",3,0)}]},` +
+		`{"ID":2,"Lines":[]}]`
+	var want, got []tdOrder
+	if err := Unmarshal([]byte(input), &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := td.Unmarshal([]byte(input), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+	if got[0].Extra["note"] != "x" || got[1].ID != 2 || got[0].Hist[0][1].SKU != "c" {
+		t.Errorf("unexpected decode: %+v", got)
+	}
+
+	// Decode applies the Decoder's options, and leaves them as they were.
+	dec := NewDecoder(strings.NewReader(`[{"ID":3}]`))
+	dec.UseExactFieldNames()
+	if err := td.Decode(dec, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got[0].ID != 0 || dec.opts.fields != nil || dec.opts.unsafeFields {
+		t.Errorf("Decode ignored UseExactFieldNames or kept its tables: %+v", got)
+	}
+
+	// Type errors name the struct and field as usual.
+	err := td.Unmarshal([]byte(`[{"lines":[{"qty":"many"}]}]`), &got)
+	if err == nil || !strings.Contains(err.Error(), "Lines[0].Qty") {
+		t.Errorf("got %v, want an error about Lines[0].Qty", err)
+	}
+}
+
+func TestTypeDecoderClassFields(t *testing.T) {
+	td := TypeDecoderFor[[]tdLine]()
+	lines := td.fields[reflect.TypeFor[tdLine]()]
+
+	// Classes with the same properties share one resolution, whatever their
+	// names and documents; arguments go to their fields by position, named
+	// or defaulted, and unknown properties are skipped.
+	for _, input := range []string{
+		"class L: price,SKU,color,qty=1\n\n[L(2.5,\"a\",\"red\"),L(qty:3,price:1,SKU:\"b\",color:null)]",
+		"class M: price,SKU,color,qty=1\n\n[M(2.5,\"a\",\"red\"),M(1,\"b\",null,3)]",
+	} {
+		var got []tdLine
+		if err := td.Unmarshal([]byte(input), &got); err != nil {
+			t.Fatal(err)
+		}
+		if want := []tdLine{{"a", 1, 2.5}, {"b", 3, 1}}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %+v, want %+v", got, want)
+		}
+	}
+	if n := lines.count.Load(); n != 1 {
+		t.Errorf("%d class signatures kept, want 1", n)
+	}
+
+	// Matching field names exactly resolves the properties anew.
+	dec := NewDecoder(strings.NewReader("class L: price,SKU,color,qty=1\n\n[L(2.5,\"a\",\"red\")]"))
+	dec.UseExactFieldNames()
+	var got []tdLine
+	if err := td.Decode(dec, &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := []tdLine{{"", 1, 2.5}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("exact names: got %+v, want %+v", got, want)
+	}
+	if n := lines.count.Load(); n != 2 {
+		t.Errorf("%d class signatures kept, want 2", n)
+	}
+
+	// Type errors still name the field.
+	err := td.Unmarshal([]byte("class L: qty\n\n[L(\"many\")]"), &got)
+	if err == nil || !strings.Contains(err.Error(), "Qty") {
+		t.Errorf("got %v, want an error about Qty", err)
+	}
+}
@@ -20,11 +20,12 @@ var _ encoding.TextUnmarshaler = (*textKey)(nil)
 func TestDecodeString_MoreBranches(t *testing.T) {
 	d := &decoder{}
 
-	// string -> []byte
+	// string -> []byte (raw, as opposed to the default base64)
 	{
 		var b []byte
 		dst := reflect.ValueOf(&b).Elem()
-		if err := d.decodeString("hi", dst); err != nil {
+		rd := &decoder{decodeOptions: decodeOptions{rawBytes: true}}
+		if err := rd.decodeString("hi", dst); err != nil {
 			t.Fatalf("decodeString: %v", err)
 		}
 		if string(b) != "hi" {
@@ -1,77 +1,180 @@
 package tron
 
 import (
+	"bytes"
+	"context"
 	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"reflect"
 	"strconv"
 	"strings"
-	"unicode/utf8"
+	"sync"
+	"time"
 )
 
+// defaultTimeLayout is used for time.Time values when no layout is configured.
+// Parsing with it accepts RFC 3339 timestamps with or without fractional seconds.
+const defaultTimeLayout = time.RFC3339Nano
+
+// decodeOptions configures a decoder. The zero value gives the defaults
+// used by Unmarshal.
+type decodeOptions struct {
+	timeLayout  string // layout for time.Time values; RFC 3339 when empty
+	rawBytes    bool   // decode strings into []byte verbatim instead of as base64
+	preferInt64 bool   // decode integral numbers into interface{} as int64
+	allErrors   bool   // return every type error joined, not just the first
+	strictJSON  bool   // reject the syntax TRON adds to JSON
+	strictUTF8  bool   // reject invalid UTF-8 and unpaired surrogates in strings
+	nonFinite   bool   // accept NaN, Infinity and -Infinity as numbers
+	lenient     bool   // skip malformed elements and members, see UnmarshalLenient
+	exactNames  bool   // match object keys to struct fields case-sensitively
+	limits      Limits // zero fields use the package defaults
+
+	extendedNumbers bool               // accept hex, binary and octal numbers and digit separators
+	trailingCommas  bool               // accept a comma before a closing bracket, brace or parenthesis
+	typedClasses    bool               // accept and check property types in class definitions
+	classShadowing  bool               // let a class definition replace an earlier, different one
+	duplicateKeys   DuplicateKeyPolicy // handling of repeated object keys
+	types           *TypeRegistry      // Go types to decode registered classes into interfaces as
+	orderedMaps     bool               // decode objects into interfaces as *OrderedMap
+	clearMaps       bool               // empty an existing map before storing an object into it
+	internStrings   bool               // share one copy of each repeated short string and key
+	parallelism     int                // goroutines decoding the elements of a root array into a slice
+	unsafeFields    bool               // store scalars into basic struct fields through their offsets
+
+	fields map[reflect.Type]*typeFields // field indexes built in advance by a TypeDecoder
+
+	cancel *canceler // polled during decoding; nil if not cancelable
+}
+
 // decoder handles type conversion from parsed values to Go types.
 type decoder struct {
-	classes map[string][]string
+	decodeOptions
+
+	classes  map[string]*classDef
+	resolved map[classFieldsKey][]structField // see classFields
 }
 
-// unmarshal is the internal implementation of Unmarshal.
-func unmarshal(data []byte, v interface{}) error {
+// unmarshal is the internal implementation of Unmarshal and Decoder.Decode.
+func unmarshal(data []byte, v interface{}, opts decodeOptions) error {
 	// Validate input
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
 		return &InvalidUnmarshalError{Type: reflect.TypeOf(v)}
 	}
-	if len(data) > maxInputBytes {
-		return &SyntaxError{msg: "input too large", Offset: 0}
-	}
-	if !utf8.Valid(data) {
-		return &SyntaxError{msg: "invalid UTF-8", Offset: 0}
-	}
 
-	// Tokenize
-	tokens, err := tokenize(string(data))
+	parser, err := newDocumentParser(data, opts)
 	if err != nil {
 		return err
 	}
+	defer parser.release()
+
+	// Parse and decode into target in a single pass
+	d := &decoder{decodeOptions: opts}
+	return d.decodeDocument(parser, rv.Elem())
+}
+
+// newDocumentParser checks data against the input limits and tokenizes it,
+// returning a parser positioned at the start of the document.
+func newDocumentParser(data []byte, opts decodeOptions) (*parser, error) {
+	if len(data) > opts.limits.withDefaults().MaxInputBytes {
+		return nil, &SyntaxError{msg: "input too large", err: ErrTooLarge, Offset: 0}
+	}
+	// Tokenize, into a slice from tokenPool
+	pooled := tokenPool.Get().(*[]Token)
+	tokens, err := appendTokens((*pooled)[:0], data, opts)
+	if err != nil {
+		tokenPool.Put(pooled)
+		return nil, err
+	}
 
-	// Parse
 	parser := newParser(tokens)
+	parser.pooled = pooled
+	parser.opts = opts
 	// Preserve number tokens as strings to avoid float64 precision loss for large integers.
 	parser.preserveNumbers = true
-	parsedValue, err := parser.parse()
-	if err != nil {
+	return parser, nil
+}
+
+// decode assigns a parsed value to a reflect.Value.
+func (d *decoder) decode(src interface{}, dst reflect.Value) error {
+	if err := d.cancel.check(); err != nil {
 		return err
 	}
 
-	// Decode into target
-	d := &decoder{
-		classes: parser.classes,
+	// Follow pointers, allocating nil ones, down to the value that holds
+	// the data; null sets the pointer itself to nil.
+	if dst.Kind() == reflect.Ptr {
+		if src == nil {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return d.decode(src, dst.Elem())
 	}
 
-	return d.decode(parsedValue, rv.Elem())
-}
+	// Strings decode natively into time.Time and time.Duration.
+	if str, ok := src.(string); ok {
+		switch dst.Type() {
+		case timeType:
+			return d.decodeTime(str, dst)
+		case durationType:
+			dur, err := time.ParseDuration(str)
+			if err != nil {
+				return &UnmarshalTypeError{Value: "string " + strconv.Quote(str), Type: dst.Type()}
+			}
+			dst.SetInt(int64(dur))
+			return nil
+		}
+	}
+
+	// Handle custom unmarshalers. UnmarshalTRON and UnmarshalJSON receive the
+	// value re-encoded as JSON, which is also valid TRON.
+	if dst.CanAddr() {
+		addr := dst.Addr()
+		if addr.Type().Implements(unmarshalerType) {
+			data, err := marshalParsed(src)
+			if err != nil {
+				return err
+			}
+			return addr.Interface().(Unmarshaler).UnmarshalTRON(data)
+		}
+		if addr.Type().Implements(jsonUnmarshalerType) {
+			data, err := marshalParsed(src)
+			if err != nil {
+				return err
+			}
+			return addr.Interface().(json.Unmarshaler).UnmarshalJSON(data)
+		}
+	}
 
-// decode assigns a parsed value to a reflect.Value.
-func (d *decoder) decode(src interface{}, dst reflect.Value) error {
 	// Handle nil
 	if src == nil {
 		return d.decodeNull(dst)
 	}
 
-	// Handle custom unmarshalers
 	if dst.CanAddr() {
 		addr := dst.Addr()
-		if addr.Type().Implements(unmarshalerType) {
-			// For custom unmarshalers, we would need to re-marshal the value
-			// For now, we'll just let it fall through to standard decoding
-		}
-
 		if addr.Type().Implements(textUnmarshalerType) {
 			if str, ok := src.(string); ok {
 				return addr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str))
 			}
 		}
+		if addr.Type().Implements(binaryUnmarshalerType) {
+			if str, ok := src.(string); ok {
+				b, err := base64.StdEncoding.DecodeString(str)
+				if err != nil {
+					return err
+				}
+				return addr.Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(b)
+			}
+		}
 	}
 
 	// Type-based decoding
@@ -94,6 +197,41 @@ func (d *decoder) decode(src interface{}, dst reflect.Value) error {
 	}
 }
 
+// marshalParsed encodes a parsed value as compact JSON, keeping number
+// literals verbatim.
+func marshalParsed(src interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(jsonValue(src)); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// jsonValue converts a parsed value into a form encoding/json marshals
+// losslessly.
+func jsonValue(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case numberLiteral:
+		return json.Number(vv)
+	case []interface{}:
+		out := make([]interface{}, len(vv))
+		for i := range vv {
+			out[i] = jsonValue(vv[i])
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(vv))
+		for k, val := range vv {
+			out[k] = jsonValue(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
 // decodeNull handles null values.
 func (d *decoder) decodeNull(dst reflect.Value) error {
 	switch dst.Kind() {
@@ -189,18 +327,29 @@ func (d *decoder) decodeNumberLiteral(src string, dst reflect.Value) error {
 
 	case reflect.Interface:
 		if dst.NumMethod() == 0 {
-			// Default to float64 to match JSON semantics.
-			f, err := strconv.ParseFloat(src, 64)
+			n, err := d.interfaceNumber(src)
 			if err != nil {
 				return &UnmarshalTypeError{Value: fmt.Sprintf("number %s", src), Type: dst.Type()}
 			}
-			dst.Set(reflect.ValueOf(f))
+			dst.Set(reflect.ValueOf(n))
 			return nil
 		}
 	}
 	return &UnmarshalTypeError{Value: "number", Type: dst.Type()}
 }
 
+// interfaceNumber converts a number literal for storage in an interface{}.
+// It yields float64 to match JSON semantics, or int64 for integral literals
+// that fit when preferInt64 is set.
+func (d *decoder) interfaceNumber(src string) (interface{}, error) {
+	if d.preferInt64 {
+		if i, err := strconv.ParseInt(src, 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	return strconv.ParseFloat(src, 64)
+}
+
 // decodeNumber decodes a numeric value.
 // Deprecated: numeric parsing now uses decodeNumberLiteral to avoid float64 precision loss.
 func (d *decoder) decodeNumber(src float64, dst reflect.Value) error {
@@ -220,24 +369,45 @@ func (d *decoder) decodeString(src string, dst reflect.Value) error {
 		}
 	case reflect.Slice:
 		if dst.Type().Elem().Kind() == reflect.Uint8 {
-			// []byte - store string as bytes
-			dst.SetBytes([]byte(src))
+			if d.rawBytes {
+				dst.SetBytes([]byte(src))
+				return nil
+			}
+			b, err := base64.StdEncoding.DecodeString(src)
+			if err != nil {
+				return err
+			}
+			dst.SetBytes(b)
 			return nil
 		}
 	}
 	return &UnmarshalTypeError{Value: "string", Type: dst.Type()}
 }
 
+// decodeTime parses src with the configured layout into a time.Time.
+func (d *decoder) decodeTime(src string, dst reflect.Value) error {
+	layout := d.timeLayout
+	if layout == "" {
+		layout = defaultTimeLayout
+	}
+	t, err := time.Parse(layout, src)
+	if err != nil {
+		return &UnmarshalTypeError{Value: "string " + strconv.Quote(src), Type: dst.Type()}
+	}
+	dst.Set(reflect.ValueOf(t))
+	return nil
+}
+
 // normalizeInterfaceValue converts parsed values into conventional Go values
 // suitable for interface{} targets (JSON-like semantics).
 func (d *decoder) normalizeInterfaceValue(v interface{}) interface{} {
 	switch vv := v.(type) {
 	case numberLiteral:
-		f, err := strconv.ParseFloat(string(vv), 64)
+		n, err := d.interfaceNumber(string(vv))
 		if err != nil {
 			return string(vv)
 		}
-		return f
+		return n
 	case []interface{}:
 		out := make([]interface{}, len(vv))
 		for i := range vv {
@@ -245,6 +415,9 @@ func (d *decoder) normalizeInterfaceValue(v interface{}) interface{} {
 		}
 		return out
 	case map[string]interface{}:
+		if d.orderedMaps {
+			return d.orderedMapOf(vv)
+		}
 		out := make(map[string]interface{}, len(vv))
 		for k, val := range vv {
 			out[k] = d.normalizeInterfaceValue(val)
@@ -278,18 +451,30 @@ func (d *decoder) decodeArray(src []interface{}, dst reflect.Value) error {
 
 // decodeSlice decodes into a slice.
 func (d *decoder) decodeSlice(src []interface{}, dst reflect.Value) error {
-	// Create new slice
-	slice := reflect.MakeSlice(dst.Type(), len(src), len(src))
+	// Reuse the backing array of dst if it is large enough, zeroing the
+	// elements as if they had been appended to an empty slice.
+	var slice reflect.Value
+	if len(src) > 0 && dst.Cap() >= len(src) {
+		slice = dst.Slice(0, len(src))
+		for i := range len(src) {
+			slice.Index(i).SetZero()
+		}
+	} else {
+		slice = reflect.MakeSlice(dst.Type(), len(src), len(src))
+	}
 
 	// Decode each element
+	var errs error
 	for i, item := range src {
 		if err := d.decode(item, slice.Index(i)); err != nil {
-			return err
+			if err := d.recordError(&errs, elementError(err, indexPath(i))); err != nil {
+				return err
+			}
 		}
 	}
 
 	dst.Set(slice)
-	return nil
+	return errs
 }
 
 // decodeArrayFixed decodes into a fixed-size array.
@@ -297,9 +482,12 @@ func (d *decoder) decodeArrayFixed(src []interface{}, dst reflect.Value) error {
 	length := dst.Len()
 
 	// Decode elements up to array length
+	var errs error
 	for i := 0; i < length && i < len(src); i++ {
 		if err := d.decode(src[i], dst.Index(i)); err != nil {
-			return err
+			if err := d.recordError(&errs, elementError(err, indexPath(i))); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -308,7 +496,7 @@ func (d *decoder) decodeArrayFixed(src []interface{}, dst reflect.Value) error {
 		dst.Index(i).Set(reflect.Zero(dst.Type().Elem()))
 	}
 
-	return nil
+	return errs
 }
 
 // decodeObject decodes an object (map or struct).
@@ -317,8 +505,16 @@ func (d *decoder) decodeObject(src map[string]interface{}, dst reflect.Value) er
 	case reflect.Map:
 		return d.decodeMap(src, dst)
 	case reflect.Struct:
+		if dst.Type() == orderedMapType {
+			d.decodeOrderedMap(src, dst)
+			return nil
+		}
 		return d.decodeStruct(src, dst)
 	case reflect.Interface:
+		if dst.NumMethod() == 0 && d.orderedMaps {
+			dst.Set(reflect.ValueOf(d.orderedMapOf(src)))
+			return nil
+		}
 		if dst.NumMethod() == 0 {
 			// Create map[string]interface{} with normalized values
 			result := make(map[string]interface{}, len(src))
@@ -340,25 +536,34 @@ func (d *decoder) decodeMap(src map[string]interface{}, dst reflect.Value) error
 	// Create map if nil
 	if dst.IsNil() {
 		dst.Set(reflect.MakeMap(dst.Type()))
+	} else if d.clearMaps {
+		dst.Clear()
 	}
 
+	var errs error
 	for k, v := range src {
 		// Convert key
 		keyVal := reflect.New(keyType).Elem()
 		if err := d.decodeMapKey(k, keyVal); err != nil {
-			return err
+			if err := d.recordError(&errs, err); err != nil {
+				return err
+			}
+			continue
 		}
 
 		// Convert value
 		elemVal := reflect.New(elemType).Elem()
 		if err := d.decode(v, elemVal); err != nil {
-			return err
+			if err := d.recordError(&errs, elementError(err, k)); err != nil {
+				return err
+			}
+			continue
 		}
 
 		dst.SetMapIndex(keyVal, elemVal)
 	}
 
-	return nil
+	return errs
 }
 
 // decodeMapKey decodes a string key into the appropriate map key type.
@@ -393,17 +598,26 @@ func (d *decoder) decodeMapKey(src string, dst reflect.Value) error {
 
 // structField holds information about a struct field.
 type structField struct {
-	index int
-	name  string
-	typ   reflect.Type
+	index         int
+	name          string
+	typ           reflect.Type
+	discriminator string // from the discriminator tag option, for interface fields
+
+	offset uintptr      // offset of the field in the struct
+	set    unsafeSetter // for UseUnsafeFieldSetters; nil unless typ is a basic type
 }
 
-// decodeStruct decodes into a struct.
-func (d *decoder) decodeStruct(src map[string]interface{}, dst reflect.Value) error {
-	t := dst.Type()
+// structFields indexes the decodable fields of a struct type by name.
+type structFields struct {
+	byName map[string]structField // by exact name
+	byFold map[string]structField // by lowercased name, first field wins
+	remain int                    // index of the catch-all field for unknown keys, or -1
+}
 
-	// Build field map (json tag name -> field info)
-	fields := make(map[string]structField)
+// newStructFields builds the field index for struct type t.
+func newStructFields(t reflect.Type) structFields {
+	// Build field map (tron/json tag name -> field info)
+	fields := structFields{byName: make(map[string]structField), byFold: make(map[string]structField), remain: -1}
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
 		if !field.IsExported() {
@@ -411,7 +625,8 @@ func (d *decoder) decodeStruct(src map[string]interface{}, dst reflect.Value) er
 		}
 
 		name := field.Name
-		if tag := field.Tag.Get("json"); tag != "" {
+		discriminator := ""
+		if tag := fieldTag(field); tag != "" {
 			parts := strings.Split(tag, ",")
 			if parts[0] == "-" {
 				continue
@@ -419,51 +634,217 @@ func (d *decoder) decodeStruct(src map[string]interface{}, dst reflect.Value) er
 			if parts[0] != "" {
 				name = parts[0]
 			}
+			if len(parts) > 1 && (contains(parts[1:], "remain") || contains(parts[1:], "inline")) && isRemainType(field.Type) {
+				fields.remain = i
+				continue
+			}
+			for _, opt := range parts[1:] {
+				if key, ok := strings.CutPrefix(opt, "discriminator="); ok {
+					discriminator = key
+				}
+			}
 		}
 
 		sf := structField{
-			index: i,
-			name:  field.Name,
-			typ:   field.Type,
+			index:         i,
+			name:          field.Name,
+			typ:           field.Type,
+			discriminator: discriminator,
+			offset:        field.Offset,
+			set:           unsafeSetterFor(field.Type),
 		}
 
-		fields[name] = sf
-		// Also support case-insensitive matching
-		fields[strings.ToLower(name)] = sf
+		fields.byName[name] = sf
+		// Also support case-insensitive matching. Keeping this separate
+		// from byName means a field whose name differs from another only
+		// by case cannot take over that field's exact name.
+		if _, exists := fields.byFold[strings.ToLower(name)]; !exists {
+			fields.byFold[strings.ToLower(name)] = sf
+		}
 	}
+	return fields
+}
 
-	// Decode each source field
-	for key, value := range src {
-		// Try exact match first
-		field, ok := fields[key]
-		if !ok {
-			// Try case-insensitive
-			field, ok = fields[strings.ToLower(key)]
+// structFieldsCache holds the field index of every struct type decoded into
+// so far, shared across calls like structTypeCache.
+var structFieldsCache sync.Map // map[reflect.Type]*structFields
+
+// structFields returns the field index for struct type t, from a
+// TypeDecoder's if it has one.
+func (d *decoder) structFields(t reflect.Type) *structFields {
+	if fields, ok := d.fields[t]; ok {
+		return fields.structFields
+	}
+	return cachedStructFields(t)
+}
+
+// cachedStructFields returns the field index for struct type t, building it
+// on first use.
+func cachedStructFields(t reflect.Type) *structFields {
+	if fields, ok := structFieldsCache.Load(t); ok {
+		return fields.(*structFields)
+	}
+	fields := newStructFields(t)
+	actual, _ := structFieldsCache.LoadOrStore(t, &fields)
+	return actual.(*structFields)
+}
+
+// lookup finds the field for an object key. An exact match always wins; if
+// there is none and exactOnly is false, the first field whose name matches
+// case-insensitively is used.
+func (f *structFields) lookup(key string, exactOnly bool) (structField, bool) {
+	field, ok := f.byName[key]
+	if !ok && !exactOnly {
+		field, ok = f.byFold[strings.ToLower(key)]
+	}
+	return field, ok
+}
+
+// fieldError wraps an error from decoding a struct member so it names the
+// struct and field. A type error from deeper inside the member already has a
+// path, which gets field prepended instead. Syntax errors and cancellation
+// pass through unchanged.
+func fieldError(err error, value string, typ reflect.Type, t reflect.Type, field string) error {
+	if isFatal(err) {
+		return err
+	}
+	if list, ok := err.(decodeErrors); ok {
+		for i := range list {
+			list[i] = fieldError(list[i], value, typ, t, field)
 		}
+		return list
+	}
+	if typeErr, ok := err.(*UnmarshalTypeError); ok && typeErr.Field != "" {
+		if typeErr.Struct == "" {
+			typeErr.Struct = t.Name()
+		}
+		typeErr.Field = joinPath(field, typeErr.Field)
+		return typeErr
+	}
+	return &UnmarshalTypeError{
+		Value:  value,
+		Type:   typ,
+		Struct: t.Name(),
+		Field:  field,
+	}
+}
+
+// elementError prepends elem, an array index or map key, to the path of a
+// type error from decoding an element of a container. Other errors are
+// returned unchanged.
+func elementError(err error, elem string) error {
+	switch err := err.(type) {
+	case *UnmarshalTypeError:
+		err.Field = joinPath(elem, err.Field)
+	case decodeErrors:
+		for _, e := range err {
+			elementError(e, elem)
+		}
+	}
+	return err
+}
+
+// indexPath returns the path element for array index i.
+func indexPath(i int) string {
+	return "[" + strconv.Itoa(i) + "]"
+}
+
+// joinPath appends the path child to parent, as in "items[2].status".
+func joinPath(parent, child string) string {
+	if child == "" || child[0] == '[' {
+		return parent + child
+	}
+	return parent + "." + child
+}
+
+// isFatal reports whether err must abort decoding rather than be reported
+// against the value being decoded.
+func isFatal(err error) bool {
+	var syntaxErr *SyntaxError
+	return errors.As(err, &syntaxErr) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// decodeStruct decodes into a struct.
+func (d *decoder) decodeStruct(src map[string]interface{}, dst reflect.Value) error {
+	t := dst.Type()
+	fields := d.structFields(t)
 
+	// Decode each source field
+	var errs error
+	for key, value := range src {
+		field, ok := fields.lookup(key, d.exactNames)
 		if !ok {
+			if fields.remain >= 0 {
+				if err := d.decodeRemain(key, value, dst.Field(fields.remain)); err != nil {
+					if err := d.recordError(&errs, fieldError(err, describeParsed(value), t.Field(fields.remain).Type.Elem(), t, key)); err != nil {
+						return err
+					}
+				}
+				continue
+			}
 			// Unknown field - ignore (JSON behavior)
 			continue
 		}
 
 		fieldVal := dst.Field(field.index)
 		if err := d.decode(value, fieldVal); err != nil {
-			return &UnmarshalTypeError{
-				Value:  fmt.Sprintf("%T", value),
-				Type:   field.typ,
-				Struct: t.Name(),
-				Field:  field.name,
+			if err := d.recordError(&errs, fieldError(err, describeParsed(value), field.typ, t, field.name)); err != nil {
+				return err
 			}
 		}
 	}
 
+	return errs
+}
+
+// describeParsed names the TRON kind of a parsed value for error messages.
+func describeParsed(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case numberLiteral, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// isRemainType reports whether t can hold the catch-all keys of a struct
+// field tagged with the "remain" or "inline" option: a map with a string key.
+func isRemainType(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String
+}
+
+// decodeRemain stores an unknown object member in the catch-all map dst,
+// allocating the map if needed.
+func (d *decoder) decodeRemain(key string, value interface{}, dst reflect.Value) error {
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMap(dst.Type()))
+	}
+	elemVal := reflect.New(dst.Type().Elem()).Elem()
+	if err := d.decode(value, elemVal); err != nil {
+		return err
+	}
+	keyVal := reflect.New(dst.Type().Key()).Elem()
+	keyVal.SetString(key)
+	dst.SetMapIndex(keyVal, elemVal)
 	return nil
 }
 
 // Helper variables for interface types.
 var (
-	unmarshalerType     = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
-	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType              = reflect.TypeOf(time.Time{})
+	durationType          = reflect.TypeOf(time.Duration(0))
+	unmarshalerType       = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	jsonUnmarshalerType   = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType   = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
 )
 
 // minInt returns the minimum value for an integer type.
@@ -0,0 +1,117 @@
+package tron
+
+import (
+	"reflect"
+	"strconv"
+	"unsafe"
+)
+
+// An unsafeSetter stores the value of tok, a scalar token, at ptr, which
+// points to a struct field of the basic type it was made for. It reports
+// false, having stored nothing, if the token does not hold a value of that
+// type that converts without error; decodeDirect then handles it, errors and
+// nulls included.
+type unsafeSetter func(ptr unsafe.Pointer, tok Token) bool
+
+// unsafeSetterFor returns the unsafeSetter for fields of type t, or nil if t
+// is not one of the predeclared string, bool, integer or float types. Named
+// types are left out since they may have unmarshalers of their own.
+func unsafeSetterFor(t reflect.Type) unsafeSetter {
+	if t.PkgPath() != "" || t.Name() == "" {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return func(ptr unsafe.Pointer, tok Token) bool {
+			if tok.Type != TokenString {
+				return false
+			}
+			*(*string)(ptr) = tok.Value
+			return true
+		}
+	case reflect.Bool:
+		return func(ptr unsafe.Pointer, tok Token) bool {
+			if tok.Type != TokenTrue && tok.Type != TokenFalse {
+				return false
+			}
+			*(*bool)(ptr) = tok.Type == TokenTrue
+			return true
+		}
+	case reflect.Int:
+		return intSetter(func(ptr unsafe.Pointer, n int64) { *(*int)(ptr) = int(n) }, 64)
+	case reflect.Int8:
+		return intSetter(func(ptr unsafe.Pointer, n int64) { *(*int8)(ptr) = int8(n) }, 8)
+	case reflect.Int16:
+		return intSetter(func(ptr unsafe.Pointer, n int64) { *(*int16)(ptr) = int16(n) }, 16)
+	case reflect.Int32:
+		return intSetter(func(ptr unsafe.Pointer, n int64) { *(*int32)(ptr) = int32(n) }, 32)
+	case reflect.Int64:
+		return intSetter(func(ptr unsafe.Pointer, n int64) { *(*int64)(ptr) = n }, 64)
+	case reflect.Uint:
+		return uintSetter(func(ptr unsafe.Pointer, n uint64) { *(*uint)(ptr) = uint(n) }, 64)
+	case reflect.Uint8:
+		return uintSetter(func(ptr unsafe.Pointer, n uint64) { *(*uint8)(ptr) = uint8(n) }, 8)
+	case reflect.Uint16:
+		return uintSetter(func(ptr unsafe.Pointer, n uint64) { *(*uint16)(ptr) = uint16(n) }, 16)
+	case reflect.Uint32:
+		return uintSetter(func(ptr unsafe.Pointer, n uint64) { *(*uint32)(ptr) = uint32(n) }, 32)
+	case reflect.Uint64:
+		return uintSetter(func(ptr unsafe.Pointer, n uint64) { *(*uint64)(ptr) = n }, 64)
+	case reflect.Float32:
+		return func(ptr unsafe.Pointer, tok Token) bool {
+			if tok.Type != TokenNumber {
+				return false
+			}
+			f, err := strconv.ParseFloat(tok.Value, 32)
+			if err != nil {
+				return false
+			}
+			*(*float32)(ptr) = float32(f)
+			return true
+		}
+	case reflect.Float64:
+		return func(ptr unsafe.Pointer, tok Token) bool {
+			if tok.Type != TokenNumber {
+				return false
+			}
+			f, err := strconv.ParseFloat(tok.Value, 64)
+			if err != nil {
+				return false
+			}
+			*(*float64)(ptr) = f
+			return true
+		}
+	}
+	return nil
+}
+
+// intSetter returns an unsafeSetter for a signed integer type of the given
+// size, parsing numbers as decodeNumberLiteral does.
+func intSetter(store func(ptr unsafe.Pointer, n int64), bits int) unsafeSetter {
+	return func(ptr unsafe.Pointer, tok Token) bool {
+		if tok.Type != TokenNumber {
+			return false
+		}
+		n, err := strconv.ParseInt(tok.Value, 10, bits)
+		if err != nil {
+			return false
+		}
+		store(ptr, n)
+		return true
+	}
+}
+
+// uintSetter is like intSetter for unsigned integer types.
+func uintSetter(store func(ptr unsafe.Pointer, n uint64), bits int) unsafeSetter {
+	return func(ptr unsafe.Pointer, tok Token) bool {
+		if tok.Type != TokenNumber {
+			return false
+		}
+		n, err := strconv.ParseUint(tok.Value, 10, bits)
+		if err != nil {
+			return false
+		}
+		store(ptr, n)
+		return true
+	}
+}
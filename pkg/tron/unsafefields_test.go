@@ -0,0 +1,107 @@
+package tron
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type unsafeFieldsRecord struct {
+	S   string  `json:"s"`
+	B   bool    `json:"b"`
+	I   int     `json:"i"`
+	I8  int8    `json:"i8"`
+	I16 int16   `json:"i16"`
+	I32 int32   `json:"i32"`
+	I64 int64   `json:"i64"`
+	U   uint    `json:"u"`
+	U8  uint8   `json:"u8"`
+	U16 uint16  `json:"u16"`
+	U32 uint32  `json:"u32"`
+	U64 uint64  `json:"u64"`
+	F32 float32 `json:"f32"`
+	F64 float64 `json:"f64"`
+
+	Named unsafeFieldsName `json:"named"`
+	Ptr   *int             `json:"ptr"`
+	Inner struct {
+		N int `json:"n"`
+	} `json:"inner"`
+}
+
+type unsafeFieldsName string
+
+func (n *unsafeFieldsName) UnmarshalText(text []byte) error {
+	*n = unsafeFieldsName(strings.ToUpper(string(text)))
+	return nil
+}
+
+func TestUnsafeFieldSettersMatchReflection(t *testing.T) {
+	inputs := []string{
+		`{"s":"x","b":true,"i":-1,"i8":127,"i16":-300,"i32":70000,"i64":-9000000000,` +
+			`"u":1,"u8":255,"u16":65535,"u32":4000000000,"u64":18446744073709551615,` +
+			`"f32":1.5,"f64":-2e-300,"named":"abc","ptr":7,"inner":{"n":3}}`,
+		`class R: s,i,f64` + "\n\n" + `[R("a",1,2.5),R("b",2,null),R(null,3,4)]`,
+		`{"i8":128}`,
+		`{"u8":-1}`,
+		`{"i":1.5,"s":"kept"}`,
+		`{"i":"1","b":1,"s":2}`,
+		`{"f32":1e40}`,
+		`{"i":1e3}`,
+		`{"S":"case-insensitive","I":5}`,
+	}
+	for _, in := range inputs {
+		for _, slice := range []bool{false, true} {
+			var typ reflect.Type = reflect.TypeOf(unsafeFieldsRecord{})
+			if slice || strings.HasPrefix(in, "class") {
+				if !strings.HasPrefix(in, "class") {
+					in = "[" + in + "]"
+				}
+				typ = reflect.SliceOf(typ)
+			}
+			decode := func(unsafe bool) (interface{}, error) {
+				v := reflect.New(typ)
+				dec := NewDecoder(strings.NewReader(in))
+				if unsafe {
+					dec.UseUnsafeFieldSetters()
+				}
+				err := dec.Decode(v.Interface())
+				return v.Elem().Interface(), err
+			}
+			want, wantErr := decode(false)
+			got, err := decode(true)
+			if (err == nil) != (wantErr == nil) || err != nil && err.Error() != wantErr.Error() {
+				t.Errorf("Decode(%s) error = %v, want %v", in, err, wantErr)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Decode(%s) = %+v, want %+v", in, got, want)
+			}
+		}
+	}
+}
+
+func TestUnsafeFieldSettersReuseStruct(t *testing.T) {
+	r := unsafeFieldsRecord{S: "old", I: 9, U64: 4}
+	dec := NewDecoder(bytes.NewReader([]byte(`{"s":"new","i":1}`)))
+	dec.UseUnsafeFieldSetters()
+	if err := dec.Decode(&r); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if r.S != "new" || r.I != 1 || r.U64 != 4 {
+		t.Fatalf("Decode = %+v, want s and i replaced and u64 kept", r)
+	}
+}
+
+func TestUnsafeSetterForTypes(t *testing.T) {
+	for _, v := range []interface{}{"", false, 0, int8(0), uint64(0), float32(0), 0.0} {
+		if unsafeSetterFor(reflect.TypeOf(v)) == nil {
+			t.Errorf("no setter for %T", v)
+		}
+	}
+	for _, v := range []interface{}{unsafeFieldsName(""), new(int), []int{}, struct{}{}} {
+		if unsafeSetterFor(reflect.TypeOf(v)) != nil {
+			t.Errorf("unexpected setter for %T", v)
+		}
+	}
+}
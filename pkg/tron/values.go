@@ -0,0 +1,413 @@
+package tron
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"iter"
+	"reflect"
+)
+
+// Values returns an iterator over the elements of the top-level TRON array
+// read from dec, decoding each one into a new T as it is reached. Only the
+// class header and the current element are held in memory, so arbitrarily
+// long arrays of class instances can be processed in constant space. Limits
+// apply to the header and to each element separately.
+//
+// If the input is not an array, or an element cannot be read or decoded, the
+// iterator yields the error and stops. An empty input yields io.EOF. Values
+// reads dec's input to the end of the document, so dec should not be used
+// afterwards.
+//
+// Values is a function rather than a Decoder method because Go methods cannot
+// have type parameters.
+func Values[T any](dec *Decoder) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		s := &streamScanner{r: bufio.NewReader(dec.r), opts: dec.opts}
+
+		classes, err := s.readHeader()
+		if err == errNotArray {
+			err = &SyntaxError{msg: "expected top-level array"}
+		}
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		for {
+			elem, more, err := s.nextElement()
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if elem == nil {
+				return
+			}
+			var v T
+			if err := decodeElement(elem, classes, reflect.ValueOf(&v).Elem(), s.opts); err != nil {
+				yield(v, err)
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+			if !more {
+				if err := s.expectEnd(); err != nil {
+					yield(zero, err)
+				}
+				return
+			}
+		}
+	}
+}
+
+// More reports whether the top-level array read by dec has another element
+// to decode. The first call reads the class header and the opening bracket;
+// from then on each call to Decode decodes the next element rather than a
+// whole document, so that a long array can be processed element by element
+// in constant space, as with Values:
+//
+//	for dec.More() {
+//		var u User
+//		if err := dec.Decode(&u); err != nil {
+//			return err
+//		}
+//	}
+//
+// More reads the next element ahead of Decode. It returns false at the end
+// of the array, and if the input cannot be read as an array, in which case
+// the next call to Decode returns the error; after the end, Decode returns
+// io.EOF. More always returns false in line mode, where Decode reads one
+// value per line.
+func (dec *Decoder) More() bool {
+	if dec.lines != nil {
+		return false
+	}
+	a := dec.array
+	if a == nil {
+		a = &arrayStream{s: &streamScanner{r: bufio.NewReader(dec.r), opts: dec.opts}, more: true}
+		dec.array = a
+		a.classes, a.err = a.s.readHeader()
+		if a.err == errNotArray {
+			a.err = &SyntaxError{msg: "expected top-level array"}
+		}
+		a.done = a.err != nil
+	}
+	if !a.pending && !a.done {
+		a.next()
+	}
+	return a.pending
+}
+
+// DecodeArray reads the top-level array from dec's input and calls fn for
+// each element as it is reached. fn decodes the element by calling
+// dec.Decode once, or skips it by returning without doing so. As with More,
+// only the class header and the current element are held in memory, so that
+// aggregation jobs can process arrays too large to build as a slice:
+//
+//	var total float64
+//	err := dec.DecodeArray(func(dec *tron.Decoder) error {
+//		var o Order
+//		if err := dec.Decode(&o); err != nil {
+//			return err
+//		}
+//		total += o.Amount
+//		return nil
+//	})
+//
+// DecodeArray returns the first error fn returns, stopping there, or else
+// the error reading the array, if any. An empty input gives io.EOF. It
+// cannot be used in line mode.
+func (dec *Decoder) DecodeArray(fn func(dec *Decoder) error) error {
+	if dec.lines != nil {
+		return errors.New("tron: DecodeArray in line mode")
+	}
+	for dec.More() {
+		if err := fn(dec); err != nil {
+			return err
+		}
+		dec.array.pending = false
+	}
+	return dec.array.err
+}
+
+// arrayStream is the state of a Decoder reading the elements of a top-level
+// array one at a time, once More has been called.
+type arrayStream struct {
+	s       *streamScanner
+	classes map[string]*classDef
+	elem    []byte // the source of the next element, if pending
+	pending bool   // elem has been read but not decoded
+	more    bool   // another element follows the last one read
+	done    bool   // the array has ended, or reading it failed with err
+	err     error
+}
+
+// next reads the next element, or the end of the array.
+func (a *arrayStream) next() {
+	if !a.more {
+		a.err, a.done = a.s.expectEnd(), true
+		return
+	}
+	elem, more, err := a.s.nextElement()
+	switch {
+	case err != nil:
+		a.err, a.done = err, true
+	case elem == nil:
+		a.more = false
+		a.next()
+	default:
+		a.elem, a.pending, a.more = elem, true, more
+	}
+}
+
+// decode decodes the pending element into v, which More has read.
+func (a *arrayStream) decode(v interface{}, opts decodeOptions) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &InvalidUnmarshalError{Type: reflect.TypeOf(v)}
+	}
+	if !a.pending {
+		if a.err != nil {
+			return a.err
+		}
+		return io.EOF
+	}
+	a.pending = false
+	return decodeElement(a.elem, a.classes, rv.Elem(), opts)
+}
+
+// streamScanner splits TRON text read from a stream into pieces that can be
+// tokenized on their own: the header and elements of a top-level array, or
+// the newline-terminated records of a session stream. It only tracks strings, comments and bracket depth;
+// the elements themselves are checked by the parser.
+type streamScanner struct {
+	r    *bufio.Reader
+	opts decodeOptions
+	buf  []byte
+	seen bool // an element has been read
+}
+
+// errNotArray is returned by streamScanner.readHeader for documents whose
+// data is not an array.
+var errNotArray = errors.New("tron: expected top-level array")
+
+// readHeader reads up to and including the opening '[' of the array and
+// returns the classes defined before it. If the document turns out not to be
+// an array, it returns errNotArray and s.buf holds the input read so far,
+// minus comments.
+func (s *streamScanner) readHeader() (map[string]*classDef, error) {
+	s.buf = s.buf[:0]
+	blank := true
+	for {
+		c, err := s.r.ReadByte()
+		if err == io.EOF {
+			if blank {
+				return nil, io.EOF
+			}
+			return nil, errNotArray
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch c {
+		case '[':
+			return s.parseHeader()
+		case '"':
+			if err := s.readString(); err != nil {
+				return nil, err
+			}
+		case '#':
+			if err := s.skipComment(); err != nil {
+				return nil, err
+			}
+			continue
+		default:
+			s.buf = append(s.buf, c)
+		}
+		if !isSpace(c) {
+			blank = false
+		}
+		if err := s.checkSize(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseHeader parses the class definitions collected in s.buf, which must
+// be followed by nothing but the array.
+func (s *streamScanner) parseHeader() (map[string]*classDef, error) {
+	tokens, err := tokenizeWith(s.buf, s.opts)
+	if err != nil {
+		return nil, err
+	}
+	p := newParser(tokens)
+	p.opts = s.opts
+	if err := p.parseHeader(); err != nil {
+		return nil, err
+	}
+	if p.current().Type != TokenEOF {
+		s.buf = append(s.buf, '[')
+		return nil, errNotArray
+	}
+	return p.classes, nil
+}
+
+// nextElement returns the source of the next array element and whether
+// another element follows it. It returns a nil slice once the array is
+// closed.
+func (s *streamScanner) nextElement() (elem []byte, more bool, err error) {
+	s.buf = s.buf[:0]
+	depth := 0
+	for {
+		c, err := s.r.ReadByte()
+		if err == io.EOF {
+			return nil, false, &SyntaxError{msg: "unexpected end of input in array"}
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		switch c {
+		case '"':
+			if err := s.readString(); err != nil {
+				return nil, false, err
+			}
+			continue
+		case '#':
+			if err := s.skipComment(); err != nil {
+				return nil, false, err
+			}
+			continue
+		case '(', '[', '{':
+			depth++
+		case ')', '}':
+			depth--
+		case ']':
+			if depth == 0 {
+				// An empty array, or the end of one with a trailing comma.
+				if (!s.seen || s.opts.trailingCommas) && len(bytes.TrimSpace(s.buf)) == 0 {
+					return nil, false, nil
+				}
+				s.seen = true
+				return s.buf, false, nil
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				s.seen = true
+				return s.buf, true, nil
+			}
+		}
+		s.buf = append(s.buf, c)
+		if err := s.checkSize(); err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+// expectEnd reports an error if anything but whitespace and comments follows
+// the array.
+func (s *streamScanner) expectEnd() error {
+	for {
+		c, err := s.r.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if c == '#' {
+			if err := s.skipComment(); err != nil {
+				return err
+			}
+			continue
+		}
+		if !isSpace(c) {
+			return &SyntaxError{msg: "unexpected trailing tokens"}
+		}
+	}
+}
+
+// readString copies a string literal whose opening quote has just been read
+// into s.buf, quotes included.
+func (s *streamScanner) readString() error {
+	s.buf = append(s.buf, '"')
+	escaped := false
+	for {
+		c, err := s.r.ReadByte()
+		if err == io.EOF {
+			return &SyntaxError{msg: "unterminated string"}
+		}
+		if err != nil {
+			return err
+		}
+		s.buf = append(s.buf, c)
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '"':
+			return s.checkSize()
+		}
+	}
+}
+
+// skipComment discards a comment whose '#' has just been read, keeping the
+// newline that ends it.
+func (s *streamScanner) skipComment() error {
+	if s.opts.strictJSON {
+		return &SyntaxError{msg: "comments are not allowed in JSON"}
+	}
+	for {
+		c, err := s.r.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if c == '\n' {
+			s.buf = append(s.buf, c)
+			return nil
+		}
+	}
+}
+
+// checkSize enforces MaxInputBytes on the header or element being read.
+func (s *streamScanner) checkSize() error {
+	if len(s.buf) > s.opts.limits.withDefaults().MaxInputBytes {
+		return &SyntaxError{msg: "input too large", err: ErrTooLarge}
+	}
+	return nil
+}
+
+// decodeElement decodes the source of one array element into dst, resolving
+// class instantiations against classes.
+func decodeElement(elem []byte, classes map[string]*classDef, dst reflect.Value, opts decodeOptions) error {
+	p, err := newDocumentParser(elem, opts)
+	if err != nil {
+		return err
+	}
+	defer p.release()
+	p.classes = classes
+	d := &decoder{decodeOptions: opts, classes: classes}
+
+	p.skipNewlines()
+	// Elements of the root array sit at depth 2, as in parseValue.
+	err = d.decodeDirect(p, dst, 2)
+	if err != nil && isFatal(err) {
+		return err
+	}
+	p.skipNewlines()
+	if p.current().Type != TokenEOF {
+		return p.syntaxError("expected ',' or ']' after array element")
+	}
+	return joinErrors(err)
+}
+
+// isSpace reports whether c is TRON whitespace, newlines included.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
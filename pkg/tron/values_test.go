@@ -0,0 +1,123 @@
+package tron
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+type valuesRow struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func collectValues[T any](t *testing.T, input string) ([]T, error) {
+	t.Helper()
+	var got []T
+	for v, err := range Values[T](NewDecoder(strings.NewReader(input))) {
+		if err != nil {
+			return got, err
+		}
+		got = append(got, v)
+	}
+	return got, nil
+}
+
+func TestValuesDecodesElementsOneAtATime(t *testing.T) {
+	input := "# rows\nclass R: id,name\n\n[R(1,\"a, [b]\"), # first\n R(2,\"\\\"c\\\"\"),\n {\"id\":3,\"name\":\"d\"}]\n"
+	got, err := collectValues[valuesRow](t, input)
+	if err != nil {
+		t.Fatalf("Values: %v", err)
+	}
+	want := []valuesRow{{1, "a, [b]"}, {2, `"c"`}, {3, "d"}}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("element %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestValuesMatchesUnmarshal(t *testing.T) {
+	input := `[[1, 2], {"a": [3]}, null, "s", 4.5]`
+	var want []interface{}
+	if err := Unmarshal([]byte(input), &want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := collectValues[interface{}](t, input)
+	if err != nil {
+		t.Fatalf("Values: %v", err)
+	}
+	if len(got) != len(want) || got[0].([]interface{})[1] != 2.0 || got[1].(map[string]interface{})["a"] == nil || got[2] != nil || got[4] != 4.5 {
+		t.Fatalf("Values = %#v, want %#v", got, want)
+	}
+}
+
+func TestValuesEmptyArrayAndInput(t *testing.T) {
+	got, err := collectValues[int](t, "class A: x,y\n\n[ ]")
+	if err != nil || len(got) != 0 {
+		t.Fatalf("empty array: %v, %v", got, err)
+	}
+	if _, err := collectValues[int](t, " \n"); err != io.EOF {
+		t.Fatalf("empty input: expected io.EOF, got %v", err)
+	}
+}
+
+func TestValuesStopsEarly(t *testing.T) {
+	n := 0
+	for v, err := range Values[int](NewDecoder(strings.NewReader("[1,2,3"))) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		n += v
+		if v == 2 {
+			break
+		}
+	}
+	if n != 3 {
+		t.Fatalf("sum = %d, want 3", n)
+	}
+}
+
+func TestValuesErrors(t *testing.T) {
+	tests := []string{
+		`{"a": 1}`,
+		`a: [1]`,
+		`[1, 2`,
+		`[1 2]`,
+		`[1,,2]`,
+		`[1] 2`,
+		`["abc`,
+		`[B(1)]`,
+	}
+	for _, input := range tests {
+		var syntaxErr *SyntaxError
+		if _, err := collectValues[interface{}](t, input); !errors.As(err, &syntaxErr) {
+			t.Errorf("%q: expected *SyntaxError, got %v", input, err)
+		}
+	}
+
+	got, err := collectValues[int](t, `[1, "x", 3]`)
+	var typeErr *UnmarshalTypeError
+	if !errors.As(err, &typeErr) || len(got) != 1 {
+		t.Fatalf("expected type error after one element, got %v, %v", got, err)
+	}
+}
+
+func TestValuesEnforcesElementLimits(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`[1, [1,2,3,4,5,6,7,8,9,10], 2]`))
+	dec.SetLimits(Limits{MaxTokens: 10})
+	var err error
+	for _, err = range Values[interface{}](dec) {
+		if err != nil {
+			break
+		}
+	}
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected token limit error, got %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+// Package tronlint checks TRON documents for constructs that are valid but
+// usually unintended or wasteful, so that teams can enforce a style in their
+// CI pipelines.
+//
+// Findings locate values by path, such as $.users[2].id, and classes by
+// name, since both stay meaningful however the document is formatted.
+package tronlint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tron-format/trongo/pkg/tron"
+)
+
+// A Rule identifies one kind of finding.
+type Rule string
+
+// The rules Lint checks.
+const (
+	// DuplicateKey reports an object, or a class definition, that has the
+	// same key twice. Decoders keep only one of the values.
+	DuplicateKey Rule = "duplicate-key"
+	// UnusedClass reports a class that is defined but never instantiated.
+	UnusedClass Rule = "unused-class"
+	// SingleUseClass reports a class that is instantiated only once, where
+	// an object literal would be shorter than the definition.
+	SingleUseClass Rule = "single-use-class"
+	// NumericString reports a string whose content is a number, which often
+	// means a number was quoted by mistake.
+	NumericString Rule = "numeric-string"
+	// DeepNesting reports values nested more deeply than Options.MaxDepth.
+	DeepNesting Rule = "deep-nesting"
+)
+
+// AllRules lists every rule, in the order findings are reported.
+var AllRules = []Rule{DuplicateKey, UnusedClass, SingleUseClass, NumericString, DeepNesting}
+
+// DefaultMaxDepth is the nesting depth DeepNesting allows by default.
+const DefaultMaxDepth = 8
+
+// A Finding is one problem found in a document.
+type Finding struct {
+	Rule    Rule
+	Path    string // the value or class the finding is about
+	Message string
+}
+
+// String formats f as "path: message (rule)".
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s (%s)", f.Path, f.Message, f.Rule)
+}
+
+// Options configures Lint.
+type Options struct {
+	// Rules are the rules to check. Nil means AllRules.
+	Rules []Rule
+	// MaxDepth is the deepest nesting DeepNesting allows, counting the root
+	// value as depth 0. Zero means DefaultMaxDepth.
+	MaxDepth int
+}
+
+// Lint checks the TRON document data and returns its findings, class
+// findings first and then value findings in document order. A nil opts
+// checks every rule with the defaults. Lint returns an error if data is not
+// a valid TRON document.
+func Lint(data []byte, opts *Options) ([]Finding, error) {
+	l := &linter{rules: AllRules, maxDepth: DefaultMaxDepth}
+	if opts != nil {
+		if opts.Rules != nil {
+			l.rules = opts.Rules
+		}
+		if opts.MaxDepth > 0 {
+			l.maxDepth = opts.MaxDepth
+		}
+	}
+
+	classes, err := tron.ExtractClasses(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range classes {
+		l.checkClass(c)
+	}
+
+	jsonData, err := tron.ToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(jsonData))
+	dec.UseNumber()
+	if err := l.walk(dec, "$", 0, false); err != nil {
+		return nil, err
+	}
+	return l.findings, nil
+}
+
+type linter struct {
+	rules    []Rule
+	maxDepth int
+	findings []Finding
+}
+
+func (l *linter) report(rule Rule, path, format string, args ...any) {
+	if slices.Contains(l.rules, rule) {
+		l.findings = append(l.findings, Finding{Rule: rule, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+func (l *linter) checkClass(c tron.ClassUsage) {
+	path := "class " + c.Name
+	seen := make(map[string]bool, len(c.Keys))
+	for _, key := range c.Keys {
+		if seen[key] {
+			l.report(DuplicateKey, path, "property %q is listed more than once", key)
+		}
+		seen[key] = true
+	}
+	switch c.Uses {
+	case 0:
+		l.report(UnusedClass, path, "class is never used")
+	case 1:
+		l.report(SingleUseClass, path, "class is used only once; an object literal would be shorter")
+	}
+}
+
+// walk reads one value from dec, at the given path and depth, and checks it.
+// Below a value already reported as too deep, nesting is not reported again.
+func (l *linter) walk(dec *json.Decoder, path string, depth int, tooDeep bool) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if !tooDeep && depth > l.maxDepth {
+		l.report(DeepNesting, path, "value is nested %d levels deep, more than %d", depth, l.maxDepth)
+		tooDeep = true
+	}
+
+	switch tok {
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			if err := l.walk(dec, path+"["+strconv.Itoa(i)+"]", depth+1, tooDeep); err != nil {
+				return err
+			}
+		}
+	case json.Delim('{'):
+		seen := make(map[string]bool)
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key := keyTok.(string)
+			child := childPath(path, key)
+			if seen[key] {
+				l.report(DuplicateKey, child, "key %q appears more than once in the object", key)
+			}
+			seen[key] = true
+			if err := l.walk(dec, child, depth+1, tooDeep); err != nil {
+				return err
+			}
+		}
+	case nil, true, false:
+		return nil
+	default:
+		if s, ok := tok.(string); ok && isNumber(s) {
+			l.report(NumericString, path, "string %q holds a number", s)
+		}
+		return nil
+	}
+	_, err = dec.Token() // closing delimiter
+	return err
+}
+
+// childPath returns the path of member key of the object at path.
+func childPath(path, key string) string {
+	if isPlainKey(key) {
+		return path + "." + key
+	}
+	return path + "[" + strconv.Quote(key) + "]"
+}
+
+// isPlainKey reports whether key can follow a dot in a path.
+func isPlainKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, c := range key {
+		if !(c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// isNumber reports whether s is exactly a number in JSON syntax, so that
+// "42" and "-1.5e3" count but "007", "1,000", "0x10" and " 42" do not.
+func isNumber(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	switch {
+	case s == "":
+		return false
+	case s[0] == '0':
+		s = s[1:]
+	case '1' <= s[0] && s[0] <= '9':
+		s = trimDigits(s)
+	default:
+		return false
+	}
+	if rest, ok := strings.CutPrefix(s, "."); ok {
+		if s = trimDigits(rest); len(s) == len(rest) {
+			return false
+		}
+	}
+	if s != "" && (s[0] == 'e' || s[0] == 'E') {
+		rest := s[1:]
+		if rest != "" && (rest[0] == '+' || rest[0] == '-') {
+			rest = rest[1:]
+		}
+		if s = trimDigits(rest); len(s) == len(rest) {
+			return false
+		}
+	}
+	return s == ""
+}
+
+// trimDigits returns s without its leading decimal digits.
+func trimDigits(s string) string {
+	return strings.TrimLeft(s, "0123456789")
+}
@@ -0,0 +1,75 @@
+package tronlint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	data := []byte(`class A: id,name
+class B: x,y
+class C: a,a
+
+{
+  "users": [A(1, "ann"), A("2", "bob")],
+  "origin": B(0, 0),
+  "n": {"k": 1, "k": 2},
+  "zip": "007",
+  "deep": [[[1]]]
+}
+`)
+	got, err := Lint(data, &Options{MaxDepth: 3})
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+	want := []Finding{
+		{SingleUseClass, "class B", "class is used only once; an object literal would be shorter"},
+		{DuplicateKey, "class C", `property "a" is listed more than once`},
+		{UnusedClass, "class C", "class is never used"},
+		{NumericString, "$.users[1].id", `string "2" holds a number`},
+		{DuplicateKey, "$.n.k", `key "k" appears more than once in the object`},
+		{DeepNesting, "$.deep[0][0][0]", "value is nested 4 levels deep, more than 3"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestLintSelectedRules(t *testing.T) {
+	data := []byte(`class A: x,y
+
+{"a b": A(1, "2")}`)
+	got, err := Lint(data, &Options{Rules: []Rule{NumericString}})
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+	want := []Finding{{NumericString, `$["a b"].y`, `string "2" holds a number`}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if s := got[0].String(); s != `$["a b"].y: string "2" holds a number (numeric-string)` {
+		t.Fatalf("String() = %q", s)
+	}
+}
+
+func TestLintInvalid(t *testing.T) {
+	if _, err := Lint([]byte("[1,"), nil); err == nil {
+		t.Fatal("expected error")
+	}
+	got, err := Lint([]byte(`{"a": [1, 2]}`), nil)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("got %v, %v", got, err)
+	}
+}
+
+func TestIsNumber(t *testing.T) {
+	for s, want := range map[string]bool{
+		"42": true, "-1.5e3": true, "0": true, "1E+2": true, "0.5": true,
+		"": false, "-": false, "007": false, "1,000": false, "0x10": false,
+		"\n42": false, "42\t": false, " 42": false, "1.": false, ".5": false, "1e": false, "1e+": false,
+	} {
+		if got := isNumber(s); got != want {
+			t.Errorf("isNumber(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
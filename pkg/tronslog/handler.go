@@ -0,0 +1,344 @@
+// Package tronslog provides a log/slog Handler that writes records as TRON.
+//
+// Each record is written on its own line, like slog.JSONHandler, but the
+// keys of a record are declared once as a class and later records of the
+// same shape are written as instantiations of it:
+//
+//	class A: time,level,msg,user
+//	A("2025-01-02T15:04:05Z","INFO","login","ann")
+//	A("2025-01-02T15:04:06Z","INFO","login","bob")
+//
+// Since most log statements produce records of a handful of shapes, this
+// removes the repeated keys that make up much of a JSON log. The output can
+// be read back with tron.NewSessionDecoder.
+package tronslog
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/tron-format/trongo/pkg/tron"
+)
+
+// Handler is a slog.Handler that writes records to an io.Writer as lines of
+// a TRON session stream, defining a class for every record shape the first
+// time it is logged.
+type Handler struct {
+	opts   slog.HandlerOptions
+	out    *output
+	groups []string // groups opened by WithGroup
+	scopes []fields // attrs added by WithAttrs: scopes[0] at the top level, scopes[i] in groups[i-1]
+}
+
+// output is the destination shared by a Handler and those derived from it.
+type output struct {
+	mu      sync.Mutex
+	w       io.Writer
+	classes map[string]string // record keys, joined by NUL -> class name
+	buf     []byte
+}
+
+// fields are encoded attrs in order.
+type fields struct {
+	keys []string
+	vals [][]byte
+}
+
+// clip returns f with its capacity limited to its length, so that appending
+// to it never writes to memory that other handlers share.
+func (f fields) clip() fields {
+	return fields{keys: slices.Clip(f.keys), vals: slices.Clip(f.vals)}
+}
+
+// NewHandler returns a Handler that writes to w, using the given options.
+// A nil opts is the same as the zero slog.HandlerOptions.
+func NewHandler(w io.Writer, opts *slog.HandlerOptions) *Handler {
+	h := &Handler{
+		out:    &output{w: w, classes: make(map[string]string)},
+		scopes: make([]fields, 1),
+	}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+// WithAttrs returns a new Handler whose records include attrs, within the
+// groups opened so far.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := h.clone()
+	last := &h2.scopes[len(h2.scopes)-1]
+	for _, a := range attrs {
+		h2.appendAttr(last, a, h2.groups)
+	}
+	return h2
+}
+
+// WithGroup returns a new Handler that puts the attrs added later under the
+// key name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := h.clone()
+	h2.groups = append(h2.groups, name)
+	h2.scopes = append(h2.scopes, fields{})
+	return h2
+}
+
+// clone returns a copy of h whose groups and scopes can be appended to
+// without affecting h.
+func (h *Handler) clone() *Handler {
+	h2 := *h
+	h2.groups = slices.Clip(h.groups)
+	h2.scopes = slices.Clone(h.scopes)
+	for i := range h2.scopes {
+		h2.scopes[i] = h2.scopes[i].clip()
+	}
+	return &h2
+}
+
+// Handle writes r as one line. If its shape has not been logged before, the
+// line is preceded by the definition of its class.
+//
+// The time, level, source and message are written as slog.JSONHandler writes
+// them, under the same keys. Attribute values of kind Any are encoded with
+// encoding/json, errors as their message; since JSON is valid TRON, any value
+// JSONHandler can log can be logged here.
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	var top fields
+	if !r.Time.IsZero() {
+		h.appendAttr(&top, slog.Time(slog.TimeKey, r.Time), nil)
+	}
+	h.appendAttr(&top, slog.Any(slog.LevelKey, r.Level), nil)
+	if h.opts.AddSource {
+		if src := r.Source(); src != nil {
+			h.appendAttr(&top, slog.Any(slog.SourceKey, src), nil)
+		}
+	}
+	h.appendAttr(&top, slog.String(slog.MessageKey, r.Message), nil)
+
+	// Record attrs go in the innermost group; each group then becomes a
+	// field of the one around it, and is left out if it is empty.
+	n := len(h.scopes) - 1
+	inner := h.scopes[n].clip()
+	r.Attrs(func(a slog.Attr) bool {
+		h.appendAttr(&inner, a, h.groups)
+		return true
+	})
+	for i := n; i > 0; i-- {
+		outer := h.scopes[i-1].clip()
+		if len(inner.keys) > 0 {
+			outer.keys = append(outer.keys, h.groups[i-1])
+			outer.vals = append(outer.vals, appendObject(nil, inner))
+		}
+		inner = outer
+	}
+	top.keys = append(top.keys, inner.keys...)
+	top.vals = append(top.vals, inner.vals...)
+
+	return h.out.write(top)
+}
+
+// appendAttr resolves a, applies ReplaceAttr to it and appends it to f.
+func (h *Handler) appendAttr(f *fields, a slog.Attr, groups []string) {
+	a.Value = a.Value.Resolve()
+	if h.opts.ReplaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if a.Key == "" {
+			// Groups without a key are inlined.
+			for _, ga := range attrs {
+				h.appendAttr(f, ga, groups)
+			}
+			return
+		}
+		var members fields
+		groups = append(slices.Clip(groups), a.Key)
+		for _, ga := range attrs {
+			h.appendAttr(&members, ga, groups)
+		}
+		if len(members.keys) > 0 {
+			f.keys = append(f.keys, a.Key)
+			f.vals = append(f.vals, appendObject(nil, members))
+		}
+		return
+	}
+
+	f.keys = append(f.keys, a.Key)
+	f.vals = append(f.vals, appendValue(nil, a.Value))
+}
+
+// appendValue appends the TRON encoding of the resolved, non-group value v.
+func appendValue(dst []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return tron.AppendString(dst, v.String())
+	case slog.KindInt64:
+		return strconv.AppendInt(dst, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(dst, v.Uint64(), 10)
+	case slog.KindFloat64:
+		f := v.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			// TRON, like JSON, has no literal for these.
+			return tron.AppendString(dst, strconv.FormatFloat(f, 'g', -1, 64))
+		}
+		return strconv.AppendFloat(dst, f, 'g', -1, 64)
+	case slog.KindBool:
+		return strconv.AppendBool(dst, v.Bool())
+	case slog.KindDuration:
+		return strconv.AppendInt(dst, int64(v.Duration()), 10)
+	case slog.KindTime:
+		return tron.AppendString(dst, v.Time().Format(time.RFC3339Nano))
+	}
+
+	switch x := v.Any().(type) {
+	case slog.Level:
+		return tron.AppendString(dst, x.String())
+	case error:
+		return tron.AppendString(dst, x.Error())
+	}
+	data, err := json.Marshal(v.Any())
+	if err != nil {
+		return tron.AppendString(dst, "!ERROR:"+err.Error())
+	}
+	return append(dst, data...)
+}
+
+// appendObject appends f as a TRON object.
+func appendObject(dst []byte, f fields) []byte {
+	dst = append(dst, '{')
+	for i, key := range f.keys {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = tron.AppendString(dst, key)
+		dst = append(dst, ':')
+		dst = append(dst, f.vals[i]...)
+	}
+	return append(dst, '}')
+}
+
+// maxClasses is how many record shapes get a class. It matches the default
+// limit of tron decoders; records of further shapes are written as objects.
+var maxClasses = tron.DefaultLimits().MaxClasses
+
+// write writes the record with the given top-level fields as one line.
+func (o *output) write(f fields) error {
+	sig := strings.Join(f.keys, "\x00")
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	buf := o.buf[:0]
+	name, ok := o.classes[sig]
+	if !ok && len(o.classes) < maxClasses && !hasDuplicates(f.keys) {
+		name = className(len(o.classes))
+		o.classes[sig] = name
+		buf = appendClassDef(buf, name, f.keys)
+		ok = true
+	}
+	if ok {
+		buf = append(buf, name...)
+		buf = append(buf, '(')
+		for i, val := range f.vals {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = append(buf, val...)
+		}
+		buf = append(buf, ')')
+	} else {
+		buf = appendObject(buf, f)
+	}
+	buf = append(buf, '\n')
+	o.buf = buf
+
+	_, err := o.w.Write(buf)
+	return err
+}
+
+// appendClassDef appends the definition of class name with the given keys.
+func appendClassDef(dst []byte, name string, keys []string) []byte {
+	dst = append(dst, "class "...)
+	dst = append(dst, name...)
+	dst = append(dst, ": "...)
+	for i, key := range keys {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		if isIdentifier(key) {
+			dst = append(dst, key...)
+		} else {
+			dst = tron.AppendString(dst, key)
+		}
+	}
+	return append(dst, '\n')
+}
+
+// className returns the name of the class with the given index, following
+// tron.Marshal: A through Z, then A1 through Z1, and so on.
+func className(index int) string {
+	name := string(rune('A' + index%26))
+	if index >= 26 {
+		name += strconv.Itoa(index / 26)
+	}
+	return name
+}
+
+// isIdentifier reports whether key can be written in a class definition
+// without quotes.
+func isIdentifier(key string) bool {
+	switch key {
+	case "", "class", "true", "false", "null":
+		return false
+	}
+	for i, r := range key {
+		if !(unicode.IsLetter(r) || r == '_' || i > 0 && (unicode.IsDigit(r) || unicode.IsMark(r))) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasDuplicates reports whether keys holds a key more than once, which a
+// class cannot express.
+func hasDuplicates(keys []string) bool {
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key] {
+			return true
+		}
+		seen[key] = true
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package tronslog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/tron-format/trongo/pkg/tron"
+)
+
+func decodeRecords(t *testing.T, data []byte) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	dec := tron.NewSessionDecoder(bytes.NewReader(data))
+	for {
+		var m map[string]any
+		err := dec.Decode(&m)
+		if err == io.EOF {
+			return records
+		}
+		if err != nil {
+			t.Fatalf("decoding %q: %v", data, err)
+		}
+		records = append(records, m)
+	}
+}
+
+func TestSlogtest(t *testing.T) {
+	var buf bytes.Buffer
+	err := slogtest.TestHandler(NewHandler(&buf, nil), func() []map[string]any {
+		return decodeRecords(t, buf.Bytes())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandlerDefinesClassOncePerShape(t *testing.T) {
+	var buf bytes.Buffer
+	removeTime := func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey && len(groups) == 0 {
+			return slog.Attr{}
+		}
+		return a
+	}
+	logger := slog.New(NewHandler(&buf, &slog.HandlerOptions{ReplaceAttr: removeTime}))
+	logger.Info("login", "user", "ann")
+	logger.Info("login", "user", "bob")
+	logger.Warn("slow", "took", 2*time.Second, "err", errors.New("timeout"))
+	logger.With("req", 7).WithGroup("db").Info("query", "rows", 3)
+
+	want := "class A: level,msg,user\n" +
+		"A(\"INFO\",\"login\",\"ann\")\n" +
+		"A(\"INFO\",\"login\",\"bob\")\n" +
+		"class B: level,msg,took,err\n" +
+		"B(\"WARN\",\"slow\",2000000000,\"timeout\")\n" +
+		"class C: level,msg,req,db\n" +
+		"C(\"INFO\",\"query\",7,{\"rows\":3})\n"
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestHandlerWritesObjectsForDuplicateKeys(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(NewHandler(&buf, nil)).Info("dup", "msg", "again")
+	if !strings.Contains(buf.String(), `"msg":"dup","msg":"again"}`) || strings.Contains(buf.String(), "class") {
+		t.Fatalf("got %q", buf.String())
+	}
+}
+
+func TestHandlerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	logger.Info("hidden")
+	logger.Error("shown")
+	if records := decodeRecords(t, buf.Bytes()); len(records) != 1 || records[0]["msg"] != "shown" {
+		t.Fatalf("got %v", records)
+	}
+}
@@ -0,0 +1,333 @@
+// Package tronyaml converts between YAML and TRON documents.
+//
+// It lives apart from package tron so that only programs which need YAML
+// depend on a YAML parser. Conversion goes through the JSON data model:
+// mappings become objects, sequences become arrays and scalars are resolved
+// by their YAML tags, so repeated mapping shapes become TRON classes exactly
+// as repeated JSON object shapes do in tron.FromJSON.
+package tronyaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tron-format/trongo/pkg/tron"
+)
+
+// maxDepth bounds the nesting of a YAML document, which also stops aliases
+// that refer to their own ancestors.
+const maxDepth = 10000
+
+// Aliases and merge keys expand to the nodes they refer to each time they
+// are used, so that a small document can describe an exponentially large one
+// ("billion laughs"). Conversion visits at most expansionRatio times as many
+// nodes as the document holds, plus minExpansion, and fails beyond that.
+const (
+	expansionRatio = 10
+	minExpansion   = 10000
+)
+
+// errExpansion is returned for documents whose aliases expand too far.
+var errExpansion = errors.New("tronyaml: document expands too much through aliases")
+
+// ToTRON converts the first document in yamlData to TRON.
+//
+// Mapping keys must be scalars and keep their order. Merge keys ("<<") are
+// expanded, with keys written in the mapping itself taking precedence.
+// Integers and floats become TRON numbers, booleans and nulls become TRON
+// literals, and all other scalars, timestamps and binary data included,
+// become strings. Infinities and NaN cannot be represented and are
+// reported as errors, as are documents whose aliases expand them to many
+// times their size.
+func ToTRON(yamlData []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(yamlData, &doc); err != nil {
+		return nil, err
+	}
+	c := &converter{budget: expansionRatio*countNodes(&doc) + minExpansion}
+	jsonData, err := c.appendJSON(nil, &doc, 0)
+	if err != nil {
+		return nil, err
+	}
+	return tron.FromJSON(jsonData)
+}
+
+// ToYAML converts a TRON document to YAML, expanding class instantiations
+// into mappings. Object members keep their order, and strings that would read
+// as other YAML types are quoted.
+func ToYAML(tronData []byte) ([]byte, error) {
+	jsonData, err := tron.ToJSON(tronData)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(jsonData))
+	dec.UseNumber()
+	root, err := decodeNode(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// countNodes returns the number of nodes in the document rooted at n, not
+// following aliases.
+func countNodes(n *yaml.Node) int {
+	count := 1
+	for _, child := range n.Content {
+		count += countNodes(child)
+	}
+	return count
+}
+
+// A converter converts a YAML node tree to JSON.
+type converter struct {
+	budget int // nodes that may still be visited
+}
+
+// visit charges one node against c's budget.
+func (c *converter) visit() error {
+	c.budget--
+	if c.budget < 0 {
+		return errExpansion
+	}
+	return nil
+}
+
+// appendJSON appends the JSON encoding of n to out.
+func (c *converter) appendJSON(out []byte, n *yaml.Node, depth int) ([]byte, error) {
+	if depth > maxDepth {
+		return nil, errors.New("tronyaml: maximum depth exceeded")
+	}
+	if err := c.visit(); err != nil {
+		return nil, err
+	}
+
+	switch n.Kind {
+	case 0, yaml.DocumentNode:
+		// Empty input leaves the document node zero.
+		if len(n.Content) == 0 {
+			return append(out, "null"...), nil
+		}
+		return c.appendJSON(out, n.Content[0], depth+1)
+	case yaml.AliasNode:
+		return c.appendJSON(out, n.Alias, depth+1)
+	case yaml.SequenceNode:
+		out = append(out, '[')
+		for i, item := range n.Content {
+			if i > 0 {
+				out = append(out, ',')
+			}
+			var err error
+			if out, err = c.appendJSON(out, item, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return append(out, ']'), nil
+	case yaml.MappingNode:
+		keys, values, err := c.mappingPairs(n, depth)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, '{')
+		for i, key := range keys {
+			if i > 0 {
+				out = append(out, ',')
+			}
+			out = tron.AppendString(out, key)
+			out = append(out, ':')
+			if out, err = c.appendJSON(out, values[i], depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return append(out, '}'), nil
+	case yaml.ScalarNode:
+		return appendScalar(out, n)
+	}
+	return nil, fmt.Errorf("tronyaml: line %d: unsupported node kind %d", n.Line, n.Kind)
+}
+
+// mappingPairs returns the keys of mapping n in order with their values,
+// expanding merge keys.
+func (c *converter) mappingPairs(n *yaml.Node, depth int) ([]string, []*yaml.Node, error) {
+	if depth > maxDepth {
+		return nil, nil, errors.New("tronyaml: maximum depth exceeded")
+	}
+	var keys []string
+	var values []*yaml.Node
+	index := make(map[string]int)
+	explicit := make(map[string]bool)
+
+	set := func(key string, value *yaml.Node, merged bool) {
+		if i, ok := index[key]; ok {
+			if !merged {
+				values[i] = value
+			}
+			return
+		}
+		index[key] = len(keys)
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k := resolveAlias(n.Content[i])
+		if k.Kind == yaml.ScalarNode && k.ShortTag() != "!!merge" {
+			explicit[k.Value] = true
+		}
+	}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if err := c.visit(); err != nil {
+			return nil, nil, err
+		}
+		k, v := resolveAlias(n.Content[i]), n.Content[i+1]
+		if k.Kind != yaml.ScalarNode {
+			return nil, nil, fmt.Errorf("tronyaml: line %d: mapping keys must be scalars", k.Line)
+		}
+		if k.ShortTag() != "!!merge" {
+			set(k.Value, v, false)
+			continue
+		}
+
+		// A merge key takes a mapping or a sequence of mappings.
+		sources := []*yaml.Node{resolveAlias(v)}
+		if sources[0].Kind == yaml.SequenceNode {
+			sources = sources[0].Content
+		}
+		for _, src := range sources {
+			src = resolveAlias(src)
+			if src.Kind != yaml.MappingNode {
+				return nil, nil, fmt.Errorf("tronyaml: line %d: merge key needs a mapping", src.Line)
+			}
+			mk, mv, err := c.mappingPairs(src, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			for j, key := range mk {
+				if !explicit[key] {
+					set(key, mv[j], true)
+				}
+			}
+		}
+	}
+	return keys, values, nil
+}
+
+// resolveAlias returns the node an alias refers to, or n itself.
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	return n
+}
+
+// appendScalar appends the JSON encoding of the scalar n to out, keeping the
+// text of numbers that are already valid JSON.
+func appendScalar(out []byte, n *yaml.Node) ([]byte, error) {
+	switch n.ShortTag() {
+	case "!!null":
+		return append(out, "null"...), nil
+	case "!!bool":
+		var b bool
+		if err := n.Decode(&b); err != nil {
+			return nil, err
+		}
+		return strconv.AppendBool(out, b), nil
+	case "!!int", "!!float":
+		if isJSONNumber(n.Value) {
+			return append(out, n.Value...), nil
+		}
+		var v interface{}
+		if err := n.Decode(&v); err != nil {
+			return nil, err
+		}
+		switch v := v.(type) {
+		case int:
+			return strconv.AppendInt(out, int64(v), 10), nil
+		case int64:
+			return strconv.AppendInt(out, v, 10), nil
+		case uint64:
+			return strconv.AppendUint(out, v, 10), nil
+		case float64:
+			if math.IsInf(v, 0) || math.IsNaN(v) {
+				return nil, fmt.Errorf("tronyaml: line %d: %s cannot be represented in TRON", n.Line, n.Value)
+			}
+			return strconv.AppendFloat(out, v, 'g', -1, 64), nil
+		}
+		return nil, fmt.Errorf("tronyaml: line %d: invalid number %s", n.Line, n.Value)
+	}
+	return tron.AppendString(out, n.Value), nil
+}
+
+// isJSONNumber reports whether s is a number literal in JSON syntax.
+func isJSONNumber(s string) bool {
+	return s != "" && (s[0] == '-' || '0' <= s[0] && s[0] <= '9') && json.Valid([]byte(s))
+}
+
+// decodeNode reads one JSON value from dec as a YAML node.
+func decodeNode(dec *json.Decoder) (*yaml.Node, error) {
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if t == '{' {
+			n = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		for dec.More() {
+			if n.Kind == yaml.MappingNode {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)})
+			}
+			item, err := decodeNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, item)
+		}
+		// Consume the closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: t}, nil
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(string(t), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: string(t)}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(t)}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
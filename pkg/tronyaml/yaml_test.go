@@ -0,0 +1,107 @@
+package tronyaml
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestToTRON(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"empty", "", "null"},
+		{"scalars", "[1, 1.50, 0x1F, 1_000, .5, true, no, null, ~, \"007\", 2024-01-02, hello]",
+			`[1,1.50,31,1000,0.5,true,"no",null,null,"007","2024-01-02","hello"]`},
+		{"ordered keys", "b: 1\na: {z: 2, y: 3}\n", `{"b":1,"a":{"z":2,"y":3}}`},
+		{"classes", "users:\n  - name: ann\n    age: 30\n  - age: 40\n    name: bo\n",
+			"class A: name,age\n\n" + `{"users":[A("ann",30),A("bo",40)]}`},
+		{"anchors and merge", "base: &b {x: 1, y: 2}\nitem:\n  <<: *b\n  y: 3\n  z: 4\n",
+			`{"base":{"x":1,"y":2},"item":{"x":1,"y":3,"z":4}}`},
+		{"merge list", "a: &a {x: 1}\nb: &b {x: 2, w: 0}\nc: {<<: [*a, *b]}\n",
+			"class A: x,w\n\n" + `{"a":{"x":1},"b":A(2,0),"c":A(1,0)}`},
+		{"numeric keys", "1: one\ntrue: yes\n", `{"1":"one","true":"yes"}`},
+	}
+	for _, tt := range tests {
+		got, err := ToTRON([]byte(tt.in))
+		if err != nil {
+			t.Errorf("%s: ToTRON: %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: ToTRON =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestToTRONErrors(t *testing.T) {
+	for _, in := range []string{"a: .inf", "[.nan]", "? [a]\n: 1\n", "a: [", "<<: 1"} {
+		if out, err := ToTRON([]byte(in)); err == nil {
+			t.Errorf("ToTRON(%q) = %s, want error", in, out)
+		}
+	}
+}
+
+func TestToTRONLimitsAliasExpansion(t *testing.T) {
+	laughs := `a: &a ["lol","lol","lol","lol","lol","lol","lol","lol","lol"]
+b: &b [*a,*a,*a,*a,*a,*a,*a,*a,*a]
+c: &c [*b,*b,*b,*b,*b,*b,*b,*b,*b]
+d: &d [*c,*c,*c,*c,*c,*c,*c,*c,*c]
+e: &e [*d,*d,*d,*d,*d,*d,*d,*d,*d]
+f: &f [*e,*e,*e,*e,*e,*e,*e,*e,*e]
+g: &g [*f,*f,*f,*f,*f,*f,*f,*f,*f]
+`
+	var merges strings.Builder
+	merges.WriteString("m0: &m0 {x: 1}\n")
+	for i := 1; i < 40; i++ {
+		fmt.Fprintf(&merges, "m%d: &m%d {<<: [*m%d, *m%d]}\n", i, i, i-1, i-1)
+	}
+	for _, in := range []string{laughs, merges.String()} {
+		if _, err := ToTRON([]byte(in)); !errors.Is(err, errExpansion) {
+			t.Errorf("ToTRON(%.40q...) error = %v, want %v", in, err, errExpansion)
+		}
+	}
+
+	// Moderate reuse stays within the budget.
+	if _, err := ToTRON([]byte(strings.Join(strings.SplitAfter(laughs, "\n")[:3], ""))); err != nil {
+		t.Errorf("ToTRON: %v", err)
+	}
+}
+
+func TestToYAML(t *testing.T) {
+	in := "class A: name,age\n\n" + `{"users":[A("ann",30),A("true",1.5)],"ok":false,"none":null,"empty":[],"n":"12"}`
+	got, err := ToYAML([]byte(in))
+	if err != nil {
+		t.Fatalf("ToYAML: %v", err)
+	}
+	want := strings.Join([]string{
+		"users:",
+		"  - name: ann",
+		"    age: 30",
+		`  - name: "true"`,
+		"    age: 1.5",
+		"ok: false",
+		"none: null",
+		"empty: []",
+		`n: "12"`,
+		"",
+	}, "\n")
+	if string(got) != want {
+		t.Fatalf("ToYAML =\n%s\nwant\n%s", got, want)
+	}
+
+	back, err := ToTRON(got)
+	if err != nil {
+		t.Fatalf("ToTRON: %v", err)
+	}
+	if string(back) != in {
+		t.Fatalf("round trip =\n%s\nwant\n%s", back, in)
+	}
+}
+
+func TestToYAMLErrors(t *testing.T) {
+	if _, err := ToYAML([]byte("[1,")); err == nil {
+		t.Fatal("expected syntax error")
+	}
+}